@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/client-go/kubernetes"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// nodeDocument is the JSON representation of the summary of a node, returned
+// by the JSON API and the once command
+type nodeDocument struct {
+	Node    string         `json:"node"`
+	Summary *stats.Summary `json:"summary,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+func nodeDocuments(results []PerNodeResult) []nodeDocument {
+	nodes := make([]nodeDocument, 0, len(results))
+	for _, result := range results {
+		node := nodeDocument{Node: result.NodeName, Summary: result.Summary}
+		if result.Err != nil {
+			node.Error = result.Err.Error()
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// apiError is the JSON body of the error responses
+type apiError struct {
+	Error string `json:"error"`
+	// Node is the node the request was for, if any
+	Node string `json:"node,omitempty"`
+	// Reason classifies the error, e.g. not_found or collection_failed
+	Reason string `json:"reason,omitempty"`
+	// RequestID is the X-Request-ID of the request
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// Reasons of the apiError responses
+const (
+	reasonBadRequest       = "bad_request"
+	reasonUnauthorized     = "unauthorized"
+	reasonForbidden        = "forbidden"
+	reasonNotFound         = "not_found"
+	reasonInvalidNodeName  = "invalid_node_name"
+	reasonThrottled        = "throttled"
+	reasonCollectionFailed = "collection_failed"
+	reasonInternal         = "internal"
+)
+
+// writeError writes an apiError with the ID of the request
+func writeError(w http.ResponseWriter, r *http.Request, code int, e apiError) {
+	e.RequestID = requestID(r.Context())
+	writeAPIJSON(w, code, e)
+}
+
+// writeCollectionError logs and writes the error of a collection that failed,
+// for the node of the node path or probe target parameter if any
+func writeCollectionError(w http.ResponseWriter, r *http.Request, err error) {
+	node := mux.Vars(r)["node"]
+	if node == "" {
+		node = r.URL.Query().Get("target")
+	}
+	logError(r.Context(), "Collecting %s failed: %v", r.URL.Path, err)
+	writeError(w, r, http.StatusInternalServerError, apiError{Error: fmt.Sprintf("error collecting node stats: %v", err), Node: node, Reason: reasonCollectionFailed})
+}
+
+func writeAPIJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// handleAPICollection writes the summaries of the selected nodes as a JSON
+// list of nodeDocument
+func handleAPICollection(w http.ResponseWriter, r *http.Request, kubeClient *kubernetes.Clientset, nodeSelector nodeSelectorFunc) {
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	ctx, span := tracer.Start(ctx, "handleAPICollection", trace.WithAttributes(attribute.String("http.target", r.URL.Path)))
+	defer span.End()
+
+	ctx, cancel := getTimeoutContext(r.WithContext(ctx))
+	defer cancel()
+
+	results, err := nodeSelector(ctx, kubeClient)
+	if err == nil {
+		err = allFailed(results)
+	}
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		writeCollectionError(w, r, err)
+		return
+	}
+
+	writeAPIJSON(w, http.StatusOK, nodeDocuments(results))
+}
+
+// corsConfig is the CORS policy of the JSON API
+type corsConfig struct {
+	// origins are the allowed origins, "*" allowing any
+	origins []string
+	methods []string
+}
+
+func (c corsConfig) allowed(origin string) bool {
+	for _, o := range c.origins {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// flagCORSConfig returns the CORS policy of the flags
+func flagCORSConfig() corsConfig {
+	return corsConfig{origins: splitList(*flagWebCORSOrigins), methods: splitList(*flagWebCORSMethods)}
+}
+
+// isCORSPreflight tells the CORS preflight requests of the JSON API, which
+// browsers send without credentials
+func isCORSPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions && strings.HasPrefix(r.URL.Path, "/api/") && r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+// answerPreflight answers a CORS preflight request with the policy of the
+// flags, without calling any handler
+func answerPreflight(w http.ResponseWriter, r *http.Request) {
+	withCORS(flagCORSConfig(), nil).ServeHTTP(w, r)
+}
+
+// withCORS lets the browsers of the allowed origins call the handler, and
+// answers the OPTIONS requests, preflight or not, without calling it
+func withCORS(c corsConfig, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if len(c.origins) > 0 {
+			w.Header().Add("Vary", "Origin")
+		}
+		if origin != "" && c.allowed(origin) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			if r.Method == http.MethodOptions {
+				w.Header().Set("Access-Control-Allow-Methods", strings.Join(c.methods, ", "))
+				w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(600))
+			}
+		}
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// splitList splits a comma separated flag value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
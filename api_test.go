@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+)
+
+func TestRouter_apiNodes(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Summary: fakekubelet.Fixture("node")})
+	srv.AddNode(fakekubelet.Node{Name: "node-b", StatusCode: http.StatusServiceUnavailable})
+	r := newRouter(kubeClient, nil, nil, allNodesSelector, singleNodeSelector)
+
+	code, body := get(t, r, "/api/v1/nodes", nil)
+	if code != http.StatusOK {
+		t.Fatalf("GET /api/v1/nodes returned %d: %s", code, body)
+	}
+	var nodes []nodeDocument
+	if err := json.Unmarshal([]byte(body), &nodes); err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 2 || nodes[0].Node != "node-a" || nodes[0].Summary == nil || nodes[1].Error == "" {
+		t.Errorf("GET /api/v1/nodes returned unexpected nodes: %s", body)
+	}
+
+	code, body = get(t, r, "/api/v1/nodes/node-b", nil)
+	if code != http.StatusInternalServerError {
+		t.Errorf("GET /api/v1/nodes/node-b returned %d, want %d", code, http.StatusInternalServerError)
+	}
+	assertContains(t, body, `"error":`)
+}
+
+func Test_withCORS(t *testing.T) {
+	called := false
+	h := withCORS(corsConfig{origins: []string{"https://dashboard.example.com"}, methods: []string{"GET", "OPTIONS"}}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/v1/nodes", nil)
+	req.Header.Set("Origin", "https://dashboard.example.com")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent || called {
+		t.Errorf("preflight returned %d, handler called: %t", rec.Code, called)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Methods"); got != "GET, OPTIONS" {
+		t.Errorf("preflight allowed methods %q", got)
+	}
+
+	_, _ = get(t, h, "/api/v1/nodes", http.Header{"Origin": {"https://dashboard.example.com"}})
+	if !called {
+		t.Errorf("handler wasn't called for an allowed origin")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/nodes", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("disallowed origin got Access-Control-Allow-Origin %q", got)
+	}
+}
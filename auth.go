@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenFile holds the static bearer token scrapers must present. The file is
+// reloaded when its modification time or size changes, so that a token
+// rotated through a mounted Secret is picked up without a restart.
+type tokenFile struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	token   []byte
+}
+
+func newTokenFile(path string) *tokenFile {
+	return &tokenFile{path: path}
+}
+
+// load returns the token in the file, reading it again if it changed since
+// the last call
+func (f *tokenFile) load() ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return nil, err
+	}
+	if f.token != nil && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.token, nil
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, err
+	}
+	token := bytes.TrimSpace(data)
+	if len(token) == 0 {
+		return nil, fmt.Errorf("token file %s is empty", f.path)
+	}
+
+	f.token, f.modTime, f.size = token, info.ModTime(), info.Size()
+	return token, nil
+}
+
+// requireToken rejects the requests that don't present the token of the file
+// as a bearer token. The namespace endpoints are left alone, as they
+// authenticate their callers' Kubernetes tokens themselves, and so are the peer
+// endpoints, which check the peer token. The CORS preflight requests of the
+// JSON API, which browsers send without credentials, are answered right away.
+func requireToken(f *tokenFile, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isCORSPreflight(r) {
+			answerPreflight(w, r)
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, "/namespace/") || strings.HasPrefix(r.URL.Path, "/peer/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if checkToken(f, w, r) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// checkToken returns whether the request presents the token of the file as a
+// bearer token, answering 401 if it doesn't
+func checkToken(f *tokenFile, w http.ResponseWriter, r *http.Request) bool {
+	token, err := f.load()
+	if err != nil {
+		logError(r.Context(), "Cannot load the auth token: %v", err)
+		writeError(w, r, http.StatusUnauthorized, apiError{Error: "unauthorized", Reason: reasonUnauthorized})
+		return false
+	}
+
+	presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(presented), token) != 1 {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="kube-summary-exporter"`)
+		writeError(w, r, http.StatusUnauthorized, apiError{Error: "unauthorized", Reason: reasonUnauthorized})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_requireToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	h := requireToken(newTokenFile(path), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, tc := range []struct {
+		target string
+		header http.Header
+		want   int
+	}{
+		{"/nodes", nil, http.StatusUnauthorized},
+		{"/nodes", http.Header{"Authorization": {"Bearer wrong"}}, http.StatusUnauthorized},
+		{"/nodes", http.Header{"Authorization": {"Bearer s3cret"}}, http.StatusOK},
+		{"/namespace/mon/pods", nil, http.StatusOK},
+	} {
+		if code, _ := get(t, h, tc.target, tc.header); code != tc.want {
+			t.Errorf("GET %s with %v returned %d, want %d", tc.target, tc.header, code, tc.want)
+		}
+	}
+
+	// Only the CORS preflight requests of the JSON API go without a token
+	for _, tc := range []struct {
+		target string
+		header http.Header
+		want   int
+	}{
+		{"/nodes", nil, http.StatusUnauthorized},
+		{"/api/v1/nodes", nil, http.StatusUnauthorized},
+		{"/api/v1/nodes", http.Header{"Access-Control-Request-Method": {"GET"}}, http.StatusNoContent},
+	} {
+		req := httptest.NewRequest(http.MethodOptions, tc.target, nil)
+		for name, values := range tc.header {
+			req.Header[name] = values
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("OPTIONS %s with %v returned %d, want %d", tc.target, tc.header, rec.Code, tc.want)
+		}
+	}
+
+	// The rotated token replaces the old one
+	if err := os.WriteFile(path, []byte("rotated"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if code, _ := get(t, h, "/nodes", http.Header{"Authorization": {"Bearer s3cret"}}); code != http.StatusUnauthorized {
+		t.Errorf("GET with the old token returned %d, want %d", code, http.StatusUnauthorized)
+	}
+	if code, _ := get(t, h, "/nodes", http.Header{"Authorization": {"Bearer rotated"}}); code != http.StatusOK {
+		t.Errorf("GET with the rotated token returned %d, want %d", code, http.StatusOK)
+	}
+}
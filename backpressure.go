@@ -0,0 +1,102 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var throttledRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metricsNamespace,
+	Name:      "throttled_requests_total",
+	Help:      "Number of requests answered with 503 and Retry-After instead of being served",
+},
+	[]string{
+		"reason",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(throttledRequests)
+}
+
+// throttleRetryAfter is the Retry-After of the throttled requests
+const throttleRetryAfter = 5 * time.Second
+
+// unthrottledPaths don't collect any summary, or serve the exporter's own
+// metrics and the progress of the collections, which must stay reachable
+// under load
+var unthrottledPaths = map[string]bool{
+	"/":                 true,
+	"/metrics":          true,
+	"/api/openapi.json": true,
+	"/peer/summaries":   true,
+	"/-/progress":       true,
+}
+
+// inFlightLimit limits the collection requests served at once, over HTTP and
+// gRPC. A nil limit doesn't limit them.
+type inFlightLimit chan struct{}
+
+// newInFlightLimit returns the limit of limit requests in flight, nil if limit
+// is 0
+func newInFlightLimit(limit int) inFlightLimit {
+	if limit <= 0 {
+		return nil
+	}
+	return make(inFlightLimit, limit)
+}
+
+// acquire returns false if the limit is reached, or the func releasing the
+// request once it is served
+func (l inFlightLimit) acquire() (func(), bool) {
+	if l == nil {
+		return func() {}, true
+	}
+	select {
+	case l <- struct{}{}:
+		return func() { <-l }, true
+	default:
+		return nil, false
+	}
+}
+
+// withBackpressure answers 503 with a Retry-After header, rather than queueing,
+// when the limit of requests in flight is reached or while ready returns
+// false, e.g. until the cache is filled by the first background collection
+// cycle. A nil ready is always ready. The CORS preflight requests of the JSON
+// API are answered right away.
+func withBackpressure(inFlight inFlightLimit, ready func() bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isCORSPreflight(r) {
+			answerPreflight(w, r)
+			return
+		}
+		if unthrottledPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if ready != nil && !ready() {
+			throttle(w, r, "cache_not_ready", "the first collection cycle isn't complete")
+			return
+		}
+
+		release, ok := inFlight.acquire()
+		if !ok {
+			throttle(w, r, "concurrency", "too many requests in flight")
+			return
+		}
+		defer release()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func throttle(w http.ResponseWriter, r *http.Request, reason, message string) {
+	throttledRequests.WithLabelValues(reason).Inc()
+	w.Header().Set("Retry-After", strconv.Itoa(int(throttleRetryAfter/time.Second)))
+	writeError(w, r, http.StatusServiceUnavailable, apiError{Error: message, Reason: reasonThrottled})
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_withBackpressure(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	h := withBackpressure(newInFlightLimit(1), nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/nodes" {
+			started <- struct{}{}
+			<-release
+		}
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nodes", nil))
+	}()
+	<-started
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/node/node-a", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "5" {
+		t.Errorf("request over the limit returned %d with Retry-After %q, want %d with 5", rec.Code, rec.Header().Get("Retry-After"), http.StatusServiceUnavailable)
+	}
+	if code, _ := get(t, h, "/metrics", nil); code != http.StatusOK {
+		t.Errorf("GET /metrics over the limit returned %d, want %d", code, http.StatusOK)
+	}
+
+	close(release)
+	<-done
+	if code, _ := get(t, h, "/node/node-a", nil); code != http.StatusOK {
+		t.Errorf("request after the limit freed up returned %d, want %d", code, http.StatusOK)
+	}
+}
+
+func Test_withBackpressure_notReady(t *testing.T) {
+	cache := newSummaryCache(1)
+	h := withBackpressure(nil, cache.ready, http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	if code, _ := get(t, h, "/nodes", nil); code != http.StatusServiceUnavailable {
+		t.Errorf("GET /nodes before the first cycle returned %d, want %d", code, http.StatusServiceUnavailable)
+	}
+	cache.update(nil)
+	if code, _ := get(t, h, "/nodes", nil); code != http.StatusOK {
+		t.Errorf("GET /nodes after the first cycle returned %d, want %d", code, http.StatusOK)
+	}
+}
+
+func Test_withBackpressure_options(t *testing.T) {
+	cache := newSummaryCache(1)
+	h := withBackpressure(nil, cache.ready, http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	// Only the CORS preflight requests skip the backpressure
+	for _, tc := range []struct {
+		target, method string
+		want           int
+	}{
+		{"/nodes", "", http.StatusServiceUnavailable},
+		{"/api/v1/nodes", "GET", http.StatusNoContent},
+	} {
+		req := httptest.NewRequest(http.MethodOptions, tc.target, nil)
+		if tc.method != "" {
+			req.Header.Set("Access-Control-Request-Method", tc.method)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("OPTIONS %s before the first cycle returned %d, want %d", tc.target, rec.Code, tc.want)
+		}
+	}
+}
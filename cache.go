@@ -0,0 +1,471 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+var expiredEntries = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metricsNamespace,
+	Name:      "cache_expired_total",
+	Help:      "Number of cached nodes and pods dropped after not appearing for the expiry window",
+},
+	[]string{
+		"kind",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(expiredEntries)
+}
+
+// summaryCache holds the summaries collected in background mode. Nodes and
+// pods are kept until they haven't appeared for expiryCycles collection
+// cycles, so a single failed node fetch doesn't make its series disappear.
+type summaryCache struct {
+	mu           sync.RWMutex
+	cycle        uint64
+	expiryCycles uint64
+	nodes        map[string]*cachedNode
+	// updated and previousUpdated are the times of the last two cycles
+	updated         time.Time
+	previousUpdated time.Time
+	// restored is set while the cache holds the results restored from
+	// --cache-file, until the first cycle completes
+	restored bool
+	// interval is the interval of the collection loop filling the cache
+	interval time.Duration
+	// rampCycles is the number of cycles of the startup ramp-up, the cache
+	// being ready once they all completed
+	rampCycles uint64
+	// nodeIntervals are the collection intervals of the nodes whose
+	// --node-interval-annotation differs from the interval, their entries
+	// expiring after as many of their own intervals
+	nodeIntervals map[string]time.Duration
+	// maxBytes caps the estimated size of the cache, see enforceMaxBytes
+	maxBytes int64
+}
+
+type cachedNode struct {
+	stats         stats.NodeStats
+	responseBytes int
+	collectedAt   time.Time
+	provider      string
+	version       string
+	metadata      summary.NodeMetadata
+	conditions    []corev1.NodeCondition
+	labels        map[string]string
+	unschedulable bool
+	taints        []corev1.Taint
+	allocatable   corev1.ResourceList
+	capacity      corev1.ResourceList
+	capabilities  map[string]bool
+	source        string
+	err           error
+	lastSeen      uint64
+	pods          map[string]*cachedPod
+	// size is the estimated memory held by the node, its pods excluded, and
+	// total the memory held along with its pods, see bytes
+	size, total int64
+}
+
+type cachedPod struct {
+	stats    stats.PodStats
+	lastSeen uint64
+	// previous are the stats of the pod in the cycle it was seen before
+	// lastSeen, see summaryCache.diff
+	previous     *stats.PodStats
+	previousSeen uint64
+	// size and previousSize are the estimated memory held by stats and
+	// previous
+	size, previousSize int64
+}
+
+func newSummaryCache(expiryCycles int) *summaryCache {
+	if expiryCycles < 1 {
+		expiryCycles = 1
+	}
+	return &summaryCache{
+		expiryCycles:  uint64(expiryCycles),
+		nodes:         map[string]*cachedNode{},
+		nodeIntervals: map[string]time.Duration{},
+	}
+}
+
+// update merges the results of a collection cycle into the cache and drops
+// the nodes and pods that have expired
+func (c *summaryCache) update(results []PerNodeResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cycle++
+	c.previousUpdated, c.updated = c.updated, time.Now()
+	if c.restored {
+		c.restored = false
+		cacheRestored.Set(0)
+	}
+
+	for _, result := range results {
+		c.merge(result, c.cycle)
+	}
+
+	for name, node := range c.nodes {
+		if c.expired(name, node.lastSeen) {
+			c.forgetNode(name)
+			expiredEntries.WithLabelValues("node").Inc()
+			expiredEntries.WithLabelValues("pod").Add(float64(len(node.pods)))
+			continue
+		}
+		for key, pod := range node.pods {
+			if c.expired(name, pod.lastSeen) {
+				delete(node.pods, key)
+				expiredEntries.WithLabelValues("pod").Inc()
+			}
+		}
+		node.total = node.bytes()
+	}
+	c.enforceMaxBytes()
+}
+
+// updateNode merges the result of a node collected during the cycle in
+// progress, which the next update completes. It lets the nodes collected at
+// staggered offsets be served as soon as they are collected.
+func (c *summaryCache) updateNode(result PerNodeResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.merge(result, c.cycle+1)
+	c.enforceMaxBytes()
+}
+
+// removeNode drops a node and its pods, e.g. once the node is deleted
+func (c *summaryCache) removeNode(nodeName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	node, ok := c.nodes[nodeName]
+	c.forgetNode(nodeName)
+	if ok {
+		expiredEntries.WithLabelValues("node").Inc()
+		expiredEntries.WithLabelValues("pod").Add(float64(len(node.pods)))
+	}
+	c.enforceMaxBytes()
+}
+
+// forgetNode drops the entries of the node along with its interval, so that
+// neither outlives the other. It must be called with the lock held.
+func (c *summaryCache) forgetNode(nodeName string) {
+	delete(c.nodes, nodeName)
+	delete(c.nodeIntervals, nodeName)
+}
+
+// merge stores the result of a node as seen in the given cycle. It must be
+// called with the lock held.
+func (c *summaryCache) merge(result PerNodeResult, cycle uint64) {
+	node, ok := c.nodes[result.NodeName]
+	if result.Err != nil {
+		// Keep serving the last successful summary until it expires
+		if ok {
+			node.err = result.Err
+		}
+		return
+	}
+	if !ok {
+		node = &cachedNode{pods: map[string]*cachedPod{}}
+		c.nodes[result.NodeName] = node
+	}
+	node.stats = result.Summary.Node
+	node.responseBytes = result.ResponseBytes
+	node.collectedAt = result.CollectedAt
+	node.provider = result.Provider
+	node.version = result.KubeletVersion
+	node.metadata = result.Metadata
+	node.conditions = result.Conditions
+	node.labels = result.NodeLabels
+	node.unschedulable = result.Unschedulable
+	node.taints = result.Taints
+	node.allocatable = result.Allocatable
+	node.capacity = result.Capacity
+	node.capabilities = result.Capabilities
+	node.source = result.Source
+	node.err = nil
+	node.lastSeen = cycle
+	node.size = deepSize(node.stats) + deepSize(node.metadata) + deepSize(node.conditions) + deepSize(node.labels) + deepSize(node.taints) +
+		deepSize(node.allocatable) + deepSize(node.capacity) + deepSize(node.capabilities) + int64(len(node.provider)+len(node.version)+len(node.source))
+
+	for _, pod := range result.Summary.Pods {
+		cached := &cachedPod{
+			stats:    pod,
+			lastSeen: cycle,
+			size:     deepSize(pod),
+		}
+		if prev, ok := node.pods[podKey(pod.PodRef)]; ok {
+			cached.previous, cached.previousSeen, cached.previousSize = &prev.stats, prev.lastSeen, prev.size
+		}
+		node.pods[podKey(pod.PodRef)] = cached
+	}
+	node.total = node.bytes()
+}
+
+// restore fills the cache with the results persisted by a previous process
+// at ts, served as stale until the first collection cycle. They expire like
+// the entries of a cycle that happened before the first one.
+func (c *summaryCache) restore(results []PerNodeResult, ts time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, result := range results {
+		c.merge(result, c.cycle)
+	}
+	c.updated = ts
+	c.restored = true
+	cacheRestored.Set(1)
+	c.enforceMaxBytes()
+}
+
+// ready returns whether a collection cycle has completed, or the cache was
+// restored
+func (c *summaryCache) ready() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cycle >= max(c.rampCycles, 1) || c.restored
+}
+
+// expired returns whether the entries of the node last seen in the cycle have
+// expired, the expiry cycles being counted in the intervals of the node. It
+// must be called with the lock held.
+func (c *summaryCache) expired(nodeName string, lastSeen uint64) bool {
+	cycles := c.expiryCycles
+	if every, ok := c.nodeIntervals[nodeName]; ok && c.interval > 0 && every > c.interval {
+		cycles *= uint64((every + c.interval - 1) / c.interval)
+	}
+	return c.cycle-lastSeen >= cycles
+}
+
+// setNodeInterval records the collection interval of the node
+func (c *summaryCache) setNodeInterval(nodeName string, every time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if every == c.interval {
+		delete(c.nodeIntervals, nodeName)
+		return
+	}
+	c.nodeIntervals[nodeName] = every
+}
+
+// due returns whether the node, collected every interval, is due in the cycle
+// starting at now: unless its interval is longer than the cycles, it is due
+// if it wasn't collected successfully within its interval, less half a cycle
+// so that the drift of the cycles doesn't delay it by a whole one
+func (c *summaryCache) due(nodeName string, every time.Duration, now time.Time) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	node, ok := c.nodes[nodeName]
+	if !ok || node.err != nil || every <= c.interval {
+		return true
+	}
+	return now.Sub(node.collectedAt) >= every-c.interval/2
+}
+
+// results returns the cached summaries of every node, sorted by node name
+func (c *summaryCache) results() []PerNodeResult {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	names := make([]string, 0, len(c.nodes))
+	for name := range c.nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make([]PerNodeResult, 0, len(names))
+	for _, name := range names {
+		results = append(results, c.nodes[name].result(name))
+	}
+	return results
+}
+
+// result returns the cached summary of a single node
+func (c *summaryCache) result(nodeName string) (PerNodeResult, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	node, ok := c.nodes[nodeName]
+	if !ok {
+		return PerNodeResult{}, false
+	}
+	return node.result(nodeName), true
+}
+
+func (n *cachedNode) result(nodeName string) PerNodeResult {
+	keys := make([]string, 0, len(n.pods))
+	for key := range n.pods {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	summary := &stats.Summary{Node: n.stats}
+	for _, key := range keys {
+		summary.Pods = append(summary.Pods, n.pods[key].stats)
+	}
+	return PerNodeResult{
+		NodeName:       nodeName,
+		Summary:        summary,
+		ResponseBytes:  n.responseBytes,
+		CollectedAt:    n.collectedAt,
+		Provider:       n.provider,
+		KubeletVersion: n.version,
+		Metadata:       n.metadata,
+		Conditions:     n.conditions,
+		NodeLabels:     n.labels,
+		Unschedulable:  n.unschedulable,
+		Taints:         n.taints,
+		Allocatable:    n.allocatable,
+		Capacity:       n.capacity,
+		Capabilities:   n.capabilities,
+		Source:         n.source,
+		Err:            n.err,
+	}
+}
+
+func podKey(ref stats.PodReference) string {
+	return ref.Namespace + "/" + ref.Name
+}
+
+// cachedAllNodesSelector selects all nodes from the cache, or with the live
+// selector if the cached results are older than the max_age of the request
+func cachedAllNodesSelector(cache *summaryCache, live nodeSelectorFunc) nodeSelectorFunc {
+	return func(ctx context.Context, kubeClient *kubernetes.Clientset) ([]PerNodeResult, error) {
+		return checkMaxAge(ctx, kubeClient, cache, cache.results(), live)
+	}
+}
+
+// cachedSingleNodeSelector selects a single node by name from the cache, or
+// with the live selector if the cached result is older than the max_age of the
+// request
+func cachedSingleNodeSelector(cache *summaryCache, nodeName string, live nodeSelectorFunc) nodeSelectorFunc {
+	return func(ctx context.Context, kubeClient *kubernetes.Clientset) ([]PerNodeResult, error) {
+		result, ok := cache.result(nodeName)
+		if !ok {
+			return nil, fmt.Errorf("node %s not found in cache", nodeName)
+		}
+		return checkMaxAge(ctx, kubeClient, cache, []PerNodeResult{result}, live)
+	}
+}
+
+// runCollectionLoop collects the summaries of the nodes every interval and
+// stores them in the cache. Failed cycles are logged and don't count towards
+// the expiry window, so an API server outage doesn't empty the cache. The
+// summaries of every successful cycle are written to the snapshot sinks. The
+// watchdog counts the stalls of the loop. With --collection-spread the nodes
+// are collected at staggered offsets, see withCollectionSpread, and cached as
+// soon as they are collected. With --startup-ramp-cycles the first cycles only
+// collect a growing share of the nodes, see withStartupRamp, and their partial
+// results aren't written to the snapshot sinks. With
+// --node-interval-annotation the nodes with a longer interval are skipped
+// until they are due, see dueNodes. With --peers-service the cycle only
+// collects the share of this replica, the other shares being fetched from the
+// peers, see peerSet.exchange.
+func runCollectionLoop(ctx context.Context, kubeClient *kubernetes.Clientset, nodesSelector nodeSelectorFunc, cache *summaryCache, interval time.Duration, wd *watchdog, snapshots ...snapshotSink) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	rampCycles := max(*flagStartupRampCycles, 1)
+	cache.mu.Lock()
+	cache.interval = interval
+	cache.rampCycles = uint64(rampCycles)
+	cache.mu.Unlock()
+
+	go wd.run(ctx, interval, 2*interval)
+
+	// rampCycle is the cycle of the startup ramp-up, only the successful
+	// cycles moving on to the next batch of nodes
+	rampCycle := 1
+	for {
+		collectCtx, cancel := context.WithTimeout(ctx, interval)
+		collectCtx, span := tracer.Start(context.WithValue(collectCtx, backgroundCollectionKey{}, true), "runCollectionCycle")
+		ramping := rampCycle < rampCycles
+		if ramping {
+			collectCtx = withStartupRamp(collectCtx, rampCycle, rampCycles)
+		}
+		if *flagNodeIntervalAnnotation != "" {
+			collectCtx = withNodeDue(collectCtx, dueNodes(cache, *flagNodeIntervalAnnotation, interval, time.Now()))
+		}
+		if *flagCollectionSpread {
+			collectCtx = withResultStream(withCollectionSpread(collectCtx, interval/2), cache.updateNode)
+		}
+		var (
+			share   peerShare
+			sharing bool
+		)
+		if peers != nil {
+			if share, sharing = peers.members(ctx); sharing {
+				collectCtx = withPeerShards(collectCtx, map[int]bool{share.self: true}, len(share.peers))
+			} else {
+				peerReplicas.Set(1)
+			}
+		}
+		results, err := nodesSelector(collectCtx, kubeClient)
+		var shared []PerNodeResult
+		if err == nil && sharing {
+			exchangeCtx, cancelExchange := context.WithTimeout(context.WithValue(ctx, backgroundCollectionKey{}, true), interval)
+			shared = peers.exchange(exchangeCtx, kubeClient, nodesSelector, share, results, 2*interval)
+			cancelExchange()
+		}
+		span.End()
+		cancel()
+		if err != nil {
+			fmt.Printf("[Error] Background collection failed: %v\n", err)
+		} else {
+			if *flagCollectionSpread {
+				// The results were merged as they were collected, but
+				// those of the peers
+				cache.update(shared)
+			} else {
+				cache.update(append(results, shared...))
+			}
+			results = append(results, shared...)
+			lastCollectionTimestamp.SetToCurrentTime()
+			startupRampRatio.Set(float64(min(rampCycle, rampCycles)) / float64(rampCycles))
+			if ramping {
+				fmt.Printf("Startup ramp-up: collected %d nodes in cycle %d of %d\n", len(results), rampCycle, rampCycles)
+				rampCycle++
+			} else if *flagNodeIntervalAnnotation != "" {
+				// The nodes that weren't due are only in the cache
+				writeSnapshots(ctx, snapshots, cache.results(), interval)
+			} else {
+				writeSnapshots(ctx, snapshots, results, interval)
+			}
+		}
+		wd.beat()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// writeSnapshots writes the results of a cycle to every snapshot sink, each
+// write being bounded by the collection interval
+func writeSnapshots(ctx context.Context, snapshots []snapshotSink, results []PerNodeResult, interval time.Duration) {
+	now := time.Now()
+	for _, s := range snapshots {
+		writeCtx, cancel := context.WithTimeout(ctx, interval)
+		err := s.WriteSnapshot(writeCtx, results, now)
+		cancel()
+		if err != nil {
+			sinkErrors.WithLabelValues(s.Name()).Inc()
+			fmt.Printf("[Error] Writing snapshot to %s failed: %v\n", s.Name(), err)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+func Test_summaryCache(t *testing.T) {
+	result := func(nodeName string, podNames ...string) PerNodeResult {
+		summary := &stats.Summary{Node: stats.NodeStats{NodeName: nodeName}}
+		for _, name := range podNames {
+			summary.Pods = append(summary.Pods, stats.PodStats{
+				PodRef: stats.PodReference{Name: name, Namespace: "ns"},
+			})
+		}
+		return PerNodeResult{NodeName: nodeName, Summary: summary}
+	}
+	contents := func(cache *summaryCache) map[string][]string {
+		out := map[string][]string{}
+		for _, r := range cache.results() {
+			out[r.NodeName] = []string{}
+			for _, pod := range r.Summary.Pods {
+				out[r.NodeName] = append(out[r.NodeName], pod.PodRef.Name)
+			}
+		}
+		return out
+	}
+
+	cache := newSummaryCache(2)
+
+	cache.update([]PerNodeResult{result("a", "p1", "p2"), result("b", "p3")})
+	cache.update([]PerNodeResult{result("a", "p1")})
+	if diff := cmp.Diff(map[string][]string{"a": {"p1", "p2"}, "b": {"p3"}}, contents(cache)); diff != "" {
+		t.Errorf("summaryCache after 2 cycles mismatch (-want +got):\n%s", diff)
+	}
+
+	cache.update([]PerNodeResult{result("a", "p1")})
+	if diff := cmp.Diff(map[string][]string{"a": {"p1"}}, contents(cache)); diff != "" {
+		t.Errorf("summaryCache after 3 cycles mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, ok := cache.result("b"); ok {
+		t.Errorf("summaryCache.result() returned expired node")
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	cacheBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "cache_bytes",
+		Help:      "Estimated memory held by the cached summaries, in bytes",
+	})
+	cacheEvictions = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "cache_evictions_total",
+		Help:      "Number of cached nodes dropped to keep the cache under --cache-max-bytes",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(cacheBytes, cacheEvictions)
+}
+
+// deepSize estimates the memory held by a value, the memory of the value
+// itself included: the strings, slices, maps and pointers it references are
+// followed. It ignores the allocator and map bucket overheads, which only
+// makes the estimate a lower bound, but reacts to the number of pods,
+// containers and volumes like the real usage does.
+func deepSize(v interface{}) int64 {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return 0
+	}
+	return int64(rv.Type().Size()) + indirectSize(rv)
+}
+
+// indirectSize returns the memory referenced by a value, outside of the value
+// itself
+func indirectSize(v reflect.Value) int64 {
+	switch v.Kind() {
+	case reflect.String:
+		return int64(v.Len())
+	case reflect.Pointer:
+		if v.IsNil() {
+			return 0
+		}
+		return int64(v.Type().Elem().Size()) + indirectSize(v.Elem())
+	case reflect.Slice:
+		if v.IsNil() {
+			return 0
+		}
+		size := int64(v.Cap()) * int64(v.Type().Elem().Size())
+		for i := range v.Len() {
+			size += indirectSize(v.Index(i))
+		}
+		return size
+	case reflect.Map:
+		var size int64
+		iter := v.MapRange()
+		for iter.Next() {
+			size += int64(iter.Key().Type().Size()) + indirectSize(iter.Key())
+			size += int64(iter.Value().Type().Size()) + indirectSize(iter.Value())
+		}
+		return size
+	case reflect.Struct:
+		// The location of a time is shared between all the times
+		if v.Type() == timeType {
+			return 0
+		}
+		var size int64
+		for i := range v.NumField() {
+			size += indirectSize(v.Field(i))
+		}
+		return size
+	case reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+		return int64(v.Elem().Type().Size()) + indirectSize(v.Elem())
+	}
+	return 0
+}
+
+// bytes returns the estimated memory held by the node and its pods
+func (n *cachedNode) bytes() int64 {
+	size := n.size
+	for _, pod := range n.pods {
+		size += pod.size + pod.previousSize
+	}
+	return size
+}
+
+// enforceMaxBytes drops the nodes collected the longest time ago, the largest
+// first among the nodes collected at the same time, until the estimated size
+// of the cache is below maxBytes, if set. It must be called with the lock held.
+func (c *summaryCache) enforceMaxBytes() {
+	var total int64
+	for _, node := range c.nodes {
+		total += node.total
+	}
+	defer func() { cacheBytes.Set(float64(total)) }()
+	if c.maxBytes <= 0 || total <= c.maxBytes {
+		return
+	}
+
+	names := make([]string, 0, len(c.nodes))
+	for name := range c.nodes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := c.nodes[names[i]], c.nodes[names[j]]
+		if !a.collectedAt.Equal(b.collectedAt) {
+			return a.collectedAt.Before(b.collectedAt)
+		}
+		if a.total != b.total {
+			return a.total > b.total
+		}
+		return names[i] < names[j]
+	})
+	for _, name := range names {
+		if total <= c.maxBytes {
+			break
+		}
+		fmt.Printf("[Warning] Dropping %s from the cache, whose %d bytes exceed --cache-max-bytes=%d\n", name, total, c.maxBytes)
+		total -= c.nodes[name].total
+		c.forgetNode(name)
+		cacheEvictions.Inc()
+	}
+}
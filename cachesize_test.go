@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+func Test_deepSize(t *testing.T) {
+	for _, tt := range []struct {
+		value interface{}
+		want  int64
+	}{
+		{"abc", 16 + 3},
+		{[]string{"ab", "c"}, 24 + 2*16 + 3},
+		{map[string]string{"a": "bc"}, 8 + 16 + 1 + 16 + 2},
+		{&stats.FsStats{}, 8 + deepSize(stats.FsStats{})},
+		{struct{ T time.Time }{time.Now()}, 24},
+	} {
+		if got := deepSize(tt.value); got != tt.want {
+			t.Errorf("deepSize(%#v) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
+
+func Test_summaryCache_maxBytes(t *testing.T) {
+	now := time.Now()
+	result := func(nodeName string, pods int, collectedAt time.Time) PerNodeResult {
+		summary := &stats.Summary{Node: stats.NodeStats{NodeName: nodeName}}
+		for i := range pods {
+			summary.Pods = append(summary.Pods, stats.PodStats{PodRef: stats.PodReference{Name: fmt.Sprintf("pod-%d", i), Namespace: "default"}})
+		}
+		return PerNodeResult{NodeName: nodeName, Summary: summary, CollectedAt: collectedAt}
+	}
+
+	cache := newSummaryCache(1)
+	results := []PerNodeResult{result("node-a", 10, now.Add(-time.Minute)), result("node-b", 10, now), result("node-c", 20, now)}
+	// The second update keeps the previous stats of the pods
+	cache.update(results)
+	cache.update(results)
+	full := testutil.ToFloat64(cacheBytes)
+	if full <= 0 {
+		t.Fatalf("got a cache size of %v, want more than 0", full)
+	}
+
+	evictions := testutil.ToFloat64(cacheEvictions)
+	cache.setNodeInterval("node-a", 5*time.Minute)
+	cache.maxBytes = int64(full) - 1
+	cache.update(results)
+	if _, ok := cache.result("node-a"); ok {
+		t.Error("node-a, collected the longest time ago, wasn't dropped")
+	}
+	if _, ok := cache.nodeIntervals["node-a"]; ok {
+		t.Error("the interval of node-a was kept after it was dropped")
+	}
+	if _, ok := cache.result("node-b"); !ok {
+		t.Error("node-b was dropped while the cache is under the limit without node-a")
+	}
+	if got := testutil.ToFloat64(cacheEvictions) - evictions; got != 1 {
+		t.Errorf("got %v evictions, want 1", got)
+	}
+	if got := testutil.ToFloat64(cacheBytes); got > float64(cache.maxBytes) {
+		t.Errorf("got a cache size of %v, want at most %d", got, cache.maxBytes)
+	}
+
+	// Among the nodes collected at the same time the largest goes first
+	cache.maxBytes = int64(testutil.ToFloat64(cacheBytes)) - 1
+	cache.update([]PerNodeResult{result("node-b", 10, now), result("node-c", 20, now)})
+	if _, ok := cache.result("node-c"); ok {
+		t.Error("node-c, the largest node, wasn't dropped")
+	}
+}
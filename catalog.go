@@ -0,0 +1,18 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+// catalogDocument is the response of /catalog
+type catalogDocument struct {
+	Metrics []summary.MetricInfo `json:"metrics"`
+}
+
+// handleCatalog lists the metrics mapped from the summaries, with their type,
+// labels and stability level, as exported with the flags and config file
+func handleCatalog(w http.ResponseWriter, r *http.Request) {
+	writeAPIJSON(w, http.StatusOK, catalogDocument{Metrics: summary.Catalog(flagCollectorOptions())})
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func Test_handleCatalog(t *testing.T) {
+	defer func(m map[string]string) { metricHelpOverrides = m }(metricHelpOverrides)
+	metricHelpOverrides = map[string]string{"kube_summary_pod_info": "Pods of the node, see the runbook"}
+	*flagNodeMetadataLabels = true
+	defer func() { *flagNodeMetadataLabels = false }()
+
+	code, body := get(t, http.HandlerFunc(handleCatalog), "/catalog", nil)
+	if code != http.StatusOK {
+		t.Fatalf("got status %d, want 200", code)
+	}
+	assertContains(t, body,
+		`"name":"kube_summary_container_logs_used_bytes","type":"gauge"`,
+		`"help":"Pods of the node, see the runbook"`,
+		`"name":"kube_summary_node_scrape_success","type":"gauge","help":"Whether the /stats/summary of the node was collected successfully","nodeLevel":true,"labels":["node","kubelet_version","os","arch","instance_type"],"stability":"beta"`,
+	)
+}
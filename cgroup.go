@@ -0,0 +1,140 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// cgroupV1UnlimitedBytes is the smallest memory.limit_in_bytes a cgroup v1
+// reports when no limit is set, the largest page aligned int64
+const cgroupV1UnlimitedBytes = 1 << 62
+
+var (
+	selfMemoryLimitDesc = prometheus.NewDesc(metricsNamespace+"_self_memory_limit_bytes",
+		"Memory limit of the cgroup of the exporter, absent when unlimited", nil, nil)
+	selfMemoryUsageDesc = prometheus.NewDesc(metricsNamespace+"_self_memory_usage_bytes",
+		"Memory used by the cgroup of the exporter, page cache included", nil, nil)
+	selfMemoryWorkingSetDesc = prometheus.NewDesc(metricsNamespace+"_self_memory_working_set_bytes",
+		"Memory used by the cgroup of the exporter minus the inactive page cache, which the kubelet compares to the limit when evicting and the kernel OOM kills on", nil, nil)
+	selfCPULimitDesc = prometheus.NewDesc(metricsNamespace+"_self_cpu_limit_cores",
+		"CPU quota of the cgroup of the exporter in cores, absent when unlimited", nil, nil)
+	selfCPUThrottledDesc = prometheus.NewDesc(metricsNamespace+"_self_cpu_throttled_seconds_total",
+		"Time the cgroup of the exporter was throttled for exceeding its CPU quota", nil, nil)
+)
+
+func init() {
+	prometheus.MustRegister(cgroupCollector{root: "/sys/fs/cgroup"})
+}
+
+// cgroupCollector exports the usage and limits of the cgroup the exporter
+// runs in, read from the cgroup v2 unified hierarchy or the cgroup v1 memory
+// and cpu controllers mounted under root, so that alerts can fire before the
+// exporter, whose memory grows with the cluster, is OOM killed. The process
+// metrics of the default registry, e.g. process_resident_memory_bytes, cover
+// the usage of the process itself. Files that can't be read, outside of a
+// container or on other platforms, are skipped.
+type cgroupCollector struct {
+	root string
+}
+
+func (c cgroupCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- selfMemoryLimitDesc
+	ch <- selfMemoryUsageDesc
+	ch <- selfMemoryWorkingSetDesc
+	ch <- selfCPULimitDesc
+	ch <- selfCPUThrottledDesc
+}
+
+func (c cgroupCollector) Collect(ch chan<- prometheus.Metric) {
+	gauge := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value)
+	}
+
+	if _, err := os.Stat(filepath.Join(c.root, "cgroup.controllers")); err == nil {
+		c.collectV2(gauge, ch)
+		return
+	}
+	c.collectV1(gauge, ch)
+}
+
+func (c cgroupCollector) collectV2(gauge func(*prometheus.Desc, float64), ch chan<- prometheus.Metric) {
+	if limit, ok := readCgroupValue(filepath.Join(c.root, "memory.max")); ok {
+		gauge(selfMemoryLimitDesc, limit)
+	}
+	if usage, ok := readCgroupValue(filepath.Join(c.root, "memory.current")); ok {
+		gauge(selfMemoryUsageDesc, usage)
+		inactive := readCgroupStat(filepath.Join(c.root, "memory.stat"))["inactive_file"]
+		gauge(selfMemoryWorkingSetDesc, max(usage-inactive, 0))
+	}
+
+	// cpu.max is "<quota> <period>", the quota being max when unlimited
+	if fields := strings.Fields(readCgroupFile(filepath.Join(c.root, "cpu.max"))); len(fields) == 2 {
+		quota, err1 := strconv.ParseFloat(fields[0], 64)
+		period, err2 := strconv.ParseFloat(fields[1], 64)
+		if err1 == nil && err2 == nil && period > 0 {
+			gauge(selfCPULimitDesc, quota/period)
+		}
+	}
+	if throttled, ok := readCgroupStat(filepath.Join(c.root, "cpu.stat"))["throttled_usec"]; ok {
+		ch <- prometheus.MustNewConstMetric(selfCPUThrottledDesc, prometheus.CounterValue, throttled/1e6)
+	}
+}
+
+func (c cgroupCollector) collectV1(gauge func(*prometheus.Desc, float64), ch chan<- prometheus.Metric) {
+	memory := filepath.Join(c.root, "memory")
+	if limit, ok := readCgroupValue(filepath.Join(memory, "memory.limit_in_bytes")); ok && limit < cgroupV1UnlimitedBytes {
+		gauge(selfMemoryLimitDesc, limit)
+	}
+	if usage, ok := readCgroupValue(filepath.Join(memory, "memory.usage_in_bytes")); ok {
+		gauge(selfMemoryUsageDesc, usage)
+		inactive := readCgroupStat(filepath.Join(memory, "memory.stat"))["total_inactive_file"]
+		gauge(selfMemoryWorkingSetDesc, max(usage-inactive, 0))
+	}
+
+	cpu := filepath.Join(c.root, "cpu")
+	quota, ok1 := readCgroupValue(filepath.Join(cpu, "cpu.cfs_quota_us"))
+	period, ok2 := readCgroupValue(filepath.Join(cpu, "cpu.cfs_period_us"))
+	if ok1 && ok2 && quota > 0 && period > 0 {
+		gauge(selfCPULimitDesc, quota/period)
+	}
+	if throttled, ok := readCgroupStat(filepath.Join(cpu, "cpu.stat"))["throttled_time"]; ok {
+		ch <- prometheus.MustNewConstMetric(selfCPUThrottledDesc, prometheus.CounterValue, throttled/1e9)
+	}
+}
+
+// readCgroupFile returns the trimmed content of a cgroup file, empty if it
+// can't be read
+func readCgroupFile(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// readCgroupValue reads a cgroup file holding a single number, which is
+// missing when the file can't be read or holds max
+func readCgroupValue(path string) (float64, bool) {
+	value, err := strconv.ParseFloat(readCgroupFile(path), 64)
+	return value, err == nil
+}
+
+// readCgroupStat reads a cgroup file of "<key> <value>" lines, such as
+// memory.stat and cpu.stat
+func readCgroupStat(path string) map[string]float64 {
+	values := map[string]float64{}
+	for _, line := range strings.Split(readCgroupFile(path), "\n") {
+		key, value, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			values[key] = v
+		}
+	}
+	return values
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func writeCgroupFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func Test_cgroupCollector(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name: "v2",
+			files: map[string]string{
+				"cgroup.controllers": "cpu memory\n",
+				"memory.max":         "268435456\n",
+				"memory.current":     "104857600\n",
+				"memory.stat":        "anon 62914560\ninactive_file 20971520\n",
+				"cpu.max":            "50000 100000\n",
+				"cpu.stat":           "usage_usec 1000\nthrottled_usec 2500000\n",
+			},
+			want: `
+# HELP kube_summary_self_cpu_limit_cores CPU quota of the cgroup of the exporter in cores, absent when unlimited
+# TYPE kube_summary_self_cpu_limit_cores gauge
+kube_summary_self_cpu_limit_cores 0.5
+# HELP kube_summary_self_cpu_throttled_seconds_total Time the cgroup of the exporter was throttled for exceeding its CPU quota
+# TYPE kube_summary_self_cpu_throttled_seconds_total counter
+kube_summary_self_cpu_throttled_seconds_total 2.5
+# HELP kube_summary_self_memory_limit_bytes Memory limit of the cgroup of the exporter, absent when unlimited
+# TYPE kube_summary_self_memory_limit_bytes gauge
+kube_summary_self_memory_limit_bytes 2.68435456e+08
+# HELP kube_summary_self_memory_usage_bytes Memory used by the cgroup of the exporter, page cache included
+# TYPE kube_summary_self_memory_usage_bytes gauge
+kube_summary_self_memory_usage_bytes 1.048576e+08
+# HELP kube_summary_self_memory_working_set_bytes Memory used by the cgroup of the exporter minus the inactive page cache, which the kubelet compares to the limit when evicting and the kernel OOM kills on
+# TYPE kube_summary_self_memory_working_set_bytes gauge
+kube_summary_self_memory_working_set_bytes 8.388608e+07
+`,
+		},
+		{
+			name: "v2 unlimited",
+			files: map[string]string{
+				"cgroup.controllers": "cpu memory\n",
+				"memory.max":         "max\n",
+				"cpu.max":            "max 100000\n",
+			},
+		},
+		{
+			name: "v1",
+			files: map[string]string{
+				"memory/memory.limit_in_bytes": "9223372036854771712\n",
+				"memory/memory.usage_in_bytes": "1000\n",
+				"memory/memory.stat":           "total_inactive_file 400\n",
+				"cpu/cpu.cfs_quota_us":         "200000\n",
+				"cpu/cpu.cfs_period_us":        "100000\n",
+			},
+			want: `
+# HELP kube_summary_self_cpu_limit_cores CPU quota of the cgroup of the exporter in cores, absent when unlimited
+# TYPE kube_summary_self_cpu_limit_cores gauge
+kube_summary_self_cpu_limit_cores 2
+# HELP kube_summary_self_memory_usage_bytes Memory used by the cgroup of the exporter, page cache included
+# TYPE kube_summary_self_memory_usage_bytes gauge
+kube_summary_self_memory_usage_bytes 1000
+# HELP kube_summary_self_memory_working_set_bytes Memory used by the cgroup of the exporter minus the inactive page cache, which the kubelet compares to the limit when evicting and the kernel OOM kills on
+# TYPE kube_summary_self_memory_working_set_bytes gauge
+kube_summary_self_memory_working_set_bytes 600
+`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeCgroupFiles(t, root, tt.files)
+			if err := testutil.CollectAndCompare(cgroupCollector{root: root}, strings.NewReader(tt.want)); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
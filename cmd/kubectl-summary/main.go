@@ -0,0 +1,249 @@
+// kubectl-summary is a kubectl plugin printing the ephemeral storage, rootfs
+// and log usage of the pods of a node or namespace, read from the kubelet
+// /stats/summary API through the API server proxy:
+//
+//	kubectl summary --node node-a
+//	kubectl summary -n team-a --sort logs
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+
+	// Support auth providers in kubeconfig files
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+)
+
+// Exit statuses of the plugin
+const (
+	exitSuccess = 0
+	exitFailed  = 1
+	exitUsage   = 2
+)
+
+// sortColumns are the columns the rows can be sorted by, the largest first
+var sortColumns = map[string]func(a, b summary.PodUsage) bool{
+	"ephemeral": func(a, b summary.PodUsage) bool { return a.EphemeralStorageUsedBytes > b.EphemeralStorageUsedBytes },
+	"rootfs":    func(a, b summary.PodUsage) bool { return a.RootfsUsedBytes > b.RootfsUsedBytes },
+	"logs":      func(a, b summary.PodUsage) bool { return a.LogsUsedBytes > b.LogsUsedBytes },
+}
+
+// row is a pod of the table along with its node
+type row struct {
+	node string
+	summary.PodUsage
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], nil, os.Stdout, os.Stderr))
+}
+
+// run runs the plugin and returns its exit status. The kubeconfig flags are
+// ignored when config is set.
+func run(args []string, config *rest.Config, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("kubectl-summary", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	kubeconfig := fs.String("kubeconfig", "", "Path to the kubeconfig file, KUBECONFIG or ~/.kube/config if empty")
+	kubeContext := fs.String("context", "", "Context of the kubeconfig to use, the current context if empty")
+	nodeName := fs.String("node", "", "Print the pods of a single node")
+	var namespace string
+	fs.StringVar(&namespace, "namespace", "", "Print the pods of a single namespace")
+	fs.StringVar(&namespace, "n", "", "Shorthand for --namespace")
+	sortBy := fs.String("sort", "ephemeral", "Column the pods are sorted by, the largest first: ephemeral, rootfs or logs")
+	concurrency := fs.Int("concurrency", 10, "Number of nodes whose summaries are fetched concurrently")
+	timeout := fs.Duration("timeout", time.Minute, "Maximum duration of the collection")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: kubectl summary [--node NAME] [-n NAMESPACE] [--sort ephemeral|rootfs|logs]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	less, ok := sortColumns[*sortBy]
+	if !ok {
+		fmt.Fprintf(stderr, "[Error] Unknown sort column %q, expected ephemeral, rootfs or logs\n", *sortBy)
+		return exitUsage
+	}
+
+	if config == nil {
+		var err error
+		loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+		loadingRules.ExplicitPath = *kubeconfig
+		config, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			loadingRules,
+			&clientcmd.ConfigOverrides{CurrentContext: *kubeContext},
+		).ClientConfig()
+		if err != nil {
+			fmt.Fprintf(stderr, "[Error] Cannot load kubeconfig: %v\n", err)
+			return exitFailed
+		}
+	}
+	kubeClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		fmt.Fprintf(stderr, "[Error] Cannot create kube client: %v\n", err)
+		return exitFailed
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	nodes := []string{*nodeName}
+	if *nodeName == "" {
+		if nodes, err = listNodeNames(ctx, kubeClient); err != nil {
+			fmt.Fprintf(stderr, "[Error] Cannot list nodes: %v\n", err)
+			return exitFailed
+		}
+	}
+
+	rows, errs := collectRows(ctx, kubeClient, nodes, namespace, max(*concurrency, 1))
+	for _, err := range errs {
+		fmt.Fprintf(stderr, "[Error] %v\n", err)
+	}
+	if len(errs) == len(nodes) && len(nodes) > 0 {
+		return exitFailed
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool { return less(rows[i].PodUsage, rows[j].PodUsage) })
+	printRows(stdout, rows, namespace == "")
+	if len(errs) > 0 {
+		return exitFailed
+	}
+	return exitSuccess
+}
+
+// listNodeNames returns the names of the nodes of the cluster
+func listNodeNames(ctx context.Context, kubeClient kubernetes.Interface) ([]string, error) {
+	nodes, err := kubeClient.CoreV1().Nodes().List(ctx, meta_v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(nodes.Items))
+	for _, node := range nodes.Items {
+		names = append(names, node.Name)
+	}
+	return names, nil
+}
+
+// collectRows fetches the summaries of the nodes and returns the usage of
+// their pods in the namespace, all of them if it's empty, sorted by node,
+// namespace and name. The nodes that couldn't be fetched are returned as
+// errors.
+func collectRows(ctx context.Context, kubeClient kubernetes.Interface, nodes []string, namespace string, concurrency int) ([]row, []error) {
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		rows []row
+		errs []error
+	)
+	sem := make(chan struct{}, concurrency)
+	for _, node := range nodes {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			s, err := fetchSummary(ctx, kubeClient, node)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("node %s: %w", node, err))
+				return
+			}
+			for _, pod := range s.Pods {
+				if namespace == "" || pod.PodRef.Namespace == namespace {
+					rows = append(rows, row{node: node, PodUsage: summary.NewPodUsage(pod)})
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].node != rows[j].node {
+			return rows[i].node < rows[j].node
+		}
+		if rows[i].Namespace != rows[j].Namespace {
+			return rows[i].Namespace < rows[j].Namespace
+		}
+		return rows[i].Pod < rows[j].Pod
+	})
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return rows, errs
+}
+
+// fetchSummary fetches the summary of a node through the API server proxy
+func fetchSummary(ctx context.Context, kubeClient kubernetes.Interface, node string) (*stats.Summary, error) {
+	data, err := kubeClient.CoreV1().RESTClient().Get().
+		Resource("nodes").Name(node).SubResource("proxy").Suffix("stats/summary").
+		DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var s stats.Summary
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("error decoding the summary: %w", err)
+	}
+	return &s, nil
+}
+
+// printRows writes the rows as a table, with the namespace column unless a
+// single namespace is printed, followed by the totals
+func printRows(w io.Writer, rows []row, withNamespace bool) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	columns := []string{"NODE", "NAMESPACE", "POD", "EPHEMERAL", "ROOTFS", "LOGS"}
+	if !withNamespace {
+		columns = append(columns[:1], columns[2:]...)
+	}
+	fmt.Fprintln(tw, strings.Join(columns, "\t"))
+
+	var total summary.PodUsage
+	for _, r := range rows {
+		fields := []string{r.node, r.Namespace, r.Pod, formatBytes(r.EphemeralStorageUsedBytes), formatBytes(r.RootfsUsedBytes), formatBytes(r.LogsUsedBytes)}
+		if !withNamespace {
+			fields = append(fields[:1], fields[2:]...)
+		}
+		fmt.Fprintln(tw, strings.Join(fields, "\t"))
+		total.EphemeralStorageUsedBytes += r.EphemeralStorageUsedBytes
+		total.RootfsUsedBytes += r.RootfsUsedBytes
+		total.LogsUsedBytes += r.LogsUsedBytes
+	}
+
+	fields := []string{"TOTAL", "", fmt.Sprintf("%d pods", len(rows)), formatBytes(total.EphemeralStorageUsedBytes), formatBytes(total.RootfsUsedBytes), formatBytes(total.LogsUsedBytes)}
+	if !withNamespace {
+		fields = append(fields[:1], fields[2:]...)
+	}
+	fmt.Fprintln(tw, strings.Join(fields, "\t"))
+	tw.Flush()
+}
+
+// formatBytes formats a size with binary units, e.g. 1.5Gi
+func formatBytes(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d", b)
+	}
+	value, suffix := float64(b)/unit, "Ki"
+	for _, s := range []string{"Mi", "Gi", "Ti", "Pi"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, s
+	}
+	return fmt.Sprintf("%.1f%s", value, suffix)
+}
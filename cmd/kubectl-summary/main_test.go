@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+)
+
+func Test_run(t *testing.T) {
+	srv := fakekubelet.NewServer()
+	t.Cleanup(srv.Close)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Summary: fakekubelet.Fixture("node")})
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--node", "node-a"}, srv.Config(), &stdout, &stderr); code != exitSuccess {
+		t.Fatalf("run() = %d, stderr: %s", code, stderr.String())
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	want := [][]string{
+		{"NODE", "NAMESPACE", "POD", "EPHEMERAL", "ROOTFS", "LOGS"},
+		{"node-a", "mon", "dev-server-0", "127.7Mi", "112.0Ki", "8.0Ki"},
+		{"node-a", "kube-system", "coredns-5d78c9869d-x2x8z", "64.0Ki", "40.0Ki", "20.0Ki"},
+		{"TOTAL", "2", "pods", "127.8Mi", "152.0Ki", "28.0Ki"},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("run() printed %d lines, want %d:\n%s", len(lines), len(want), stdout.String())
+	}
+	for i, line := range lines {
+		if got := strings.Fields(line); strings.Join(got, " ") != strings.Join(want[i], " ") {
+			t.Errorf("line %d = %q, want %q", i, got, want[i])
+		}
+	}
+
+	// A namespace drops the namespace column, the logs sort the pods
+	stdout.Reset()
+	if code := run([]string{"-n", "kube-system", "--sort", "logs"}, srv.Config(), &stdout, &stderr); code != exitSuccess {
+		t.Fatalf("run() = %d, stderr: %s", code, stderr.String())
+	}
+	if out := stdout.String(); strings.Contains(out, "NAMESPACE") || strings.Contains(out, "dev-server-0") || !strings.Contains(out, "coredns-5d78c9869d-x2x8z") {
+		t.Errorf("unexpected output of -n kube-system:\n%s", out)
+	}
+
+	stderr.Reset()
+	if code := run([]string{"--node", "missing"}, srv.Config(), &stdout, &stderr); code != exitFailed {
+		t.Errorf("run() of a missing node = %d, want %d", code, exitFailed)
+	}
+	if !strings.Contains(stderr.String(), "node missing") {
+		t.Errorf("unexpected stderr: %s", stderr.String())
+	}
+
+	if code := run([]string{"--sort", "size"}, srv.Config(), &stdout, &stderr); code != exitUsage {
+		t.Errorf("run() with an unknown sort column = %d, want %d", code, exitUsage)
+	}
+}
+
+func Test_formatBytes(t *testing.T) {
+	for b, want := range map[uint64]string{
+		0:            "0",
+		1023:         "1023",
+		1536:         "1.5Ki",
+		5 << 30:      "5.0Gi",
+		3 << 40 / 2:  "1.5Ti",
+		133947392:    "127.7Mi",
+		1 << 50 * 10: "10.0Pi",
+	} {
+		if got := formatBytes(b); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", b, got, want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/kubernetes"
+)
+
+var coalescedRequests = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: metricsNamespace,
+	Name:      "coalesced_requests_total",
+	Help:      "Number of requests served with the results of an identical collection already in flight",
+})
+
+func init() {
+	prometheus.MustRegister(coalescedRequests)
+}
+
+// collectionCall is a collection in flight, shared by the identical requests
+// that arrive before it completes
+type collectionCall struct {
+	done    chan struct{}
+	results []PerNodeResult
+	err     error
+}
+
+// collectionGroup coalesces the concurrent collections of the same nodes, so
+// that e.g. the two replicas of an HA Prometheus pair scraping /nodes at the
+// same time cause a single round of kubelet queries
+type collectionGroup struct {
+	// timeout bounds the shared collections, 0 for none
+	timeout time.Duration
+
+	mu    sync.Mutex
+	calls map[string]*collectionCall
+}
+
+func newCollectionGroup(timeout time.Duration) *collectionGroup {
+	return &collectionGroup{timeout: timeout, calls: map[string]*collectionCall{}}
+}
+
+// do runs the selector, unless a call with the same key is in flight, in which
+// case its results are returned once it completes. The call runs with the
+// values of the context of the first request but not its cancellation, so
+// that the requests sharing it don't fail when the first one goes away, and
+// with the timeout of the group instead. Every request stops waiting when its
+// own context is done. The results are shared and must not be modified.
+func (g *collectionGroup) do(ctx context.Context, key string, fn func(context.Context) ([]PerNodeResult, error)) ([]PerNodeResult, error) {
+	g.mu.Lock()
+	call, ok := g.calls[key]
+	if ok {
+		coalescedRequests.Inc()
+	} else {
+		call = &collectionCall{done: make(chan struct{})}
+		g.calls[key] = call
+		go g.run(context.WithoutCancel(ctx), key, call, fn)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.results, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// run runs the shared call and forgets it once completed
+func (g *collectionGroup) run(ctx context.Context, key string, call *collectionCall, fn func(context.Context) ([]PerNodeResult, error)) {
+	if g.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.timeout)
+		defer cancel()
+	}
+	call.results, call.err = fn(ctx)
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	close(call.done)
+}
+
+// coalesced returns a selector whose concurrent calls with the same key share
+// a single collection. It returns the selector unchanged if group is nil.
+func coalesced(group *collectionGroup, key string, nodeSelector nodeSelectorFunc) nodeSelectorFunc {
+	if group == nil {
+		return nodeSelector
+	}
+	return func(ctx context.Context, kubeClient *kubernetes.Clientset) ([]PerNodeResult, error) {
+		return group.do(ctx, key, func(ctx context.Context) ([]PerNodeResult, error) {
+			return nodeSelector(ctx, kubeClient)
+		})
+	}
+}
+
+// nodesCollectionKey identifies the collection of all nodes for a request,
+// which depends on the nodes it excludes
+func nodesCollectionKey(r *http.Request) string {
+	var names []string
+	for _, value := range r.URL.Query()["exclude"] {
+		names = append(names, splitList(value)...)
+	}
+	return excludedCollectionKey(names)
+}
+
+// excludedCollectionKey identifies the collection of all nodes but the
+// excluded ones
+func excludedCollectionKey(excluded []string) string {
+	names := slices.Clone(excluded)
+	sort.Strings(names)
+	return "nodes?exclude=" + strings.Join(names, ",")
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+)
+
+func Test_collectionGroup(t *testing.T) {
+	group := newCollectionGroup(0)
+	before := testutil.ToFloat64(coalescedRequests)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	calls := 0
+	fn := func(context.Context) ([]PerNodeResult, error) {
+		calls++
+		close(started)
+		<-release
+		return []PerNodeResult{{NodeName: "node-a"}}, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([][]PerNodeResult, 2)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[0], _ = group.do(context.Background(), "nodes", fn)
+	}()
+	<-started
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[1], _ = group.do(context.Background(), "nodes", fn)
+	}()
+	for testutil.ToFloat64(coalescedRequests)-before < 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// A waiter stops waiting when its own context is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := group.do(ctx, "nodes", fn); err != context.Canceled {
+		t.Errorf("do() with a canceled context returned %v, want %v", err, context.Canceled)
+	}
+
+	close(release)
+	wg.Wait()
+	if calls != 1 {
+		t.Errorf("the collection ran %d times, want 1", calls)
+	}
+	for i, r := range results {
+		if len(r) != 1 || r[0].NodeName != "node-a" {
+			t.Errorf("request %d got %v", i, r)
+		}
+	}
+
+	// The call is forgotten once completed
+	if _, err := group.do(context.Background(), "nodes", func(context.Context) ([]PerNodeResult, error) {
+		calls++
+		return nil, nil
+	}); err != nil || calls != 2 {
+		t.Errorf("do() after the call completed didn't run the collection again")
+	}
+}
+
+func Test_collectionGroup_firstCanceled(t *testing.T) {
+	group := newCollectionGroup(time.Minute)
+	before := testutil.ToFloat64(coalescedRequests)
+
+	release := make(chan struct{})
+	fn := func(ctx context.Context) ([]PerNodeResult, error) {
+		select {
+		case <-release:
+			return []PerNodeResult{{NodeName: "node-a"}}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	first, cancel := context.WithCancel(context.Background())
+	firstDone := make(chan error)
+	go func() {
+		_, err := group.do(first, "nodes", fn)
+		firstDone <- err
+	}()
+	for {
+		group.mu.Lock()
+		_, ok := group.calls["nodes"]
+		group.mu.Unlock()
+		if ok {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	var results []PerNodeResult
+	var err error
+	joined := make(chan struct{})
+	go func() {
+		defer close(joined)
+		results, err = group.do(context.Background(), "nodes", fn)
+	}()
+	for testutil.ToFloat64(coalescedRequests)-before < 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The first request going away doesn't cancel the shared collection
+	cancel()
+	if err := <-firstDone; err != context.Canceled {
+		t.Errorf("do() of the canceled first request returned %v, want %v", err, context.Canceled)
+	}
+	close(release)
+	<-joined
+	if err != nil || len(results) != 1 || results[0].NodeName != "node-a" {
+		t.Errorf("do() of the joined request = %v, %v, want the results of node-a", results, err)
+	}
+}
+
+func TestRouter_coalesceRequests(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Summary: fakekubelet.Fixture("node"), Delay: 500 * time.Millisecond})
+	r := newRouter(kubeClient, nil, nil, allNodesSelector, singleNodeSelector)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/node/node-a", nil))
+			if rec.Code != http.StatusOK {
+				t.Errorf("GET /node/node-a returned %d: %s", rec.Code, rec.Body)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := srv.SummaryRequests("node-a"); n != 1 {
+		t.Errorf("node-a received %d summary requests, want 1", n)
+	}
+}
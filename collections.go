@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// collections holds the last collections of all nodes for /debug/collections
+var collections = newCollectionLog(50)
+
+// backgroundCollectionKey marks the context of the collections of the
+// background loop, as opposed to the ones of a request
+type backgroundCollectionKey struct{}
+
+// collectionRecord is a collection of all nodes
+type collectionRecord struct {
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	DurationSeconds float64   `json:"durationSeconds"`
+	// Background tells whether the collection is a cycle of the background
+	// loop rather than a request
+	Background bool `json:"background"`
+	// Nodes is the number of nodes attempted
+	Nodes  int `json:"nodes"`
+	Failed int `json:"failed"`
+	// ResponseBytes is the size of the /stats/summary responses fetched
+	ResponseBytes int `json:"responseBytes"`
+	// Error is why the nodes couldn't be listed, when the collection failed
+	// as a whole
+	Error string `json:"error,omitempty"`
+}
+
+// collectionsDocument is the response of /debug/collections
+type collectionsDocument struct {
+	// Collections are the last collections, the most recent first
+	Collections []collectionRecord `json:"collections"`
+}
+
+// collectionLog is a ring of the last collections of all nodes, to tell when
+// the collections started failing or slowing down without a Prometheus
+// server scraping the exporter's own metrics
+type collectionLog struct {
+	// size is the number of collections kept, 0 disables the log
+	size int
+
+	mu      sync.Mutex
+	records []collectionRecord
+	next    int
+}
+
+func newCollectionLog(size int) *collectionLog {
+	return &collectionLog{size: size}
+}
+
+// record adds a collection that started at start, overwriting the oldest one
+// once the log is full
+func (l *collectionLog) record(ctx context.Context, start time.Time, results []PerNodeResult, err error) {
+	if l.size <= 0 {
+		return
+	}
+	end := time.Now()
+	c := collectionRecord{
+		Start:           start,
+		End:             end,
+		DurationSeconds: end.Sub(start).Seconds(),
+		Background:      ctx.Value(backgroundCollectionKey{}) != nil,
+		Nodes:           len(results),
+		Failed:          failedNodes(results),
+	}
+	for _, result := range results {
+		c.ResponseBytes += result.ResponseBytes
+	}
+	if err != nil {
+		c.Error = err.Error()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.records) < l.size {
+		l.records = append(l.records, c)
+	} else {
+		l.records[l.next] = c
+	}
+	l.next = (l.next + 1) % l.size
+}
+
+// list returns the collections, the most recent first
+func (l *collectionLog) list() []collectionRecord {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	records := make([]collectionRecord, 0, len(l.records))
+	for i := range l.records {
+		records = append(records, l.records[(l.next-1-i+2*len(l.records))%len(l.records)])
+	}
+	return records
+}
+
+func handleCollections(w http.ResponseWriter, r *http.Request) {
+	writeAPIJSON(w, http.StatusOK, collectionsDocument{Collections: collections.list()})
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+)
+
+func Test_collectionLog(t *testing.T) {
+	l := newCollectionLog(2)
+	ctx := context.Background()
+	start := time.Now()
+
+	l.record(ctx, start, []PerNodeResult{{NodeName: "node-a", ResponseBytes: 10}}, nil)
+	l.record(context.WithValue(ctx, backgroundCollectionKey{}, true), start, []PerNodeResult{
+		{NodeName: "node-a", ResponseBytes: 10},
+		{NodeName: "node-b", Err: errors.New("unreachable")},
+	}, nil)
+	l.record(ctx, start, nil, errors.New("error enumerating nodes"))
+
+	records := l.list()
+	if len(records) != 2 {
+		t.Fatalf("got %d collections, want the last 2", len(records))
+	}
+	if records[0].Error != "error enumerating nodes" || records[0].Nodes != 0 {
+		t.Errorf("got %+v first, want the failed collection", records[0])
+	}
+	if got := records[1]; !got.Background || got.Nodes != 2 || got.Failed != 1 || got.ResponseBytes != 10 {
+		t.Errorf("got %+v second, want the background collection of 2 nodes, 1 failed", got)
+	}
+
+	disabled := newCollectionLog(0)
+	disabled.record(ctx, start, nil, nil)
+	if records := disabled.list(); len(records) != 0 {
+		t.Errorf("got %d collections with a size of 0, want none", len(records))
+	}
+}
+
+func Test_handleCollections(t *testing.T) {
+	defer func(l *collectionLog) { collections = l }(collections)
+	collections = newCollectionLog(10)
+
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Summary: fakekubelet.Fixture("node")})
+	srv.AddNode(fakekubelet.Node{Name: "node-b", StatusCode: http.StatusInternalServerError})
+
+	r := newRouter(kubeClient, nil, nil, allNodesSelector, singleNodeSelector)
+	get(t, r, "/nodes", nil)
+	get(t, r, "/node/node-a", nil)
+
+	code, body := get(t, r, "/debug/collections", nil)
+	if code != http.StatusOK {
+		t.Fatalf("got status %d, want 200", code)
+	}
+	var doc collectionsDocument
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Collections) != 1 {
+		t.Fatalf("got %d collections, want only the one of all nodes: %s", len(doc.Collections), body)
+	}
+	c := doc.Collections[0]
+	if c.Background || c.Nodes != 2 || c.Failed != 1 || c.ResponseBytes == 0 || c.End.Before(c.Start) {
+		t.Errorf("got %+v, want a request collection of 2 nodes, 1 failed", c)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+// config is the YAML file set by --config-file
+type config struct {
+	// MetricRelabelConfigs are applied to every series when it is emitted,
+	// with the semantics of the Prometheus metric_relabel_configs
+	MetricRelabelConfigs []relabelConfig `json:"metric_relabel_configs,omitempty"`
+	// Sinks are the outputs the metrics of all nodes are pushed to, along
+	// with the sinks of the flags
+	Sinks []sinkConfig `json:"sinks,omitempty"`
+	// MetricHelp overrides the help text of the metrics of the catalog, by
+	// name, e.g. to link to internal runbooks
+	MetricHelp map[string]string `json:"metric_help,omitempty"`
+	// CustomMetrics are read from the summaries with JSONPath expressions,
+	// e.g. to export the fields the catalog doesn't map yet
+	CustomMetrics []summary.CustomMetric `json:"custom_metrics,omitempty"`
+}
+
+// metricHelpOverrides and customMetrics are the help texts and custom metrics
+// of the config file
+var (
+	metricHelpOverrides map[string]string
+	customMetrics       []summary.CustomMetric
+)
+
+// loadConfig reads the config file, rejecting unknown fields and invalid sinks,
+// and returns its compiled relabel rules
+func loadConfig(path string) (*config, []relabelRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var c config
+	if err := yaml.UnmarshalStrict(data, &c); err != nil {
+		return nil, nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+
+	rules := make([]relabelRule, 0, len(c.MetricRelabelConfigs))
+	for i, rc := range c.MetricRelabelConfigs {
+		rule, err := rc.compile()
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid metric_relabel_configs[%d] in %s: %w", i, path, err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := summary.ValidateHelpOverrides(c.MetricHelp); err != nil {
+		return nil, nil, fmt.Errorf("invalid metric_help in %s: %w", path, err)
+	}
+	if err := summary.ValidateCustomMetrics(c.CustomMetrics); err != nil {
+		return nil, nil, fmt.Errorf("invalid custom_metrics in %s: %w", path, err)
+	}
+	for i, sc := range c.Sinks {
+		if _, err := newPushSink(sc); err != nil {
+			return nil, nil, fmt.Errorf("invalid sinks[%d] in %s: %w", i, path, err)
+		}
+	}
+	return &c, rules, nil
+}
+
+// pushSinks returns the sinks of the flags and of the config, which has been
+// validated by loadConfig
+func (c *config) pushSinks() []pushSink {
+	var sinks []pushSink
+	for _, sc := range append(flagSinks(), c.Sinks...) {
+		s, err := newPushSink(sc)
+		if err != nil {
+			fmt.Printf("[Error] Ignoring %s sink: %v\n", sc.Type, err)
+			continue
+		}
+		sinks = append(sinks, s)
+	}
+	return sinks
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_loadConfig(t *testing.T) {
+	write := func(content string) string {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	c, rules, err := loadConfig(write(`
+sinks:
+  - type: otlp
+    url: http://collector:4318/v1/metrics
+    interval: 30s
+metric_relabel_configs:
+  - source_labels: [namespace]
+    regex: kube-.*
+    action: drop
+  - source_labels: [namespace]
+    target_label: team
+metric_help:
+  kube_summary_pod_ephemeral_storage_used_bytes: See https://runbooks.example.com/ephemeral-storage
+custom_metrics:
+  - name: kube_summary_pod_swap_usage_bytes
+    path: "{.pods[*]}"
+    value: "{.swap.swapUsageBytes}"
+    labels:
+      namespace: "{.podRef.namespace}"
+      pod: "{.podRef.name}"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 2 || rules[0].action != "drop" || rules[1].action != "replace" || rules[1].replacement != "$1" {
+		t.Errorf("loadConfig() rules = %+v", rules)
+	}
+	if sinks := c.pushSinks(); len(sinks) != 1 || sinks[0].sink.Name() != "otlp" || sinks[0].interval != 30*time.Second {
+		t.Errorf("pushSinks() = %+v", sinks)
+	}
+	if len(c.MetricHelp) != 1 {
+		t.Errorf("loadConfig() metric help = %+v", c.MetricHelp)
+	}
+	if len(c.CustomMetrics) != 1 || c.CustomMetrics[0].Labels["pod"] != "{.podRef.name}" {
+		t.Errorf("loadConfig() custom metrics = %+v", c.CustomMetrics)
+	}
+
+	for _, content := range []string{
+		"metric_relabel_config: []",
+		"metric_relabel_configs: [{action: bogus}]",
+		"sinks: [{type: remote_write}]",
+		"sinks: [{type: otlp, url: http://collector:4318/v1/metrics, interval: soon}]",
+		"metric_help: {kube_summary_unknown: Unknown}",
+		"custom_metrics: [{name: kube_summary_pod_info, value: '{.node}'}]",
+	} {
+		if _, _, err := loadConfig(write(content)); err == nil {
+			t.Errorf("loadConfig(%q) accepted an invalid config", content)
+		}
+	}
+}
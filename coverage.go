@@ -0,0 +1,167 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+// Coverage of a section of the summary of a node
+const (
+	coveragePresent     = "present"
+	coveragePartial     = "partial"
+	coverageAbsent      = "absent"
+	coverageUnsupported = "unsupported"
+)
+
+// Sections of the coverage report
+const (
+	coverageRootfs           = "rootfs"
+	coverageLogs             = "logs"
+	coverageEphemeralStorage = "ephemeral_storage"
+	coverageImageFs          = "imagefs"
+	coverageNetwork          = "network"
+	coverageSwap             = "swap"
+	coveragePSI              = "psi"
+)
+
+// nodeCoverage tells which sections the summary of a node had on its last
+// scrape
+type nodeCoverage struct {
+	Node      string    `json:"node"`
+	ScrapedAt time.Time `json:"scrapedAt"`
+	Provider  string    `json:"provider,omitempty"`
+	// Sections maps each section to present, partial when some of the
+	// containers or pods lack it, absent or unsupported by the provider
+	Sections map[string]string `json:"sections"`
+	// Missing is the number of containers or pods lacking each partial or
+	// absent section
+	Missing map[string]int `json:"missing,omitempty"`
+}
+
+// coverageDocument is the response of /debug/coverage
+type coverageDocument struct {
+	Nodes []nodeCoverage `json:"nodes"`
+}
+
+// coverageStore holds the coverage of the last scrape of every node
+type coverageStore struct {
+	mu    sync.Mutex
+	nodes map[string]nodeCoverage
+}
+
+var lastCoverage = &coverageStore{nodes: map[string]nodeCoverage{}}
+
+func init() {
+	onNodeRemoved(func(nodeName string) {
+		lastCoverage.remove(nodeName)
+	})
+}
+
+func (s *coverageStore) record(c nodeCoverage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nodes[c.Node] = c
+}
+
+// remove forgets the coverage of a node that left the node source
+func (s *coverageStore) remove(nodeName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.nodes, nodeName)
+}
+
+// list returns the coverage of the nodes sorted by name, only the named node
+// if nodeName isn't empty
+func (s *coverageStore) list(nodeName string) []nodeCoverage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	nodes := []nodeCoverage{}
+	for name, c := range s.nodes {
+		if nodeName == "" || name == nodeName {
+			nodes = append(nodes, c)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Node < nodes[j].Node })
+	return nodes
+}
+
+// summaryCoverage returns the coverage of a summary. The container and pod
+// sections are absent when no container or pod has them, including when the
+// node runs no pods at all.
+func summaryCoverage(result PerNodeResult) nodeCoverage {
+	c := nodeCoverage{
+		Node:      result.NodeName,
+		ScrapedAt: result.CollectedAt,
+		Provider:  result.Provider,
+		Sections:  map[string]string{},
+		Missing:   map[string]int{},
+	}
+	s := result.Summary
+	unsupported := summary.UnsupportedSections(result.Provider)
+
+	var containers int
+	for _, pod := range s.Pods {
+		containers += len(pod.Containers)
+	}
+	missing := missingSections(s, nil)
+	counted := func(name, section string, total int) {
+		switch {
+		case unsupported[section]:
+			c.Sections[name] = coverageUnsupported
+		case missing[section] == 0 && total > 0:
+			c.Sections[name] = coveragePresent
+		case missing[section] < total:
+			c.Sections[name] = coveragePartial
+			c.Missing[name] = missing[section]
+		default:
+			c.Sections[name] = coverageAbsent
+			c.Missing[name] = missing[section]
+		}
+	}
+	counted(coverageRootfs, summary.SectionContainerRootfs, containers)
+	counted(coverageLogs, summary.SectionContainerLogs, containers)
+	counted(coverageEphemeralStorage, summary.SectionPodEphemeralStorage, len(s.Pods))
+	counted(coverageImageFs, summary.SectionNodeRuntimeImageFS, 1)
+
+	present := func(name string, ok bool) {
+		c.Sections[name] = coverageAbsent
+		if ok {
+			c.Sections[name] = coveragePresent
+		}
+	}
+	present(coverageNetwork, hasNetworkStats(s.Node.Network))
+	present(coverageSwap, result.Capabilities[summary.CapabilitySwap])
+	present(coveragePSI, result.Capabilities[summary.CapabilityPSI])
+
+	if len(c.Missing) == 0 {
+		c.Missing = nil
+	}
+	return c
+}
+
+// hasNetworkStats returns whether the node reported the stats of at least one
+// interface
+func hasNetworkStats(network *stats.NetworkStats) bool {
+	return network != nil && (network.InterfaceStats.Name != "" || len(network.Interfaces) > 0)
+}
+
+// recordCoverage records the coverage of a successfully scraped summary
+func recordCoverage(result PerNodeResult) {
+	if result.Summary == nil {
+		return
+	}
+	lastCoverage.record(summaryCoverage(result))
+}
+
+// handleCoverage writes the coverage of the last scrape of every node, or of
+// the node of the node query parameter
+func handleCoverage(w http.ResponseWriter, r *http.Request) {
+	writeAPIJSON(w, http.StatusOK, coverageDocument{Nodes: lastCoverage.list(r.URL.Query().Get("node"))})
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+func Test_summaryCoverage(t *testing.T) {
+	collectedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	result := PerNodeResult{
+		NodeName:    "node-a",
+		CollectedAt: collectedAt,
+		Summary: &stats.Summary{
+			Node: stats.NodeStats{
+				Network: &stats.NetworkStats{Interfaces: []stats.InterfaceStats{{Name: "eth0"}}},
+			},
+			Pods: []stats.PodStats{
+				{
+					Containers: []stats.ContainerStats{
+						{Logs: &stats.FsStats{}, Rootfs: &stats.FsStats{}},
+						{Logs: &stats.FsStats{}},
+					},
+				},
+			},
+		},
+		Capabilities: map[string]bool{summary.CapabilityPSI: true},
+	}
+
+	want := nodeCoverage{
+		Node:      "node-a",
+		ScrapedAt: collectedAt,
+		Sections: map[string]string{
+			coverageRootfs:           coveragePartial,
+			coverageLogs:             coveragePresent,
+			coverageEphemeralStorage: coverageAbsent,
+			coverageImageFs:          coverageAbsent,
+			coverageNetwork:          coveragePresent,
+			coverageSwap:             coverageAbsent,
+			coveragePSI:              coveragePresent,
+		},
+		Missing: map[string]int{
+			coverageRootfs:           1,
+			coverageEphemeralStorage: 1,
+			coverageImageFs:          1,
+		},
+	}
+	if diff := cmp.Diff(want, summaryCoverage(result)); diff != "" {
+		t.Errorf("summaryCoverage() mismatch (-want +got):\n%s", diff)
+	}
+
+	result.Provider = "virtual-kubelet"
+	got := summaryCoverage(result)
+	for _, section := range []string{coverageRootfs, coverageLogs, coverageImageFs} {
+		if got.Sections[section] != coverageUnsupported {
+			t.Errorf("%s of a virtual kubelet is %s, want %s", section, got.Sections[section], coverageUnsupported)
+		}
+	}
+}
+
+func Test_handleCoverage(t *testing.T) {
+	defer func(s *coverageStore) { lastCoverage = s }(lastCoverage)
+	lastCoverage = &coverageStore{nodes: map[string]nodeCoverage{}}
+
+	recordCoverage(PerNodeResult{NodeName: "node-b", Summary: &stats.Summary{}})
+	recordCoverage(PerNodeResult{NodeName: "node-a", Summary: &stats.Summary{}})
+	recordCoverage(PerNodeResult{NodeName: "node-c"})
+
+	code, body := get(t, http.HandlerFunc(handleCoverage), "/debug/coverage", nil)
+	if code != http.StatusOK {
+		t.Fatalf("got status %d, want 200", code)
+	}
+	assertContains(t, body, `"node":"node-a"`, `"node":"node-b"`, `"swap":"absent"`)
+	assertNotContains(t, body, "node-c")
+
+	_, body = get(t, http.HandlerFunc(handleCoverage), "/debug/coverage?node=node-b", nil)
+	assertContains(t, body, `"node":"node-b"`)
+	assertNotContains(t, body, "node-a")
+
+	// The nodes that left the node source are forgotten
+	sourceNodes.listed([]corev1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "node-a"}}, {ObjectMeta: metav1.ObjectMeta{Name: "node-b"}}})
+	sourceNodes.listed([]corev1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "node-a"}}})
+	_, body = get(t, http.HandlerFunc(handleCoverage), "/debug/coverage", nil)
+	assertContains(t, body, `"node":"node-a"`)
+	assertNotContains(t, body, "node-b")
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csvHeader is the first row of the files written by the csv sink
+var csvHeader = []string{"timestamp", "metric", "labels", "value"}
+
+// csvSink appends one row per sample to a file, with the labels formatted as
+// in the Prometheus text format, e.g. for spreadsheets or ad hoc analysis
+type csvSink struct {
+	path string
+}
+
+func newCSVSink(path string) *csvSink {
+	return &csvSink{
+		path: path,
+	}
+}
+
+func (s *csvSink) Name() string {
+	return "csv"
+}
+
+func (s *csvSink) Write(ctx context.Context, samples []sample, ts time.Time) error {
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(f)
+	if info.Size() == 0 {
+		_ = cw.Write(csvHeader)
+	}
+	timestamp := ts.UTC().Format(time.RFC3339)
+	for _, s := range samples {
+		_ = cw.Write([]string{timestamp, s.Name, formatLabels(s.Labels), strconv.FormatFloat(s.Value, 'g', -1, 64)})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// formatLabels formats labels as name="value" pairs separated by commas
+func formatLabels(labels []labelPair) string {
+	pairs := make([]string, 0, len(labels))
+	for _, l := range labels {
+		pairs = append(pairs, l.Name+"="+strconv.Quote(l.Value))
+	}
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_csvSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "samples.csv")
+	s := newCSVSink(path)
+	samples := []sample{{Name: "kube_summary_node_scrape_success", Labels: []labelPair{{"node", "node-a"}, {"provider", "aws"}}, Value: 1}}
+	for i := 0; i < 2; i++ {
+		if err := s.Write(context.Background(), samples, time.Unix(1669817681, 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := `2022-11-30T14:14:41Z,kube_summary_node_scrape_success,"node=""node-a"",provider=""aws""",1` + "\n"
+	if want := "timestamp,metric,labels,value\n" + row + row; string(got) != want {
+		t.Errorf("csv sink wrote %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	degraded = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "degraded",
+		Help:      "Whether the last collection of all nodes failed for more than --degraded-failure-ratio of the nodes and the previous successful snapshot was served instead",
+	})
+	degradedSnapshotAge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "degraded_snapshot_age_seconds",
+		Help:      "Age of the snapshot served by the last degraded collection, 0 when not degraded",
+	})
+)
+
+// lastGoodSnapshot holds the last collection of all nodes that didn't exceed
+// --degraded-failure-ratio
+var lastGoodSnapshot = newSnapshotFallback(0, 0)
+
+func init() {
+	prometheus.MustRegister(degraded, degradedSnapshotAge)
+}
+
+// snapshotFallback serves the last successful collection of all nodes when
+// too many nodes fail, e.g. during an API server blip, instead of a partial
+// result whose missing series would fire every absent() alert at once.
+type snapshotFallback struct {
+	// failureRatio is the ratio of failed nodes above which the snapshot is
+	// served, 0 disables the fallback
+	failureRatio float64
+	// maxAge is the age above which the snapshot isn't served anymore, 0 for
+	// no limit
+	maxAge time.Duration
+	now    func() time.Time
+
+	mu          sync.Mutex
+	results     []PerNodeResult
+	collectedAt time.Time
+}
+
+func newSnapshotFallback(failureRatio float64, maxAge time.Duration) *snapshotFallback {
+	return &snapshotFallback{failureRatio: failureRatio, maxAge: maxAge, now: time.Now}
+}
+
+// selector wraps the selector of all nodes. Its results are kept as the
+// snapshot unless too many nodes failed, in which case the snapshot is
+// returned instead. The collections narrowed by the exclude parameter or a
+// SummaryScrape aren't kept, as they don't hold all nodes, but can be served
+// the snapshot, which their filters then apply to. The streamed collections,
+// already written as the nodes are collected, are left alone.
+func (f *snapshotFallback) selector(nodesSelector nodeSelectorFunc) nodeSelectorFunc {
+	return func(ctx context.Context, kubeClient *kubernetes.Clientset) ([]PerNodeResult, error) {
+		results, err := nodesSelector(ctx, kubeClient)
+		if f.failureRatio <= 0 || ctx.Value(resultStreamKey{}) != nil {
+			return results, err
+		}
+
+		if err == nil && failedRatio(results) <= f.failureRatio {
+			if ctx.Value(excludedNodesKey{}) == nil && ctx.Value(nodeLabelSelectorKey{}) == nil {
+				f.store(results)
+			}
+			degraded.Set(0)
+			degradedSnapshotAge.Set(0)
+			return results, nil
+		}
+
+		snapshot, age, ok := f.snapshot()
+		if !ok {
+			return results, err
+		}
+		failure := fmt.Sprintf("%d of %d nodes failed", failedNodes(results), len(results))
+		if err != nil {
+			failure = err.Error()
+		}
+		fmt.Printf("[Warning] Serving the snapshot of all nodes collected %s ago, the collection is degraded: %s\n", age.Round(time.Second), failure)
+		degraded.Set(1)
+		degradedSnapshotAge.Set(age.Seconds())
+		return snapshot, nil
+	}
+}
+
+// store keeps the results as the snapshot
+func (f *snapshotFallback) store(results []PerNodeResult) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.results, f.collectedAt = results, f.now()
+}
+
+// snapshot returns the snapshot and its age, if there is one younger than
+// maxAge
+func (f *snapshotFallback) snapshot() ([]PerNodeResult, time.Duration, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	age := f.now().Sub(f.collectedAt)
+	if f.results == nil || (f.maxAge > 0 && age > f.maxAge) {
+		return nil, 0, false
+	}
+	return f.results, age, true
+}
+
+// failedNodes returns the number of nodes that couldn't be collected
+func failedNodes(results []PerNodeResult) int {
+	var failed int
+	for _, result := range results {
+		if result.Err != nil && result.Summary == nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+// failedRatio returns the ratio of the nodes that couldn't be collected
+func failedRatio(results []PerNodeResult) float64 {
+	if len(results) == 0 {
+		return 0
+	}
+	return float64(failedNodes(results)) / float64(len(results))
+}
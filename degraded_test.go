@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+)
+
+func TestRouter_degraded(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	for _, name := range []string{"node-a", "node-b", "node-c"} {
+		srv.AddNode(fakekubelet.Node{Name: name, Summary: fakekubelet.Fixture("node")})
+	}
+	defer func(f *snapshotFallback) { lastGoodSnapshot = f }(lastGoodSnapshot)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	lastGoodSnapshot = newSnapshotFallback(0.5, 10*time.Minute)
+	lastGoodSnapshot.now = func() time.Time { return now }
+	r := newRouter(kubeClient, nil, nil, allNodesSelector, singleNodeSelector)
+
+	if code, body := get(t, r, "/nodes", nil); code != http.StatusOK {
+		t.Fatalf("GET /nodes returned %d: %s", code, body)
+	}
+
+	// A single failed node is below the ratio
+	srv.AddNode(fakekubelet.Node{Name: "node-a", StatusCode: http.StatusInternalServerError})
+	_, body := get(t, r, "/nodes", nil)
+	assertContains(t, body, `kube_summary_node_scrape_success{kubelet_version="",node="node-a"} 0`)
+
+	// Two failed nodes out of three serve the snapshot, which the exclude
+	// parameter still applies to
+	srv.AddNode(fakekubelet.Node{Name: "node-b", StatusCode: http.StatusInternalServerError})
+	now = now.Add(time.Minute)
+	_, body = get(t, r, "/nodes?exclude=node-c", nil)
+	assertContains(t, body, `kube_summary_node_scrape_success{kubelet_version="",node="node-b"} 1`)
+	assertNotContains(t, body, `node="node-c"`)
+	if got := testutil.ToFloat64(degraded); got != 1 {
+		t.Errorf("kube_summary_degraded = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(degradedSnapshotAge); got != 60 {
+		t.Errorf("kube_summary_degraded_snapshot_age_seconds = %v, want 60", got)
+	}
+
+	// Until the snapshot is too old
+	now = now.Add(10 * time.Minute)
+	_, body = get(t, r, "/nodes", nil)
+	assertContains(t, body, `kube_summary_node_scrape_success{kubelet_version="",node="node-b"} 0`)
+
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Summary: fakekubelet.Fixture("node")})
+	srv.AddNode(fakekubelet.Node{Name: "node-b", Summary: fakekubelet.Fixture("node")})
+	get(t, r, "/nodes", nil)
+	if got := testutil.ToFloat64(degraded); got != 0 {
+		t.Errorf("kube_summary_degraded = %v after recovering, want 0", got)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// devRequestTimeout bounds the API server calls and the collections in --dev
+// mode, so that a stopped kind node fails fast instead of hanging the request
+const devRequestTimeout = 10 * time.Second
+
+// isDevContext returns whether a kubeconfig context is one of the local
+// clusters created by kind or minikube
+func isDevContext(name string) bool {
+	return strings.HasPrefix(name, "kind-") || name == "minikube"
+}
+
+// devKubeContext returns the kubeconfig context of a local kind or minikube
+// cluster, preferring the current context
+func devKubeContext(path string) (string, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if path != "" {
+		loadingRules.ExplicitPath = path
+	}
+	config, err := loadingRules.Load()
+	if err != nil {
+		return "", err
+	}
+	if isDevContext(config.CurrentContext) {
+		return config.CurrentContext, nil
+	}
+
+	names := make([]string, 0, len(config.Contexts))
+	for name := range config.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if isDevContext(name) {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("no kind or minikube context found in the kubeconfig")
+}
+
+// applyDevMode overrides the flags for local development: it selects the
+// context of a local cluster, unless --kube-context is set, and disables the
+// background loops, so that every request collects the nodes live
+func applyDevMode(w io.Writer) error {
+	if *flagKubeContext == "" {
+		name, err := devKubeContext(*flagKubeConfigPath)
+		if err != nil {
+			return err
+		}
+		*flagKubeContext = name
+	}
+
+	*flagCollectionInterval = 0
+	*flagCacheFile = ""
+	*flagKubeConfigReloadInterval = 0
+	*flagCoalesceRequests = false
+	*flagGraphiteAddress = ""
+	*flagStatsdAddress = ""
+	*flagKafkaBrokers = ""
+	*flagObjectStorageBucket = ""
+	*flagWebhookURL = ""
+
+	fmt.Fprintf(w, "Dev mode: using context %s, collecting on every request with a %s timeout, background collection and outputs disabled\n", *flagKubeContext, devRequestTimeout)
+	return nil
+}
+
+// withDevProgress bounds the collections of the requests by devRequestTimeout
+// and prints the outcome of every node to w as it is collected
+func withDevProgress(w io.Writer, next http.Handler) http.Handler {
+	var mu sync.Mutex
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), devRequestTimeout)
+		defer cancel()
+
+		start := time.Now()
+		fmt.Fprintf(w, "%s %s\n", r.Method, r.URL.RequestURI())
+		ctx = withResultStream(ctx, func(result PerNodeResult) {
+			mu.Lock()
+			defer mu.Unlock()
+			fmt.Fprintf(w, "  %s\n", devProgressLine(result, time.Since(start)))
+		})
+		next.ServeHTTP(rw, r.WithContext(ctx))
+		fmt.Fprintf(w, "  done in %s\n", time.Since(start).Round(time.Millisecond))
+	})
+}
+
+// devProgressLine describes the result of a node collected after elapsed
+func devProgressLine(result PerNodeResult, elapsed time.Duration) string {
+	elapsed = elapsed.Round(time.Millisecond)
+	if result.Err != nil {
+		return fmt.Sprintf("✗ %-30s %8s  %v", result.NodeName, elapsed, result.Err)
+	}
+	var pods int
+	if result.Summary != nil {
+		pods = len(result.Summary.Pods)
+	}
+	return fmt.Sprintf("✓ %-30s %8s  %d pods, %d bytes", result.NodeName, elapsed, pods, result.ResponseBytes)
+}
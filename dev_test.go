@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+)
+
+func Test_devKubeContext(t *testing.T) {
+	write := func(current string, contexts ...string) string {
+		var b strings.Builder
+		b.WriteString("apiVersion: v1\nkind: Config\ncurrent-context: " + current + "\ncontexts:\n")
+		for _, name := range contexts {
+			b.WriteString("  - name: " + name + "\n    context: {cluster: " + name + ", user: " + name + "}\n")
+		}
+		path := filepath.Join(t.TempDir(), "kubeconfig")
+		if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	for _, tc := range []struct {
+		path string
+		want string
+	}{
+		{write("prod", "prod", "minikube", "kind-dev"), "kind-dev"},
+		{write("minikube", "kind-dev", "minikube"), "minikube"},
+	} {
+		got, err := devKubeContext(tc.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("devKubeContext() = %s, want %s", got, tc.want)
+		}
+	}
+
+	if _, err := devKubeContext(write("prod", "prod")); err == nil {
+		t.Error("devKubeContext() didn't fail without a local cluster")
+	}
+}
+
+func Test_devProgressLine(t *testing.T) {
+	ok := PerNodeResult{NodeName: "kind-worker", Summary: &stats.Summary{Pods: make([]stats.PodStats, 3)}, ResponseBytes: 2048}
+	if got, want := devProgressLine(ok, 35*time.Millisecond), "✓ kind-worker                        35ms  3 pods, 2048 bytes"; got != want {
+		t.Errorf("devProgressLine() = %q, want %q", got, want)
+	}
+	failed := PerNodeResult{NodeName: "kind-worker2", Err: errors.New("connection refused")}
+	if got := devProgressLine(failed, time.Second); !strings.HasPrefix(got, "✗ kind-worker2") || !strings.HasSuffix(got, "connection refused") {
+		t.Errorf("devProgressLine() = %q", got)
+	}
+}
+
+func Test_withDevProgress(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "kind-worker", Summary: fakekubelet.Fixture("node")})
+
+	var out bytes.Buffer
+	h := withDevProgress(&out, newRouter(kubeClient, nil, nil, allNodesSelector, singleNodeSelector))
+	if code, body := get(t, h, "/nodes", nil); code != http.StatusOK {
+		t.Fatalf("GET /nodes returned %d: %s", code, body)
+	}
+	assertContains(t, out.String(), "GET /nodes\n", "  ✓ kind-worker", "  done in ")
+}
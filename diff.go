@@ -0,0 +1,91 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// podDelta is the change of the storage used by a pod between the last two
+// collection cycles
+type podDelta struct {
+	Node      string `json:"node"`
+	Namespace string `json:"namespace"`
+	Pod       string `json:"pod"`
+	// EphemeralStorageUsedBytes is the usage in the last cycle
+	EphemeralStorageUsedBytes uint64 `json:"ephemeralStorageUsedBytes"`
+	// The deltas are negative when the usage decreased
+	EphemeralStorageUsedBytesDelta int64 `json:"ephemeralStorageUsedBytesDelta"`
+	RootfsUsedBytesDelta           int64 `json:"rootfsUsedBytesDelta"`
+	LogsUsedBytesDelta             int64 `json:"logsUsedBytesDelta"`
+}
+
+// diffDocument is the body of /diff responses
+type diffDocument struct {
+	From time.Time  `json:"from"`
+	To   time.Time  `json:"to"`
+	Pods []podDelta `json:"pods"`
+}
+
+// diff returns the pods seen in both of the last two cycles whose usage
+// changed, the largest ephemeral storage growth first
+func (c *summaryCache) diff() diffDocument {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	doc := diffDocument{From: c.previousUpdated, To: c.updated, Pods: []podDelta{}}
+	if c.cycle < 2 {
+		return doc
+	}
+	for nodeName, node := range c.nodes {
+		for _, pod := range node.pods {
+			if pod.lastSeen != c.cycle || pod.previous == nil || pod.previousSeen != c.cycle-1 {
+				continue
+			}
+			var before, after chargebackUsage
+			before.add(*pod.previous)
+			after.add(pod.stats)
+			d := podDelta{
+				Node:                           nodeName,
+				Namespace:                      pod.stats.PodRef.Namespace,
+				Pod:                            pod.stats.PodRef.Name,
+				EphemeralStorageUsedBytes:      after.ephemeralStorageUsedBytes,
+				EphemeralStorageUsedBytesDelta: int64(after.ephemeralStorageUsedBytes) - int64(before.ephemeralStorageUsedBytes),
+				RootfsUsedBytesDelta:           int64(after.rootfsUsedBytes) - int64(before.rootfsUsedBytes),
+				LogsUsedBytesDelta:             int64(after.logsUsedBytes) - int64(before.logsUsedBytes),
+			}
+			if d.EphemeralStorageUsedBytesDelta != 0 || d.RootfsUsedBytesDelta != 0 || d.LogsUsedBytesDelta != 0 {
+				doc.Pods = append(doc.Pods, d)
+			}
+		}
+	}
+	sort.Slice(doc.Pods, func(i, j int) bool {
+		a, b := doc.Pods[i], doc.Pods[j]
+		if a.EphemeralStorageUsedBytesDelta != b.EphemeralStorageUsedBytesDelta {
+			return a.EphemeralStorageUsedBytesDelta > b.EphemeralStorageUsedBytesDelta
+		}
+		return a.Node+"/"+a.Namespace+"/"+a.Pod < b.Node+"/"+b.Namespace+"/"+b.Pod
+	})
+	return doc
+}
+
+// handleDiff serves the per pod usage deltas between the last two background
+// collection cycles, limited to the first limit pods if set
+func handleDiff(w http.ResponseWriter, r *http.Request, cache *summaryCache) {
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, r, http.StatusBadRequest, apiError{Error: "invalid limit " + strconv.Quote(v), Reason: reasonBadRequest})
+			return
+		}
+		limit = n
+	}
+
+	doc := cache.diff()
+	if limit > 0 && len(doc.Pods) > limit {
+		doc.Pods = doc.Pods[:limit]
+	}
+	writeAPIJSON(w, http.StatusOK, doc)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+func Test_summaryCache_diff(t *testing.T) {
+	result := func(nodeName string, usedBytes map[string]uint64) PerNodeResult {
+		summary := &stats.Summary{Node: stats.NodeStats{NodeName: nodeName}}
+		for name, used := range usedBytes {
+			summary.Pods = append(summary.Pods, stats.PodStats{
+				PodRef:           stats.PodReference{Name: name, Namespace: "ns"},
+				EphemeralStorage: &stats.FsStats{UsedBytes: &used},
+			})
+		}
+		return PerNodeResult{NodeName: nodeName, Summary: summary}
+	}
+
+	cache := newSummaryCache(3)
+	cache.update([]PerNodeResult{result("a", map[string]uint64{"grows": 1 << 20, "shrinks": 1 << 30, "same": 10})})
+	if got := cache.diff().Pods; len(got) != 0 {
+		t.Errorf("diff() after a single cycle = %v, want no pods", got)
+	}
+
+	cache.update([]PerNodeResult{result("a", map[string]uint64{"grows": 3 << 30, "shrinks": 1 << 20, "same": 10, "new": 1 << 30})})
+	doc := cache.diff()
+	want := []podDelta{
+		{Node: "a", Namespace: "ns", Pod: "grows", EphemeralStorageUsedBytes: 3 << 30, EphemeralStorageUsedBytesDelta: 3<<30 - 1<<20},
+		{Node: "a", Namespace: "ns", Pod: "shrinks", EphemeralStorageUsedBytes: 1 << 20, EphemeralStorageUsedBytesDelta: 1<<20 - 1<<30},
+	}
+	if diff := cmp.Diff(want, doc.Pods); diff != "" {
+		t.Errorf("diff() mismatch (-want +got):\n%s", diff)
+	}
+	if !doc.From.Before(doc.To) {
+		t.Errorf("diff() is from %v to %v", doc.From, doc.To)
+	}
+
+	// A pod missing from a cycle has no delta in the next one
+	cache.update([]PerNodeResult{result("a", map[string]uint64{"grows": 4 << 30})})
+	cache.update([]PerNodeResult{result("a", map[string]uint64{"grows": 5 << 30, "shrinks": 1})})
+	if got := cache.diff().Pods; len(got) != 1 || got[0].Pod != "grows" {
+		t.Errorf("diff() = %v, want only the grows pod", got)
+	}
+}
+
+func TestRouter_diff(t *testing.T) {
+	_, kubeClient := newTestServer(t)
+	cache := newSummaryCache(1)
+	for _, used := range []uint64{1000, 1000000} {
+		pod := stats.PodStats{PodRef: stats.PodReference{Name: "p", Namespace: "ns"}, EphemeralStorage: &stats.FsStats{UsedBytes: &used}}
+		cache.update([]PerNodeResult{{NodeName: "a", Summary: &stats.Summary{Pods: []stats.PodStats{pod}}}})
+	}
+
+	code, body := get(t, newRouter(kubeClient, cache, nil, allNodesSelector, singleNodeSelector), "/diff?limit=10", nil)
+	if code != http.StatusOK {
+		t.Fatalf("GET /diff returned %d: %s", code, body)
+	}
+	var doc diffDocument
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Pods) != 1 || doc.Pods[0].EphemeralStorageUsedBytesDelta != 999000 {
+		t.Errorf("GET /diff returned %s", body)
+	}
+
+	if code, _ := get(t, newRouter(kubeClient, nil, nil, allNodesSelector, singleNodeSelector), "/diff", nil); code != http.StatusNotFound {
+		t.Errorf("GET /diff without background collection returned %d, want %d", code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"flag"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// redacted replaces the secrets of the effective config, as in the URLs
+// redacted by net/url
+const redacted = "xxxxx"
+
+// secretFlags are the flags whose whole value is a secret, e.g. the Slack
+// webhook URLs holding their token in the path. The URLs of the other
+// flags only get their password redacted.
+var secretFlags = map[string]bool{
+	"webhook-url": true,
+}
+
+// activeConfig is the config file loaded on startup, empty without
+// --config-file
+var activeConfig = &config{}
+
+// effectiveConfigDocument is the response of /-/config
+type effectiveConfigDocument struct {
+	// Flags are the values of all the flags, their default if unset
+	Flags map[string]string `json:"flags"`
+	// ConfigFile is the content of the --config-file
+	ConfigFile     *config                 `json:"configFile"`
+	ConfigFilePath string                  `json:"configFilePath,omitempty"`
+	SummaryScrapes []summaryScrapeDocument `json:"summaryScrapes,omitempty"`
+	InvalidScrapes []string                `json:"invalidSummaryScrapes,omitempty"`
+	Filters        filtersDocument         `json:"filters"`
+}
+
+// summaryScrapeDocument is a valid SummaryScrape resource, as applied
+type summaryScrapeDocument struct {
+	// Namespace is empty for a ClusterSummaryScrape
+	Namespace    string            `json:"namespace,omitempty"`
+	Name         string            `json:"name"`
+	NodeSelector string            `json:"nodeSelector,omitempty"`
+	Namespaces   []string          `json:"namespaces,omitempty"`
+	Sections     []string          `json:"sections,omitempty"`
+	ExtraLabels  map[string]string `json:"extraLabels,omitempty"`
+	Thresholds   []string          `json:"thresholds,omitempty"`
+}
+
+// filtersDocument are the filters deciding which nodes, pods and series are
+// exported
+type filtersDocument struct {
+	// ExcludeNodes are the --exclude-node patterns
+	ExcludeNodes []string `json:"excludeNodes,omitempty"`
+	// Shard is the shard of the nodes of this replica, if sharded
+	Shard *shardDocument `json:"shard,omitempty"`
+	// MaxPodsPerNode is --max-pods-per-node, 0 for no limit
+	MaxPodsPerNode int    `json:"maxPodsPerNode"`
+	MirrorPods     string `json:"mirrorPods"`
+	// PodScrapeAnnotation is --pod-scrape-annotation and OptedOutPods the
+	// namespace/name of the pods it opts out
+	PodScrapeAnnotation string   `json:"podScrapeAnnotation,omitempty"`
+	OptedOutPods        []string `json:"optedOutPods,omitempty"`
+	// OmitZeroValues are the sections whose zero values aren't exported
+	OmitZeroValues []string `json:"omitZeroValues,omitempty"`
+	// MetricRelabelRules is the number of metric_relabel_configs applied to
+	// every series
+	MetricRelabelRules int `json:"metricRelabelRules"`
+}
+
+// shardDocument is the nodeSharding of this replica
+type shardDocument struct {
+	Shards int    `json:"shards"`
+	Shard  int    `json:"shard"`
+	Zone   string `json:"zone,omitempty"`
+}
+
+// handleEffectiveConfig writes the configuration the exporter runs with, from
+// the flags, config file and SummaryScrape resources, with the secrets
+// redacted, and the filters it applies, to tell why a node or pod isn't
+// exported
+func handleEffectiveConfig(scrapes *summaryScrapes) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeAPIJSON(w, http.StatusOK, effectiveConfig(flag.CommandLine, activeConfig, scrapes))
+	}
+}
+
+// effectiveConfig returns the document of /-/config
+func effectiveConfig(fs *flag.FlagSet, cfg *config, scrapes *summaryScrapes) effectiveConfigDocument {
+	doc := effectiveConfigDocument{
+		Flags:          map[string]string{},
+		ConfigFile:     redactConfig(cfg),
+		ConfigFilePath: *flagConfigFile,
+		Filters: filtersDocument{
+			MaxPodsPerNode:     *flagMaxPodsPerNode,
+			MirrorPods:         flagMirrorPods.value,
+			MetricRelabelRules: len(metricRelabelRules),
+		},
+	}
+	fs.VisitAll(func(f *flag.Flag) {
+		doc.Flags[f.Name] = redactFlag(f)
+	})
+
+	if patterns := flagExcludeNodes.String(); patterns != "" {
+		doc.Filters.ExcludeNodes = strings.Split(patterns, ",")
+	}
+	if nodeShard.enabled() {
+		doc.Filters.Shard = &shardDocument{Shards: max(nodeShard.shards, 1), Shard: nodeShard.shard, Zone: nodeShard.zone}
+	}
+	if *flagPodScrapeAnnotation != "" {
+		doc.Filters.PodScrapeAnnotation = *flagPodScrapeAnnotation
+		doc.Filters.OptedOutPods = optedOutPods.list()
+	}
+	if sections := flagOmitZeroValues.String(); sections != "" {
+		doc.Filters.OmitZeroValues = strings.Split(sections, ",")
+	}
+
+	if scrapes != nil {
+		for _, s := range scrapes.list() {
+			doc.SummaryScrapes = append(doc.SummaryScrapes, newSummaryScrapeDocument(s))
+		}
+		doc.InvalidScrapes = scrapes.invalidKeys()
+	}
+	return doc
+}
+
+// newSummaryScrapeDocument returns the document of a SummaryScrape
+func newSummaryScrapeDocument(s *summaryScrape) summaryScrapeDocument {
+	doc := summaryScrapeDocument{
+		Namespace:   s.namespace,
+		Name:        s.name,
+		Namespaces:  sortedKeys(s.namespaces),
+		Sections:    sortedKeys(s.sections),
+		ExtraLabels: s.extraLabels,
+	}
+	if !s.nodeSelector.Empty() {
+		doc.NodeSelector = s.nodeSelector.String()
+	}
+	for _, rule := range s.rules {
+		doc.Thresholds = append(doc.Thresholds, rule.String())
+	}
+	return doc
+}
+
+// invalidKeys returns the namespace/name of the SummaryScrape resources
+// ignored for their invalid spec, sorted
+func (c *summaryScrapes) invalidKeys() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return sortedKeys(c.invalid)
+}
+
+// sortedKeys returns the keys of the set, sorted
+func sortedKeys(set map[string]bool) []string {
+	var keys []string
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// redactFlag returns the value of the flag with its secrets redacted: the
+// values of the headers, the passwords of the URLs and the whole value of the
+// secretFlags
+func redactFlag(f *flag.Flag) string {
+	value := f.Value.String()
+	switch {
+	case value == "":
+		return value
+	case secretFlags[f.Name]:
+		return redacted
+	}
+	if headers, ok := f.Value.(headerFlag); ok {
+		return redactHeaders(http.Header(headers)).String()
+	}
+	return redactURL(value)
+}
+
+// redactConfig returns a copy of the config file with the header values and
+// URL passwords of its sinks redacted
+func redactConfig(cfg *config) *config {
+	c := *cfg
+	c.Sinks = make([]sinkConfig, len(cfg.Sinks))
+	for i, sc := range cfg.Sinks {
+		sc.URL = redactURL(sc.URL)
+		if sc.Headers != nil {
+			headers := make(map[string]string, len(sc.Headers))
+			for name := range sc.Headers {
+				headers[name] = redacted
+			}
+			sc.Headers = headers
+		}
+		c.Sinks[i] = sc
+	}
+	return &c
+}
+
+// redactHeaders returns the headers with their values redacted
+func redactHeaders(h http.Header) headerFlag {
+	r := headerFlag{}
+	for name, values := range h {
+		for range values {
+			http.Header(r).Add(name, redacted)
+		}
+	}
+	return r
+}
+
+// redactURL returns the value with the password redacted if it's a URL with
+// one, unchanged otherwise
+func redactURL(value string) string {
+	u, err := url.Parse(value)
+	if err != nil || u.User == nil {
+		return value
+	}
+	if _, ok := u.User.Password(); !ok {
+		return value
+	}
+	return u.Redacted()
+}
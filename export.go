@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/pager"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+// chargebackUsage is the storage used by the pods of a group
+type chargebackUsage struct {
+	pods                      int
+	ephemeralStorageUsedBytes uint64
+	rootfsUsedBytes           uint64
+	logsUsedBytes             uint64
+}
+
+// add adds the usage of a pod, the rootfs and logs of its containers
+func (u *chargebackUsage) add(pod stats.PodStats) {
+	usage := summary.NewPodUsage(pod)
+	u.pods++
+	u.ephemeralStorageUsedBytes += usage.EphemeralStorageUsedBytes
+	u.rootfsUsedBytes += usage.RootfsUsedBytes
+	u.logsUsedBytes += usage.LogsUsedBytes
+}
+
+// parseGroupBy returns the pod label of a groupBy query parameter, namespace
+// or label:<name>, or "" to group by namespace
+func parseGroupBy(groupBy string) (string, error) {
+	switch {
+	case groupBy == "" || groupBy == "namespace":
+		return "", nil
+	case strings.HasPrefix(groupBy, "label:") && len(groupBy) > len("label:"):
+		return strings.TrimPrefix(groupBy, "label:"), nil
+	}
+	return "", fmt.Errorf("invalid groupBy %q, expected namespace or label:<name>", groupBy)
+}
+
+// aggregateUsage sums the usage of the pods by namespace, or by the value of a
+// pod label looked up in podLabels by namespace/name
+func aggregateUsage(results []PerNodeResult, label string, podLabels map[string]map[string]string) map[string]*chargebackUsage {
+	groups := map[string]*chargebackUsage{}
+	for _, result := range results {
+		if result.Summary == nil {
+			continue
+		}
+		for _, pod := range result.Summary.Pods {
+			group := pod.PodRef.Namespace
+			if label != "" {
+				group = podLabels[pod.PodRef.Namespace+"/"+pod.PodRef.Name][label]
+			}
+			if groups[group] == nil {
+				groups[group] = &chargebackUsage{}
+			}
+			groups[group].add(pod)
+		}
+	}
+	return groups
+}
+
+// listPodLabels returns the labels of the pods of every namespace by
+// namespace/name
+func listPodLabels(ctx context.Context, kubeClient *kubernetes.Clientset) (map[string]map[string]string, error) {
+	p := pager.New(func(ctx context.Context, opts meta_v1.ListOptions) (runtime.Object, error) {
+		return kubeClient.CoreV1().Pods("").List(ctx, opts)
+	})
+
+	podLabels := map[string]map[string]string{}
+	err := p.EachListItem(ctx, meta_v1.ListOptions{}, func(obj runtime.Object) error {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok {
+			return fmt.Errorf("unexpected object %T in pod list", obj)
+		}
+		podLabels[pod.Namespace+"/"+pod.Name] = pod.Labels
+		return nil
+	})
+	return podLabels, err
+}
+
+// handleCSVExport serves the storage used by the pods of the latest
+// collection, aggregated by namespace or by pod label, as CSV for chargeback
+// tooling. Pods without the label are aggregated in the empty group.
+func handleCSVExport(w http.ResponseWriter, r *http.Request, kubeClient *kubernetes.Clientset, nodesSelector nodeSelectorFunc) {
+	label, err := parseGroupBy(r.URL.Query().Get("groupBy"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apiError{Error: err.Error(), Reason: reasonBadRequest})
+		return
+	}
+
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	ctx, span := tracer.Start(ctx, "handleCSVExport", trace.WithAttributes(attribute.String("http.target", r.URL.Path)))
+	defer span.End()
+
+	ctx, cancel := getTimeoutContext(r.WithContext(ctx))
+	defer cancel()
+
+	results, err := nodesSelector(ctx, kubeClient)
+	if err == nil {
+		err = allFailed(results)
+	}
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		writeCollectionError(w, r, err)
+		return
+	}
+
+	var podLabels map[string]map[string]string
+	if label != "" {
+		if podLabels, err = listPodLabels(ctx, kubeClient); err != nil {
+			span.SetStatus(codes.Error, err.Error())
+			logError(ctx, "Listing the pods of %s failed: %v", r.URL.Path, err)
+			writeError(w, r, http.StatusInternalServerError, apiError{Error: fmt.Sprintf("error listing pods: %v", err), Reason: reasonInternal})
+			return
+		}
+	}
+
+	groups := aggregateUsage(results, label, podLabels)
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	header := "namespace"
+	if label != "" {
+		header = label
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="kube-summary.csv"`)
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{header, "pods", "ephemeral_storage_used_bytes", "rootfs_used_bytes", "logs_used_bytes"})
+	for _, name := range names {
+		u := groups[name]
+		_ = cw.Write([]string{
+			name,
+			strconv.Itoa(u.pods),
+			strconv.FormatUint(u.ephemeralStorageUsedBytes, 10),
+			strconv.FormatUint(u.rootfsUsedBytes, 10),
+			strconv.FormatUint(u.logsUsedBytes, 10),
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		fmt.Printf("[Error] Writing the CSV export failed: %v\n", err)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+)
+
+func Test_parseGroupBy(t *testing.T) {
+	for groupBy, want := range map[string]string{"": "", "namespace": "", "label:team": "team"} {
+		if got, err := parseGroupBy(groupBy); err != nil || got != want {
+			t.Errorf("parseGroupBy(%q) = %q, %v, want %q", groupBy, got, err, want)
+		}
+	}
+	for _, groupBy := range []string{"label:", "pod", "team"} {
+		if _, err := parseGroupBy(groupBy); err == nil {
+			t.Errorf("parseGroupBy(%q) didn't fail", groupBy)
+		}
+	}
+}
+
+func TestRouter_csvExport(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	// Both nodes report the same pods, which the fake API server lists twice
+	podLabels := map[string]map[string]string{"mon/dev-server-0": {"team": "observability"}}
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Summary: fakekubelet.Fixture("node"), PodLabels: podLabels})
+	srv.AddNode(fakekubelet.Node{Name: "node-b", Summary: fakekubelet.Fixture("node"), PodLabels: podLabels})
+	r := newRouter(kubeClient, nil, nil, allNodesSelector, singleNodeSelector)
+
+	code, body := get(t, r, "/export/csv?groupBy=namespace", nil)
+	if code != http.StatusOK {
+		t.Fatalf("GET /export/csv returned %d: %s", code, body)
+	}
+	want := `namespace,pods,ephemeral_storage_used_bytes,rootfs_used_bytes,logs_used_bytes
+kube-system,2,131072,81920,40960
+mon,2,267894784,229376,16384
+`
+	if body != want {
+		t.Errorf("GET /export/csv?groupBy=namespace returned\n%s\nwant\n%s", body, want)
+	}
+
+	_, body = get(t, r, "/export/csv?groupBy=label:team", nil)
+	want = `team,pods,ephemeral_storage_used_bytes,rootfs_used_bytes,logs_used_bytes
+,2,131072,81920,40960
+observability,2,267894784,229376,16384
+`
+	if body != want {
+		t.Errorf("GET /export/csv?groupBy=label:team returned\n%s\nwant\n%s", body, want)
+	}
+
+	if code, _ := get(t, r, "/export/csv?groupBy=pod", nil); code != http.StatusBadRequest {
+		t.Errorf("GET /export/csv with an invalid groupBy returned %d, want %d", code, http.StatusBadRequest)
+	}
+}
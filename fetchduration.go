@@ -0,0 +1,37 @@
+package main
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var fetchDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace:                   metricsNamespace,
+	Name:                        "node_summary_fetch_duration_seconds",
+	Help:                        "Duration of the /stats/summary requests by the --fetch-duration-node-label label of the nodes",
+	Buckets:                     prometheus.ExponentialBuckets(0.01, 2, 12),
+	NativeHistogramBucketFactor: 1.1,
+},
+	[]string{
+		"node_group",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(fetchDuration)
+}
+
+// observeFetchDuration records the duration of a summary request of the node,
+// a retry being a request of its own, grouped by the value of the node label
+// rather than per node, which keeps the cardinality of the histogram down to
+// the number of groups (e.g. node pools). All nodes fall into the empty group
+// if the label is empty.
+func observeFetchDuration(node corev1.Node, label string, d time.Duration) {
+	var group string
+	if label != "" {
+		group = node.Labels[label]
+	}
+	fetchDuration.WithLabelValues(group).Observe(d.Seconds())
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	corev1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_observeFetchDuration(t *testing.T) {
+	count := func(group string) uint64 {
+		var m dto.Metric
+		if err := fetchDuration.WithLabelValues(group).(prometheus.Histogram).Write(&m); err != nil {
+			t.Fatal(err)
+		}
+		return m.GetHistogram().GetSampleCount()
+	}
+	node := func(pool string) corev1.Node {
+		return corev1.Node{ObjectMeta: meta_v1.ObjectMeta{Labels: map[string]string{"pool": pool}}}
+	}
+
+	a, b, none := count("test-a"), count("test-b"), count("")
+	observeFetchDuration(node("test-a"), "pool", time.Second)
+	observeFetchDuration(node("test-a"), "pool", time.Second)
+	observeFetchDuration(node("test-b"), "pool", time.Second)
+	observeFetchDuration(node("test-b"), "", time.Second)
+
+	if got := count("test-a") - a; got != 2 {
+		t.Errorf("test-a group observed %d durations, want 2", got)
+	}
+	if got := count("test-b") - b; got != 1 {
+		t.Errorf("test-b group observed %d durations, want 1", got)
+	}
+	if got := count("") - none; got != 1 {
+		t.Errorf("empty group observed %d durations, want 1", got)
+	}
+}
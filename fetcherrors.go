@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"sync"
+	"syscall"
+
+	"github.com/prometheus/client_golang/prometheus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var summaryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metricsNamespace,
+	Name:      "node_summary_errors_total",
+	Help:      "Number of failed /stats/summary requests by error class (too_large, unmarshal, unauthorized, forbidden, not_found, timeout, connection_refused, server_error, canceled or other), HTTP status code and, for the common setup failures, reason (rbac_nodes_proxy, kubelet_authorization, kubelet_path_not_found or node_not_found)",
+},
+	[]string{
+		"node",
+		"class",
+		"code",
+		"reason",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(summaryErrors)
+	onNodeRemoved(func(nodeName string) {
+		summaryErrors.DeletePartialMatch(prometheus.Labels{"node": nodeName})
+	})
+}
+
+// classifySummaryError returns the class of a getNodeSummary error, telling
+// RBAC, networking and kubelet health issues apart, along with the HTTP status
+// code returned by the API server, if any
+func classifySummaryError(err error) (class, code string) {
+	var status apierrors.APIStatus
+	if errors.As(err, &status) {
+		code = strconv.Itoa(int(status.Status().Code))
+	}
+
+	var (
+		netErr    net.Error
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.Is(err, errSummaryTooLarge):
+		return "too_large", code
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return "unmarshal", code
+	case apierrors.IsUnauthorized(err):
+		return "unauthorized", code
+	case apierrors.IsForbidden(err):
+		return "forbidden", code
+	case apierrors.IsNotFound(err):
+		return "not_found", code
+	case errors.Is(err, context.DeadlineExceeded), apierrors.IsTimeout(err), apierrors.IsServerTimeout(err),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout", code
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "connection_refused", code
+	case apierrors.IsServiceUnavailable(err), apierrors.IsInternalError(err), apierrors.IsUnexpectedServerError(err):
+		return "server_error", code
+	case errors.Is(err, context.Canceled):
+		return "canceled", code
+	}
+	return "other", code
+}
+
+// Reasons of the forbidden and not found summary errors, the most common setup
+// failures, see summaryErrorReason
+const (
+	summaryReasonNodesProxyRBAC      = "rbac_nodes_proxy"
+	summaryReasonKubeletAuthz        = "kubelet_authorization"
+	summaryReasonNodeNotFound        = "node_not_found"
+	summaryReasonKubeletPathNotFound = "kubelet_path_not_found"
+)
+
+var (
+	// rbacForbiddenRegexp matches the denials of the RBAC authorizer of the
+	// API server
+	rbacForbiddenRegexp = regexp.MustCompile(`User "([^"]*)" cannot get resource "nodes/proxy"`)
+	// kubeletForbiddenRegexp matches the denials of the webhook authorizer of
+	// the kubelet, relayed by the API server proxy
+	kubeletForbiddenRegexp = regexp.MustCompile(`Forbidden \(user=([^,]*), verb=[^,]*, resource=[^,]*, subresource=([^)]*)\)`)
+)
+
+// summaryErrorReason returns the reason of a forbidden or not found
+// getNodeSummary error, along with a hint to remediate it, or an empty reason
+// if it's none of the known ones. The API server answers with a Status of its
+// own, while the responses of the kubelet it relays are turned into a generic
+// error by client-go.
+func summaryErrorReason(nodeName string, err error) (reason, hint string) {
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) {
+		return "", ""
+	}
+	s := status.Status()
+	fromKubelet := s.Details != nil && len(s.Details.Causes) > 0 && s.Details.Causes[0].Type == meta_v1.CauseTypeUnexpectedServerResponse
+
+	switch {
+	case apierrors.IsForbidden(err):
+		if m := rbacForbiddenRegexp.FindStringSubmatch(s.Message); m != nil {
+			return summaryReasonNodesProxyRBAC, fmt.Sprintf("%s can't get nodes/proxy, which the summaries are fetched through: bind it to a ClusterRole granting get on nodes/proxy, see manifests/cluster/clusterrole.yaml", m[1])
+		}
+		if m := kubeletForbiddenRegexp.FindStringSubmatch(s.Message); m != nil {
+			return summaryReasonKubeletAuthz, fmt.Sprintf("the kubelet of node %s denied %s get on nodes/%s through its webhook authorization: grant it, e.g. by binding %s to the system:kubelet-api-admin ClusterRole", nodeName, m[1], m[2], m[1])
+		}
+	case apierrors.IsNotFound(err):
+		if fromKubelet {
+			return summaryReasonKubeletPathNotFound, fmt.Sprintf("the kubelet of node %s doesn't serve /stats/summary, e.g. a virtual kubelet without the summary API or a --kubelet-port answering another server: skip it with --exclude-node=%s", nodeName, nodeName)
+		}
+		return summaryReasonNodeNotFound, fmt.Sprintf("node %s isn't known to the API server: it was deleted since the nodes were listed, or the node source lists a node that doesn't exist, e.g. a stale --nodes or --nodes-file entry", nodeName)
+	}
+	return "", ""
+}
+
+// loggedSummaryHints are the reasons whose hint was logged
+var loggedSummaryHints sync.Map
+
+// logSummaryHint logs the hint of the reason the first time a node fails with
+// it, the other nodes failing the same way being counted by summaryErrors
+func logSummaryHint(nodeName, reason, hint string) {
+	if _, logged := loggedSummaryHints.LoadOrStore(reason, true); logged {
+		return
+	}
+	fmt.Printf("[Warning] Fetching /stats/summary failed reason=%s node=%s hint=%q\n", reason, nodeName, hint)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+)
+
+func Test_classifySummaryError(t *testing.T) {
+	nodes := schema.GroupResource{Resource: "nodes"}
+	wrap := func(err error) error {
+		return fmt.Errorf("error querying /stats/summary for node-a: %w", err)
+	}
+	var unmarshalErr error = json.Unmarshal([]byte("{"), &struct{}{})
+
+	for _, tc := range []struct {
+		err         error
+		class, code string
+	}{
+		{wrap(apierrors.NewForbidden(nodes, "node-a", fmt.Errorf("denied"))), "forbidden", "403"},
+		{wrap(apierrors.NewNotFound(nodes, "node-a")), "not_found", "404"},
+		{wrap(apierrors.NewUnauthorized("no token")), "unauthorized", "401"},
+		{wrap(apierrors.NewServiceUnavailable("kubelet down")), "server_error", "503"},
+		{wrap(apierrors.NewGenericServerResponse(http.StatusBadGateway, "get", nodes, "node-a", "", 0, true)), "server_error", "502"},
+		{wrap(fmt.Errorf("unexpected")), "other", ""},
+		{wrap(context.DeadlineExceeded), "timeout", ""},
+		{wrap(syscall.ECONNREFUSED), "connection_refused", ""},
+		{wrap(unmarshalErr), "unmarshal", ""},
+		{wrap(errSummaryTooLarge), "too_large", ""},
+	} {
+		class, code := classifySummaryError(tc.err)
+		if class != tc.class || code != tc.code {
+			t.Errorf("classifySummaryError(%v) = %q, %q, want %q, %q", tc.err, class, code, tc.class, tc.code)
+		}
+	}
+}
+
+func Test_summaryErrorReason(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{
+		Name:       "node-rbac",
+		StatusCode: http.StatusForbidden,
+		ErrorBody:  `{"kind":"Status","apiVersion":"v1","status":"Failure","message":"nodes \"node-rbac\" is forbidden: User \"system:serviceaccount:sys-mon:kube-summary-exporter\" cannot get resource \"nodes/proxy\" in API group \"\" at the cluster scope","reason":"Forbidden","details":{"name":"node-rbac","kind":"nodes"},"code":403}`,
+	})
+	srv.AddNode(fakekubelet.Node{
+		Name:       "node-kubelet",
+		StatusCode: http.StatusForbidden,
+		ErrorBody:  "Forbidden (user=kube-apiserver-kubelet-client, verb=get, resource=nodes, subresource=stats)",
+	})
+	srv.AddNode(fakekubelet.Node{Name: "node-virtual", StatusCode: http.StatusNotFound, ErrorBody: "404 page not found"})
+	srv.AddNode(fakekubelet.Node{Name: "node-other", StatusCode: http.StatusForbidden})
+
+	for _, tc := range []struct {
+		node, reason, hint string
+	}{
+		{"node-rbac", summaryReasonNodesProxyRBAC, "system:serviceaccount:sys-mon:kube-summary-exporter can't get nodes/proxy"},
+		{"node-kubelet", summaryReasonKubeletAuthz, "denied kube-apiserver-kubelet-client get on nodes/stats"},
+		{"node-virtual", summaryReasonKubeletPathNotFound, "--exclude-node=node-virtual"},
+		{"node-gone", summaryReasonNodeNotFound, "node node-gone isn't known to the API server"},
+		{"node-other", "", ""},
+	} {
+		_, _, _, err := getNodeSummary(context.Background(), kubeClient, tc.node)
+		if err == nil {
+			t.Fatalf("getNodeSummary(%s) didn't fail", tc.node)
+		}
+		reason, hint := summaryErrorReason(tc.node, err)
+		if reason != tc.reason || !strings.Contains(hint, tc.hint) {
+			t.Errorf("summaryErrorReason(%s, %v) = %q, %q, want %q and a hint containing %q", tc.node, err, reason, hint, tc.reason, tc.hint)
+		}
+		class, code := classifySummaryError(err)
+		if got := testutil.ToFloat64(summaryErrors.WithLabelValues(tc.node, class, code, tc.reason)); got != 1 {
+			t.Errorf("%s summary errors with reason %q = %v, want 1", tc.node, tc.reason, got)
+		}
+	}
+}
+
+func Test_summaryErrors_removedNodes(t *testing.T) {
+	summaryErrors.WithLabelValues("node-left", "timeout", "", "").Inc()
+	summaryTooLarge.WithLabelValues("node-left").Inc()
+	sourceNodes.listed([]corev1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "node-left"}}})
+
+	// The series of a node are deleted once it leaves the node source
+	sourceNodes.listed(nil)
+	if n := summaryErrors.DeletePartialMatch(prometheus.Labels{"node": "node-left"}); n != 0 {
+		t.Errorf("got %d summary error series of node-left once it left, want none", n)
+	}
+	if summaryTooLarge.DeleteLabelValues("node-left") {
+		t.Error("got a too large summary series of node-left once it left, want none")
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+// stringSliceFlag is a flag that can be repeated, collecting every value
+type stringSliceFlag []string
+
+func (f *stringSliceFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringSliceFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// headerFlag is a repeatable flag of "Name: value" HTTP headers
+type headerFlag http.Header
+
+func (f headerFlag) String() string {
+	var headers []string
+	for name, values := range f {
+		for _, value := range values {
+			headers = append(headers, name+": "+value)
+		}
+	}
+	return strings.Join(headers, ",")
+}
+
+func (f headerFlag) Set(value string) error {
+	name, v, ok := strings.Cut(value, ":")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("invalid header %q, expected \"Name: value\"", value)
+	}
+	http.Header(f).Add(strings.TrimSpace(name), strings.TrimSpace(v))
+	return nil
+}
+
+// byteSizeFlag is a size in bytes, accepting SI (KB, MB, GB) and IEC (KiB,
+// MiB, GiB) suffixes, as well as the Ki, Mi and Gi suffixes of Kubernetes
+// quantities
+type byteSizeFlag int64
+
+var byteSizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	// Longest suffixes first, so that "MiB" isn't matched as "B"
+	{"KiB", 1 << 10},
+	{"MiB", 1 << 20},
+	{"GiB", 1 << 30},
+	{"Ki", 1 << 10},
+	{"Mi", 1 << 20},
+	{"Gi", 1 << 30},
+	{"KB", 1000},
+	{"MB", 1000 * 1000},
+	{"GB", 1000 * 1000 * 1000},
+	{"B", 1},
+}
+
+func (f *byteSizeFlag) String() string {
+	return strconv.FormatInt(int64(*f), 10)
+}
+
+func (f *byteSizeFlag) Set(value string) error {
+	size := strings.TrimSpace(value)
+	multiplier := int64(1)
+	for _, unit := range byteSizeUnits {
+		if n, ok := strings.CutSuffix(size, unit.suffix); ok {
+			size, multiplier = strings.TrimSpace(n), unit.multiplier
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(size, 10, 64)
+	if err != nil || n < 0 {
+		return fmt.Errorf("invalid size %q", size)
+	}
+	if n > math.MaxInt64/multiplier {
+		return fmt.Errorf("size %q overflows int64", value)
+	}
+	*f = byteSizeFlag(n * multiplier)
+	return nil
+}
+
+// Int64 returns the size in bytes
+func (f byteSizeFlag) Int64() int64 {
+	return int64(f)
+}
+
+// byteBucketsFlag is a comma separated list of histogram bucket upper bounds,
+// each a size accepted by byteSizeFlag
+type byteBucketsFlag []float64
+
+func (f *byteBucketsFlag) String() string {
+	var buckets []string
+	for _, b := range *f {
+		buckets = append(buckets, strconv.FormatFloat(b, 'f', -1, 64))
+	}
+	return strings.Join(buckets, ",")
+}
+
+func (f *byteBucketsFlag) Set(value string) error {
+	var buckets []float64
+	for _, v := range strings.Split(value, ",") {
+		var size byteSizeFlag
+		if err := size.Set(v); err != nil {
+			return err
+		}
+		if len(buckets) > 0 && float64(size) <= buckets[len(buckets)-1] {
+			return fmt.Errorf("buckets must be in increasing order, got %q", value)
+		}
+		buckets = append(buckets, float64(size))
+	}
+	*f = buckets
+	return nil
+}
+
+// nodePatternsFlag is a repeatable flag of node names or regular expressions,
+// each of which has to match the whole node name
+type nodePatternsFlag []*regexp.Regexp
+
+func (f *nodePatternsFlag) String() string {
+	var patterns []string
+	for _, re := range *f {
+		patterns = append(patterns, strings.TrimSuffix(strings.TrimPrefix(re.String(), "^(?:"), ")$"))
+	}
+	return strings.Join(patterns, ",")
+}
+
+func (f *nodePatternsFlag) Set(value string) error {
+	re, err := regexp.Compile("^(?:" + value + ")$")
+	if err != nil {
+		return fmt.Errorf("invalid node pattern %q: %v", value, err)
+	}
+	*f = append(*f, re)
+	return nil
+}
+
+// matches reports whether the node name matches any of the patterns
+func (f nodePatternsFlag) matches(nodeName string) bool {
+	for _, re := range f {
+		if re.MatchString(nodeName) {
+			return true
+		}
+	}
+	return false
+}
+
+// sectionsFlag is a comma separated, repeatable flag of summary sections
+type sectionsFlag map[string]bool
+
+func (f sectionsFlag) String() string {
+	var names []string
+	for _, section := range summary.Sections {
+		if f[section] {
+			names = append(names, section)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+func (f sectionsFlag) Set(value string) error {
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if !slices.Contains(summary.Sections, name) {
+			return fmt.Errorf("unknown metric group %q, expected one of %s", name, strings.Join(summary.Sections, ", "))
+		}
+		f[name] = true
+	}
+	return nil
+}
+
+// choiceFlag is a flag whose value is one of a fixed set of choices
+type choiceFlag struct {
+	value   string
+	choices []string
+}
+
+func (f *choiceFlag) String() string {
+	return f.value
+}
+
+func (f *choiceFlag) Set(value string) error {
+	if !slices.Contains(f.choices, value) {
+		return fmt.Errorf("unknown value %q, expected one of %s", value, strings.Join(f.choices, ", "))
+	}
+	f.value = value
+	return nil
+}
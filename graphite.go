@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// graphiteSink pushes samples to Graphite using the plaintext protocol. Paths
+// follow the convention of the client_golang Graphite bridge:
+// prefix.name.label_name.label_value...
+type graphiteSink struct {
+	address string
+	prefix  string
+	timeout time.Duration
+}
+
+func newGraphiteSink(address, prefix string) *graphiteSink {
+	return &graphiteSink{
+		address: address,
+		prefix:  prefix,
+		timeout: 10 * time.Second,
+	}
+}
+
+func (g *graphiteSink) Name() string {
+	return "graphite"
+}
+
+func (g *graphiteSink) Write(ctx context.Context, samples []sample, ts time.Time) error {
+	dialer := net.Dialer{Timeout: g.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", g.address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetWriteDeadline(deadline)
+	}
+
+	return writeGraphite(conn, g.prefix, samples, ts)
+}
+
+// writeGraphite writes one "path value timestamp" line per sample
+func writeGraphite(w io.Writer, prefix string, samples []sample, ts time.Time) error {
+	bw := bufio.NewWriter(w)
+	timestamp := strconv.FormatInt(ts.Unix(), 10)
+
+	for _, s := range samples {
+		if math.IsNaN(s.Value) || math.IsInf(s.Value, 0) {
+			continue
+		}
+
+		if prefix != "" {
+			bw.WriteString(prefix)
+			bw.WriteByte('.')
+		}
+		bw.WriteString(graphiteSanitize(s.Name))
+		for _, l := range s.Labels {
+			if l.Value == "" {
+				continue
+			}
+			bw.WriteByte('.')
+			bw.WriteString(graphiteSanitize(l.Name))
+			bw.WriteByte('.')
+			bw.WriteString(graphiteSanitize(l.Value))
+		}
+		bw.WriteByte(' ')
+		bw.WriteString(strconv.FormatFloat(s.Value, 'g', -1, 64))
+		bw.WriteByte(' ')
+		bw.WriteString(timestamp)
+		bw.WriteByte('\n')
+	}
+
+	return bw.Flush()
+}
+
+// graphiteSanitize replaces the characters that have a meaning in Graphite
+// paths
+func graphiteSanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_graphiteSink(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	lines := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var got []string
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			got = append(got, scanner.Text())
+		}
+		lines <- got
+	}()
+
+	samples := []sample{
+		{
+			Name:   "kube_summary_pod_ephemeral_storage_used_bytes",
+			Labels: []labelPair{{"namespace", "mon"}, {"node", "node.example.com"}, {"pod", "dev-server-0"}},
+			Value:  8192,
+		},
+		{
+			Name:   "kube_summary_node_scrape_success",
+			Labels: []labelPair{{"node", "node-a"}, {"provider", ""}},
+			Value:  1,
+		},
+	}
+
+	s := newGraphiteSink(ln.Addr().String(), "k8s")
+	if err := s.Write(context.Background(), samples, time.Unix(1669817681, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"k8s.kube_summary_pod_ephemeral_storage_used_bytes.namespace.mon.node.node_example_com.pod.dev-server-0 8192 1669817681",
+		"k8s.kube_summary_node_scrape_success.node.node-a 1 1669817681",
+	}
+	select {
+	case got := <-lines:
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("graphiteSink.Write() mismatch (-want +got):\n%s", diff)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the graphite lines")
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summarypb"
+)
+
+var grpcRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metricsNamespace,
+	Name:      "grpc_requests_total",
+	Help:      "Number of the requests of the gRPC server, by method and status code",
+},
+	[]string{
+		"method",
+		"code",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(grpcRequests)
+}
+
+// summaryService serves the summaries over gRPC, for the services that want
+// the structured summaries rather than parsing the metrics. It serves the same
+// summaries as the JSON API, from the cache with background collection.
+type summaryService struct {
+	summarypb.UnimplementedSummaryServiceServer
+
+	kubeClient    *kubernetes.Clientset
+	nodesSelector nodeSelectorFunc
+	nodeSelector  func(string) nodeSelectorFunc
+	exists        nodeExists
+	// group coalesces the concurrent identical calls if not nil, like the
+	// live collections of the HTTP endpoints
+	group *collectionGroup
+}
+
+// GetNodeSummary returns the summary of a node, failing with Unavailable if it
+// couldn't be collected
+func (s *summaryService) GetNodeSummary(ctx context.Context, req *summarypb.GetNodeSummaryRequest) (*summarypb.NodeSummary, error) {
+	name := req.GetNodeName()
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid node name %q: %s", name, strings.Join(errs, ", "))
+	}
+	ok, err := s.exists(ctx, name)
+	if err != nil {
+		logError(ctx, "Listing the nodes to check %s failed: %v", name, err)
+	} else if !ok {
+		return nil, status.Errorf(codes.NotFound, "node %q not found", name)
+	}
+
+	results, err := coalesced(s.group, "node/"+name, s.nodeSelector(name))(ctx, s.kubeClient)
+	if err == nil {
+		err = allFailed(results)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "error collecting node stats: %v", err)
+	}
+	if len(results) == 0 {
+		return nil, status.Errorf(codes.NotFound, "node %q not found", name)
+	}
+	return nodeSummaryMessage(results[0]), nil
+}
+
+// ListSummaries streams the summaries of all nodes, each as soon as it is
+// collected. The nodes that couldn't be collected are sent with their error,
+// the call only fails if the nodes couldn't be listed.
+func (s *summaryService) ListSummaries(req *summarypb.ListSummariesRequest, stream grpc.ServerStreamingServer[summarypb.NodeSummary]) error {
+	var (
+		mu      sync.Mutex
+		sent    = map[string]bool{}
+		sendErr error
+	)
+	send := func(result PerNodeResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		if sent[result.NodeName] || sendErr != nil {
+			return
+		}
+		sent[result.NodeName] = true
+		sendErr = stream.Send(nodeSummaryMessage(result))
+	}
+
+	selector := s.nodesSelector
+	if len(req.GetExclude()) > 0 {
+		selector = excludedNodesFilter(req.GetExclude(), selector)
+	}
+	// The calls sharing the collection of another are sent its results once
+	// it completes
+	selector = coalesced(s.group, excludedCollectionKey(req.GetExclude()), selector)
+	results, err := selector(withResultStream(stream.Context(), send), s.kubeClient)
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "error collecting node stats: %v", err)
+	}
+	// The nodes that weren't streamed, e.g. when served from the cache
+	for _, result := range results {
+		send(result)
+	}
+	return sendErr
+}
+
+// nodeSummaryMessage returns the message of the summary of a node
+func nodeSummaryMessage(result PerNodeResult) *summarypb.NodeSummary {
+	m := &summarypb.NodeSummary{NodeName: result.NodeName, Summary: summarypb.FromSummary(result.Summary)}
+	if result.Err != nil {
+		m.Error = result.Err.Error()
+	}
+	return m
+}
+
+// newGRPCServer returns the gRPC server of the summary service, with the
+// reflection service for grpcurl and the like. With a token file, the calls
+// must present its token in the authorization metadata, as a bearer token.
+// The calls of the summary service share the limit of requests in flight of
+// the HTTP endpoints, and the ones without a deadline are bounded by timeout.
+func newGRPCServer(service *summaryService, tokens *tokenFile, inFlight inFlightLimit, timeout time.Duration) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			var resp interface{}
+			err := serveGRPCCall(ctx, info.FullMethod, tokens, inFlight, timeout, func(ctx context.Context) error {
+				var err error
+				resp, err = handler(ctx, req)
+				return err
+			})
+			grpcRequests.WithLabelValues(info.FullMethod, status.Code(err).String()).Inc()
+			return resp, err
+		}),
+		grpc.ChainStreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			err := serveGRPCCall(ss.Context(), info.FullMethod, tokens, inFlight, timeout, func(ctx context.Context) error {
+				return handler(srv, contextServerStream{ServerStream: ss, ctx: ctx})
+			})
+			grpcRequests.WithLabelValues(info.FullMethod, status.Code(err).String()).Inc()
+			return err
+		}),
+	)
+	summarypb.RegisterSummaryServiceServer(server, service)
+	reflection.Register(server)
+	return server
+}
+
+// serveGRPCCall serves a call once its token is checked. The calls of the
+// summary service fail with Unavailable when the limit of requests in flight
+// is reached, and run with timeout unless they have a deadline.
+func serveGRPCCall(ctx context.Context, method string, tokens *tokenFile, inFlight inFlightLimit, timeout time.Duration, serve func(context.Context) error) error {
+	if err := checkGRPCToken(ctx, tokens); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(method, "/"+summarypb.SummaryService_ServiceDesc.ServiceName+"/") {
+		return serve(ctx)
+	}
+
+	release, ok := inFlight.acquire()
+	if !ok {
+		throttledRequests.WithLabelValues("concurrency").Inc()
+		return status.Error(codes.Unavailable, "too many requests in flight")
+	}
+	defer release()
+
+	if _, ok := ctx.Deadline(); !ok && timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return serve(ctx)
+}
+
+// contextServerStream is a server stream with another context
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s contextServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// checkGRPCToken fails with Unauthenticated unless the call presents the token
+// of the file, if any
+func checkGRPCToken(ctx context.Context, tokens *tokenFile) error {
+	if tokens == nil {
+		return nil
+	}
+	token, err := tokens.load()
+	if err != nil {
+		logError(ctx, "Cannot load the auth token: %v", err)
+		return status.Error(codes.Unauthenticated, "unauthorized")
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		if presented, ok := strings.CutPrefix(value, "Bearer "); ok && subtle.ConstantTimeCompare([]byte(presented), token) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "unauthorized")
+}
+
+// serveGRPC serves the summary service on the address
+func serveGRPC(address string, server *grpc.Server) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("cannot listen on %s: %v", address, err)
+	}
+	fmt.Printf("Serving gRPC on %s\n", address)
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			fmt.Printf("[Error] gRPC server: %v\n", err)
+		}
+	}()
+	return nil
+}
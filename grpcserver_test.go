@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summarypb"
+)
+
+// newTestGRPCClient serves the summary service of the fake API server on an
+// in-memory listener and returns a client of it
+func newTestGRPCClient(t *testing.T, kubeClient *kubernetes.Clientset, tokens *tokenFile) summarypb.SummaryServiceClient {
+	t.Helper()
+
+	service := &summaryService{kubeClient: kubeClient, nodesSelector: allNodesSelector, nodeSelector: singleNodeSelector, exists: servedNodeExists(kubeClient, nil)}
+	return dialTestGRPCServer(t, newGRPCServer(service, tokens, nil, 0))
+}
+
+// dialTestGRPCServer serves the server on an in-memory listener and returns a
+// client of it
+func dialTestGRPCServer(t *testing.T, server *grpc.Server) summarypb.SummaryServiceClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return summarypb.NewSummaryServiceClient(conn)
+}
+
+func TestSummaryService_GetNodeSummary(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Summary: fakekubelet.Fixture("node")})
+	srv.AddNode(fakekubelet.Node{Name: "node-b", StatusCode: http.StatusServiceUnavailable})
+	client := newTestGRPCClient(t, kubeClient, nil)
+
+	node, err := client.GetNodeSummary(context.Background(), &summarypb.GetNodeSummaryRequest{NodeName: "node-a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node.GetNodeName() != "node-a" || len(node.GetSummary().GetPods()) == 0 || node.GetError() != "" {
+		t.Errorf("GetNodeSummary(node-a) returned %v", node)
+	}
+
+	for name, want := range map[string]codes.Code{
+		"node-b":    codes.Unavailable,
+		"node-c":    codes.NotFound,
+		"Not_A_DNS": codes.InvalidArgument,
+	} {
+		_, err := client.GetNodeSummary(context.Background(), &summarypb.GetNodeSummaryRequest{NodeName: name})
+		if got := status.Code(err); got != want {
+			t.Errorf("GetNodeSummary(%s) failed with %s, want %s: %v", name, got, want, err)
+		}
+	}
+}
+
+func TestSummaryService_ListSummaries(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Summary: fakekubelet.Fixture("node")})
+	srv.AddNode(fakekubelet.Node{Name: "node-b", StatusCode: http.StatusServiceUnavailable})
+	srv.AddNode(fakekubelet.Node{Name: "node-c", Summary: fakekubelet.Fixture("node")})
+	client := newTestGRPCClient(t, kubeClient, nil)
+
+	list := func(exclude ...string) map[string]*summarypb.NodeSummary {
+		t.Helper()
+		stream, err := client.ListSummaries(context.Background(), &summarypb.ListSummariesRequest{Exclude: exclude})
+		if err != nil {
+			t.Fatal(err)
+		}
+		nodes := map[string]*summarypb.NodeSummary{}
+		for {
+			node, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return nodes
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			nodes[node.GetNodeName()] = node
+		}
+	}
+
+	nodes := list()
+	if len(nodes) != 3 {
+		t.Fatalf("ListSummaries() returned %d nodes, want 3", len(nodes))
+	}
+	if nodes["node-a"].GetSummary() == nil || nodes["node-b"].GetError() == "" || nodes["node-b"].GetSummary() != nil {
+		t.Errorf("ListSummaries() returned unexpected nodes: %v", nodes)
+	}
+
+	nodes = list("node-a", "node-b")
+	if len(nodes) != 1 || nodes["node-c"] == nil {
+		t.Errorf("ListSummaries(exclude node-a, node-b) returned %v, want node-c only", nodes)
+	}
+}
+
+func TestSummaryService_token(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Summary: fakekubelet.Fixture("node")})
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	client := newTestGRPCClient(t, kubeClient, newTokenFile(path))
+	req := &summarypb.GetNodeSummaryRequest{NodeName: "node-a"}
+
+	if _, err := client.GetNodeSummary(context.Background(), req); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("GetNodeSummary() without a token failed with %v, want Unauthenticated", err)
+	}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer wrong")
+	if _, err := client.GetNodeSummary(ctx, req); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("GetNodeSummary() with the wrong token failed with %v, want Unauthenticated", err)
+	}
+	ctx = metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+	if _, err := client.GetNodeSummary(ctx, req); err != nil {
+		t.Errorf("GetNodeSummary() with the token failed: %v", err)
+	}
+
+	stream, err := client.ListSummaries(context.Background(), &summarypb.ListSummariesRequest{})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("ListSummaries() without a token failed with %v, want Unauthenticated", err)
+	}
+}
+
+func TestSummaryService_limits(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Summary: fakekubelet.Fixture("node"), Delay: time.Second})
+	service := &summaryService{kubeClient: kubeClient, nodesSelector: allNodesSelector, nodeSelector: singleNodeSelector, exists: servedNodeExists(kubeClient, nil)}
+	client := dialTestGRPCServer(t, newGRPCServer(service, nil, newInFlightLimit(1), 100*time.Millisecond))
+
+	// The call without a deadline is bounded by the timeout, and holds the
+	// only request in flight meanwhile
+	done := make(chan error)
+	go func() {
+		_, err := client.GetNodeSummary(context.Background(), &summarypb.GetNodeSummaryRequest{NodeName: "node-a"})
+		done <- err
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for srv.SummaryRequests("node-a") == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("node-a was never collected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	stream, err := client.ListSummaries(context.Background(), &summarypb.ListSummariesRequest{})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.Unavailable || !strings.Contains(err.Error(), "too many requests") {
+		t.Errorf("ListSummaries() over the limit failed with %v, want Unavailable", err)
+	}
+
+	start := time.Now()
+	if err := <-done; status.Code(err) != codes.Unavailable {
+		t.Errorf("GetNodeSummary() of a slow node failed with %v, want Unavailable", err)
+	}
+	if elapsed := time.Since(start); elapsed > 900*time.Millisecond {
+		t.Errorf("GetNodeSummary() took %s, want the timeout", elapsed)
+	}
+}
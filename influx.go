@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	influxMeasurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	influxTagEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+)
+
+// writeInflux writes the metrics in the InfluxDB line protocol
+func writeInflux(w http.ResponseWriter, r *http.Request, registry prometheus.Gatherer) {
+	families, err := registry.Gather()
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, apiError{Error: "error gathering metrics: " + err.Error(), Reason: reasonInternal})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_ = writeLineProtocol(w, flattenFamilies(families), time.Now())
+}
+
+// writeLineProtocol writes one line per sample, using the metric name as the
+// measurement, the labels as tags and the value as the "value" field. Samples
+// with a NaN or infinite value, which InfluxDB can't store, are skipped.
+func writeLineProtocol(w io.Writer, samples []sample, ts time.Time) error {
+	bw := bufio.NewWriter(w)
+	timestamp := strconv.FormatInt(ts.UnixNano(), 10)
+
+	for _, s := range samples {
+		if math.IsNaN(s.Value) || math.IsInf(s.Value, 0) {
+			continue
+		}
+
+		bw.WriteString(influxMeasurementEscaper.Replace(s.Name))
+		for _, l := range s.Labels {
+			// Empty tag values aren't allowed
+			if l.Value == "" {
+				continue
+			}
+			bw.WriteByte(',')
+			bw.WriteString(influxTagEscaper.Replace(l.Name))
+			bw.WriteByte('=')
+			bw.WriteString(influxTagEscaper.Replace(l.Value))
+		}
+		bw.WriteString(" value=")
+		bw.WriteString(strconv.FormatFloat(s.Value, 'g', -1, 64))
+		bw.WriteByte(' ')
+		bw.WriteString(timestamp)
+		bw.WriteByte('\n')
+	}
+
+	return bw.Flush()
+}
+
+// influxSink posts samples in the line protocol to an InfluxDB write endpoint,
+// e.g. http://influxdb:8086/api/v2/write?bucket=k8s&org=ops with an
+// "Authorization: Token ..." header
+type influxSink struct {
+	url     string
+	headers http.Header
+}
+
+func newInfluxSink(url string, headers map[string]string) *influxSink {
+	h := sinkHeaders(headers)
+	h.Set("Content-Type", "text/plain; charset=utf-8")
+	return &influxSink{
+		url:     url,
+		headers: h,
+	}
+}
+
+func (s *influxSink) Name() string {
+	return "influx"
+}
+
+func (s *influxSink) Write(ctx context.Context, samples []sample, ts time.Time) error {
+	var body bytes.Buffer
+	if err := writeLineProtocol(&body, samples, ts); err != nil {
+		return err
+	}
+	return postSamples(ctx, s.url, s.headers, body.Bytes())
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_writeLineProtocol(t *testing.T) {
+	samples := []sample{
+		{
+			Name:   "kube_summary_pod_ephemeral_storage_used_bytes",
+			Labels: []labelPair{{"namespace", "mon"}, {"node", "node a"}, {"pod", "a=b,c"}},
+			Value:  1.33947392e+08,
+		},
+		{
+			Name:   "kube_summary_node_scrape_success",
+			Labels: []labelPair{{"node", "node-a"}, {"provider", ""}},
+			Value:  1,
+		},
+		{
+			Name:  "kube_summary_skipped",
+			Value: math.NaN(),
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := writeLineProtocol(&buf, samples, time.Unix(1669817681, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `kube_summary_pod_ephemeral_storage_used_bytes,namespace=mon,node=node\ a,pod=a\=b\,c value=1.33947392e+08 1669817681000000000
+kube_summary_node_scrape_success,node=node-a value=1 1669817681000000000
+`
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("writeLineProtocol() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,393 @@
+// Package fakekubelet provides a fake Kubernetes API server, including the node
+// proxy to the kubelets' /stats/summary endpoint, so that the exporter can be
+// tested end to end without a cluster.
+package fakekubelet
+
+import (
+	"compress/gzip"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/rest"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+//go:embed fixtures/*.json
+var fixtures embed.FS
+
+// Fixture returns a golden /stats/summary response from the fixtures
+// directory, by name without the .json extension. It panics if the fixture
+// doesn't exist.
+func Fixture(name string) []byte {
+	d, err := fixtures.ReadFile(path.Join("fixtures", name+".json"))
+	if err != nil {
+		panic(fmt.Sprintf("fakekubelet: unknown fixture %q", name))
+	}
+	return d
+}
+
+// Node is a node registered with the fake API server
+type Node struct {
+	Name        string
+	Labels      map[string]string
+	Annotations map[string]string
+	// KubeletVersion is reported in the node status
+	KubeletVersion string
+	// Conditions are reported in the node status
+	Conditions []corev1.NodeCondition
+	// Unschedulable and Taints are set in the node spec
+	Unschedulable bool
+	Taints        []corev1.Taint
+	// Summary is the /stats/summary response body of the node's kubelet
+	Summary []byte
+	// StatusCode, if set, is returned instead of the summary
+	StatusCode int
+	// ErrorBody, if set, is the body returned with the StatusCode instead of
+	// its status text, e.g. the Status of an RBAC denial, sent as JSON if it
+	// starts with a brace
+	ErrorBody string
+	// ResourceMetrics is the /metrics/resource response body of the node's
+	// kubelet, which responds with 404 Not Found if it's empty
+	ResourceMetrics string
+	// Delay is waited before responding to /stats/summary requests
+	Delay time.Duration
+	// LeaseRenewTime, if set, is the renew time of the node's Lease in the
+	// kube-node-lease namespace
+	LeaseRenewTime time.Time
+	// KubeletPort, if set, is the only port the kubelet answers on in the
+	// proxy path, nodes/{name}:{port}/proxy, other requests failing as if the
+	// default port was unreachable
+	KubeletPort int
+	// PodLabels are the labels of the pods of the summary in the pod list, by
+	// namespace/name
+	PodLabels map[string]map[string]string
+}
+
+// Server is a fake API server listening on a local address
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	nodes    map[string]Node
+	tokens   map[string][]string
+	requests map[string]int
+
+	listRequests int
+}
+
+// NewServer starts a fake API server without any nodes. It should be closed
+// once the test is done.
+func NewServer() *Server {
+	s := &Server{
+		nodes:    map[string]Node{},
+		tokens:   map[string][]string{},
+		requests: map[string]int{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/nodes", s.listNodes)
+	mux.HandleFunc("GET /api/v1/nodes/{name}", s.getNode)
+	mux.HandleFunc("GET /api/v1/nodes/{name}/proxy/stats/summary", s.getSummary)
+	mux.HandleFunc("GET /api/v1/nodes/{name}/proxy/metrics/resource", s.getResourceMetrics)
+	mux.HandleFunc("GET /api/v1/namespaces/{namespace}/pods", s.listPods)
+	mux.HandleFunc("GET /api/v1/pods", s.listPods)
+	mux.HandleFunc("GET /apis/coordination.k8s.io/v1/namespaces/kube-node-lease/leases", s.listLeases)
+	mux.HandleFunc("POST /apis/authentication.k8s.io/v1/tokenreviews", s.reviewToken)
+	mux.HandleFunc("POST /apis/authorization.k8s.io/v1/subjectaccessreviews", s.reviewAccess)
+	s.Server = httptest.NewServer(mux)
+
+	return s
+}
+
+// Config returns a client config pointing at the server
+func (s *Server) Config() *rest.Config {
+	return &rest.Config{Host: s.URL}
+}
+
+// AddNode registers a node, replacing any node with the same name
+func (s *Server) AddNode(node Node) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nodes[node.Name] = node
+}
+
+// RemoveNode unregisters a node
+func (s *Server) RemoveNode(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.nodes, name)
+}
+
+// AddToken registers a bearer token whose user may list pods in the given
+// namespaces. The user name is the token itself.
+func (s *Server) AddToken(token string, namespaces ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[token] = namespaces
+}
+
+// SummaryRequests returns the number of /stats/summary requests the node
+// received
+func (s *Server) SummaryRequests(name string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[name]
+}
+
+// ListRequests returns the number of node list requests, counting each page
+func (s *Server) ListRequests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.listRequests
+}
+
+func (s *Server) sortedNodes() []Node {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	nodes := make([]Node, 0, len(s.nodes))
+	for _, node := range s.nodes {
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	return nodes
+}
+
+func (s *Server) node(name string) (Node, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	node, ok := s.nodes[name]
+	return node, ok
+}
+
+func toNode(node Node) corev1.Node {
+	return corev1.Node{
+		TypeMeta:   meta_v1.TypeMeta{Kind: "Node", APIVersion: "v1"},
+		ObjectMeta: meta_v1.ObjectMeta{Name: node.Name, Labels: node.Labels, Annotations: node.Annotations},
+		Spec:       corev1.NodeSpec{Unschedulable: node.Unschedulable, Taints: node.Taints},
+		Status: corev1.NodeStatus{
+			NodeInfo:   corev1.NodeSystemInfo{KubeletVersion: node.KubeletVersion},
+			Conditions: node.Conditions,
+		},
+	}
+}
+
+// listNodes supports pagination, the continue token being the offset of the
+// next page
+func (s *Server) listNodes(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.listRequests++
+	s.mu.Unlock()
+
+	nodes := s.sortedNodes()
+	if v := r.URL.Query().Get("labelSelector"); v != "" {
+		selector, err := labels.Parse(v)
+		if err != nil {
+			writeStatus(w, http.StatusBadRequest, meta_v1.StatusReasonBadRequest, err.Error())
+			return
+		}
+		nodes = slices.DeleteFunc(nodes, func(node Node) bool { return !selector.Matches(labels.Set(node.Labels)) })
+	}
+	offset, _ := strconv.Atoi(r.URL.Query().Get("continue"))
+	offset = min(offset, len(nodes))
+	end := len(nodes)
+	if limit, _ := strconv.Atoi(r.URL.Query().Get("limit")); limit > 0 && offset+limit < end {
+		end = offset + limit
+	}
+
+	list := corev1.NodeList{TypeMeta: meta_v1.TypeMeta{Kind: "NodeList", APIVersion: "v1"}}
+	for _, node := range nodes[offset:end] {
+		list.Items = append(list.Items, toNode(node))
+	}
+	if end < len(nodes) {
+		list.Continue = strconv.Itoa(end)
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) getNode(w http.ResponseWriter, r *http.Request) {
+	node, ok := s.node(r.PathValue("name"))
+	if !ok {
+		writeStatus(w, http.StatusNotFound, meta_v1.StatusReasonNotFound, fmt.Sprintf("nodes %q not found", r.PathValue("name")))
+		return
+	}
+	writeJSON(w, http.StatusOK, toNode(node))
+}
+
+func (s *Server) getSummary(w http.ResponseWriter, r *http.Request) {
+	name, port, _ := strings.Cut(r.PathValue("name"), ":")
+	node, ok := s.node(name)
+	if !ok {
+		writeStatus(w, http.StatusNotFound, meta_v1.StatusReasonNotFound, fmt.Sprintf("nodes %q not found", name))
+		return
+	}
+	if node.KubeletPort != 0 && port != strconv.Itoa(node.KubeletPort) {
+		writeStatus(w, http.StatusServiceUnavailable, meta_v1.StatusReasonServiceUnavailable, fmt.Sprintf("error trying to reach service: dial tcp %s:10250: connect: connection refused", name))
+		return
+	}
+
+	s.mu.Lock()
+	s.requests[name]++
+	s.mu.Unlock()
+
+	if node.Delay > 0 {
+		select {
+		case <-time.After(node.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if node.StatusCode != 0 && node.StatusCode != http.StatusOK {
+		if strings.HasPrefix(node.ErrorBody, "{") {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(node.StatusCode)
+			_, _ = io.WriteString(w, node.ErrorBody)
+			return
+		}
+		body := node.ErrorBody
+		if body == "" {
+			body = http.StatusText(node.StatusCode)
+		}
+		http.Error(w, body, node.StatusCode)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		defer zw.Close()
+		_, _ = zw.Write(node.Summary)
+		return
+	}
+	_, _ = w.Write(node.Summary)
+}
+
+func (s *Server) getResourceMetrics(w http.ResponseWriter, r *http.Request) {
+	name, _, _ := strings.Cut(r.PathValue("name"), ":")
+	node, ok := s.node(name)
+	if !ok {
+		writeStatus(w, http.StatusNotFound, meta_v1.StatusReasonNotFound, fmt.Sprintf("nodes %q not found", name))
+		return
+	}
+	if node.ResourceMetrics == "" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	_, _ = io.WriteString(w, node.ResourceMetrics)
+}
+
+func (s *Server) listPods(w http.ResponseWriter, r *http.Request) {
+	namespace := r.PathValue("namespace")
+
+	list := corev1.PodList{TypeMeta: meta_v1.TypeMeta{Kind: "PodList", APIVersion: "v1"}}
+	for _, node := range s.sortedNodes() {
+		var summary stats.Summary
+		if err := json.Unmarshal(node.Summary, &summary); err != nil {
+			continue
+		}
+		for _, pod := range summary.Pods {
+			if namespace != "" && pod.PodRef.Namespace != namespace {
+				continue
+			}
+			list.Items = append(list.Items, corev1.Pod{
+				TypeMeta: meta_v1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      pod.PodRef.Name,
+					Namespace: pod.PodRef.Namespace,
+					Labels:    node.PodLabels[pod.PodRef.Namespace+"/"+pod.PodRef.Name],
+				},
+				Spec: corev1.PodSpec{NodeName: node.Name},
+			})
+		}
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) listLeases(w http.ResponseWriter, r *http.Request) {
+	list := coordinationv1.LeaseList{TypeMeta: meta_v1.TypeMeta{Kind: "LeaseList", APIVersion: "coordination.k8s.io/v1"}}
+	for _, node := range s.sortedNodes() {
+		if node.LeaseRenewTime.IsZero() {
+			continue
+		}
+		renewTime := meta_v1.NewMicroTime(node.LeaseRenewTime)
+		list.Items = append(list.Items, coordinationv1.Lease{
+			TypeMeta:   meta_v1.TypeMeta{Kind: "Lease", APIVersion: "coordination.k8s.io/v1"},
+			ObjectMeta: meta_v1.ObjectMeta{Name: node.Name, Namespace: "kube-node-lease"},
+			Spec:       coordinationv1.LeaseSpec{HolderIdentity: &node.Name, RenewTime: &renewTime},
+		})
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) reviewToken(w http.ResponseWriter, r *http.Request) {
+	var review authenticationv1.TokenReview
+	if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+		writeStatus(w, http.StatusBadRequest, meta_v1.StatusReasonBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	_, ok := s.tokens[review.Spec.Token]
+	s.mu.Unlock()
+
+	review.Status = authenticationv1.TokenReviewStatus{Authenticated: ok}
+	if ok {
+		review.Status.User = authenticationv1.UserInfo{Username: review.Spec.Token}
+	}
+	writeJSON(w, http.StatusCreated, review)
+}
+
+func (s *Server) reviewAccess(w http.ResponseWriter, r *http.Request) {
+	var review authorizationv1.SubjectAccessReview
+	if err := json.NewDecoder(r.Body).Decode(&review); err != nil || review.Spec.ResourceAttributes == nil {
+		writeStatus(w, http.StatusBadRequest, meta_v1.StatusReasonBadRequest, "invalid subject access review")
+		return
+	}
+
+	s.mu.Lock()
+	namespaces := s.tokens[review.Spec.User]
+	s.mu.Unlock()
+
+	for _, namespace := range namespaces {
+		if namespace == review.Spec.ResourceAttributes.Namespace {
+			review.Status.Allowed = true
+		}
+	}
+	writeJSON(w, http.StatusCreated, review)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeStatus(w http.ResponseWriter, code int, reason meta_v1.StatusReason, message string) {
+	writeJSON(w, code, meta_v1.Status{
+		TypeMeta: meta_v1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+		Status:   meta_v1.StatusFailure,
+		Message:  message,
+		Reason:   reason,
+		Code:     int32(code),
+	})
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// kafkaSink publishes one JSON message per node and collection cycle to a
+// Kafka topic, keyed by node name so that the snapshots of a node stay in
+// order within a partition
+type kafkaSink struct {
+	writer *kafka.Writer
+}
+
+func newKafkaSink(brokers []string, topic string) *kafkaSink {
+	return &kafkaSink{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Topic:        topic,
+			Balancer:     &kafka.Hash{},
+			Compression:  kafka.Gzip,
+			RequiredAcks: kafka.RequireOne,
+		},
+	}
+}
+
+func (k *kafkaSink) Name() string {
+	return "kafka"
+}
+
+func (k *kafkaSink) WriteSnapshot(ctx context.Context, results []PerNodeResult, ts time.Time) error {
+	snapshots, err := marshalNodeSnapshots(results, ts)
+	if err != nil {
+		return err
+	}
+	if len(snapshots) == 0 {
+		return nil
+	}
+
+	messages := make([]kafka.Message, 0, len(snapshots))
+	for node, value := range snapshots {
+		messages = append(messages, kafka.Message{
+			Key:   []byte(node),
+			Value: value,
+			Time:  ts,
+		})
+	}
+	sort.Slice(messages, func(i, j int) bool { return string(messages[i].Key) < string(messages[j].Key) })
+
+	return k.writer.WriteMessages(ctx, messages...)
+}
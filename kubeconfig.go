@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+var clientConfigReloads = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metricsNamespace,
+	Name:      "client_config_reloads_total",
+	Help:      "Number of times the Kubernetes client was rebuilt after its kubeconfig or credential files changed",
+},
+	[]string{
+		"result",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(clientConfigReloads)
+}
+
+// informerSyncTimeout bounds the initial sync of the informers started at
+// startup, which never completes without the RBAC, or the CRD, of their
+// resources
+var informerSyncTimeout = time.Minute
+
+// reloadingTransport sends the requests of the Kubernetes clients with a
+// transport built from the latest client config. The kubeconfig and the files
+// it refers to (token, certificates) are checked for changes at most every
+// interval, and on the next request after a 401, so that rotated credentials
+// are picked up without restarting the exporter.
+type reloadingTransport struct {
+	load     func() (*rest.Config, error)
+	files    func(*rest.Config) []string
+	interval time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	rt        http.RoundTripper
+	host      *url.URL
+	stamps    map[string]fileStamp
+	lastCheck time.Time
+}
+
+// fileStamp identifies a version of a file, the zero value standing for a
+// missing file
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func statFile(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}
+}
+
+// newReloadingKubeConfig returns a client config for the same clusters as
+// newKubeConfig, whose transport is rebuilt when the kubeconfig or credential
+// files change. An interval of 0 disables reloading.
+func newReloadingKubeConfig(path string, headers http.Header, interval time.Duration) (*rest.Config, error) {
+	config, err := newKubeConfig(path, headers)
+	if err != nil || interval <= 0 {
+		return config, err
+	}
+
+	t := &reloadingTransport{
+		load: func() (*rest.Config, error) {
+			return newKubeConfig(path, headers)
+		},
+		files: func(config *rest.Config) []string {
+			return append(kubeConfigFiles(path), configFiles(config)...)
+		},
+		interval: interval,
+		now:      time.Now,
+	}
+	if err := t.apply(config); err != nil {
+		return nil, err
+	}
+
+	return &rest.Config{
+		Host:      config.Host,
+		APIPath:   config.APIPath,
+		UserAgent: config.UserAgent,
+		QPS:       config.QPS,
+		Burst:     config.Burst,
+		Timeout:   config.Timeout,
+		Transport: t,
+	}, nil
+}
+
+// kubeConfigFiles returns the kubeconfig files loaded by newKubeConfig
+func kubeConfigFiles(path string) []string {
+	if path != "" {
+		return []string{path}
+	}
+	return clientcmd.NewDefaultClientConfigLoadingRules().GetLoadingPrecedence()
+}
+
+// configFiles returns the credential files referred to by the config, e.g.
+// the in-cluster service account token
+func configFiles(config *rest.Config) []string {
+	var files []string
+	for _, f := range []string{config.BearerTokenFile, config.CertFile, config.KeyFile, config.CAFile} {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// apply builds the transport of the config and records the version of its
+// files. It must be called with the lock held, or before the transport is
+// used.
+func (t *reloadingTransport) apply(config *rest.Config) error {
+	rt, err := rest.TransportFor(config)
+	if err != nil {
+		return err
+	}
+	host, _, err := rest.DefaultServerUrlFor(config)
+	if err != nil {
+		return err
+	}
+
+	stamps := map[string]fileStamp{}
+	for _, f := range t.files(config) {
+		stamps[f] = statFile(f)
+	}
+
+	t.rt, t.host, t.stamps = rt, host, stamps
+	t.lastCheck = t.now()
+	return nil
+}
+
+// changed returns whether any of the files changed since they were recorded
+func (t *reloadingTransport) changed() bool {
+	for f, stamp := range t.stamps {
+		if statFile(f) != stamp {
+			return true
+		}
+	}
+	return false
+}
+
+// current returns the transport and host to send a request with, reloading
+// the config first if it is due. A config that fails to load keeps the
+// previous transport in use.
+func (t *reloadingTransport) current() (http.RoundTripper, *url.URL) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.now().Sub(t.lastCheck) < t.interval {
+		return t.rt, t.host
+	}
+	t.lastCheck = t.now()
+	if !t.changed() {
+		return t.rt, t.host
+	}
+
+	config, err := t.load()
+	if err == nil {
+		err = t.apply(config)
+	}
+	if err != nil {
+		clientConfigReloads.WithLabelValues("error").Inc()
+		fmt.Printf("[Error] Reloading the kube client config failed: %v\n", err)
+		return t.rt, t.host
+	}
+	clientConfigReloads.WithLabelValues("success").Inc()
+	return t.rt, t.host
+}
+
+func (t *reloadingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt, host := t.current()
+
+	req = req.Clone(req.Context())
+	req.URL.Scheme = host.Scheme
+	req.URL.Host = host.Host
+	req.Host = ""
+
+	resp, err := rt.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		// The credentials may have been rotated, check the files on the next
+		// request rather than waiting for the interval
+		t.mu.Lock()
+		t.lastCheck = time.Time{}
+		t.mu.Unlock()
+	}
+	return resp, err
+}
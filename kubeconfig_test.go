@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+)
+
+func writeKubeConfig(t *testing.T, path, server string, modTime time.Time) {
+	t.Helper()
+
+	config := fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+  - name: test
+    cluster:
+      server: %s
+users:
+  - name: test
+    user:
+      token: test-token
+contexts:
+  - name: test
+    context:
+      cluster: test
+      user: test
+current-context: test
+`, server)
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_newReloadingKubeConfig(t *testing.T) {
+	before := fakekubelet.NewServer()
+	t.Cleanup(before.Close)
+	before.AddNode(fakekubelet.Node{Name: "node-before"})
+	after := fakekubelet.NewServer()
+	t.Cleanup(after.Close)
+	after.AddNode(fakekubelet.Node{Name: "node-after"})
+
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	now := time.Now()
+	writeKubeConfig(t, path, before.URL, now.Add(-time.Hour))
+
+	config, err := newReloadingKubeConfig(path, nil, time.Nanosecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kubeClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodeName := func() string {
+		t.Helper()
+		nodes, err := kubeClient.CoreV1().Nodes().List(context.Background(), meta_v1.ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(nodes.Items) != 1 {
+			t.Fatalf("listed %d nodes, want 1", len(nodes.Items))
+		}
+		return nodes.Items[0].Name
+	}
+
+	if got := nodeName(); got != "node-before" {
+		t.Errorf("listed %s before the reload, want node-before", got)
+	}
+
+	writeKubeConfig(t, path, after.URL, now)
+	if got := nodeName(); got != "node-after" {
+		t.Errorf("listed %s after the reload, want node-after", got)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+// nodeLeaseNamespace holds the Lease the kubelet of each node renews as its
+// heartbeat
+const nodeLeaseNamespace = "kube-node-lease"
+
+// errStaleLease is returned for the nodes that aren't queried because their
+// Lease wasn't renewed within --node-lease-stale-threshold
+var errStaleLease = errors.New("node lease is stale")
+
+var staleLeaseSkips = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metricsNamespace,
+	Name:      "node_lease_stale_skips_total",
+	Help:      "Number of times a node wasn't queried because its Lease wasn't renewed within --node-lease-stale-threshold",
+},
+	[]string{
+		"node",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(staleLeaseSkips)
+}
+
+// staleLeases returns how long ago the Lease of each of the nodes whose Lease
+// is older than the threshold was renewed. Nodes without a Lease, or a renew
+// time, aren't considered stale. The check is skipped if the threshold is 0 or
+// the Leases can't be listed, so that it never prevents a collection.
+func staleLeases(ctx context.Context, kubeClient *kubernetes.Clientset, nodes []corev1.Node, threshold time.Duration, now time.Time) map[string]time.Duration {
+	if threshold <= 0 || len(nodes) == 0 {
+		return nil
+	}
+
+	ctx, span := tracer.Start(ctx, "listNodeLeases")
+	defer span.End()
+	leases, err := kubeClient.CoordinationV1().Leases(nodeLeaseNamespace).List(ctx, meta_v1.ListOptions{})
+	if err != nil {
+		logError(ctx, "Cannot list the node leases, querying all nodes: %v", err)
+		return nil
+	}
+
+	renewed := make(map[string]time.Time, len(leases.Items))
+	for _, lease := range leases.Items {
+		if lease.Spec.RenewTime != nil {
+			renewed[lease.Name] = lease.Spec.RenewTime.Time
+		}
+	}
+
+	stale := map[string]time.Duration{}
+	for _, node := range nodes {
+		t, ok := renewed[node.Name]
+		if !ok {
+			continue
+		}
+		if age := now.Sub(t); age > threshold {
+			stale[node.Name] = age
+		}
+	}
+	return stale
+}
+
+// staleLeaseResult is the result of a node skipped because of its stale Lease
+func staleLeaseResult(node corev1.Node, age time.Duration) PerNodeResult {
+	staleLeaseSkips.WithLabelValues(node.Name).Inc()
+	result := summary.NewNodeResult(node)
+	result.Err = fmt.Errorf("skipping %s: %w, renewed %s ago", node.Name, errStaleLease, age.Round(time.Second))
+	return result
+}
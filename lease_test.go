@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+)
+
+func Test_staleLeases(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	now := time.Now()
+	srv.AddNode(fakekubelet.Node{Name: "node-fresh", LeaseRenewTime: now.Add(-10 * time.Second)})
+	srv.AddNode(fakekubelet.Node{Name: "node-stale", LeaseRenewTime: now.Add(-5 * time.Minute)})
+	srv.AddNode(fakekubelet.Node{Name: "node-without-lease"})
+
+	var nodes []corev1.Node
+	for _, name := range []string{"node-fresh", "node-stale", "node-without-lease"} {
+		nodes = append(nodes, corev1.Node{ObjectMeta: meta_v1.ObjectMeta{Name: name}})
+	}
+
+	if stale := staleLeases(context.Background(), kubeClient, nodes, 0, now); stale != nil {
+		t.Errorf("staleLeases() with a 0 threshold = %v, want nil", stale)
+	}
+
+	stale := staleLeases(context.Background(), kubeClient, nodes, time.Minute, now)
+	if len(stale) != 1 || stale["node-stale"].Round(time.Second) != 5*time.Minute {
+		t.Errorf("staleLeases() = %v, want node-stale renewed 5m ago", stale)
+	}
+}
+
+func TestRouter_staleLease(t *testing.T) {
+	*flagNodeLeaseStaleThreshold = time.Minute
+	defer func() { *flagNodeLeaseStaleThreshold = 0 }()
+
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Summary: fakekubelet.Fixture("node"), LeaseRenewTime: time.Now()})
+	srv.AddNode(fakekubelet.Node{Name: "node-b", Summary: fakekubelet.Fixture("node"), LeaseRenewTime: time.Now().Add(-time.Hour)})
+	r := newRouter(kubeClient, nil, nil, allNodesSelector, singleNodeSelector)
+
+	_, body := get(t, r, "/nodes", nil)
+	assertContains(t, body, `kube_summary_node_scrape_success{kubelet_version="",node="node-a"} 1`, `kube_summary_node_scrape_success{kubelet_version="",node="node-b"} 0`)
+	if n := srv.SummaryRequests("node-b"); n != 0 {
+		t.Errorf("node-b with a stale lease received %d summary requests", n)
+	}
+}
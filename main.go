@@ -2,494 +2,415 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	corev1 "k8s.io/api/core/v1"
-	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
 
 	// Support auth providers in kubeconfig files
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/exporter"
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
 )
 
-var metricsNamespace = "kube_summary"
+var metricsNamespace = summary.Namespace
+
+// PerNodeResult and collectorOptions are the types of pkg/summary, which maps
+// the summaries to metrics
+type (
+	PerNodeResult    = summary.NodeResult
+	collectorOptions = summary.Options
+)
 
-type PerNodeResult struct {
-	NodeName string
-	Summary  *stats.Summary
+// flagCollectorOptions returns the collector options set by the flags
+func flagCollectorOptions() collectorOptions {
+	return collectorOptions{
+		MaxPodsPerNode:          *flagMaxPodsPerNode,
+		OmitZeroValues:          flagOmitZeroValues,
+		EphemeralStorageBuckets: flagEphemeralStorageBuckets,
+		NodeResources:           *flagExportNodeResources,
+		NodeScheduling:          *flagExportNodeScheduling,
+		NodeTaints:              flagNodeTaints,
+		PodInfo:                 *flagExportPodInfo,
+		ResourceUsage:           *flagResourceMetricsFallback,
+		NodeMetadataLabels:      *flagNodeMetadataLabels,
+		ContainerLogMaxSize:     flagContainerLogMaxSize.Int64(),
+		MirrorPods:              flagMirrorPods.value,
+		IsMirrorPod:             mirrorPods.contains,
+		HelpOverrides:           metricHelpOverrides,
+		CustomMetrics:           customMetrics,
+		DeprecatedMetrics:       *flagEnableDeprecatedMetrics,
+	}
 }
 
-// collectSummaryMetrics collects metrics from a /stats/summary response
-func collectSummaryMetrics(results []PerNodeResult, registry *prometheus.Registry) {
-	var (
-		containerLogsInodesFree = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace: metricsNamespace,
-			Name:      "container_logs_inodes_free",
-			Help:      "Number of available Inodes for logs",
-		},
-			[]string{
-				"node",
-				"pod",
-				"namespace",
-				"name",
-			},
-		)
-		containerLogsInodes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace: metricsNamespace,
-			Name:      "container_logs_inodes",
-			Help:      "Number of Inodes for logs",
-		},
-			[]string{
-				"node",
-				"pod",
-				"namespace",
-				"name",
-			},
-		)
-		containerLogsInodesUsed = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace: metricsNamespace,
-			Name:      "container_logs_inodes_used",
-			Help:      "Number of used Inodes for logs",
-		},
-			[]string{
-				"node",
-				"pod",
-				"namespace",
-				"name",
-			},
-		)
-		containerLogsAvailableBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace: metricsNamespace,
-			Name:      "container_logs_available_bytes",
-			Help:      "Number of bytes that aren't consumed by the container logs",
-		},
-			[]string{
-				"node",
-				"pod",
-				"namespace",
-				"name",
-			},
-		)
-		containerLogsCapacityBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace: metricsNamespace,
-			Name:      "container_logs_capacity_bytes",
-			Help:      "Number of bytes that can be consumed by the container logs",
-		},
-			[]string{
-				"node",
-				"pod",
-				"namespace",
-				"name",
-			},
-		)
-		containerLogsUsedBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace: metricsNamespace,
-			Name:      "container_logs_used_bytes",
-			Help:      "Number of bytes that are consumed by the container logs",
-		},
-			[]string{
-				"node",
-				"pod",
-				"namespace",
-				"name",
-			},
-		)
-		containerRootFsInodesFree = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace: metricsNamespace,
-			Name:      "container_rootfs_inodes_free",
-			Help:      "Number of available Inodes",
-		},
-			[]string{
-				"node",
-				"pod",
-				"namespace",
-				"name",
-			},
-		)
-		containerRootFsInodes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace: metricsNamespace,
-			Name:      "container_rootfs_inodes",
-			Help:      "Number of Inodes",
-		},
-			[]string{
-				"node",
-				"pod",
-				"namespace",
-				"name",
-			},
-		)
-		containerRootFsInodesUsed = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace: metricsNamespace,
-			Name:      "container_rootfs_inodes_used",
-			Help:      "Number of used Inodes",
-		},
-			[]string{
-				"node",
-				"pod",
-				"namespace",
-				"name",
-			},
-		)
-		containerRootFsAvailableBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace: metricsNamespace,
-			Name:      "container_rootfs_available_bytes",
-			Help:      "Number of bytes that aren't consumed by the container",
-		},
-			[]string{
-				"node",
-				"pod",
-				"namespace",
-				"name",
-			},
-		)
-		containerRootFsCapacityBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace: metricsNamespace,
-			Name:      "container_rootfs_capacity_bytes",
-			Help:      "Number of bytes that can be consumed by the container",
-		},
-			[]string{
-				"node",
-				"pod",
-				"namespace",
-				"name",
-			},
-		)
-		containerRootFsUsedBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace: metricsNamespace,
-			Name:      "container_rootfs_used_bytes",
-			Help:      "Number of bytes that are consumed by the container",
-		},
-			[]string{
-				"node",
-				"pod",
-				"namespace",
-				"name",
-			},
-		)
-		podEphemeralStorageAvailableBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace: metricsNamespace,
-			Name:      "pod_ephemeral_storage_available_bytes",
-			Help:      "Number of bytes of Ephemeral storage that aren't consumed by the pod",
-		},
-			[]string{
-				"node",
-				"pod",
-				"namespace",
-			},
-		)
-		podEphemeralStorageCapacityBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace: metricsNamespace,
-			Name:      "pod_ephemeral_storage_capacity_bytes",
-			Help:      "Number of bytes of Ephemeral storage that can be consumed by the pod",
-		},
-			[]string{
-				"node",
-				"pod",
-				"namespace",
-			},
-		)
-		podEphemeralStorageUsedBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace: metricsNamespace,
-			Name:      "pod_ephemeral_storage_used_bytes",
-			Help:      "Number of bytes of Ephemeral storage that are consumed by the pod",
-		},
-			[]string{
-				"node",
-				"pod",
-				"namespace",
-			},
-		)
-		podEphemeralStorageInodesFree = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace: metricsNamespace,
-			Name:      "pod_ephemeral_storage_inodes_free",
-			Help:      "Number of available Inodes for pod Ephemeral storage",
-		},
-			[]string{
-				"node",
-				"pod",
-				"namespace",
-			},
-		)
-		podEphemeralStorageInodes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace: metricsNamespace,
-			Name:      "pod_ephemeral_storage_inodes",
-			Help:      "Number of Inodes for pod Ephemeral storage",
-		},
-			[]string{
-				"node",
-				"pod",
-				"namespace",
-			},
-		)
-		podEphemeralStorageInodesUsed = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace: metricsNamespace,
-			Name:      "pod_ephemeral_storage_inodes_used",
-			Help:      "Number of used Inodes for pod Ephemeral storage",
-		},
-			[]string{
-				"node",
-				"pod",
-				"namespace",
-			},
-		)
-		nodeRuntimeImageFSAvailableBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace: metricsNamespace,
-			Name:      "node_runtime_imagefs_available_bytes",
-			Help:      "Number of bytes of node Runtime ImageFS that aren't consumed",
-		},
-			[]string{
-				"node",
-			},
-		)
-		nodeRuntimeImageFSCapacityBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace: metricsNamespace,
-			Name:      "node_runtime_imagefs_capacity_bytes",
-			Help:      "Number of bytes of node Runtime ImageFS that can be consumed",
-		},
-			[]string{
-				"node",
-			},
-		)
-		nodeRuntimeImageFSUsedBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace: metricsNamespace,
-			Name:      "node_runtime_imagefs_used_bytes",
-			Help:      "Number of bytes of node Runtime ImageFS that are consumed",
-		},
-			[]string{
-				"node",
-			},
-		)
-		nodeRuntimeImageFSInodesFree = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace: metricsNamespace,
-			Name:      "node_runtime_imagefs_inodes_free",
-			Help:      "Number of available Inodes for node Runtime ImageFS",
-		},
-			[]string{
-				"node",
-			},
-		)
-		nodeRuntimeImageFSInodes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace: metricsNamespace,
-			Name:      "node_runtime_imagefs_inodes",
-			Help:      "Number of Inodes for node Runtime ImageFS",
-		},
-			[]string{
-				"node",
-			},
-		)
-		nodeRuntimeImageFSInodesUsed = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace: metricsNamespace,
-			Name:      "node_runtime_imagefs_inodes_used",
-			Help:      "Number of used Inodes for node Runtime ImageFS",
-		},
-			[]string{
-				"node",
-			},
-		)
-	)
-	registry.MustRegister(
-		containerLogsInodesFree,
-		containerLogsInodes,
-		containerLogsInodesUsed,
-		containerLogsAvailableBytes,
-		containerLogsCapacityBytes,
-		containerLogsUsedBytes,
-		containerRootFsInodesFree,
-		containerRootFsInodes,
-		containerRootFsInodesUsed,
-		containerRootFsAvailableBytes,
-		containerRootFsCapacityBytes,
-		containerRootFsUsedBytes,
-		podEphemeralStorageAvailableBytes,
-		podEphemeralStorageCapacityBytes,
-		podEphemeralStorageUsedBytes,
-		podEphemeralStorageInodesFree,
-		podEphemeralStorageInodes,
-		podEphemeralStorageInodesUsed,
-		nodeRuntimeImageFSAvailableBytes,
-		nodeRuntimeImageFSCapacityBytes,
-		nodeRuntimeImageFSUsedBytes,
-		nodeRuntimeImageFSInodesFree,
-		nodeRuntimeImageFSInodes,
-		nodeRuntimeImageFSInodesUsed,
-	)
+// nodeSelectorFunc returns the summaries of a set of nodes
+type nodeSelectorFunc func(context.Context, *kubernetes.Clientset) ([]PerNodeResult, error)
 
-	for _, entry := range results {
-		nodeName := entry.NodeName
-		summary := entry.Summary
-
-		for _, pod := range summary.Pods {
-			for _, container := range pod.Containers {
-				if logs := container.Logs; logs != nil {
-					if inodesFree := logs.InodesFree; inodesFree != nil {
-						containerLogsInodesFree.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace, container.Name).Set(float64(*inodesFree))
-					}
-					if inodes := logs.Inodes; inodes != nil {
-						containerLogsInodes.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace, container.Name).Set(float64(*inodes))
-					}
-					if inodesUsed := logs.InodesUsed; inodesUsed != nil {
-						containerLogsInodesUsed.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace, container.Name).Set(float64(*inodesUsed))
-					}
-					if availableBytes := logs.AvailableBytes; availableBytes != nil {
-						containerLogsAvailableBytes.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace, container.Name).Set(float64(*availableBytes))
-					}
-					if capacityBytes := logs.CapacityBytes; capacityBytes != nil {
-						containerLogsCapacityBytes.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace, container.Name).Set(float64(*capacityBytes))
-					}
-					if usedBytes := logs.UsedBytes; usedBytes != nil {
-						containerLogsUsedBytes.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace, container.Name).Set(float64(*usedBytes))
-					}
-				}
-				if rootfs := container.Rootfs; rootfs != nil {
-					if inodesFree := rootfs.InodesFree; inodesFree != nil {
-						containerRootFsInodesFree.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace, container.Name).Set(float64(*inodesFree))
-					}
-					if inodes := rootfs.Inodes; inodes != nil {
-						containerRootFsInodes.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace, container.Name).Set(float64(*inodes))
-					}
-					if inodesUsed := rootfs.InodesUsed; inodesUsed != nil {
-						containerRootFsInodesUsed.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace, container.Name).Set(float64(*inodesUsed))
-					}
-					if availableBytes := rootfs.AvailableBytes; availableBytes != nil {
-						containerRootFsAvailableBytes.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace, container.Name).Set(float64(*availableBytes))
-					}
-					if capacityBytes := rootfs.CapacityBytes; capacityBytes != nil {
-						containerRootFsCapacityBytes.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace, container.Name).Set(float64(*capacityBytes))
-					}
-					if usedBytes := rootfs.UsedBytes; usedBytes != nil {
-						containerRootFsUsedBytes.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace, container.Name).Set(float64(*usedBytes))
-					}
-				}
-			}
+// metricsWriter writes the metrics gathered by the registry to the response
+type metricsWriter func(w http.ResponseWriter, r *http.Request, registry prometheus.Gatherer)
 
-			if ephemeralStorage := pod.EphemeralStorage; ephemeralStorage != nil {
-				if ephemeralStorage.AvailableBytes != nil {
-					podEphemeralStorageAvailableBytes.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace).Set(float64(*ephemeralStorage.AvailableBytes))
-				}
-				if ephemeralStorage.CapacityBytes != nil {
-					podEphemeralStorageCapacityBytes.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace).Set(float64(*ephemeralStorage.CapacityBytes))
-				}
-				if ephemeralStorage.UsedBytes != nil {
-					podEphemeralStorageUsedBytes.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace).Set(float64(*ephemeralStorage.UsedBytes))
-				}
-				if ephemeralStorage.InodesFree != nil {
-					podEphemeralStorageInodesFree.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace).Set(float64(*ephemeralStorage.InodesFree))
-				}
-				if ephemeralStorage.Inodes != nil {
-					podEphemeralStorageInodes.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace).Set(float64(*ephemeralStorage.Inodes))
-				}
-				if ephemeralStorage.InodesUsed != nil {
-					podEphemeralStorageInodesUsed.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace).Set(float64(*ephemeralStorage.InodesUsed))
-				}
-			}
-		}
-
-		if runtime := summary.Node.Runtime; runtime != nil {
-			if runtime.ImageFs.AvailableBytes != nil {
-				nodeRuntimeImageFSAvailableBytes.WithLabelValues(nodeName).Set(float64(*runtime.ImageFs.AvailableBytes))
-			}
-			if runtime.ImageFs.CapacityBytes != nil {
-				nodeRuntimeImageFSCapacityBytes.WithLabelValues(nodeName).Set(float64(*runtime.ImageFs.CapacityBytes))
-			}
-			if runtime.ImageFs.UsedBytes != nil {
-				nodeRuntimeImageFSUsedBytes.WithLabelValues(nodeName).Set(float64(*runtime.ImageFs.UsedBytes))
-			}
-			if runtime.ImageFs.InodesFree != nil {
-				nodeRuntimeImageFSInodesFree.WithLabelValues(nodeName).Set(float64(*runtime.ImageFs.InodesFree))
-			}
-			if runtime.ImageFs.Inodes != nil {
-				nodeRuntimeImageFSInodes.WithLabelValues(nodeName).Set(float64(*runtime.ImageFs.Inodes))
-			}
-			if runtime.ImageFs.InodesUsed != nil {
-				nodeRuntimeImageFSInodesUsed.WithLabelValues(nodeName).Set(float64(*runtime.ImageFs.InodesUsed))
-			}
-		}
-	}
+// writePrometheus writes the metrics in the Prometheus exposition format
+func writePrometheus(w http.ResponseWriter, r *http.Request, registry prometheus.Gatherer) {
+	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	h.ServeHTTP(w, r)
 }
 
 // handleMetricsCollection is a generic handler for collecting metrics
-func handleMetricsCollection(w http.ResponseWriter, r *http.Request, kubeClient *kubernetes.Clientset, nodeSelector func(context.Context, *kubernetes.Clientset) ([]PerNodeResult, error)) {
+func handleMetricsCollection(w http.ResponseWriter, r *http.Request, kubeClient *kubernetes.Clientset, nodeSelector nodeSelectorFunc) {
+	handleCollection(w, r, kubeClient, nodeSelector, flagCollectorOptions(), writePrometheus)
+}
+
+// handleSelfMetrics serves the metrics of the exporter itself, followed by the
+// metrics of the selected nodes if --metrics-include-summaries is set. The
+// collection is bounded by the scrape timeout, like on the other endpoints,
+// and a failed collection still serves the exporter metrics so that the
+// scrape degrades instead of failing, unless the summary metrics fail
+// --self-check. In background mode the nodes are selected from the cache,
+// which doesn't block on the kubelets.
+func handleSelfMetrics(w http.ResponseWriter, r *http.Request, kubeClient *kubernetes.Clientset, nodesSelector nodeSelectorFunc) {
+	if !*flagMetricsIncludeSummaries {
+		promhttp.Handler().ServeHTTP(w, r)
+		return
+	}
+
 	ctx, cancel := getTimeoutContext(r)
 	defer cancel()
 
+	registry := prometheus.NewRegistry()
+	results, err := nodesSelector(ctx, kubeClient)
+	if err != nil {
+		logError(ctx, "Collecting the node summaries of /metrics failed: %v", err)
+	} else {
+		summary.Collect(results, registry, flagCollectorOptions())
+	}
+
+	h := promhttp.HandlerFor(prometheus.Gatherers{prometheus.DefaultGatherer, selfChecked(relabeled(registry))}, promhttp.HandlerOpts{})
+	h.ServeHTTP(w, r)
+}
+
+// handleCollection collects the metrics of the selected nodes and writes them
+// with the given writer
+func handleCollection(w http.ResponseWriter, r *http.Request, kubeClient *kubernetes.Clientset, nodeSelector nodeSelectorFunc, opts collectorOptions, write metricsWriter) {
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	ctx, span := tracer.Start(ctx, "handleMetricsCollection", trace.WithAttributes(attribute.String("http.target", r.URL.Path)))
+	defer span.End()
+
+	ctx, cancel := getTimeoutContext(r.WithContext(ctx))
+	defer cancel()
+
 	results, err := nodeSelector(ctx, kubeClient)
+	if err == nil {
+		err = allFailed(results)
+	}
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error collecting node stats: %v", err), http.StatusInternalServerError)
+		span.SetStatus(codes.Error, err.Error())
+		writeCollectionError(w, r, err)
 		return
 	}
 
+	_, encodeSpan := tracer.Start(ctx, "encodeMetrics")
+	defer encodeSpan.End()
+
 	registry := prometheus.NewRegistry()
-	collectSummaryMetrics(results, registry)
-	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
-	h.ServeHTTP(w, r)
+	summary.Collect(results, registry, opts)
+	write(w, r, selfChecked(relabeled(registry)))
+}
+
+// allFailed returns the error of the first result if no node was collected
+// successfully. Partial failures are reported through the per node success
+// gauge instead.
+func allFailed(results []PerNodeResult) error {
+	for _, result := range results {
+		if result.Err == nil || result.Summary != nil {
+			return nil
+		}
+	}
+	if len(results) > 0 {
+		return results[0].Err
+	}
+	return nil
 }
 
 // allNodesSelector selects all nodes in the cluster
-func allNodesSelector(ctx context.Context, kubeClient *kubernetes.Clientset) ([]PerNodeResult, error) {
-	nodes, err := kubeClient.CoreV1().Nodes().List(ctx, meta_v1.ListOptions{}) // Использование meta_v1.ListOptions
-	if err != nil {
-		return nil, fmt.Errorf("error enumerating nodes: %v", err)
+var allNodesSelector = sourceNodesSelector(kubeNodeSource{})
+
+// listNodes lists all the nodes in pages of pageSize with exporter.ListNodes
+func listNodes(ctx context.Context, kubeClient *kubernetes.Clientset, pageSize int64) ([]corev1.Node, error) {
+	return exporter.ListNodes(ctx, kubeClient, "", pageSize)
+}
+
+// excludeNodes returns the nodes whose name doesn't match any of the patterns
+func excludeNodes(nodes []corev1.Node, patterns nodePatternsFlag) []corev1.Node {
+	if len(patterns) == 0 {
+		return nodes
 	}
 
-	return collectNodeStats(ctx, kubeClient, nodes.Items)
+	included := make([]corev1.Node, 0, len(nodes))
+	for _, node := range nodes {
+		if !patterns.matches(node.Name) {
+			included = append(included, node)
+		}
+	}
+	return included
 }
 
-// singleNodeSelector selects a single node by name
-func singleNodeSelector(nodeName string) func(context.Context, *kubernetes.Clientset) ([]PerNodeResult, error) {
+type excludedNodesKey struct{}
+
+// excludedNodesFilter wraps a node selector so that the named nodes are
+// neither collected nor returned, e.g. to skip known bad nodes in an ad-hoc
+// scrape. Nodes already collected in the background are only dropped from the
+// results.
+func excludedNodesFilter(names []string, nodeSelector nodeSelectorFunc) nodeSelectorFunc {
+	excluded := make(map[string]bool, len(names))
+	for _, name := range names {
+		excluded[name] = true
+	}
+
 	return func(ctx context.Context, kubeClient *kubernetes.Clientset) ([]PerNodeResult, error) {
-		node, err := kubeClient.CoreV1().Nodes().Get(ctx, nodeName, meta_v1.GetOptions{}) // Использование meta_v1.GetOptions
+		results, err := nodeSelector(context.WithValue(ctx, excludedNodesKey{}, excluded), kubeClient)
 		if err != nil {
-			return nil, fmt.Errorf("error getting node %s: %v", nodeName, err)
+			return nil, err
 		}
 
-		return collectNodeStats(ctx, kubeClient, []corev1.Node{*node}) // Использование corev1.Node
+		filtered := make([]PerNodeResult, 0, len(results))
+		for _, result := range results {
+			if !excluded[result.NodeName] {
+				filtered = append(filtered, result)
+			}
+		}
+		return filtered, nil
 	}
 }
 
-// collectNodeStats collects stats for the given nodes
-func collectNodeStats(ctx context.Context, kubeClient *kubernetes.Clientset, nodes []corev1.Node) ([]PerNodeResult, error) {
-	var results []PerNodeResult
+// requestExcluded returns whether the node is excluded by the context
+func requestExcluded(ctx context.Context, nodeName string) bool {
+	excluded, _ := ctx.Value(excludedNodesKey{}).(map[string]bool)
+	return excluded[nodeName]
+}
+
+type nodeLabelSelectorKey struct{}
 
+// requestSelected returns whether the node labels match the label selector of
+// the context, set by summaryScrapeFilter. Every node is selected by default.
+func requestSelected(ctx context.Context, nodeLabels map[string]string) bool {
+	selector, ok := ctx.Value(nodeLabelSelectorKey{}).(labels.Selector)
+	return !ok || selector.Matches(labels.Set(nodeLabels))
+}
+
+// singleNodeSelector selects a single node of the cluster by name
+var singleNodeSelector = sourceNodeSelector(kubeNodeSource{})
+
+// collectNodeStats collects stats for the given nodes, up to --concurrency at a
+// time. A node that fails, or isn't reached before the context deadline, is
+// returned with its error set so the results already gathered are still
+// served. Nodes excluded, not selected, not ramped up yet, collected by a peer
+// or not due by the context are skipped. Each result is also passed to the
+// stream of the context, if any, as soon as it is collected.
+func collectNodeStats(ctx context.Context, kubeClient *kubernetes.Clientset, nodes []corev1.Node) []PerNodeResult {
+	included := make([]corev1.Node, 0, len(nodes))
 	for _, node := range nodes {
-		summary, err := getNodeSummary(ctx, kubeClient, node.Name)
-		if err != nil {
-			return nil, err
+		if !requestExcluded(ctx, node.Name) && requestSelected(ctx, node.Labels) && rampedUp(ctx, node.Name) && peerOwned(ctx, node.Name) && nodeDue(ctx, node) {
+			included = append(included, node)
 		}
+	}
 
-		results = append(results, PerNodeResult{
-			NodeName: node.Name,
-			Summary:  summary,
-		})
+	progressTotal(ctx, len(included))
+	stale := staleLeases(ctx, kubeClient, included, *flagNodeLeaseStaleThreshold, time.Now())
+	concurrency := max(*flagConcurrency, 1)
+	if window := collectionSpread(ctx); window > 0 {
+		return collectSpread(ctx, kubeClient, included, stale, window, concurrency)
+	}
+	results := make([]PerNodeResult, len(included))
+
+	var (
+		mu   sync.Mutex
+		next int
+		wg   sync.WaitGroup
+	)
+	for range min(concurrency, len(included)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				mu.Lock()
+				i := next
+				next++
+				mu.Unlock()
+				if i >= len(included) {
+					return
+				}
+
+				if age, ok := stale[included[i].Name]; ok {
+					results[i] = staleLeaseResult(included[i], age)
+				} else {
+					results[i] = collectNode(ctx, kubeClient, included[i], len(included)-i, concurrency)
+				}
+				streamResult(ctx, results[i])
+				progressNode(ctx, results[i])
+			}
+		}()
 	}
+	wg.Wait()
 
-	return results, nil
+	return results
 }
 
-// getNodeSummary retrieves the summary for a single node
-func getNodeSummary(ctx context.Context, kubeClient *kubernetes.Clientset, nodeName string) (*stats.Summary, error) {
-	req := kubeClient.CoreV1().RESTClient().Get().Resource("nodes").Name(nodeName).SubResource("proxy").Suffix("stats/summary")
-	resp, err := req.DoRaw(ctx)
+// collectNode collects the stats of a single node. If the context has a
+// deadline the node only gets its share of the remaining time, so that a few
+// slow kubelets can't starve the nodes queued after them.
+func collectNode(ctx context.Context, kubeClient *kubernetes.Clientset, node corev1.Node, remainingNodes, concurrency int) PerNodeResult {
+	result := summary.NewNodeResult(node)
+
+	if err := ctx.Err(); err != nil {
+		result.Err = fmt.Errorf("error querying /stats/summary for %s: %v", node.Name, err)
+		return result
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, nodeBudget(deadline, time.Now(), remainingNodes, concurrency))
+		defer cancel()
+	}
+
+	// Each request is timed on its own, without the backoff between the
+	// retries and the fallback
+	fetch := func() {
+		start := time.Now()
+		result.Summary, result.Capabilities, result.ResponseBytes, result.Err = getNodeSummary(ctx, kubeClient, node.Name)
+		observeFetchDuration(node, *flagFetchDurationNodeLabel, time.Since(start))
+		slowNodes.observe(node.Name, time.Since(start))
+	}
+	summaryRetryBudget.request()
+	fetch()
+	for retry := 0; result.Err != nil && retry < *flagSummaryRetries && retryableSummaryError(result.Err); retry++ {
+		if !summaryRetryBudget.retry() || !sleepRetry(ctx, retry) {
+			break
+		}
+		fetch()
+	}
+	if result.Err != nil && *flagResourceMetricsFallback && resourceMetricsFallback(result.Err) {
+		fallBackToResourceMetrics(ctx, kubeClient, &result)
+	}
+	if result.Err != nil {
+		logError(ctx, "%v", result.Err)
+		return result
+	}
+	result.CollectedAt = time.Now()
+	// The summaries of /metrics/resource have no storage stats to miss
+	if result.Source != summary.SourceResourceMetrics {
+		countMissingStats(node.Name, result.Provider, result.Summary)
+	}
+	recordCoverage(result)
+	if *flagPodGracePeriod > 0 {
+		result.Summary = podGrace.apply(node.Name, result.Summary, *flagPodGracePeriod)
+	}
+	// After the grace period, which would keep the dropped pods otherwise
+	result.Summary = dropOptedOutPods(result.Summary, optedOutPods.contains)
+	return result
+}
+
+// nodeBudget divides the time left until the deadline by the number of rounds
+// needed to collect the remaining nodes, the next one included, with the given
+// concurrency. Nodes finishing early leave more time to the following ones.
+func nodeBudget(deadline, now time.Time, remainingNodes, concurrency int) time.Duration {
+	rounds := (remainingNodes + concurrency - 1) / concurrency
+	if rounds < 1 {
+		rounds = 1
+	}
+	return deadline.Sub(now) / time.Duration(rounds)
+}
+
+// openSummary opens the /stats/summary response of a node, through the API
+// server proxy unless the kubelets are reached directly with --kubelets
+var openSummary = proxySummary
+
+// proxySummary opens the /stats/summary response of a node through the API
+// server proxy with exporter.OpenSummary
+func proxySummary(ctx context.Context, kubeClient *kubernetes.Clientset, nodeName string) (io.ReadCloser, error) {
+	return exporter.OpenSummary(ctx, kubeClient, nodeName, *flagKubeletPort, *flagRequestGzip)
+}
+
+// getNodeSummary retrieves the summary for a single node, along with its
+// capabilities and the size of the raw response
+func getNodeSummary(ctx context.Context, kubeClient *kubernetes.Clientset, nodeName string) (_ *stats.Summary, _ map[string]bool, _ int, err error) {
+	ctx, span := tracer.Start(ctx, "getNodeSummary", trace.WithAttributes(attribute.String("node", nodeName)))
+	defer func() {
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+			class, code := classifySummaryError(err)
+			reason, hint := summaryErrorReason(nodeName, err)
+			summaryErrors.WithLabelValues(nodeName, class, code, reason).Inc()
+			if reason != "" {
+				logSummaryHint(nodeName, reason, hint)
+			}
+		}
+		span.End()
+	}()
+
+	stream, err := openSummary(ctx, kubeClient, nodeName)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("error querying /stats/summary for %s: %w", nodeName, err)
+	}
+	defer stream.Close()
+
+	maxBytes := flagMaxSummaryBytes.Int64()
+	resp, err := exporter.ReadSummary(stream, maxBytes)
+	if errors.Is(err, errSummaryTooLarge) {
+		summaryTooLarge.WithLabelValues(nodeName).Inc()
+		return nil, nil, 0, fmt.Errorf("error reading /stats/summary response for %s: %w of %d bytes", nodeName, err, maxBytes)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("error querying /stats/summary for %s: %v", nodeName, err)
+		return nil, nil, 0, fmt.Errorf("error reading /stats/summary response for %s: %w", nodeName, err)
 	}
 
-	summary := &stats.Summary{}
-	if err := json.Unmarshal(resp, summary); err != nil {
-		return nil, fmt.Errorf("error unmarshaling /stats/summary response for %s: %v", nodeName, err)
+	summary, capabilities, err := decodeSummary(nodeName, resp)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("error unmarshaling /stats/summary response for %s: %w", nodeName, err)
 	}
 
-	return summary, nil
+	return summary, capabilities, len(resp), nil
+}
+
+var errSummaryTooLarge = exporter.ErrSummaryTooLarge
+
+var summaryTooLarge = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metricsNamespace,
+	Name:      "node_summary_too_large_total",
+	Help:      "Number of /stats/summary responses rejected because they exceeded --max-summary-bytes",
+},
+	[]string{
+		"node",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(summaryTooLarge)
+	onNodeRemoved(func(nodeName string) {
+		summaryTooLarge.DeleteLabelValues(nodeName)
+	})
 }
 
 // getTimeoutContext returns a context with timeout based on the X-Prometheus-Scrape-Timeout-Seconds header
@@ -503,10 +424,22 @@ func getTimeoutContext(r *http.Request) (context.Context, context.CancelFunc) {
 	return context.WithCancel(r.Context())
 }
 
-// newKubeClient returns a Kubernetes client (clientset) from the supplied
+// newKubeClient returns a Kubernetes client (clientset) from the config
+// returned by newKubeConfig
+func newKubeClient(path string, headers http.Header) (*kubernetes.Clientset, error) {
+	config, err := newKubeConfig(path, headers)
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(config)
+}
+
+// newKubeConfig returns a Kubernetes client config from the supplied
 // kubeconfig path, the KUBECONFIG environment variable, the default config file
 // location ($HOME/.kube/config) or from the in-cluster service account environment.
-func newKubeClient(path string) (*kubernetes.Clientset, error) {
+// The extra headers are added to every request sent to the API server, and so
+// to the kubelets through the node proxy.
+func newKubeConfig(path string, headers http.Header) (*rest.Config, error) {
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
 	if path != "" {
 		loadingRules.ExplicitPath = path
@@ -514,7 +447,7 @@ func newKubeClient(path string) (*kubernetes.Clientset, error) {
 
 	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
 		loadingRules,
-		&clientcmd.ConfigOverrides{},
+		&clientcmd.ConfigOverrides{CurrentContext: *flagKubeContext},
 	)
 
 	config, err := kubeConfig.ClientConfig()
@@ -522,44 +455,405 @@ func newKubeClient(path string) (*kubernetes.Clientset, error) {
 		return nil, err
 	}
 
-	return kubernetes.NewForConfig(config)
+	if len(headers) > 0 {
+		config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+			return &headerRoundTripper{headers: headers, rt: rt}
+		})
+	}
+
+	return config, nil
+}
+
+// headerRoundTripper adds a set of headers to every request
+type headerRoundTripper struct {
+	headers http.Header
+	rt      http.RoundTripper
+}
+
+func (h *headerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for name, values := range h.headers {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	return h.rt.RoundTrip(req)
 }
 
 var (
-	flagListenAddress  = flag.String("listen-address", ":9779", "Listen address")
-	flagKubeConfigPath = flag.String("kubeconfig", "", "Path of a kubeconfig file, if not provided the app will try $KUBECONFIG, $HOME/.kube/config or in cluster config")
+	flagListenAddress                = flag.String("listen-address", ":9779", "Listen address")
+	flagPeersService                 = flag.String("peers-service", "", "DNS name of the headless Service of the exporter, whose replicas split the background collection of the nodes between them and fetch the summaries of the other shares from each other")
+	flagPeerTokenFile                = flag.String("peer-token-file", "", "File holding the bearer token the replicas of --peers-service present to each other, reloaded when it changes")
+	flagPeerAddress                  = flag.String("peer-address", "", "IP of this replica among the addresses of --peers-service, $POD_IP if empty")
+	flagGRPCListenAddress            = flag.String("grpc-listen-address", "", "Listen address of the gRPC summary service, disabled if empty")
+	flagGRPCTimeout                  = flag.Duration("grpc-timeout", 30*time.Second, "Timeout of the gRPC calls without a deadline, 0 disables it")
+	flagDev                          = flag.Bool("dev", false, "Local development mode: use the context of a kind or minikube cluster, collect on every request with short timeouts, print the progress of every collection and disable the background loops")
+	flagSelfCheck                    = flag.Bool("self-check", false, "Check the output of every collection, failing it on invalid exposition, metric and label names or values, duplicate series and promtool lint problems, e.g. with --dev or the once command in CI")
+	flagConfigFile                   = flag.String("config-file", "", "YAML file holding the metric_relabel_configs applied to every series when it is emitted and the sinks the metrics are pushed to")
+	flagWebRoutePrefix               = flag.String("web.route-prefix", "", "Path prefix the handlers are served under, defaults to the path of --web.external-url")
+	flagWebExternalURL               = flag.String("web.external-url", "", "URL the exporter is reachable at through a reverse proxy, used for the links of the landing page")
+	flagWebCORSOrigins               = flag.String("web.cors-origins", "", "Comma separated origins allowed to call the JSON API from a browser, * allowing any, CORS is disabled if empty")
+	flagWebCORSMethods               = flag.String("web.cors-methods", "GET, OPTIONS", "Comma separated methods allowed in CORS requests to the JSON API")
+	flagWebAuthTokenFile             = flag.String("web.auth-token-file", "", "File holding a static bearer token that requests must present, reloaded when it changes. The namespace endpoints keep authenticating Kubernetes tokens")
+	flagKubeConfigPath               = flag.String("kubeconfig", "", "Path of a kubeconfig file, if not provided the app will try $KUBECONFIG, $HOME/.kube/config or in cluster config")
+	flagKubeContext                  = flag.String("kube-context", "", "Context of the kubeconfig to use, the current context if empty")
+	flagKubeConfigReloadInterval     = flag.Duration("kubeconfig-reload-interval", 30*time.Second, "Interval at which the kubeconfig and the credential files it refers to, e.g. the service account token, are checked for changes and the client rebuilt, 0 disables reloading")
+	flagConcurrency                  = flag.Int("concurrency", 1, "Number of nodes whose summaries are collected in parallel")
+	flagNodeLeaseStaleThreshold      = flag.Duration("node-lease-stale-threshold", 0, "Skip the nodes whose Lease in kube-node-lease wasn't renewed for longer than this, instead of waiting for their kubelet to time out (0 to query all nodes)")
+	flagMaxRequestsInFlight          = flag.Int("max-requests-in-flight", 0, "Maximum number of collection requests served at once, over HTTP and gRPC, further requests are answered with 503 and Retry-After, or Unavailable, 0 disables the limit")
+	flagFetchDurationNodeLabel       = flag.String("fetch-duration-node-label", "", "Node label, e.g. a node pool label, whose values partition kube_summary_node_summary_fetch_duration_seconds, a single histogram is exported if empty")
+	flagSlowNodeThreshold            = flag.Duration("slow-node-threshold", 0, "Flag the nodes whose p95 of the last --slow-node-window /stats/summary requests exceeds this duration with kube_summary_node_slow, 0 disables the detection")
+	flagSlowNodeWindow               = flag.Int("slow-node-window", 20, "Number of the last /stats/summary requests of a node the p95 of --slow-node-threshold is computed over")
+	flagStreamNodes                  = flag.Bool("stream-nodes", false, "Write the metrics of each node to /nodes responses as soon as the node is collected instead of buffering the whole response")
+	flagCoalesceRequests             = flag.Bool("coalesce-requests", true, "Share the collection in flight between concurrent identical requests for /nodes, /node/{node} and /influx instead of querying the kubelets again")
+	flagCoalesceTimeout              = flag.Duration("coalesce-timeout", time.Minute, "Timeout of the collections shared by --coalesce-requests, which don't stop when the request that started them goes away, 0 for none")
+	flagMetricsIncludeSummaries      = flag.Bool("metrics-include-summaries", false, "Serve the metrics of all nodes on /metrics too, from the cache in background mode, so that a single target scrapes both")
+	flagMaxPodsPerNode               = flag.Int("max-pods-per-node", 0, "Only export per pod and per container series for the K largest ephemeral storage consumers on each node, 0 disables the limit")
+	flagExportNodeResources          = flag.Bool("export-node-resources", false, "Export the allocatable and capacity cpu, memory, ephemeral storage and pods of the node status as kube_summary_node_allocatable_* and kube_summary_node_capacity_*")
+	flagExportNodeScheduling         = flag.Bool("export-node-scheduling", false, "Export whether the nodes are cordoned as kube_summary_node_unschedulable and, for the --node-taint keys, tainted as kube_summary_node_taint")
+	flagExportPodInfo                = flag.Bool("export-pod-info", false, "Export kube_summary_pod_info{node,pod,namespace,uid} for every exported pod, to tell apart recreated pods with the same name and join on the UID")
+	flagResourceMetricsFallback      = flag.Bool("resource-metrics-fallback", false, "Fall back to the kubelet /metrics/resource endpoint for the nodes whose /stats/summary is unavailable, and export the CPU and memory usage of every node, pod and container with a source label")
+	flagPodScrapeAnnotation          = flag.String("pod-scrape-annotation", "", "Annotation opting a pod out of the collection when set to \"false\", e.g. kube-summary.io/scrape, told apart with a pod informer")
+	flagNodeMetadataLabels           = flag.Bool("node-metadata-labels", false, "Add the os, arch and instance_type labels of the node status and well-known labels to the node level series")
+	flagEnableDeprecatedMetrics      = flag.Bool("enable-deprecated-metrics", false, "Also export the deprecated metrics of the catalog, see /catalog, under their old names along with the metrics replacing them")
+	flagRequestGzip                  = flag.Bool("request-gzip", true, "Ask the API server and kubelets for gzip compressed /stats/summary responses")
+	flagKubeletPort                  = flag.Int("kubelet-port", 0, "Port of the kubelets in the proxy path, nodes/{node}:{port}/proxy/stats/summary, for kubelets listening on a port other than the one reported in the node status (0 to let the API server pick it)")
+	flagOTLPEndpoint                 = flag.String("otlp-endpoint", "", "Export traces of the collection pipeline over OTLP/HTTP to this host:port, tracing is disabled if empty")
+	flagOTLPInsecure                 = flag.Bool("otlp-insecure", false, "Use plain HTTP instead of HTTPS to export traces")
+	flagNodesFile                    = flag.String("nodes-file", "", "File listing the nodes to collect, one per line, instead of listing the nodes from the API server. The file is reloaded when it changes")
+	flagNodes                        = flag.String("nodes", "", "Comma separated list of the nodes to collect, instead of listing the nodes from the API server")
+	flagNodesHTTPSDURL               = flag.String("nodes-http-sd-url", "", "URL of a Prometheus HTTP service discovery endpoint whose targets are the names of the nodes to collect, instead of listing the nodes from the API server")
+	flagNodesHTTPSDRefreshInterval   = flag.Duration("nodes-http-sd-refresh-interval", time.Minute, "Interval between requests to the --nodes-http-sd-url endpoint")
+	flagKubelets                     = flag.String("kubelets", "", "Comma separated list of kubelet addresses, host[:port], URL or name=address, optionally suffixed by @zone, to fetch /stats/summary from directly, without any API server")
+	flagKubeletTokenFile             = flag.String("kubelet-token-file", "", "File holding the bearer token sent to the --kubelets, reloaded when it changes")
+	flagKubeletCAFile                = flag.String("kubelet-ca-file", "", "CA certificates the serving certificates of the --kubelets are verified with")
+	flagKubeletClientCertFile        = flag.String("kubelet-client-cert-file", "", "Client certificate presented to the --kubelets")
+	flagKubeletClientKeyFile         = flag.String("kubelet-client-key-file", "", "Key of the --kubelet-client-cert-file")
+	flagKubeletInsecureSkipTLSVerify = flag.Bool("kubelet-insecure-skip-tls-verify", false, "Don't verify the serving certificates of the --kubelets, which are self-signed by default")
+	flagKubeletMaxIdleConnsPerHost   = flag.Int("kubelet-max-idle-conns-per-host", 25, "Maximum number of idle connections kept open to each of the --kubelets")
+	flagKubeletIdleConnTimeout       = flag.Duration("kubelet-idle-conn-timeout", 90*time.Second, "Time after which the idle connections to the --kubelets are closed, 0 to keep them open")
+	flagKubeletKeepAlive             = flag.Duration("kubelet-keep-alive", 30*time.Second, "Interval between the TCP keep-alive probes of the connections to the --kubelets, negative to disable them")
+	flagKubeletDialTimeout           = flag.Duration("kubelet-dial-timeout", 30*time.Second, "Timeout of the connection to the --kubelets, DNS resolution included, 0 for none")
+	flagKubeletTLSHandshakeTimeout   = flag.Duration("kubelet-tls-handshake-timeout", 10*time.Second, "Timeout of the TLS handshake with the --kubelets, 0 for none")
+	flagNodeListPageSize             = flag.Int64("node-list-page-size", 500, "Number of nodes requested per page when listing the nodes from the API server")
+	flagShards                       = flag.Int("shards", 1, "Number of shards the nodes are split into by a hash of their name, each replica of the exporter collecting one of them when listing all nodes")
+	flagShard                        = flag.Int("shard", 0, "Shard of the nodes this replica collects, from 0 to --shards - 1")
+	flagShardZone                    = flag.String("shard-zone", "", "Zone of this replica with --shard-by=zone, only the nodes of this topology.kubernetes.io/zone being collected")
+	flagCollectionInterval           = flag.Duration("collection-interval", 0, "Collect the summaries of all nodes in the background at this interval and serve them from a cache, 0 collects on every request")
+	flagCacheExpiryCycles            = flag.Int("cache-expiry-cycles", 3, "Number of background collection cycles a node or pod may be missing before its series are dropped from the cache")
+	flagPodGracePeriod               = flag.Duration("pod-grace-period", 0, "Keep exporting the last stats of a pod missing from the summary of its node for this long, so that pods the kubelet briefly leaves out don't get gaps in their series, 0 disables it")
+	flagCollectionSpread             = flag.Bool("collection-spread", false, "Stagger the background collection of each node over the first half of the interval, at an offset derived from a hash of its name, instead of collecting all nodes at the start of each cycle")
+	flagStartupRampCycles            = flag.Int("startup-ramp-cycles", 0, "Number of background collection cycles the first collection of all nodes is ramped up over on startup, collecting a share more of the nodes every cycle, 0 or 1 to collect every node from the first cycle")
+	flagNodeIntervalAnnotation       = flag.String("node-interval-annotation", "", "Annotation of the nodes overriding their background collection interval, e.g. kube-summary.io/interval: \"2m\"")
+	flagCollectionLogSize            = flag.Int("collection-log-size", 50, "Number of collections of all nodes kept for /debug/collections, 0 to disable")
+	flagCacheFile                    = flag.String("cache-file", "", "Persist the cache to this file after every background collection cycle, and serve its contents as stale after a restart until the first cycle completes")
+	flagCacheFileMaxAge              = flag.Duration("cache-file-max-age", time.Hour, "Ignore a --cache-file written longer ago than this on startup")
+	flagGraphiteAddress              = flag.String("graphite-address", "", "Push the metrics of all nodes to this Graphite plaintext host:port, disabled if empty")
+	flagGraphitePrefix               = flag.String("graphite-prefix", "", "Prefix of the metric paths pushed to Graphite")
+	flagGraphiteInterval             = flag.Duration("graphite-interval", time.Minute, "Interval between pushes to Graphite")
+	flagStatsdAddress                = flag.String("statsd-address", "", "Emit the metrics of all nodes as DogStatsD gauges to this UDP host:port, disabled if empty")
+	flagStatsdPrefix                 = flag.String("statsd-prefix", "", "Prefix of the metric names emitted to DogStatsD")
+	flagStatsdInterval               = flag.Duration("statsd-interval", time.Minute, "Interval between emissions to DogStatsD")
+	flagKafkaBrokers                 = flag.String("kafka-brokers", "", "Comma separated Kafka brokers to publish the summary of every node to after each background collection cycle, disabled if empty")
+	flagKafkaTopic                   = flag.String("kafka-topic", "kube-summary", "Kafka topic the summaries are published to")
+	flagObjectStorageEndpoint        = flag.String("object-storage-endpoint", "s3.amazonaws.com", "S3 compatible endpoint the snapshots are uploaded to, e.g. storage.googleapis.com for GCS")
+	flagObjectStorageBucket          = flag.String("object-storage-bucket", "", "Bucket to upload the gzipped JSON summaries of all nodes to after each background collection cycle, disabled if empty")
+	flagObjectStoragePrefix          = flag.String("object-storage-prefix", "kube-summary/", "Prefix of the snapshot object keys, followed by the timestamp of the cycle")
+	flagObjectStorageRetention       = flag.Duration("object-storage-retention", 7*24*time.Hour, "Age after which the snapshots under the prefix are removed, 0 keeps them forever")
+	flagObjectStorageInsecure        = flag.Bool("object-storage-insecure", false, "Use plain HTTP instead of HTTPS to talk to the object storage endpoint")
+	flagWebhookURL                   = flag.String("webhook-url", "", "POST the alerts of the --threshold rules to this Slack compatible or generic webhook after each background collection cycle, disabled if empty")
+	flagWebhookCooldown              = flag.Duration("webhook-cooldown", time.Hour, "Minimum interval between two notifications of the same alert while it keeps firing")
+	flagSummaryScrapes               = flag.Bool("summary-scrapes", false, "Watch the SummaryScrape resources of every namespace and the ClusterSummaryScrape resources, and serve the metrics each one selects at /scrape/{namespace}/{name} and /scrape/{name}")
+	flagThresholds                   thresholdRulesFlag
+	flagUpstreamHeaders              = headerFlag{}
+	flagMaxSummaryBytes              = byteSizeFlag(50 * 1000 * 1000)
+	flagSummaryRetries               = flag.Int("summary-retries", 0, "Number of times a /stats/summary request failing with a timeout, a refused connection or a server error is retried within a collection, as long as the retry budget allows")
+	flagRetryBudgetRatio             = flag.Float64("retry-budget-ratio", 0.2, "Maximum number of retries per /stats/summary request over the last minute, on top of 10 retries, further retries being shed, 0 disables the budget")
+	flagDegradedFailureRatio         = flag.Float64("degraded-failure-ratio", 0, "Serve the last collection of all nodes instead when more than this ratio of the nodes fail, e.g. 0.5, until it's older than --degraded-max-age, 0 disables it. Only applies when collecting on every request")
+	flagDegradedMaxAge               = flag.Duration("degraded-max-age", 10*time.Minute, "Age above which the last collection isn't served anymore by --degraded-failure-ratio, 0 for no limit")
+	flagContainerLogMaxSize          = byteSizeFlag(0)
+	flagCacheMaxBytes                = byteSizeFlag(0)
+	flagExcludeNodes                 nodePatternsFlag
+	flagNodeTaints                   stringSliceFlag
+	flagOmitZeroValues               = sectionsFlag{}
+
+	flagEphemeralStorageBuckets byteBucketsFlag
+	flagMirrorPods              = choiceFlag{value: summary.MirrorPodsInclude, choices: summary.MirrorPodsModes}
+	flagCollectionMode          = choiceFlag{value: collectionModeCycle, choices: collectionModes}
+	flagShardBy                 = choiceFlag{value: shardByHash, choices: shardByModes}
 )
 
 func main() {
+	flag.Var(&flagCacheMaxBytes, "cache-max-bytes", "Cap on the estimated memory of the background collection cache (e.g. 512Mi), the nodes collected the longest time ago being dropped above it (0 for no cap)")
+	flag.Var(&flagContainerLogMaxSize, "container-log-max-size", "containerLogMaxSize of the kubelet config (e.g. 10Mi), kube_summary_container_logs_used_ratio is exported against it if set")
+	flag.Var(&flagMaxSummaryBytes, "max-summary-bytes", "Maximum size of a node's /stats/summary response, larger responses are rejected (e.g. 50MB, 64MiB), 0 disables the limit")
+	flag.Var(&flagNodeTaints, "node-taint", "Taint key exported by kube_summary_node_taint with --export-node-scheduling, e.g. ToBeDeletedByClusterAutoscaler, can be repeated")
+	flag.Var(&flagExcludeNodes, "exclude-node", "Node name, or regular expression matching the whole name, to skip when collecting all nodes, can be repeated")
+	flag.Var(flagOmitZeroValues, "omit-zero-values", "Comma separated metric groups whose zero values aren't exported, among "+strings.Join(summary.Sections, ", "))
+	flag.Var(&flagEphemeralStorageBuckets, "ephemeral-storage-buckets", "Comma separated buckets of kube_summary_node_pod_ephemeral_storage_used_bytes (e.g. 100MiB,1GiB,10GiB), exponential from 1MiB to 256GiB by default")
+	flag.Var(&flagThresholds, "threshold", "Threshold rule <metric><op><value>, with op one of >= <= > <, firing for every series of the metric that breaches it, can be repeated")
+	flag.Var(&flagCollectionMode, "collection-mode", "How the background collection runs: cycle collects all the nodes every --collection-interval, workqueue collects every node on its own schedule as soon as the node informer sees it, retrying the failed nodes with backoff")
+	flag.Var(&flagShardBy, "shard-by", "How the nodes are assigned to the shards: hash splits all nodes by a hash of their name, zone only assigns the nodes of the --shard-zone, split by hash between the --shards replicas of the zone")
+	flag.Var(&flagMirrorPods, "mirror-pods", "How the mirror pods of the static pods, told apart with a pod informer, are exported: include, drop or label them with kube_summary_pod_mirror")
+	flag.Var(flagUpstreamHeaders, "upstream-header", "Extra \"Name: value\" header added to the requests sent to the API server and kubelets, can be repeated")
+
+	if len(os.Args) > 1 && os.Args[1] == "once" {
+		os.Exit(runOnce(os.Args[2:]))
+	}
 	flag.Parse()
 
-	kubeClient, err := newKubeClient(*flagKubeConfigPath)
+	if *flagDev {
+		if err := applyDevMode(os.Stdout); err != nil {
+			fmt.Printf("[Error] Cannot set up dev mode: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *flagRetryBudgetRatio < 0 {
+		fmt.Println("[Error] --retry-budget-ratio can't be negative")
+		os.Exit(1)
+	}
+	summaryRetryBudget.ratio = *flagRetryBudgetRatio
+	if *flagSlowNodeWindow < 1 {
+		fmt.Println("[Error] --slow-node-window must be at least 1")
+		os.Exit(1)
+	}
+	slowNodes = newSlowNodeDetector(*flagSlowNodeThreshold, *flagSlowNodeWindow)
+	if *flagCollectionLogSize < 0 {
+		fmt.Println("[Error] --collection-log-size can't be negative")
+		os.Exit(1)
+	}
+	collections = newCollectionLog(*flagCollectionLogSize)
+	if *flagDegradedFailureRatio < 0 || *flagDegradedFailureRatio >= 1 {
+		fmt.Println("[Error] --degraded-failure-ratio must be between 0 and 1")
+		os.Exit(1)
+	}
+	lastGoodSnapshot = newSnapshotFallback(*flagDegradedFailureRatio, *flagDegradedMaxAge)
+
+	cfg := &config{}
+	if *flagConfigFile != "" {
+		c, rules, err := loadConfig(*flagConfigFile)
+		if err != nil {
+			fmt.Printf("[Error] Cannot load config file: %v\n", err)
+			os.Exit(1)
+		}
+		cfg = c
+		activeConfig = c
+		metricRelabelRules = rules
+		metricHelpOverrides = c.MetricHelp
+		customMetrics = c.CustomMetrics
+	}
+
+	var kubeConfig *rest.Config
+	var err error
+	if *flagKubelets != "" {
+		kubeConfig, err = setupStandaloneKubelets()
+	} else {
+		kubeConfig, err = newReloadingKubeConfig(*flagKubeConfigPath, http.Header(flagUpstreamHeaders), *flagKubeConfigReloadInterval)
+	}
 	if err != nil {
-		fmt.Printf("[Error] Cannot create kube client: %v", err)
+		fmt.Printf("[Error] Cannot create kube client: %v\n", err)
+		os.Exit(1)
+	}
+	if *flagDev {
+		kubeConfig.Timeout = devRequestTimeout
+	}
+	kubeClient, err := kubernetes.NewForConfig(kubeConfig)
+	if err != nil {
+		fmt.Printf("[Error] Cannot create kube client: %v\n", err)
 		os.Exit(1)
 	}
 
-	r := mux.NewRouter()
-	r.HandleFunc("/nodes", func(w http.ResponseWriter, r *http.Request) {
-		handleMetricsCollection(w, r, kubeClient, allNodesSelector)
-	})
-	r.HandleFunc("/node/{node}", func(w http.ResponseWriter, r *http.Request) {
-		nodeName := mux.Vars(r)["node"]
-		handleMetricsCollection(w, r, kubeClient, singleNodeSelector(nodeName))
-	})
-	r.Handle("/metrics", promhttp.Handler())
-	r.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		_, _ = w.Write([]byte(`<html>
-    <head><title>Kube Summary Exporter</title></head>
-    <body>
-        <h1>Kube Summary Exporter</h1>
-        <p><a href="/nodes">Retrieve metrics for all nodes</a></p>
-        <p><a href="/node/example-node">Retrieve metrics for 'example-node'</a></p>
-        <p><a href="/metrics">Metrics</a></p>
-    </body>
-</html>`))
-	})
+	shutdownTracing := func(context.Context) error { return nil }
+	if *flagOTLPEndpoint != "" {
+		if shutdownTracing, err = setupTracing(context.Background(), *flagOTLPEndpoint, *flagOTLPInsecure); err != nil {
+			fmt.Printf("[Error] Cannot set up tracing: %v\n", err)
+			os.Exit(1)
+		}
+		flushTracingOnSignal(shutdownTracing)
+	}
+
+	source, err := newNodeSource()
+	if err != nil {
+		fmt.Printf("[Error] Invalid node source: %v\n", err)
+		os.Exit(1)
+	}
+	nodeShard, err = newNodeSharding(*flagShards, *flagShard, flagShardBy.value, *flagShardZone)
+	if err != nil {
+		fmt.Printf("[Error] Invalid sharding: %v\n", err)
+		os.Exit(1)
+	}
+	if nodeShard.zone != "" && !nodeSourceHasLabels(source) {
+		fmt.Println("[Error] --shard-by=zone needs the zones of the nodes, which --nodes and --nodes-file don't have, and --kubelets only with name=address@zone entries")
+		os.Exit(1)
+	}
+	nodesSelector := sourceNodesSelector(source)
+	nodeSelector := sourceNodeSelector(source)
+	nodeNamesSource = sourceNodeNames(source)
+
+	var scrapes *summaryScrapes
+	if *flagSummaryScrapes {
+		dynamicClient, err := dynamic.NewForConfig(kubeConfig)
+		if err != nil {
+			fmt.Printf("[Error] Cannot create dynamic kube client: %v\n", err)
+			os.Exit(1)
+		}
+		scrapes = newSummaryScrapes(nodeSourceHasLabels(source))
+		if err := runSummaryScrapeController(context.Background(), dynamicClient, scrapes); err != nil {
+			fmt.Printf("[Error] Cannot watch SummaryScrape resources: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if err := runFlagPodInformer(context.Background(), kubeClient); err != nil {
+		fmt.Printf("[Error] Cannot watch pods: %v\n", err)
+		os.Exit(1)
+	}
+
+	var snapshots []snapshotSink
+	if *flagKafkaBrokers != "" {
+		snapshots = append(snapshots, newKafkaSink(strings.Split(*flagKafkaBrokers, ","), *flagKafkaTopic))
+	}
+	if *flagObjectStorageBucket != "" {
+		store, err := newMinioStore(*flagObjectStorageEndpoint, *flagObjectStorageBucket, *flagObjectStorageInsecure)
+		if err != nil {
+			fmt.Printf("[Error] Cannot create object storage client: %v\n", err)
+			os.Exit(1)
+		}
+		snapshots = append(snapshots, newObjectStorageSink(store, *flagObjectStoragePrefix, *flagObjectStorageRetention))
+	}
+	if len(flagThresholds) > 0 && *flagWebhookURL == "" {
+		fmt.Println("[Error] --threshold rules are notified to the webhook, set --webhook-url")
+		os.Exit(1)
+	}
+	if *flagWebhookURL != "" {
+		snapshots = append(snapshots, newWebhookNotifier(*flagWebhookURL, flagThresholds, scrapes, *flagWebhookCooldown))
+	}
+	if len(flagNodeTaints) > 0 && !*flagExportNodeScheduling {
+		fmt.Println("[Error] --node-taint keys are exported with the node scheduling state, set --export-node-scheduling")
+		os.Exit(1)
+	}
+	if *flagStartupRampCycles < 0 {
+		fmt.Println("[Error] --startup-ramp-cycles must not be negative")
+		os.Exit(1)
+	}
+	if *flagNodeIntervalAnnotation != "" && *flagCollectionInterval <= 0 {
+		fmt.Println("[Error] --node-interval-annotation overrides the interval of the background collection, set --collection-interval")
+		os.Exit(1)
+	}
+	if *flagStartupRampCycles > 1 && *flagCollectionInterval <= 0 {
+		fmt.Println("[Error] --startup-ramp-cycles ramps up the background collection, set --collection-interval")
+		os.Exit(1)
+	}
+	if (len(snapshots) > 0 || *flagCacheFile != "") && *flagCollectionInterval <= 0 {
+		fmt.Println("[Error] Snapshot outputs, webhook notifications and --cache-file require background collection, set --collection-interval")
+		os.Exit(1)
+	}
+
+	prefix, err := routePrefix(*flagWebExternalURL, *flagWebRoutePrefix)
+	if err != nil {
+		fmt.Printf("[Error] %v\n", err)
+		os.Exit(1)
+	}
+	if *flagPeersService != "" {
+		switch {
+		case *flagCollectionInterval <= 0:
+			fmt.Println("[Error] --peers-service shares the background collection, set --collection-interval")
+			os.Exit(1)
+		case flagCollectionMode.value == collectionModeWorkqueue:
+			fmt.Println("[Error] --peers-service shares the collection loop, --collection-mode=workqueue collects the nodes on their own schedule")
+			os.Exit(1)
+		case *flagPeerTokenFile == "":
+			fmt.Println("[Error] --peers-service requires --peer-token-file, the token the replicas present to each other")
+			os.Exit(1)
+		}
+		if peers, err = newPeerSet(*flagPeersService, *flagPeerAddress, *flagListenAddress, prefix, newTokenFile(*flagPeerTokenFile)); err != nil {
+			fmt.Printf("[Error] %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	var (
+		cache        *summaryCache
+		ready, alive func() bool
+	)
+	if *flagCollectionInterval > 0 {
+		cache = newSummaryCache(*flagCacheExpiryCycles)
+		cache.maxBytes = flagCacheMaxBytes.Int64()
+		if *flagCacheFile != "" {
+			if err := restoreCacheFile(*flagCacheFile, cache, *flagCacheFileMaxAge, time.Now()); err != nil {
+				fmt.Printf("[Error] Cannot restore the cache: %v\n", err)
+			}
+			snapshots = append(snapshots, newCacheFileSink(*flagCacheFile, cache))
+		}
+		wd := newWatchdog()
+		ready = cache.ready
+		alive = func() bool { return wd.healthy(2 * *flagCollectionInterval) }
+		if flagCollectionMode.value == collectionModeWorkqueue {
+			if source != (kubeNodeSource{}) || *flagCollectionSpread {
+				fmt.Printf("[Error] --collection-mode=workqueue watches the nodes of the API server and collects them on their own schedule, it can't be used with another node source or --collection-spread\n")
+				os.Exit(1)
+			}
+			if *flagStartupRampCycles > 1 {
+				fmt.Printf("[Error] --startup-ramp-cycles ramps up the collection loop, --collection-mode=workqueue collects the nodes on their own schedule\n")
+				os.Exit(1)
+			}
+			if err := runNodeReconciler(context.Background(), kubeClient, cache, *flagCollectionInterval, wd, snapshots...); err != nil {
+				fmt.Printf("[Error] Cannot watch nodes: %v\n", err)
+				os.Exit(1)
+			}
+		} else {
+			go runCollectionLoop(context.Background(), kubeClient, nodesSelector, cache, *flagCollectionInterval, wd, snapshots...)
+		}
+	}
+
+	pushSelector := nodesSelector
+	if cache != nil {
+		pushSelector = cachedAllNodesSelector(cache, nodesSelector)
+	}
+	collectSamples := sampleCollector(kubeClient, pushSelector)
+	if !*flagDev {
+		for _, s := range cfg.pushSinks() {
+			go runPushLoop(context.Background(), s.sink, s.interval, collectSamples)
+		}
+	}
+
+	var handler http.Handler = newRouter(kubeClient, cache, scrapes, nodesSelector, nodeSelector)
+	inFlight := newInFlightLimit(*flagMaxRequestsInFlight)
+	handler = withBackpressure(inFlight, ready, handler)
+	if *flagDev {
+		handler = withDevProgress(os.Stdout, handler)
+	}
+	if *flagWebAuthTokenFile != "" {
+		handler = requireToken(newTokenFile(*flagWebAuthTokenFile), handler)
+	}
+	handler = withRoutePrefix(prefix, handler)
+	handler = withRequestID(handler)
+
+	if *flagGRPCListenAddress != "" {
+		servedNodesSelector, servedNodeSelector := servedSelectors(cache, nodesSelector, nodeSelector)
+		service := &summaryService{kubeClient: kubeClient, nodesSelector: servedNodesSelector, nodeSelector: servedNodeSelector, exists: servedNodeExists(kubeClient, cache)}
+		if cache == nil && *flagCoalesceRequests {
+			service.group = newCollectionGroup(*flagCoalesceTimeout)
+		}
+		var tokens *tokenFile
+		if *flagWebAuthTokenFile != "" {
+			tokens = newTokenFile(*flagWebAuthTokenFile)
+		}
+		if err := serveGRPC(*flagGRPCListenAddress, newGRPCServer(service, tokens, inFlight, *flagGRPCTimeout)); err != nil {
+			fmt.Printf("[Error] %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	listener, err := net.Listen("tcp", *flagListenAddress)
+	if err != nil {
+		fmt.Printf("[Error] Cannot listen on %s: %v\n", *flagListenAddress, err)
+		os.Exit(1)
+	}
+	go runSystemdNotify(context.Background(), ready, alive)
 
 	fmt.Printf("Listening on %s\n", *flagListenAddress)
-	fmt.Printf("error: %v\n", http.ListenAndServe(*flagListenAddress, r))
+	fmt.Printf("error: %v\n", http.Serve(listener, handler))
+	flushTracing(shutdownTracing)
 }
@@ -1,14 +1,24 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
 )
 
 func Test_collectSummaryMetrics(t *testing.T) {
@@ -48,6 +58,27 @@ kube_summary_container_rootfs_inodes_used{name="dev-server",namespace="mon",node
 # HELP kube_summary_container_rootfs_used_bytes Number of bytes that are consumed by the container
 # TYPE kube_summary_container_rootfs_used_bytes gauge
 kube_summary_container_rootfs_used_bytes{name="dev-server",namespace="mon",node="dev-server-node",pod="dev-server-0"} 114688
+# HELP kube_summary_node_container_log_files Approximate number of log files of all the containers of the node, from the Inodes used by their logs
+# TYPE kube_summary_node_container_log_files gauge
+kube_summary_node_container_log_files{kubelet_version="",node="dev-server-node"} 1
+# HELP kube_summary_node_pod_ephemeral_storage_used_bytes Distribution of the Ephemeral storage consumed by the pods of the node
+# TYPE kube_summary_node_pod_ephemeral_storage_used_bytes histogram
+kube_summary_node_pod_ephemeral_storage_used_bytes_bucket{kubelet_version="",node="dev-server-node",le="1.048576e+06"} 0
+kube_summary_node_pod_ephemeral_storage_used_bytes_bucket{kubelet_version="",node="dev-server-node",le="4.194304e+06"} 0
+kube_summary_node_pod_ephemeral_storage_used_bytes_bucket{kubelet_version="",node="dev-server-node",le="1.6777216e+07"} 0
+kube_summary_node_pod_ephemeral_storage_used_bytes_bucket{kubelet_version="",node="dev-server-node",le="6.7108864e+07"} 0
+kube_summary_node_pod_ephemeral_storage_used_bytes_bucket{kubelet_version="",node="dev-server-node",le="2.68435456e+08"} 1
+kube_summary_node_pod_ephemeral_storage_used_bytes_bucket{kubelet_version="",node="dev-server-node",le="1.073741824e+09"} 1
+kube_summary_node_pod_ephemeral_storage_used_bytes_bucket{kubelet_version="",node="dev-server-node",le="4.294967296e+09"} 1
+kube_summary_node_pod_ephemeral_storage_used_bytes_bucket{kubelet_version="",node="dev-server-node",le="1.7179869184e+10"} 1
+kube_summary_node_pod_ephemeral_storage_used_bytes_bucket{kubelet_version="",node="dev-server-node",le="6.8719476736e+10"} 1
+kube_summary_node_pod_ephemeral_storage_used_bytes_bucket{kubelet_version="",node="dev-server-node",le="2.74877906944e+11"} 1
+kube_summary_node_pod_ephemeral_storage_used_bytes_bucket{kubelet_version="",node="dev-server-node",le="+Inf"} 1
+kube_summary_node_pod_ephemeral_storage_used_bytes_sum{kubelet_version="",node="dev-server-node"} 1.33947392e+08
+kube_summary_node_pod_ephemeral_storage_used_bytes_count{kubelet_version="",node="dev-server-node"} 1
+# HELP kube_summary_node_scrape_success Whether the /stats/summary of the node was collected successfully
+# TYPE kube_summary_node_scrape_success gauge
+kube_summary_node_scrape_success{kubelet_version="",node="dev-server-node"} 1
 # HELP kube_summary_pod_ephemeral_storage_available_bytes Number of bytes of Ephemeral storage that aren't consumed by the pod
 # TYPE kube_summary_pod_ephemeral_storage_available_bytes gauge
 kube_summary_pod_ephemeral_storage_available_bytes{namespace="mon",node="dev-server-node",pod="dev-server-0"} 9.0016837632e+10
@@ -66,6 +97,12 @@ kube_summary_pod_ephemeral_storage_inodes_used{namespace="mon",node="dev-server-
 # HELP kube_summary_pod_ephemeral_storage_used_bytes Number of bytes of Ephemeral storage that are consumed by the pod
 # TYPE kube_summary_pod_ephemeral_storage_used_bytes gauge
 kube_summary_pod_ephemeral_storage_used_bytes{namespace="mon",node="dev-server-node",pod="dev-server-0"} 1.33947392e+08
+# HELP kube_summary_pod_largest_volume_used_bytes Number of bytes that are consumed by the largest volume of the pod
+# TYPE kube_summary_pod_largest_volume_used_bytes gauge
+kube_summary_pod_largest_volume_used_bytes{namespace="mon",node="dev-server-node",pod="dev-server-0"} 1.33500928e+08
+# HELP kube_summary_pod_volumes Number of volumes of the pod reported by the kubelet, projected, secret and configmap volumes included
+# TYPE kube_summary_pod_volumes gauge
+kube_summary_pod_volumes{namespace="mon",node="dev-server-node",pod="dev-server-0"} 2
 `
 
 	d, err := os.ReadFile("test-summary.json")
@@ -73,10 +110,10 @@ kube_summary_pod_ephemeral_storage_used_bytes{namespace="mon",node="dev-server-n
 		t.Fatal(err)
 	}
 
-	var summary stats.Summary
+	var nodeSummary stats.Summary
 	registry := prometheus.NewRegistry()
 
-	err = json.Unmarshal(d, &summary)
+	err = json.Unmarshal(d, &nodeSummary)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -84,11 +121,11 @@ kube_summary_pod_ephemeral_storage_used_bytes{namespace="mon",node="dev-server-n
 	results := []PerNodeResult{
 		{
 			NodeName: "dev-server-node",
-			Summary:  &summary,
+			Summary:  &nodeSummary,
 		},
 	}
 
-	collectSummaryMetrics(results, registry)
+	summary.Collect(results, registry, collectorOptions{})
 
 	tmpfile, err := os.CreateTemp("", "test-summary.prom")
 	if err != nil {
@@ -106,6 +143,327 @@ kube_summary_pod_ephemeral_storage_used_bytes{namespace="mon",node="dev-server-n
 	}
 
 	if diff := cmp.Diff(string(fileBytes), expectedOut); diff != "" {
-		t.Errorf("collectSummaryMetrics() metrics mismatch (-want +got):\n%s", diff)
+		t.Errorf("summary.Collect() metrics mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func Test_byteSizeFlag(t *testing.T) {
+	for value, want := range map[string]int64{
+		"0":     0,
+		"1024":  1024,
+		"50MB":  50 * 1000 * 1000,
+		"64MiB": 64 << 20,
+		"2 GiB": 2 << 30,
+		"10B":   10,
+		"10Mi":  10 << 20,
+	} {
+		var f byteSizeFlag
+		if err := f.Set(value); err != nil {
+			t.Errorf("byteSizeFlag.Set(%q) unexpected error: %v", value, err)
+			continue
+		}
+		if f.Int64() != want {
+			t.Errorf("byteSizeFlag.Set(%q) = %d, want %d", value, f.Int64(), want)
+		}
+	}
+
+	var f byteSizeFlag
+	for _, value := range []string{"", "MB", "-1", "1.5MB", "10TB", "9223372036854775807KB", "8589934592GiB"} {
+		if err := f.Set(value); err == nil {
+			t.Errorf("byteSizeFlag.Set(%q) accepted an invalid size", value)
+		}
+	}
+}
+
+func Test_allFailed(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	if err := allFailed([]PerNodeResult{{NodeName: "a", Err: errFailed}, {NodeName: "b", Summary: &stats.Summary{}}}); err != nil {
+		t.Errorf("allFailed() with a partial failure returned %v, want nil", err)
+	}
+	if err := allFailed([]PerNodeResult{{NodeName: "a", Err: errFailed}}); err != errFailed {
+		t.Errorf("allFailed() with no successful node returned %v, want %v", err, errFailed)
+	}
+	if err := allFailed(nil); err != nil {
+		t.Errorf("allFailed() without nodes returned %v, want nil", err)
+	}
+}
+
+func Test_headerRoundTripper(t *testing.T) {
+	headers := headerFlag{}
+	for _, h := range []string{"X-Tenant: team-a", "X-Tenant: team-b", "X-Trace:abc"} {
+		if err := headers.Set(h); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := headers.Set("invalid"); err == nil {
+		t.Errorf("headerFlag.Set() accepted a header without a value")
+	}
+
+	var got http.Header
+	rt := &headerRoundTripper{
+		headers: http.Header(headers),
+		rt: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			got = req.Header
+			return &http.Response{StatusCode: http.StatusOK}, nil
+		}),
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/nodes", nil)
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+
+	want := http.Header{"X-Tenant": {"team-a", "team-b"}, "X-Trace": {"abc"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("headerRoundTripper headers mismatch (-want +got):\n%s", diff)
+	}
+	if len(req.Header) != 0 {
+		t.Errorf("headerRoundTripper modified the original request")
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func Test_collectSummaryMetrics_partialProvider(t *testing.T) {
+	d, err := os.ReadFile("test-summary.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var nodeSummary stats.Summary
+	if err := json.Unmarshal(d, &nodeSummary); err != nil {
+		t.Fatal(err)
+	}
+	// Runtime without an image filesystem must not panic
+	nodeSummary.Node.Runtime = &stats.RuntimeStats{}
+
+	registry := prometheus.NewRegistry()
+	summary.Collect([]PerNodeResult{
+		{
+			NodeName: "virtual-node",
+			Summary:  &nodeSummary,
+			Provider: summary.DetectProvider(corev1.Node{ObjectMeta: meta_v1.ObjectMeta{Labels: map[string]string{"type": "virtual-kubelet"}}}),
+		},
+	}, registry, collectorOptions{})
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, mf := range families {
+		names = append(names, mf.GetName())
+	}
+
+	want := []string{
+		"kube_summary_node_partial_summary",
+		"kube_summary_node_pod_ephemeral_storage_used_bytes",
+		"kube_summary_node_scrape_success",
+		"kube_summary_pod_ephemeral_storage_available_bytes",
+		"kube_summary_pod_ephemeral_storage_capacity_bytes",
+		"kube_summary_pod_ephemeral_storage_inodes",
+		"kube_summary_pod_ephemeral_storage_inodes_free",
+		"kube_summary_pod_ephemeral_storage_inodes_used",
+		"kube_summary_pod_ephemeral_storage_used_bytes",
+		"kube_summary_pod_largest_volume_used_bytes",
+		"kube_summary_pod_volumes",
+	}
+	if diff := cmp.Diff(want, names); diff != "" {
+		t.Errorf("summary.Collect() metric families mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func Test_excludeNodes(t *testing.T) {
+	var patterns nodePatternsFlag
+	for _, p := range []string{"edge-device-1", "arm64-test-.*"} {
+		if err := patterns.Set(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := patterns.Set("("); err == nil {
+		t.Errorf("nodePatternsFlag.Set() accepted an invalid regular expression")
+	}
+
+	var nodes []corev1.Node
+	for _, name := range []string{"worker-1", "edge-device-1", "edge-device-10", "arm64-test-a", "worker-arm64-test-b"} {
+		nodes = append(nodes, corev1.Node{ObjectMeta: meta_v1.ObjectMeta{Name: name}})
+	}
+
+	var got []string
+	for _, node := range excludeNodes(nodes, patterns) {
+		got = append(got, node.Name)
+	}
+	if diff := cmp.Diff([]string{"worker-1", "edge-device-10", "worker-arm64-test-b"}, got); diff != "" {
+		t.Errorf("excludeNodes() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func Test_nodeBudget(t *testing.T) {
+	now := time.Now()
+	deadline := now.Add(30 * time.Second)
+
+	for _, tc := range []struct {
+		remainingNodes, concurrency int
+		want                        time.Duration
+	}{
+		{3, 1, 10 * time.Second},
+		{1, 1, 30 * time.Second},
+		{10, 5, 15 * time.Second},
+		{11, 5, 10 * time.Second},
+		{2, 5, 30 * time.Second},
+	} {
+		if got := nodeBudget(deadline, now, tc.remainingNodes, tc.concurrency); got != tc.want {
+			t.Errorf("nodeBudget(%d nodes, concurrency %d) = %s, want %s", tc.remainingNodes, tc.concurrency, got, tc.want)
+		}
+	}
+}
+
+func Test_sectionsFlag(t *testing.T) {
+	f := sectionsFlag{}
+	if err := f.Set("container_logs, node_runtime_imagefs"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := f.String(), "container_logs,node_runtime_imagefs"; got != want {
+		t.Errorf("sectionsFlag.String() = %q, want %q", got, want)
+	}
+	if err := f.Set("container_logs,unknown"); err == nil {
+		t.Errorf("sectionsFlag.Set() accepted an unknown metric group")
+	}
+}
+
+func Test_collectSummaryMetrics_omitZeroValues(t *testing.T) {
+	zero := uint64(0)
+	ten := uint64(10)
+	nodeSummary := &stats.Summary{
+		Pods: []stats.PodStats{{
+			PodRef: stats.PodReference{Name: "pod", Namespace: "ns"},
+			Containers: []stats.ContainerStats{{
+				Name:   "container",
+				Logs:   &stats.FsStats{Inodes: &zero, UsedBytes: &ten},
+				Rootfs: &stats.FsStats{Inodes: &zero},
+			}},
+		}},
+	}
+
+	registry := prometheus.NewRegistry()
+	summary.Collect([]PerNodeResult{{NodeName: "node", Summary: nodeSummary}}, registry, collectorOptions{
+		OmitZeroValues: map[string]bool{summary.SectionContainerLogs: true},
+	})
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, mf := range families {
+		names = append(names, mf.GetName())
+	}
+	want := []string{
+		"kube_summary_container_logs_used_bytes",
+		"kube_summary_container_rootfs_inodes",
+		"kube_summary_node_scrape_success",
+	}
+	if diff := cmp.Diff(want, names); diff != "" {
+		t.Errorf("summary.Collect() metric families mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func Test_byteBucketsFlag(t *testing.T) {
+	var f byteBucketsFlag
+	if err := f.Set("100MiB,1GiB,10GB"); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(byteBucketsFlag{100 << 20, 1 << 30, 10e9}, f); diff != "" {
+		t.Errorf("byteBucketsFlag.Set() mismatch (-want +got):\n%s", diff)
+	}
+	for _, value := range []string{"1GiB,100MiB", "1GiB,abc", ""} {
+		if err := f.Set(value); err == nil {
+			t.Errorf("byteBucketsFlag.Set(%q) accepted invalid buckets", value)
+		}
+	}
+}
+
+func Test_podInfo(t *testing.T) {
+	big, small := uint64(100), uint64(1)
+	pod := func(name, uid string, used *uint64) stats.PodStats {
+		return stats.PodStats{
+			PodRef:           stats.PodReference{Name: name, Namespace: "ns", UID: uid},
+			EphemeralStorage: &stats.FsStats{UsedBytes: used},
+		}
+	}
+	nodeSummary := &stats.Summary{Pods: []stats.PodStats{pod("a", "uid-a", &big), pod("b", "uid-b", &small)}}
+
+	samples, err := resultSamples([]PerNodeResult{{NodeName: "node", Summary: nodeSummary}}, collectorOptions{PodInfo: true, MaxPodsPerNode: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []sample
+	for _, s := range samples {
+		if s.Name == "kube_summary_pod_info" {
+			got = append(got, s)
+		}
+	}
+	want := []sample{{
+		Name:   "kube_summary_pod_info",
+		Labels: []labelPair{{"namespace", "ns"}, {"node", "node"}, {"pod", "a"}, {"uid", "uid-a"}},
+		Value:  1,
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("kube_summary_pod_info mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func Test_containerLogsUsedRatio(t *testing.T) {
+	used := uint64(15 << 20)
+	nodeSummary := &stats.Summary{Pods: []stats.PodStats{{
+		PodRef:     stats.PodReference{Name: "a", Namespace: "ns"},
+		Containers: []stats.ContainerStats{{Name: "app", Logs: &stats.FsStats{UsedBytes: &used}}},
+	}}}
+	results := []PerNodeResult{{NodeName: "node", Summary: nodeSummary}}
+
+	ratio := func(opts collectorOptions) []sample {
+		samples, err := resultSamples(results, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []sample
+		for _, s := range samples {
+			if s.Name == "kube_summary_container_logs_used_ratio" {
+				got = append(got, s)
+			}
+		}
+		return got
+	}
+
+	if got := ratio(collectorOptions{}); len(got) != 0 {
+		t.Errorf("kube_summary_container_logs_used_ratio exported without a max size: %v", got)
+	}
+	want := []sample{{
+		Name:   "kube_summary_container_logs_used_ratio",
+		Labels: []labelPair{{"name", "app"}, {"namespace", "ns"}, {"node", "node"}, {"pod", "a"}},
+		Value:  1.5,
+	}}
+	if diff := cmp.Diff(want, ratio(collectorOptions{ContainerLogMaxSize: 10 << 20})); diff != "" {
+		t.Errorf("kube_summary_container_logs_used_ratio mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func Test_kubeletPort(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Summary: fakekubelet.Fixture("node"), KubeletPort: 10255})
+
+	if _, _, _, err := getNodeSummary(context.Background(), kubeClient, "node-a"); err == nil {
+		t.Errorf("getNodeSummary() through the default port didn't fail")
+	}
+
+	*flagKubeletPort = 10255
+	defer func() { *flagKubeletPort = 0 }()
+	if _, _, _, err := getNodeSummary(context.Background(), kubeClient, "node-a"); err != nil {
+		t.Errorf("getNodeSummary() through the kubelet port failed: %v", err)
 	}
 }
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+type cacheRequestKey struct{}
+
+// cacheRequest is how a request wants to be served from the cache
+type cacheRequest struct {
+	// maxAge is the max_age parameter, the maximum age of the cached results,
+	// if maxAgeSet. max_age=0 always collects the results again.
+	maxAge    time.Duration
+	maxAgeSet bool
+	// header holds the headers of the response, receiving the Age and
+	// Cache-Control of the cached results
+	header http.Header
+}
+
+// withMaxAgeParam parses the max_age query parameter, in seconds, of the
+// requests served from the cache
+func withMaxAgeParam(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := &cacheRequest{header: w.Header()}
+		if v := r.URL.Query().Get("max_age"); v != "" {
+			seconds, err := strconv.ParseFloat(v, 64)
+			if err != nil || seconds < 0 || math.IsInf(seconds, 0) {
+				writeError(w, r, http.StatusBadRequest, apiError{Error: "invalid max_age " + strconv.Quote(v) + ", expected a number of seconds", Reason: reasonBadRequest})
+				return
+			}
+			req.maxAge, req.maxAgeSet = time.Duration(seconds*float64(time.Second)), true
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), cacheRequestKey{}, req)))
+	})
+}
+
+// resultsAge returns the age of the oldest collected result
+func resultsAge(results []PerNodeResult, now time.Time) time.Duration {
+	var age time.Duration
+	for _, result := range results {
+		if !result.CollectedAt.IsZero() {
+			age = max(age, now.Sub(result.CollectedAt))
+		}
+	}
+	return age
+}
+
+// checkMaxAge returns the cached results, or collects them with the live
+// selector if they are older than the max_age of the request. The Age and
+// Cache-Control headers of the response tell how old the cached results are
+// and that they are refreshed every collection interval.
+func checkMaxAge(ctx context.Context, kubeClient *kubernetes.Clientset, cache *summaryCache, results []PerNodeResult, live nodeSelectorFunc) ([]PerNodeResult, error) {
+	req, _ := ctx.Value(cacheRequestKey{}).(*cacheRequest)
+	if req == nil {
+		return results, nil
+	}
+
+	age := resultsAge(results, time.Now())
+	if req.maxAgeSet && (req.maxAge == 0 || age > req.maxAge) {
+		req.header.Set("Cache-Control", "no-cache")
+		return live(ctx, kubeClient)
+	}
+
+	cache.mu.RLock()
+	interval := cache.interval
+	cache.mu.RUnlock()
+	req.header.Set("Age", strconv.Itoa(int(age.Seconds())))
+	if interval > 0 {
+		req.header.Set("Cache-Control", "max-age="+strconv.Itoa(int(interval.Seconds())))
+	}
+	return results, nil
+}
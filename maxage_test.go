@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+)
+
+func TestRouter_maxAge(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Summary: fakekubelet.Fixture("node")})
+
+	cache := newSummaryCache(1)
+	cache.interval = 5 * time.Minute
+	cache.update([]PerNodeResult{{
+		NodeName:    "node-a",
+		Summary:     &stats.Summary{Node: stats.NodeStats{NodeName: "node-a"}},
+		CollectedAt: time.Now().Add(-2 * time.Minute),
+	}})
+	r := newRouter(kubeClient, cache, nil, allNodesSelector, singleNodeSelector)
+
+	serve := func(target string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	rec := serve("/node/node-a")
+	if rec.Code != http.StatusOK || rec.Header().Get("Age") != "120" || rec.Header().Get("Cache-Control") != "max-age=300" {
+		t.Errorf("GET /node/node-a returned %d with Age %q and Cache-Control %q", rec.Code, rec.Header().Get("Age"), rec.Header().Get("Cache-Control"))
+	}
+	if n := srv.SummaryRequests("node-a"); n != 0 {
+		t.Errorf("node-a received %d summary requests for a cached response", n)
+	}
+
+	for _, target := range []string{"/node/node-a?max_age=300", "/nodes?max_age=300"} {
+		if rec := serve(target); rec.Header().Get("Age") != "120" {
+			t.Errorf("GET %s of a younger cache wasn't served from it", target)
+		}
+	}
+
+	// max_age=0 asks for fresh results, however young the cache
+	for _, target := range []string{"/node/node-a?max_age=60", "/nodes?max_age=60", "/node/node-a?max_age=0", "/nodes?max_age=0"} {
+		before := srv.SummaryRequests("node-a")
+		rec := serve(target)
+		if rec.Code != http.StatusOK || rec.Header().Get("Cache-Control") != "no-cache" || rec.Header().Get("Age") != "" {
+			t.Errorf("GET %s returned %d with Age %q and Cache-Control %q", target, rec.Code, rec.Header().Get("Age"), rec.Header().Get("Cache-Control"))
+		}
+		if n := srv.SummaryRequests("node-a") - before; n != 1 {
+			t.Errorf("GET %s of an older cache sent %d summary requests, want 1", target, n)
+		}
+	}
+
+	if rec := serve("/nodes?max_age=soon"); rec.Code != http.StatusBadRequest {
+		t.Errorf("GET with an invalid max_age returned %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
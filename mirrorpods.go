@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	toolscache "k8s.io/client-go/tools/cache"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+// mirrorPods are the mirror pods seen by the pod informer, started unless
+// --mirror-pods is include
+var mirrorPods = newPodSet(isMirrorPod)
+
+// podSet holds the pods seen by the pod informer that match a predicate, by
+// namespace/name. The kubelet reports a static pod with its own UID, not the
+// one of its mirror pod, so the UID can't be used to look them up.
+type podSet struct {
+	match func(*corev1.Pod) bool
+
+	mu   sync.RWMutex
+	pods map[string]bool
+}
+
+func newPodSet(match func(*corev1.Pod) bool) *podSet {
+	return &podSet{match: match, pods: map[string]bool{}}
+}
+
+func (s *podSet) contains(namespace, name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.pods[namespace+"/"+name]
+}
+
+func (s *podSet) set(pod *corev1.Pod, in bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if in {
+		s.pods[pod.Namespace+"/"+pod.Name] = true
+	} else {
+		delete(s.pods, pod.Namespace+"/"+pod.Name)
+	}
+}
+
+// list returns the namespace/name of the pods of the set, sorted
+func (s *podSet) list() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return sortedKeys(s.pods)
+}
+
+// isMirrorPod tells whether the pod is the mirror pod of a static pod
+func isMirrorPod(pod *corev1.Pod) bool {
+	_, ok := pod.Annotations[corev1.MirrorPodAnnotationKey]
+	return ok
+}
+
+// eventHandler updates the set on the informer events
+func (s *podSet) eventHandler() toolscache.ResourceEventHandler {
+	return toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if pod, ok := obj.(*corev1.Pod); ok {
+				s.set(pod, s.match(pod))
+			}
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			if pod, ok := obj.(*corev1.Pod); ok {
+				s.set(pod, s.match(pod))
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if pod, ok := obj.(*corev1.Pod); ok {
+				s.set(pod, false)
+			}
+		},
+	}
+}
+
+// stripPod returns a transform keeping only the metadata needed to match the
+// pods, the annotations of the keys included, so that the informer cache of a
+// large cluster stays small
+func stripPod(annotations ...string) toolscache.TransformFunc {
+	return func(obj interface{}) (interface{}, error) {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok {
+			return obj, nil
+		}
+		stripped := &corev1.Pod{ObjectMeta: meta_v1.ObjectMeta{
+			Name:            pod.Name,
+			Namespace:       pod.Namespace,
+			UID:             pod.UID,
+			ResourceVersion: pod.ResourceVersion,
+		}}
+		for _, key := range annotations {
+			if value, ok := pod.Annotations[key]; ok {
+				if stripped.Annotations == nil {
+					stripped.Annotations = map[string]string{}
+				}
+				stripped.Annotations[key] = value
+			}
+		}
+		return stripped, nil
+	}
+}
+
+// runPodInformer watches the pods of every namespace, keeping the annotations
+// of the keys, and keeps the sets up to date until the context is done. It
+// returns once the informer is synced, failing if it isn't within
+// informerSyncTimeout, e.g. without the RBAC to list the pods.
+func runPodInformer(ctx context.Context, kubeClient kubernetes.Interface, annotations []string, sets ...*podSet) error {
+	factory := informers.NewSharedInformerFactory(kubeClient, 0)
+	informer := factory.Core().V1().Pods().Informer()
+	if err := informer.SetTransform(stripPod(annotations...)); err != nil {
+		return err
+	}
+	for _, pods := range sets {
+		if _, err := informer.AddEventHandler(pods.eventHandler()); err != nil {
+			return err
+		}
+	}
+
+	factory.Start(ctx.Done())
+	syncCtx, cancel := context.WithTimeout(ctx, informerSyncTimeout)
+	defer cancel()
+	for resource, synced := range factory.WaitForCacheSync(syncCtx.Done()) {
+		if !synced {
+			return fmt.Errorf("%s not synced within %s, can the exporter list and watch them?", resource, informerSyncTimeout)
+		}
+	}
+	return nil
+}
+
+// runFlagPodInformer runs the pod informer of the sets needed by the flags,
+// the mirror pods unless --mirror-pods is include and the pods opted out with
+// --pod-scrape-annotation, if any
+func runFlagPodInformer(ctx context.Context, kubeClient kubernetes.Interface) error {
+	var (
+		sets        []*podSet
+		annotations []string
+	)
+	if flagMirrorPods.value != summary.MirrorPodsInclude {
+		sets = append(sets, mirrorPods)
+		annotations = append(annotations, corev1.MirrorPodAnnotationKey)
+	}
+	if *flagPodScrapeAnnotation != "" {
+		optedOutPods = newPodSet(scrapeOptedOut(*flagPodScrapeAnnotation))
+		sets = append(sets, optedOutPods)
+		annotations = append(annotations, *flagPodScrapeAnnotation)
+	}
+	if len(sets) == 0 {
+		return nil
+	}
+	return runPodInformer(ctx, kubeClient, annotations, sets...)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+func Test_mirrorPodSet(t *testing.T) {
+	mirror := &corev1.Pod{ObjectMeta: meta_v1.ObjectMeta{
+		Name:        "kube-proxy-node-a",
+		Namespace:   "kube-system",
+		Annotations: map[string]string{corev1.MirrorPodAnnotationKey: "hash", "other": "annotation"},
+	}}
+	regular := &corev1.Pod{ObjectMeta: meta_v1.ObjectMeta{Name: "coredns", Namespace: "kube-system"}}
+
+	stripped, err := stripPod(corev1.MirrorPodAnnotationKey)(mirror)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := stripped.(*corev1.Pod).Annotations; len(got) != 1 || got[corev1.MirrorPodAnnotationKey] != "hash" {
+		t.Errorf("stripPod() kept the annotations %v", got)
+	}
+
+	pods := newPodSet(isMirrorPod)
+	h := pods.eventHandler()
+	h.OnAdd(stripped, false)
+	h.OnAdd(regular, false)
+	if !pods.contains("kube-system", "kube-proxy-node-a") || pods.contains("kube-system", "coredns") {
+		t.Errorf("mirrorPodSet = %v, want only kube-system/kube-proxy-node-a", pods.pods)
+	}
+
+	h.OnDelete(toolscache.DeletedFinalStateUnknown{Key: "kube-system/kube-proxy-node-a", Obj: mirror})
+	if pods.contains("kube-system", "kube-proxy-node-a") {
+		t.Errorf("mirrorPodSet kept a deleted pod")
+	}
+}
+
+func Test_mirrorPods(t *testing.T) {
+	pod := func(name string, used uint64) stats.PodStats {
+		return stats.PodStats{
+			PodRef:           stats.PodReference{Name: name, Namespace: "kube-system"},
+			EphemeralStorage: &stats.FsStats{UsedBytes: &used},
+		}
+	}
+	results := []PerNodeResult{{NodeName: "node-a", Summary: &stats.Summary{Pods: []stats.PodStats{pod("kube-proxy-node-a", 2), pod("coredns", 1)}}}}
+	isMirrorPod := func(namespace, name string) bool { return name == "kube-proxy-node-a" }
+
+	series := func(mode string, maxPods int) map[string][]string {
+		t.Helper()
+		samples, err := resultSamples(results, collectorOptions{MirrorPods: mode, IsMirrorPod: isMirrorPod, MaxPodsPerNode: maxPods})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pods := map[string][]string{}
+		for _, s := range samples {
+			for _, l := range s.Labels {
+				if l.Name == "pod" {
+					pods[s.Name] = append(pods[s.Name], l.Value)
+				}
+			}
+		}
+		return pods
+	}
+
+	if got := series(summary.MirrorPodsInclude, 0)["kube_summary_pod_ephemeral_storage_used_bytes"]; len(got) != 2 {
+		t.Errorf("include exported the pods %v, want both", got)
+	}
+	if got := series(summary.MirrorPodsDrop, 0)["kube_summary_pod_ephemeral_storage_used_bytes"]; len(got) != 1 || got[0] != "coredns" {
+		t.Errorf("drop exported the pods %v, want coredns", got)
+	}
+	// The dropped mirror pod doesn't take the slot of coredns
+	if got := series(summary.MirrorPodsDrop, 1)["kube_summary_pod_ephemeral_storage_used_bytes"]; len(got) != 1 || got[0] != "coredns" {
+		t.Errorf("drop with --max-pods-per-node=1 exported the pods %v, want coredns", got)
+	}
+	labeled := series(summary.MirrorPodsLabel, 0)
+	if got := labeled["kube_summary_pod_ephemeral_storage_used_bytes"]; len(got) != 2 {
+		t.Errorf("label exported the pods %v, want both", got)
+	}
+	if got := labeled["kube_summary_pod_mirror"]; len(got) != 1 || got[0] != "kube-proxy-node-a" {
+		t.Errorf("kube_summary_pod_mirror exported the pods %v, want kube-proxy-node-a", got)
+	}
+}
+
+// newForbiddenKubeClient returns a kube client of an API server answering 403
+// to every request, whose informers never sync
+func newForbiddenKubeClient(t *testing.T) *kubernetes.Clientset {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"Forbidden","code":403}`, http.StatusForbidden)
+	}))
+	t.Cleanup(srv.Close)
+	kubeClient, err := kubernetes.NewForConfig(&rest.Config{Host: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kubeClient
+}
+
+func Test_runPodInformer_syncTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { informerSyncTimeout = timeout }(informerSyncTimeout)
+	informerSyncTimeout = 100 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := runPodInformer(ctx, newForbiddenKubeClient(t), nil, newPodSet(isMirrorPod)); err == nil || !strings.Contains(err.Error(), "not synced") {
+		t.Errorf("runPodInformer() without access to the pods = %v, want a sync error", err)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+var missingStats = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metricsNamespace,
+	Name:      "missing_stats_total",
+	Help:      "Number of containers, pods or nodes whose collected summary lacked the section, counted on every collection",
+},
+	[]string{
+		"node",
+		"section",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(missingStats)
+}
+
+// countMissingStats counts the blocks missing from a summary for the sections
+// the provider of the node supports. A runtime regression that stops
+// reporting a section otherwise only shows as series silently disappearing.
+func countMissingStats(nodeName, provider string, s *stats.Summary) {
+	for section, n := range missingSections(s, summary.UnsupportedSections(provider)) {
+		missingStats.WithLabelValues(nodeName, section).Add(float64(n))
+	}
+}
+
+// missingSections returns the number of containers, pods or nodes missing each
+// section of the summary, skipping the unsupported sections
+func missingSections(s *stats.Summary, unsupported map[string]bool) map[string]int {
+	missing := map[string]int{}
+	for _, section := range summary.Sections {
+		if !unsupported[section] {
+			missing[section] = 0
+		}
+	}
+
+	for _, pod := range s.Pods {
+		for _, container := range pod.Containers {
+			if container.Logs == nil {
+				missing[summary.SectionContainerLogs]++
+			}
+			if container.Rootfs == nil {
+				missing[summary.SectionContainerRootfs]++
+			}
+		}
+		if pod.EphemeralStorage == nil {
+			missing[summary.SectionPodEphemeralStorage]++
+		}
+	}
+	if s.Node.Runtime == nil || s.Node.Runtime.ImageFs == nil {
+		missing[summary.SectionNodeRuntimeImageFS]++
+	}
+
+	for section := range unsupported {
+		delete(missing, section)
+	}
+	return missing
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+func Test_missingSections(t *testing.T) {
+	nodeSummary := &stats.Summary{
+		Pods: []stats.PodStats{
+			{
+				Containers: []stats.ContainerStats{
+					{Logs: &stats.FsStats{}, Rootfs: &stats.FsStats{}},
+					{Logs: &stats.FsStats{}},
+				},
+				EphemeralStorage: &stats.FsStats{},
+			},
+			{
+				Containers: []stats.ContainerStats{{}},
+			},
+		},
+	}
+
+	want := map[string]int{
+		summary.SectionContainerLogs:       1,
+		summary.SectionContainerRootfs:     2,
+		summary.SectionPodEphemeralStorage: 1,
+		summary.SectionNodeRuntimeImageFS:  1,
+	}
+	if diff := cmp.Diff(want, missingSections(nodeSummary, nil)); diff != "" {
+		t.Errorf("missingSections() mismatch (-want +got):\n%s", diff)
+	}
+
+	want = map[string]int{
+		summary.SectionPodEphemeralStorage: 1,
+	}
+	if diff := cmp.Diff(want, missingSections(nodeSummary, summary.UnsupportedSections("virtual-kubelet"))); diff != "" {
+		t.Errorf("missingSections() of a virtual kubelet mismatch (-want +got):\n%s", diff)
+	}
+}
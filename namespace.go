@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+var (
+	errUnauthenticated = errors.New("unauthenticated")
+	errUnauthorized    = errors.New("unauthorized")
+)
+
+// namespaceNodesSelector selects the nodes running pods of the namespace
+func namespaceNodesSelector(namespace string) nodeSelectorFunc {
+	return func(ctx context.Context, kubeClient *kubernetes.Clientset) ([]PerNodeResult, error) {
+		pods, err := kubeClient.CoreV1().Pods(namespace).List(ctx, meta_v1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("error listing pods in namespace %s: %v", namespace, err)
+		}
+
+		var nodes []corev1.Node
+		seen := map[string]bool{}
+		for _, pod := range pods.Items {
+			if nodeName := pod.Spec.NodeName; nodeName != "" && !seen[nodeName] {
+				seen[nodeName] = true
+				nodes = append(nodes, corev1.Node{ObjectMeta: meta_v1.ObjectMeta{Name: nodeName}})
+			}
+		}
+
+		return collectNodeStats(ctx, kubeClient, nodes), nil
+	}
+}
+
+// namespaceFilter wraps a node selector so that only the pods of the namespace
+// are returned. Node level stats are dropped, as they aren't scoped to the
+// namespace.
+func namespaceFilter(namespace string, nodeSelector nodeSelectorFunc) nodeSelectorFunc {
+	return func(ctx context.Context, kubeClient *kubernetes.Clientset) ([]PerNodeResult, error) {
+		results, err := nodeSelector(ctx, kubeClient)
+		if err != nil {
+			return nil, err
+		}
+		return filterNamespaces(results, map[string]bool{namespace: true}), nil
+	}
+}
+
+// filterNamespaces returns the results holding pods of the namespaces, with
+// only these pods and without the node level stats, nor the platform,
+// conditions and scheduling state of the node, which aren't the tenant's to
+// see. The node labels are kept for the node selectors.
+func filterNamespaces(results []PerNodeResult, namespaces map[string]bool) []PerNodeResult {
+	filtered := make([]PerNodeResult, 0, len(results))
+	for _, result := range results {
+		if result.Summary == nil {
+			continue
+		}
+
+		summary := &stats.Summary{Node: stats.NodeStats{NodeName: result.Summary.Node.NodeName}}
+		for _, pod := range result.Summary.Pods {
+			if namespaces[pod.PodRef.Namespace] {
+				summary.Pods = append(summary.Pods, pod)
+			}
+		}
+		if len(summary.Pods) == 0 {
+			continue
+		}
+
+		filtered = append(filtered, PerNodeResult{
+			NodeName:       result.NodeName,
+			Summary:        summary,
+			Provider:       result.Provider,
+			KubeletVersion: result.KubeletVersion,
+			NodeLabels:     result.NodeLabels,
+			Err:            result.Err,
+		})
+	}
+	return filtered
+}
+
+// authorizeNamespace checks that the bearer token of the request belongs to a
+// user that may list pods in the namespace, using a TokenReview followed by a
+// SubjectAccessReview
+func authorizeNamespace(ctx context.Context, kubeClient *kubernetes.Clientset, r *http.Request, namespace string) error {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return errUnauthenticated
+	}
+
+	review, err := kubeClient.AuthenticationV1().TokenReviews().Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}, meta_v1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("error reviewing token: %v", err)
+	}
+	if !review.Status.Authenticated {
+		return errUnauthenticated
+	}
+
+	user := review.Status.User
+	extra := make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+	for k, v := range user.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+
+	access, err := kubeClient.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			Extra:  extra,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      "list",
+				Resource:  "pods",
+			},
+		},
+	}, meta_v1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("error reviewing access: %v", err)
+	}
+	if !access.Status.Allowed {
+		return errUnauthorized
+	}
+
+	return nil
+}
+
+// handleNamespaceMetricsCollection serves the metrics of the pods in a single
+// namespace to callers allowed to list pods in it
+func handleNamespaceMetricsCollection(w http.ResponseWriter, r *http.Request, kubeClient *kubernetes.Clientset, namespace string, nodeSelector nodeSelectorFunc) {
+	switch err := authorizeNamespace(r.Context(), kubeClient, r, namespace); {
+	case errors.Is(err, errUnauthenticated):
+		writeError(w, r, http.StatusUnauthorized, apiError{Error: "unauthorized", Reason: reasonUnauthorized})
+		return
+	case errors.Is(err, errUnauthorized):
+		writeError(w, r, http.StatusForbidden, apiError{Error: fmt.Sprintf("cannot list pods in namespace %s", namespace), Reason: reasonForbidden})
+		return
+	case err != nil:
+		logError(r.Context(), "Authorizing %s failed: %v", r.URL.Path, err)
+		writeError(w, r, http.StatusInternalServerError, apiError{Error: fmt.Sprintf("error authorizing request: %v", err), Reason: reasonInternal})
+		return
+	}
+
+	handleMetricsCollection(w, r, kubeClient, namespaceFilter(namespace, nodeSelector))
+}
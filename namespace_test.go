@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+func Test_namespaceFilter(t *testing.T) {
+	pod := func(namespace, name string) stats.PodStats {
+		return stats.PodStats{PodRef: stats.PodReference{Namespace: namespace, Name: name}}
+	}
+	selector := func(context.Context, *kubernetes.Clientset) ([]PerNodeResult, error) {
+		return []PerNodeResult{
+			{
+				NodeName:      "a",
+				Summary:       &stats.Summary{Pods: []stats.PodStats{pod("team-a", "p1"), pod("team-b", "p2")}},
+				Metadata:      summary.NodeMetadata{InstanceType: "m5.large"},
+				Conditions:    []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+				Unschedulable: true,
+				Taints:        []corev1.Taint{{Key: "dedicated", Effect: corev1.TaintEffectNoSchedule}},
+			},
+			{NodeName: "b", Summary: &stats.Summary{Pods: []stats.PodStats{pod("team-b", "p3")}}},
+			{NodeName: "c"},
+		}, nil
+	}
+
+	results, err := namespaceFilter("team-a", selector)(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string][]string{}
+	for _, result := range results {
+		for _, pod := range result.Summary.Pods {
+			got[result.NodeName] = append(got[result.NodeName], pod.PodRef.Name)
+		}
+	}
+	if diff := cmp.Diff(map[string][]string{"a": {"p1"}}, got); diff != "" {
+		t.Errorf("namespaceFilter() mismatch (-want +got):\n%s", diff)
+	}
+	if r := results[0]; r.Metadata != (summary.NodeMetadata{}) || r.Conditions != nil || r.Unschedulable || r.Taints != nil {
+		t.Errorf("namespaceFilter() kept the node state in %+v, want it cleared", r)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// invalidNodeIntervals are the invalid --node-interval-annotation values
+// already warned about, by node
+var invalidNodeIntervals = struct {
+	sync.Mutex
+	values map[string]string
+}{values: map[string]string{}}
+
+// nodeInterval returns the background collection interval of the node: the
+// duration of its annotation if it's set and valid, the interval otherwise
+func nodeInterval(node corev1.Node, annotation string, interval time.Duration) time.Duration {
+	value, ok := node.Annotations[annotation]
+	if annotation == "" || !ok {
+		return interval
+	}
+	d, err := time.ParseDuration(value)
+	if err == nil && d > 0 {
+		return d
+	}
+
+	invalidNodeIntervals.Lock()
+	defer invalidNodeIntervals.Unlock()
+	if invalidNodeIntervals.values[node.Name] != value {
+		invalidNodeIntervals.values[node.Name] = value
+		fmt.Printf("[Warning] Ignoring the %s annotation of node %s, %q isn't a positive duration\n", annotation, node.Name, value)
+	}
+	return interval
+}
+
+type nodeDueKey struct{}
+
+// withNodeDue returns a context under which collectNodeStats only collects
+// the nodes that are due, the others being left in the cache as they are
+func withNodeDue(ctx context.Context, due func(corev1.Node) bool) context.Context {
+	return context.WithValue(ctx, nodeDueKey{}, due)
+}
+
+// nodeDue returns whether the node is due for collection under the context.
+// Every node is due without a due func.
+func nodeDue(ctx context.Context, node corev1.Node) bool {
+	due, ok := ctx.Value(nodeDueKey{}).(func(corev1.Node) bool)
+	return !ok || due(node)
+}
+
+// dueNodes returns the due func of the cycle of the collection loop starting
+// at start: the nodes whose --node-interval-annotation is longer than the
+// interval are only due once it elapsed since they were collected, see
+// summaryCache.due
+func dueNodes(cache *summaryCache, annotation string, interval time.Duration, start time.Time) func(corev1.Node) bool {
+	return func(node corev1.Node) bool {
+		every := nodeInterval(node, annotation, interval)
+		cache.setNodeInterval(node.Name, every)
+		return cache.due(node.Name, every, start)
+	}
+}
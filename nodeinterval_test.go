@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+)
+
+const testIntervalAnnotation = "kube-summary.io/interval"
+
+func Test_nodeInterval(t *testing.T) {
+	annotated := func(value string) corev1.Node {
+		return corev1.Node{ObjectMeta: meta_v1.ObjectMeta{Name: "node-a", Annotations: map[string]string{testIntervalAnnotation: value}}}
+	}
+
+	for _, tc := range []struct {
+		name       string
+		node       corev1.Node
+		annotation string
+		want       time.Duration
+	}{
+		{"annotated", annotated("2m"), testIntervalAnnotation, 2 * time.Minute},
+		{"shorter", annotated("10s"), testIntervalAnnotation, 10 * time.Second},
+		{"not annotated", corev1.Node{}, testIntervalAnnotation, 30 * time.Second},
+		{"invalid", annotated("hourly"), testIntervalAnnotation, 30 * time.Second},
+		{"negative", annotated("-1m"), testIntervalAnnotation, 30 * time.Second},
+		{"disabled", annotated("2m"), "", 30 * time.Second},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := nodeInterval(tc.node, tc.annotation, 30*time.Second); got != tc.want {
+				t.Errorf("nodeInterval() = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func Test_summaryCache_due(t *testing.T) {
+	cache := newSummaryCache(3)
+	cache.interval = 30 * time.Second
+	collected := time.Now()
+	cache.update([]PerNodeResult{{NodeName: "node-a", Summary: &stats.Summary{}, CollectedAt: collected}})
+
+	for _, tc := range []struct {
+		node  string
+		every time.Duration
+		at    time.Duration
+		want  bool
+	}{
+		{"node-a", 2 * time.Minute, 30 * time.Second, false},
+		{"node-a", 2 * time.Minute, 90 * time.Second, false},
+		// Half a cycle early, so that the cycle two minutes after the
+		// collection, which started slightly earlier, collects it
+		{"node-a", 2 * time.Minute, 105 * time.Second, true},
+		{"node-a", 30 * time.Second, 0, true},
+		{"node-a", 10 * time.Second, 0, true},
+		{"node-b", 2 * time.Minute, 0, true},
+	} {
+		if got := cache.due(tc.node, tc.every, collected.Add(tc.at)); got != tc.want {
+			t.Errorf("due(%s, %s) %s after the collection = %t, want %t", tc.node, tc.every, tc.at, got, tc.want)
+		}
+	}
+}
+
+func Test_summaryCache_nodeIntervalExpiry(t *testing.T) {
+	cache := newSummaryCache(3)
+	cache.interval = 30 * time.Second
+	s := &stats.Summary{Pods: []stats.PodStats{{PodRef: stats.PodReference{Namespace: "default", Name: "pod-a"}}}}
+	cache.update([]PerNodeResult{
+		{NodeName: "node-a", Summary: s, CollectedAt: time.Now()},
+		{NodeName: "node-b", Summary: s, CollectedAt: time.Now()},
+	})
+	// node-a is collected every 4 cycles, it expires after 3 of its intervals
+	cache.setNodeInterval("node-a", 2*time.Minute)
+
+	for cycle := 1; cycle < 12; cycle++ {
+		cache.update(nil)
+	}
+	if _, ok := cache.result("node-a"); !ok {
+		t.Error("node-a, collected every 2m, expired after 11 cycles of 30s")
+	}
+	if _, ok := cache.result("node-b"); ok {
+		t.Error("node-b, collected every cycle, didn't expire after 11 cycles")
+	}
+	if result, _ := cache.result("node-a"); result.Summary == nil || len(result.Summary.Pods) != 1 {
+		t.Error("the pods of node-a expired before the node")
+	}
+
+	cache.update(nil)
+	if _, ok := cache.result("node-a"); ok {
+		t.Error("node-a didn't expire after 3 of its intervals")
+	}
+}
+
+func Test_collectNodeStats_nodeInterval(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Summary: fakekubelet.Fixture("node")})
+	srv.AddNode(fakekubelet.Node{Name: "edge-a", Summary: fakekubelet.Fixture("node"), Annotations: map[string]string{testIntervalAnnotation: "2m"}})
+	cache := newSummaryCache(3)
+	cache.interval = 30 * time.Second
+
+	start := time.Now()
+	for cycle := 0; cycle < 5; cycle++ {
+		at := start.Add(time.Duration(cycle) * 30 * time.Second)
+		results, err := allNodesSelector(withNodeDue(context.Background(), dueNodes(cache, testIntervalAnnotation, 30*time.Second, at)), kubeClient)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range results {
+			// The cycles are simulated, the collections happen at once
+			results[i].CollectedAt = at
+		}
+		cache.update(results)
+	}
+
+	if n := srv.SummaryRequests("node-a"); n != 5 {
+		t.Errorf("node-a was collected %d times in 5 cycles, want 5", n)
+	}
+	if n := srv.SummaryRequests("edge-a"); n != 2 {
+		t.Errorf("edge-a, annotated with a 2m interval, was collected %d times in 5 cycles of 30s, want 2", n)
+	}
+	if _, ok := cache.result("edge-a"); !ok {
+		t.Error("edge-a isn't cached between its collections")
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+)
+
+func TestRouter_nodeMetadataLabels(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{
+		Name:    "node-a",
+		Summary: fakekubelet.Fixture("node"),
+		Labels: map[string]string{
+			corev1.LabelOSStable:           "linux",
+			corev1.LabelArchStable:         "amd64",
+			corev1.LabelInstanceTypeStable: "m5.large",
+		},
+	})
+	r := newRouter(kubeClient, nil, newSummaryScrapes(true), allNodesSelector, singleNodeSelector)
+
+	code, body := get(t, r, "/nodes", nil)
+	if code != http.StatusOK {
+		t.Fatalf("GET /nodes returned %d: %s", code, body)
+	}
+	assertNotContains(t, body, `instance_type=`)
+
+	*flagNodeMetadataLabels = true
+	defer func() { *flagNodeMetadataLabels = false }()
+	code, body = get(t, r, "/nodes", nil)
+	if code != http.StatusOK {
+		t.Fatalf("GET /nodes returned %d: %s", code, body)
+	}
+	assertContains(t, body,
+		`kube_summary_node_scrape_success{arch="amd64",instance_type="m5.large",kubelet_version="",node="node-a",os="linux"} 1`,
+		`kube_summary_node_runtime_imagefs_used_bytes{arch="amd64",instance_type="m5.large",kubelet_version="",node="node-a",os="linux"}`,
+	)
+	assertNotContains(t, body, `kube_summary_container_logs_used_bytes{arch=`)
+}
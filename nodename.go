@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// nodeNamesRefreshInterval is the age after which the node names are
+	// listed again
+	nodeNamesRefreshInterval = time.Minute
+	// nodeNamesMissRefreshInterval is the age after which a request for an
+	// unknown node lists the node names again, so that a node that just
+	// joined is found while misspelled names don't list the nodes every time
+	nodeNamesMissRefreshInterval = 5 * time.Second
+)
+
+var nodeRequestsRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metricsNamespace,
+	Name:      "node_requests_rejected_total",
+	Help:      "Number of requests for a single node rejected before any call to the API server, by reason",
+},
+	[]string{
+		"reason",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(nodeRequestsRejected)
+}
+
+// nodeNamesSource lists the names of the nodes the node path parameter is
+// checked against, the nodes of the nodeSource
+var nodeNamesSource = sourceNodeNames(kubeNodeSource{})
+
+// nodeNameCache holds the names of the nodes, listed at most every
+// nodeNamesRefreshInterval, or nodeNamesMissRefreshInterval for unknown names
+type nodeNameCache struct {
+	list func(context.Context, *kubernetes.Clientset) ([]string, error)
+	now  func() time.Time
+
+	mu       sync.Mutex
+	names    map[string]bool
+	listedAt time.Time
+}
+
+func newNodeNameCache(list func(context.Context, *kubernetes.Clientset) ([]string, error)) *nodeNameCache {
+	return &nodeNameCache{list: list, now: time.Now}
+}
+
+// has returns whether the node exists
+func (c *nodeNameCache) has(ctx context.Context, kubeClient *kubernetes.Clientset, name string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	age := c.now().Sub(c.listedAt)
+	if c.names != nil && (c.names[name] || age < nodeNamesMissRefreshInterval) && age < nodeNamesRefreshInterval {
+		return c.names[name], nil
+	}
+
+	names, err := c.list(ctx, kubeClient)
+	if err != nil {
+		return false, err
+	}
+	c.names = make(map[string]bool, len(names))
+	for _, n := range names {
+		c.names[n] = true
+	}
+	c.listedAt = c.now()
+	return c.names[name], nil
+}
+
+// nodeExists tells whether a node exists, see nodeNameCache
+type nodeExists func(ctx context.Context, name string) (bool, error)
+
+// withNodeValidation rejects the requests whose node path parameter isn't a
+// valid node name, or names a node that doesn't exist, with a 404 and a JSON
+// error. A failure to list the nodes lets the request through, to fail or
+// succeed on its own.
+func withNodeValidation(exists nodeExists, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := mux.Vars(r)["node"]
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			nodeRequestsRejected.WithLabelValues("invalid_name").Inc()
+			writeError(w, r, http.StatusNotFound, apiError{Error: fmt.Sprintf("invalid node name %q: %s", name, strings.Join(errs, ", ")), Node: name, Reason: reasonInvalidNodeName})
+			return
+		}
+
+		ok, err := exists(r.Context(), name)
+		if err != nil {
+			logError(r.Context(), "Listing the nodes to check %s failed: %v", name, err)
+		} else if !ok {
+			nodeRequestsRejected.WithLabelValues("not_found").Inc()
+			writeError(w, r, http.StatusNotFound, apiError{Error: fmt.Sprintf("node %q not found", name), Node: name, Reason: reasonNotFound})
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+func Test_nodeNameCache(t *testing.T) {
+	names, lists := []string{"node-a"}, 0
+	c := newNodeNameCache(func(context.Context, *kubernetes.Clientset) ([]string, error) {
+		lists++
+		return names, nil
+	})
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	has := func(name string, want bool, wantLists int) {
+		t.Helper()
+		got, err := c.has(context.Background(), nil, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want || lists != wantLists {
+			t.Errorf("has(%s) = %t after %d lists, want %t after %d", name, got, lists, want, wantLists)
+		}
+	}
+
+	has("node-a", true, 1)
+	has("node-b", false, 1)
+
+	// A node that joined is found once the miss refresh interval is over
+	names = []string{"node-a", "node-b"}
+	now = now.Add(nodeNamesMissRefreshInterval)
+	has("node-a", true, 1)
+	has("node-b", true, 2)
+
+	// A node that left is forgotten once the refresh interval is over
+	names = []string{"node-b"}
+	now = now.Add(nodeNamesRefreshInterval)
+	has("node-a", false, 3)
+}
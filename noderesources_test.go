@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func Test_collectNodeResources(t *testing.T) {
+	results := []PerNodeResult{
+		{
+			NodeName:       "node-a",
+			KubeletVersion: "v1.30.2",
+			Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:              resource.MustParse("3500m"),
+				corev1.ResourceMemory:           resource.MustParse("14Gi"),
+				corev1.ResourceEphemeralStorage: resource.MustParse("90Gi"),
+				corev1.ResourcePods:             resource.MustParse("110"),
+			},
+			Capacity: corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("4"),
+			},
+		},
+		// Failed nodes still report the resources of their node object
+		{NodeName: "node-b", Err: errSummaryTooLarge, Capacity: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("20")}},
+	}
+
+	samples, err := resultSamples(results, collectorOptions{NodeResources: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]float64{}
+	for _, s := range samples {
+		if s.Name == "kube_summary_node_scrape_success" {
+			continue
+		}
+		got[s.Name+"/"+s.Labels[0].Value+"/"+s.Labels[1].Value] = s.Value
+	}
+
+	want := map[string]float64{
+		"kube_summary_node_allocatable_cpu_cores/v1.30.2/node-a":               3.5,
+		"kube_summary_node_allocatable_memory_bytes/v1.30.2/node-a":            14 << 30,
+		"kube_summary_node_allocatable_ephemeral_storage_bytes/v1.30.2/node-a": 90 << 30,
+		"kube_summary_node_allocatable_pods/v1.30.2/node-a":                    110,
+		"kube_summary_node_capacity_cpu_cores/v1.30.2/node-a":                  4,
+		"kube_summary_node_capacity_pods//node-b":                              20,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("collectNodeResources() mismatch (-want +got):\n%s", diff)
+	}
+
+	samples, err = resultSamples(results, collectorOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range samples {
+		if s.Name != "kube_summary_node_scrape_success" {
+			t.Errorf("unexpected %s sample without NodeResources", s.Name)
+		}
+	}
+}
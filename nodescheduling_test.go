@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+)
+
+func TestRouter_nodeScheduling(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{
+		Name:          "node-a",
+		Summary:       fakekubelet.Fixture("node"),
+		Unschedulable: true,
+		Taints:        []corev1.Taint{{Key: "ToBeDeletedByClusterAutoscaler", Effect: corev1.TaintEffectNoSchedule}},
+	})
+	srv.AddNode(fakekubelet.Node{Name: "node-b", Summary: fakekubelet.Fixture("node")})
+	r := newRouter(kubeClient, nil, newSummaryScrapes(true), allNodesSelector, singleNodeSelector)
+
+	code, body := get(t, r, "/nodes", nil)
+	if code != http.StatusOK {
+		t.Fatalf("GET /nodes returned %d: %s", code, body)
+	}
+	assertNotContains(t, body, `kube_summary_node_unschedulable`, `kube_summary_node_taint`)
+
+	defer func(export bool, taints stringSliceFlag) {
+		*flagExportNodeScheduling, flagNodeTaints = export, taints
+	}(*flagExportNodeScheduling, flagNodeTaints)
+	*flagExportNodeScheduling = true
+	flagNodeTaints = stringSliceFlag{"ToBeDeletedByClusterAutoscaler"}
+	code, body = get(t, r, "/nodes", nil)
+	if code != http.StatusOK {
+		t.Fatalf("GET /nodes returned %d: %s", code, body)
+	}
+	assertContains(t, body,
+		`kube_summary_node_unschedulable{kubelet_version="",node="node-a"} 1`,
+		`kube_summary_node_unschedulable{kubelet_version="",node="node-b"} 0`,
+		`kube_summary_node_taint{key="ToBeDeletedByClusterAutoscaler",kubelet_version="",node="node-a"} 1`,
+		`kube_summary_node_taint{key="ToBeDeletedByClusterAutoscaler",kubelet_version="",node="node-b"} 0`,
+	)
+}
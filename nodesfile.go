@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// nodesFile is a file listing the nodes to collect, one name per line. Empty
+// lines and lines starting with # are ignored. The file is reloaded when its
+// modification time or size changes.
+type nodesFile struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	nodes   []string
+}
+
+func newNodesFile(path string) *nodesFile {
+	return &nodesFile{path: path}
+}
+
+// load returns the node names in the file, reading it again if it changed
+// since the last call
+func (f *nodesFile) load() ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return nil, err
+	}
+	if f.nodes != nil && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.nodes, nil
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		nodes = append(nodes, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	f.nodes, f.modTime, f.size = nodes, info.ModTime(), info.Size()
+	return nodes, nil
+}
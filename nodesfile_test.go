@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_nodesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nodes")
+	if err := os.WriteFile(path, []byte("# edge nodes\nnode-a\n\n  node-b  \n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f := newNodesFile(path)
+	nodes, err := f.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"node-a", "node-b"}, nodes); diff != "" {
+		t.Errorf("nodesFile.load() mismatch (-want +got):\n%s", diff)
+	}
+
+	if err := os.WriteFile(path, []byte("node-c\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Make sure the modification time changes on filesystems with a coarse
+	// resolution
+	later := time.Now().Add(time.Second)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	nodes, err = f.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"node-c"}, nodes); diff != "" {
+		t.Errorf("nodesFile.load() after reload mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	corev1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// nodeSource discovers the nodes to collect. The summaries are fetched the
+// same way whatever the source, which only decides the nodes and the labels
+// they are selected by.
+type nodeSource interface {
+	// nodes lists the nodes to collect
+	nodes(ctx context.Context, kubeClient *kubernetes.Clientset) ([]corev1.Node, error)
+	// node returns a single node by name
+	node(ctx context.Context, kubeClient *kubernetes.Clientset, name string) (corev1.Node, error)
+}
+
+// newNodeSource returns the node source set by the flags, the nodes of the API
+// server unless --kubelets, --nodes, --nodes-file or --nodes-http-sd-url is set
+func newNodeSource() (nodeSource, error) {
+	var sources []nodeSource
+	if *flagKubelets != "" {
+		targets, err := parseKubelets(*flagKubelets)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, kubeletNodeSource(targets))
+	}
+	if *flagNodes != "" {
+		sources = append(sources, staticNodeSource(splitList(*flagNodes)))
+	}
+	if *flagNodesFile != "" {
+		sources = append(sources, fileNodeSource{file: newNodesFile(*flagNodesFile)})
+	}
+	if *flagNodesHTTPSDURL != "" {
+		if *flagNodesHTTPSDRefreshInterval <= 0 {
+			return nil, errors.New("--nodes-http-sd-refresh-interval must be positive")
+		}
+		sources = append(sources, newHTTPSDNodeSource(*flagNodesHTTPSDURL, *flagNodesHTTPSDRefreshInterval))
+	}
+
+	switch len(sources) {
+	case 0:
+		return kubeNodeSource{}, nil
+	case 1:
+		return sources[0], nil
+	default:
+		return nil, errors.New("only one of --kubelets, --nodes, --nodes-file and --nodes-http-sd-url can be set")
+	}
+}
+
+// sourceNodesSelector selects all the nodes of the source, the collection
+// being followed on /-/progress while it runs
+func sourceNodesSelector(source nodeSource) nodeSelectorFunc {
+	return func(ctx context.Context, kubeClient *kubernetes.Clientset) ([]PerNodeResult, error) {
+		start := time.Now()
+		ctx, done := progress.track(ctx)
+		defer done()
+		nodes, err := source.nodes(ctx, kubeClient)
+		if err != nil {
+			err = fmt.Errorf("error enumerating nodes: %v", err)
+			collections.record(ctx, start, nil, err)
+			return nil, err
+		}
+		sourceNodes.listed(nodes)
+		results := collectNodeStats(ctx, kubeClient, nodeShard.filter(excludeNodes(nodes, flagExcludeNodes)))
+		collections.record(ctx, start, results, nil)
+		return results, nil
+	}
+}
+
+// sourceNodes tracks the nodes of the source, to forget the nodes that left it
+var sourceNodes = &nodeTracker{known: map[string]bool{}}
+
+// nodeTracker calls the funcs registered with onNodeRemoved for the nodes that
+// left the node source, so that the per node series of the nodes that are
+// gone don't pile up
+type nodeTracker struct {
+	mu       sync.Mutex
+	known    map[string]bool
+	handlers []func(nodeName string)
+}
+
+// onNodeRemoved registers a func forgetting a node that left the node source
+func onNodeRemoved(f func(nodeName string)) {
+	sourceNodes.mu.Lock()
+	defer sourceNodes.mu.Unlock()
+	sourceNodes.handlers = append(sourceNodes.handlers, f)
+}
+
+// listed records the nodes of a listing of the source, forgetting the known
+// nodes it doesn't have
+func (t *nodeTracker) listed(nodes []corev1.Node) {
+	known := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		known[node.Name] = true
+	}
+
+	t.mu.Lock()
+	var removed []string
+	for name := range t.known {
+		if !known[name] {
+			removed = append(removed, name)
+		}
+	}
+	t.known = known
+	handlers := t.handlers
+	t.mu.Unlock()
+
+	for _, name := range removed {
+		for _, f := range handlers {
+			f(name)
+		}
+	}
+}
+
+// removed forgets a node that was deleted, for the sources that watch the
+// nodes rather than list them
+func (t *nodeTracker) removed(nodeName string) {
+	t.mu.Lock()
+	delete(t.known, nodeName)
+	handlers := t.handlers
+	t.mu.Unlock()
+
+	for _, f := range handlers {
+		f(nodeName)
+	}
+}
+
+// sourceNodeSelector selects a single node of the source by name
+func sourceNodeSelector(source nodeSource) func(string) nodeSelectorFunc {
+	return func(nodeName string) nodeSelectorFunc {
+		return func(ctx context.Context, kubeClient *kubernetes.Clientset) ([]PerNodeResult, error) {
+			node, err := source.node(ctx, kubeClient, nodeName)
+			if err != nil {
+				return nil, err
+			}
+			return collectNodeStats(ctx, kubeClient, []corev1.Node{node}), nil
+		}
+	}
+}
+
+// sourceNodeNames lists the names of the nodes of the source
+func sourceNodeNames(source nodeSource) func(context.Context, *kubernetes.Clientset) ([]string, error) {
+	return func(ctx context.Context, kubeClient *kubernetes.Clientset) ([]string, error) {
+		nodes, err := source.nodes(ctx, kubeClient)
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, 0, len(nodes))
+		for _, node := range nodes {
+			names = append(names, node.Name)
+		}
+		return names, nil
+	}
+}
+
+// kubeNodeSource lists the nodes from the API server
+type kubeNodeSource struct{}
+
+func (kubeNodeSource) nodes(ctx context.Context, kubeClient *kubernetes.Clientset) ([]corev1.Node, error) {
+	ctx, span := tracer.Start(ctx, "listNodes")
+	defer span.End()
+
+	nodes, err := listNodes(ctx, kubeClient, *flagNodeListPageSize)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("nodes", len(nodes)))
+	return nodes, nil
+}
+
+func (kubeNodeSource) node(ctx context.Context, kubeClient *kubernetes.Clientset, name string) (corev1.Node, error) {
+	node, err := kubeClient.CoreV1().Nodes().Get(ctx, name, meta_v1.GetOptions{})
+	if err != nil {
+		return corev1.Node{}, fmt.Errorf("error getting node %s: %v", name, err)
+	}
+	return *node, nil
+}
+
+// staticNodeSource is a fixed list of node names, set by --nodes
+type staticNodeSource []string
+
+func (s staticNodeSource) nodes(context.Context, *kubernetes.Clientset) ([]corev1.Node, error) {
+	return namedNodes(s), nil
+}
+
+func (s staticNodeSource) node(ctx context.Context, kubeClient *kubernetes.Clientset, name string) (corev1.Node, error) {
+	return listedNode(ctx, kubeClient, s, name)
+}
+
+// kubeletNodeSource is the nodes of the --kubelets, with the
+// topology.kubernetes.io/zone label of their zone if set
+type kubeletNodeSource []kubeletTarget
+
+func (s kubeletNodeSource) nodes(context.Context, *kubernetes.Clientset) ([]corev1.Node, error) {
+	nodes := make([]corev1.Node, 0, len(s))
+	for _, target := range s {
+		node := corev1.Node{ObjectMeta: meta_v1.ObjectMeta{Name: target.name}}
+		if target.zone != "" {
+			node.Labels = map[string]string{corev1.LabelTopologyZone: target.zone}
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+func (s kubeletNodeSource) node(ctx context.Context, kubeClient *kubernetes.Clientset, name string) (corev1.Node, error) {
+	return listedNode(ctx, kubeClient, s, name)
+}
+
+// fileNodeSource lists the nodes of a nodesFile, set by --nodes-file
+type fileNodeSource struct {
+	file *nodesFile
+}
+
+func (s fileNodeSource) nodes(context.Context, *kubernetes.Clientset) ([]corev1.Node, error) {
+	names, err := s.file.load()
+	if err != nil {
+		return nil, fmt.Errorf("error reading nodes file: %v", err)
+	}
+	return namedNodes(names), nil
+}
+
+func (s fileNodeSource) node(ctx context.Context, kubeClient *kubernetes.Clientset, name string) (corev1.Node, error) {
+	return listedNode(ctx, kubeClient, s, name)
+}
+
+// httpSDTargetGroup is an entry of a Prometheus HTTP service discovery
+// response, whose targets are node names
+type httpSDTargetGroup struct {
+	Targets []string          `json:"targets"`
+	Labels  map[string]string `json:"labels,omitempty"`
+}
+
+// httpSDNodeSource lists the nodes from a Prometheus HTTP service discovery
+// endpoint, set by --nodes-http-sd-url. The nodes get the labels of their
+// target group, which SummaryScrape node selectors match. The response is
+// fetched at most every refresh interval, and the nodes of the last successful
+// response are kept when the endpoint fails.
+type httpSDNodeSource struct {
+	url             string
+	refreshInterval time.Duration
+	client          *http.Client
+	now             func() time.Time
+
+	mu        sync.Mutex
+	cached    []corev1.Node
+	fetchedAt time.Time
+	// refreshing is closed once the fetch in flight completes, nil without
+	// any
+	refreshing chan struct{}
+}
+
+// httpSDTimeout is the timeout of the HTTP service discovery requests, or
+// the refresh interval if shorter, so that a hung endpoint doesn't hold up
+// the collections
+const httpSDTimeout = 10 * time.Second
+
+func newHTTPSDNodeSource(url string, refreshInterval time.Duration) *httpSDNodeSource {
+	return &httpSDNodeSource{
+		url:             url,
+		refreshInterval: refreshInterval,
+		client:          &http.Client{Timeout: min(refreshInterval, httpSDTimeout)},
+		now:             time.Now,
+	}
+}
+
+// nodes returns the nodes of the last response, fetching them again once the
+// refresh interval elapsed. The fetch runs without the lock: while it is in
+// flight, and when it fails, the nodes of the last response are returned, the
+// callers only waiting for the first one.
+func (s *httpSDNodeSource) nodes(ctx context.Context, kubeClient *kubernetes.Clientset) ([]corev1.Node, error) {
+	s.mu.Lock()
+	if s.cached != nil && (s.refreshing != nil || s.now().Sub(s.fetchedAt) < s.refreshInterval) {
+		defer s.mu.Unlock()
+		return s.cached, nil
+	}
+	if wait := s.refreshing; wait != nil {
+		s.mu.Unlock()
+		select {
+		case <-wait:
+			return s.nodes(ctx, kubeClient)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	done := make(chan struct{})
+	s.refreshing = done
+	s.mu.Unlock()
+
+	nodes, err := s.fetch(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refreshing = nil
+	close(done)
+	if err != nil {
+		if s.cached == nil {
+			return nil, err
+		}
+		logError(ctx, "Keeping the %d nodes of the last HTTP service discovery response: %v", len(s.cached), err)
+		return s.cached, nil
+	}
+	s.cached, s.fetchedAt = nodes, s.now()
+	return nodes, nil
+}
+
+func (s *httpSDNodeSource) node(ctx context.Context, kubeClient *kubernetes.Clientset, name string) (corev1.Node, error) {
+	return listedNode(ctx, kubeClient, s, name)
+}
+
+func (s *httpSDNodeSource) fetch(ctx context.Context) ([]corev1.Node, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Prometheus-Refresh-Interval-Seconds", strconv.Itoa(int(s.refreshInterval.Seconds())))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("%s returned %s: %s", s.url, resp.Status, msg)
+	}
+
+	var groups []httpSDTargetGroup
+	if err := json.NewDecoder(resp.Body).Decode(&groups); err != nil {
+		return nil, fmt.Errorf("error decoding the response of %s: %w", s.url, err)
+	}
+
+	nodes := []corev1.Node{}
+	for _, group := range groups {
+		for _, target := range group.Targets {
+			nodes = append(nodes, corev1.Node{ObjectMeta: meta_v1.ObjectMeta{Name: target, Labels: group.Labels}})
+		}
+	}
+	return nodes, nil
+}
+
+// namedNodes returns nodes with only their name set
+func namedNodes(names []string) []corev1.Node {
+	nodes := make([]corev1.Node, 0, len(names))
+	for _, name := range names {
+		nodes = append(nodes, corev1.Node{ObjectMeta: meta_v1.ObjectMeta{Name: name}})
+	}
+	return nodes
+}
+
+// listedNode returns the node of the source by name, for the sources that can
+// only list their nodes
+func listedNode(ctx context.Context, kubeClient *kubernetes.Clientset, source nodeSource, name string) (corev1.Node, error) {
+	nodes, err := source.nodes(ctx, kubeClient)
+	if err != nil {
+		return corev1.Node{}, fmt.Errorf("error getting node %s: %v", name, err)
+	}
+	for _, node := range nodes {
+		if node.Name == name {
+			return node, nil
+		}
+	}
+	return corev1.Node{}, fmt.Errorf("error getting node %s: not found", name)
+}
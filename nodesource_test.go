@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+)
+
+func Test_sourceNodesSelector_file(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Summary: fakekubelet.Fixture("node")})
+	srv.AddNode(fakekubelet.Node{Name: "node-b", Summary: fakekubelet.Fixture("node")})
+
+	path := filepath.Join(t.TempDir(), "nodes")
+	if err := os.WriteFile(path, []byte("node-b\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := sourceNodesSelector(fileNodeSource{file: newNodesFile(path)})(context.Background(), kubeClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].NodeName != "node-b" || results[0].Err != nil {
+		t.Errorf("sourceNodesSelector() returned %+v, want only node-b", results)
+	}
+	if n := srv.SummaryRequests("node-a"); n != 0 {
+		t.Errorf("node-a received %d summary requests, want 0", n)
+	}
+}
+
+func Test_sourceNodeSelector_static(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Summary: fakekubelet.Fixture("node")})
+
+	selector := sourceNodeSelector(staticNodeSource{"node-a"})
+	results, err := selector("node-a")(context.Background(), kubeClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Err != nil {
+		t.Errorf("sourceNodeSelector() returned %+v, want node-a", results)
+	}
+
+	if _, err := selector("node-b")(context.Background(), kubeClient); err == nil {
+		t.Error("sourceNodeSelector() of a node missing from the list returned no error")
+	}
+}
+
+func Test_httpSDNodeSource(t *testing.T) {
+	var requests int
+	fail := false
+	sd := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if got := r.Header.Get("X-Prometheus-Refresh-Interval-Seconds"); got != "60" {
+			t.Errorf("got refresh interval header %q, want 60", got)
+		}
+		if fail {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"targets":["edge-a","edge-b"],"labels":{"site":"lon"}},{"targets":["edge-c"]}]`))
+	}))
+	t.Cleanup(sd.Close)
+
+	now := time.Now()
+	s := newHTTPSDNodeSource(sd.URL, time.Minute)
+	s.now = func() time.Time { return now }
+
+	names := func() []string {
+		t.Helper()
+		names, err := sourceNodeNames(s)(context.Background(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return names
+	}
+
+	if diff := cmp.Diff([]string{"edge-a", "edge-b", "edge-c"}, names()); diff != "" {
+		t.Errorf("nodes mismatch (-want +got):\n%s", diff)
+	}
+	node, err := s.node(context.Background(), nil, "edge-b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node.Labels["site"] != "lon" {
+		t.Errorf("got labels %v, want site=lon", node.Labels)
+	}
+	if requests != 1 {
+		t.Errorf("got %d requests within the refresh interval, want 1", requests)
+	}
+
+	fail = true
+	now = now.Add(time.Minute)
+	if diff := cmp.Diff([]string{"edge-a", "edge-b", "edge-c"}, names()); diff != "" {
+		t.Errorf("nodes after a failure mismatch (-want +got):\n%s", diff)
+	}
+	if requests != 2 {
+		t.Errorf("got %d requests after the refresh interval, want 2", requests)
+	}
+
+	if _, err := newHTTPSDNodeSource(sd.URL, time.Minute).nodes(context.Background(), nil); err == nil {
+		t.Error("nodes() of a failing endpoint returned no error")
+	}
+}
+
+func Test_httpSDNodeSource_slowRefresh(t *testing.T) {
+	block := make(chan struct{})
+	var blocked atomic.Bool
+	sd := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if blocked.Load() {
+			<-block
+		}
+		_, _ = w.Write([]byte(`[{"targets":["edge-a"]}]`))
+	}))
+	t.Cleanup(sd.Close)
+	t.Cleanup(func() { close(block) })
+
+	s := newHTTPSDNodeSource(sd.URL, time.Minute)
+	if _, err := s.nodes(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// The callers don't wait for a refresh in flight, the nodes of the last
+	// response being returned meanwhile
+	blocked.Store(true)
+	s.mu.Lock()
+	s.fetchedAt = time.Time{}
+	s.mu.Unlock()
+	go func() { _, _ = s.nodes(context.Background(), nil) }()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.mu.Lock()
+		refreshing := s.refreshing != nil
+		s.mu.Unlock()
+		if refreshing {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the refresh never started")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	nodes, err := s.nodes(ctx, nil)
+	if err != nil || len(nodes) != 1 || nodes[0].Name != "edge-a" {
+		t.Errorf("nodes() during a refresh = %v, %v, want the last nodes", nodes, err)
+	}
+}
+
+func Test_newNodeSource(t *testing.T) {
+	defer func(nodes, file string) { *flagNodes, *flagNodesFile = nodes, file }(*flagNodes, *flagNodesFile)
+
+	*flagNodes, *flagNodesFile = "", ""
+	if s, err := newNodeSource(); err != nil || s != (kubeNodeSource{}) {
+		t.Errorf("newNodeSource() = %v, %v, want the API server source", s, err)
+	}
+
+	*flagNodes = "node-a, node-b"
+	s, err := newNodeSource()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(staticNodeSource{"node-a", "node-b"}, s); diff != "" {
+		t.Errorf("newNodeSource() mismatch (-want +got):\n%s", diff)
+	}
+
+	*flagNodesFile = "nodes"
+	if _, err := newNodeSource(); err == nil {
+		t.Error("newNodeSource() with both --nodes and --nodes-file returned no error")
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// snapshotKeyLayout names the objects by the end of their collection cycle,
+// so that the keys sort chronologically
+const snapshotKeyLayout = "2006-01-02T15-04-05Z"
+
+// objectStore is the subset of an S3 compatible bucket used by the object
+// storage sink
+type objectStore interface {
+	PutObject(ctx context.Context, key string, data []byte, contentType, contentEncoding string) error
+	ListObjects(ctx context.Context, prefix string) ([]string, error)
+	RemoveObject(ctx context.Context, key string) error
+}
+
+// objectStorageSink uploads the summaries of the nodes collected in every cycle
+// as a single gzipped JSON document, and removes the documents older than the
+// retention
+type objectStorageSink struct {
+	store     objectStore
+	prefix    string
+	retention time.Duration
+}
+
+func newObjectStorageSink(store objectStore, prefix string, retention time.Duration) *objectStorageSink {
+	return &objectStorageSink{store: store, prefix: prefix, retention: retention}
+}
+
+func (o *objectStorageSink) Name() string {
+	return "object-storage"
+}
+
+// cycleSnapshot is the document uploaded for a collection cycle
+type cycleSnapshot struct {
+	Timestamp time.Time      `json:"timestamp"`
+	Nodes     []nodeSnapshot `json:"nodes"`
+}
+
+func (o *objectStorageSink) WriteSnapshot(ctx context.Context, results []PerNodeResult, ts time.Time) error {
+	doc := cycleSnapshot{Timestamp: ts.UTC()}
+	for _, result := range results {
+		if result.Err != nil || result.Summary == nil {
+			continue
+		}
+		doc.Nodes = append(doc.Nodes, nodeSnapshot{Node: result.NodeName, Timestamp: ts.UTC(), Summary: result.Summary})
+	}
+	if len(doc.Nodes) == 0 {
+		return nil
+	}
+	sort.Slice(doc.Nodes, func(i, j int) bool { return doc.Nodes[i].Node < doc.Nodes[j].Node })
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(doc); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	key := o.prefix + ts.UTC().Format(snapshotKeyLayout) + ".json.gz"
+	if err := o.store.PutObject(ctx, key, buf.Bytes(), "application/json", "gzip"); err != nil {
+		return fmt.Errorf("error uploading %s: %v", key, err)
+	}
+
+	if o.retention > 0 {
+		return o.expire(ctx, ts.Add(-o.retention))
+	}
+	return nil
+}
+
+// expire removes the snapshots older than the cutoff. Objects under the prefix
+// whose key isn't a snapshot timestamp are left alone.
+func (o *objectStorageSink) expire(ctx context.Context, cutoff time.Time) error {
+	keys, err := o.store.ListObjects(ctx, o.prefix)
+	if err != nil {
+		return fmt.Errorf("error listing snapshots: %v", err)
+	}
+
+	for _, key := range keys {
+		name, ok := strings.CutSuffix(strings.TrimPrefix(key, o.prefix), ".json.gz")
+		if !ok {
+			continue
+		}
+		ts, err := time.Parse(snapshotKeyLayout, name)
+		if err != nil || !ts.Before(cutoff) {
+			continue
+		}
+		if err := o.store.RemoveObject(ctx, key); err != nil {
+			return fmt.Errorf("error removing expired snapshot %s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// minioStore is an S3 compatible bucket, which includes GCS through its XML
+// API. Credentials are read from the AWS and MinIO environment variables, the
+// AWS credentials file or the instance metadata.
+type minioStore struct {
+	client *minio.Client
+	bucket string
+}
+
+func newMinioStore(endpoint, bucket string, insecure bool) (*minioStore, error) {
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds: credentials.NewChainCredentials([]credentials.Provider{
+			&credentials.EnvAWS{},
+			&credentials.EnvMinio{},
+			&credentials.FileAWSCredentials{},
+			&credentials.IAM{},
+		}),
+		Secure: !insecure,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &minioStore{client: client, bucket: bucket}, nil
+}
+
+func (m *minioStore) PutObject(ctx context.Context, key string, data []byte, contentType, contentEncoding string) error {
+	_, err := m.client.PutObject(ctx, m.bucket, key, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType:     contentType,
+		ContentEncoding: contentEncoding,
+	})
+	return err
+}
+
+func (m *minioStore) ListObjects(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	for object := range m.client.ListObjects(ctx, m.bucket, minio.ListObjectsOptions{Prefix: prefix}) {
+		if object.Err != nil {
+			return nil, object.Err
+		}
+		keys = append(keys, object.Key)
+	}
+	return keys, nil
+}
+
+func (m *minioStore) RemoveObject(ctx context.Context, key string) error {
+	return m.client.RemoveObject(ctx, m.bucket, key, minio.RemoveObjectOptions{})
+}
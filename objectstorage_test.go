@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+type memoryStore map[string][]byte
+
+func (m memoryStore) PutObject(ctx context.Context, key string, data []byte, contentType, contentEncoding string) error {
+	m[key] = data
+	return nil
+}
+
+func (m memoryStore) ListObjects(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	for key := range m {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (m memoryStore) RemoveObject(ctx context.Context, key string) error {
+	delete(m, key)
+	return nil
+}
+
+func (m memoryStore) keys() []string {
+	keys, _ := m.ListObjects(context.Background(), "")
+	sort.Strings(keys)
+	return keys
+}
+
+func Test_objectStorageSink(t *testing.T) {
+	store := memoryStore{"snapshots/README": nil}
+	sink := newObjectStorageSink(store, "snapshots/", 2*time.Hour)
+
+	results := []PerNodeResult{
+		{NodeName: "node-b", Summary: &stats.Summary{Node: stats.NodeStats{NodeName: "node-b"}}},
+		{NodeName: "node-a", Summary: &stats.Summary{Node: stats.NodeStats{NodeName: "node-a"}}},
+		{NodeName: "node-c", Err: errSummaryTooLarge},
+	}
+
+	start := time.Date(2024, 3, 1, 3, 12, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		if err := sink.WriteSnapshot(context.Background(), results, start.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{
+		"snapshots/2024-03-01T04-12-00Z.json.gz",
+		"snapshots/2024-03-01T05-12-00Z.json.gz",
+		"snapshots/2024-03-01T06-12-00Z.json.gz",
+		"snapshots/README",
+	}
+	if diff := cmp.Diff(want, store.keys()); diff != "" {
+		t.Errorf("stored objects mismatch (-want +got):\n%s", diff)
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(store["snapshots/2024-03-01T06-12-00Z.json.gz"]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc cycleSnapshot
+	if err := json.NewDecoder(zr).Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+	var nodes []string
+	for _, n := range doc.Nodes {
+		nodes = append(nodes, n.Node)
+	}
+	if diff := cmp.Diff([]string{"node-a", "node-b"}, nodes); diff != "" {
+		t.Errorf("snapshot nodes mismatch (-want +got):\n%s", diff)
+	}
+}
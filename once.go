@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+// Exit statuses of the once command
+const (
+	onceSuccess = 0
+	onceFailed  = 1
+	oncePartial = 2
+	onceUsage   = 3
+)
+
+// runOnce implements `kube-summary-exporter once [--node X | --all]`, which
+// collects the summaries a single time, writes them to stdout and returns the
+// exit status. Every flag of the server is accepted as well.
+func runOnce(args []string) int {
+	fs := flag.NewFlagSet("once", flag.ContinueOnError)
+	flag.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+	nodeName := fs.String("node", "", "Collect a single node")
+	all := fs.Bool("all", false, "Collect all nodes, the default unless --node is set")
+	format := fs.String("format", "prometheus", "Output format, prometheus or json")
+	timeout := fs.Duration("timeout", time.Minute, "Maximum duration of the collection")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s once [--node X | --all] [--format prometheus|json]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return onceUsage
+	}
+	if *nodeName != "" && *all {
+		fmt.Fprintln(os.Stderr, "[Error] --node and --all are mutually exclusive")
+		return onceUsage
+	}
+	if *format != "prometheus" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "[Error] Unknown format %q, expected prometheus or json\n", *format)
+		return onceUsage
+	}
+
+	if *flagRetryBudgetRatio < 0 {
+		fmt.Fprintln(os.Stderr, "[Error] --retry-budget-ratio can't be negative")
+		return onceUsage
+	}
+	summaryRetryBudget.ratio = *flagRetryBudgetRatio
+
+	if *flagConfigFile != "" {
+		c, rules, err := loadConfig(*flagConfigFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[Error] Cannot load config file: %v\n", err)
+			return onceUsage
+		}
+		metricRelabelRules = rules
+		metricHelpOverrides = c.MetricHelp
+		customMetrics = c.CustomMetrics
+	}
+
+	var (
+		kubeClient *kubernetes.Clientset
+		err        error
+	)
+	if *flagKubelets != "" {
+		var config *rest.Config
+		if config, err = setupStandaloneKubelets(); err == nil {
+			kubeClient, err = kubernetes.NewForConfig(config)
+		}
+	} else {
+		kubeClient, err = newKubeClient(*flagKubeConfigPath, http.Header(flagUpstreamHeaders))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[Error] Cannot create kube client: %v\n", err)
+		return onceFailed
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	if err := runFlagPodInformer(ctx, kubeClient); err != nil {
+		fmt.Fprintf(os.Stderr, "[Error] Cannot watch pods: %v\n", err)
+		return onceFailed
+	}
+
+	source, err := newNodeSource()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[Error] Invalid node source: %v\n", err)
+		return onceFailed
+	}
+	selector := sourceNodesSelector(source)
+	if *nodeName != "" {
+		selector = sourceNodeSelector(source)(*nodeName)
+	}
+
+	status, err := collectOnce(ctx, kubeClient, selector, *format, os.Stdout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[Error] %v\n", err)
+	}
+	return status
+}
+
+// collectOnce collects the selected nodes, writes them to w in the format and
+// returns the exit status: onceSuccess if every node was collected,
+// oncePartial if some failed and onceFailed if none could be collected
+func collectOnce(ctx context.Context, kubeClient *kubernetes.Clientset, selector nodeSelectorFunc, format string, w io.Writer) (int, error) {
+	results, err := selector(ctx, kubeClient)
+	if err == nil {
+		err = allFailed(results)
+	}
+	if err == nil && len(results) == 0 {
+		err = errors.New("no nodes selected")
+	}
+	if err != nil {
+		return onceFailed, fmt.Errorf("error collecting node stats: %v", err)
+	}
+
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(nodeDocuments(results))
+	default:
+		registry := prometheus.NewRegistry()
+		summary.Collect(results, registry, flagCollectorOptions())
+		err = writeText(w, selfChecked(relabeled(registry)))
+	}
+	if err != nil {
+		return onceFailed, fmt.Errorf("error writing metrics: %v", err)
+	}
+
+	for _, result := range results {
+		if result.Err != nil {
+			return oncePartial, fmt.Errorf("some nodes failed, see kube_summary_node_scrape_success")
+		}
+	}
+	return onceSuccess, nil
+}
+
+// writeText writes the metrics gathered by the registry in the Prometheus
+// text format, which promtool can check
+func writeText(w io.Writer, registry prometheus.Gatherer) error {
+	families, err := registry.Gather()
+	if err != nil {
+		return err
+	}
+
+	enc := expfmt.NewEncoder(w, expfmt.NewFormat(expfmt.TypeTextPlain))
+	for _, mf := range families {
+		if err := enc.Encode(mf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
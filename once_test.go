@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+)
+
+func Test_collectOnce(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Summary: fakekubelet.Fixture("node")})
+
+	var out bytes.Buffer
+	status, err := collectOnce(context.Background(), kubeClient, allNodesSelector, "prometheus", &out)
+	if status != onceSuccess || err != nil {
+		t.Fatalf("collectOnce() = %d, %v, want %d", status, err, onceSuccess)
+	}
+	assertContains(t, out.String(), `kube_summary_node_scrape_success{kubelet_version="",node="node-a"} 1`)
+
+	srv.AddNode(fakekubelet.Node{Name: "node-b", StatusCode: http.StatusServiceUnavailable})
+	out.Reset()
+	status, err = collectOnce(context.Background(), kubeClient, allNodesSelector, "json", &out)
+	if status != oncePartial || err == nil {
+		t.Fatalf("collectOnce() with a failed node = %d, %v, want %d", status, err, oncePartial)
+	}
+	var nodes []nodeDocument
+	if err := json.Unmarshal(out.Bytes(), &nodes); err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 2 || nodes[0].Summary == nil || nodes[1].Error == "" {
+		t.Errorf("collectOnce() wrote unexpected nodes: %s", out.String())
+	}
+
+	if status, _ := collectOnce(context.Background(), kubeClient, singleNodeSelector("node-b"), "json", &out); status != onceFailed {
+		t.Errorf("collectOnce() of a failed node = %d, want %d", status, onceFailed)
+	}
+}
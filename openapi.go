@@ -0,0 +1,238 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+// apiParameter documents a query or path parameter of an endpoint
+type apiParameter struct {
+	Name        string
+	In          string
+	Description string
+	Required    bool
+}
+
+// apiEndpoint documents an endpoint of the HTTP API for the OpenAPI document.
+// Response is a value of the JSON response type, or nil for the endpoints
+// responding in a text format.
+type apiEndpoint struct {
+	Path        string
+	Summary     string
+	Parameters  []apiParameter
+	Response    interface{}
+	ContentType string
+}
+
+var (
+	excludeParameter = apiParameter{Name: "exclude", In: "query", Description: "Comma separated node names to skip, can be repeated"}
+	nodeParameter    = apiParameter{Name: "node", In: "path", Description: "Node name", Required: true}
+	prometheusText   = "text/plain; version=0.0.4"
+)
+
+// apiEndpoints are the endpoints described by /api/openapi.json
+var apiEndpoints = []apiEndpoint{
+	{Path: "/api/v1/nodes", Summary: "Summaries of all nodes", Parameters: []apiParameter{excludeParameter}, Response: []nodeDocument{}},
+	{Path: "/api/v1/nodes/{node}", Summary: "Summary of a single node", Parameters: []apiParameter{nodeParameter}, Response: []nodeDocument{}},
+	{Path: "/nodes", Summary: "Metrics of all nodes", Parameters: []apiParameter{excludeParameter}, ContentType: prometheusText},
+	{Path: "/node/{node}", Summary: "Metrics of a single node", Parameters: []apiParameter{nodeParameter}, ContentType: prometheusText},
+	{Path: "/probe", Summary: "Metrics of the target node, following the multi-target exporter pattern", Parameters: []apiParameter{
+		{Name: "target", In: "query", Description: "Node name", Required: true},
+		{Name: "module", In: "query", Description: "default, or comma separated metric groups among " + strings.Join(summary.Sections, ", ")},
+	}, ContentType: prometheusText},
+	{Path: "/influx", Summary: "Metrics of all nodes in the InfluxDB line protocol", Parameters: []apiParameter{excludeParameter}, ContentType: "text/plain"},
+	{Path: "/influx/node/{node}", Summary: "Metrics of a single node in the InfluxDB line protocol", Parameters: []apiParameter{nodeParameter}, ContentType: "text/plain"},
+	{Path: "/namespace/{namespace}/pods", Summary: "Metrics of the pods of a namespace, for callers allowed to list them", Parameters: []apiParameter{
+		{Name: "namespace", In: "path", Description: "Namespace", Required: true},
+	}, ContentType: prometheusText},
+	{Path: "/scrape/{namespace}/{name}", Summary: "Metrics selected by a SummaryScrape resource, with --summary-scrapes", Parameters: []apiParameter{
+		{Name: "namespace", In: "path", Description: "Namespace of the SummaryScrape", Required: true},
+		{Name: "name", In: "path", Description: "Name of the SummaryScrape", Required: true},
+	}, ContentType: prometheusText},
+	{Path: "/scrape/{name}", Summary: "Metrics selected by a ClusterSummaryScrape resource, with --summary-scrapes", Parameters: []apiParameter{
+		{Name: "name", In: "path", Description: "Name of the ClusterSummaryScrape", Required: true},
+	}, ContentType: prometheusText},
+	{Path: "/diff", Summary: "Storage usage deltas of the pods between the last two cycles, in background mode", Parameters: []apiParameter{
+		{Name: "limit", In: "query", Description: "Maximum number of pods, the largest ephemeral storage growth first"},
+	}, Response: diffDocument{}},
+	{Path: "/export/csv", Summary: "Storage used by the pods of all nodes, aggregated by namespace or pod label", Parameters: []apiParameter{
+		{Name: "groupBy", In: "query", Description: "namespace, the default, or label:<name> to aggregate by the value of a pod label"},
+	}, ContentType: "text/csv"},
+	{Path: "/peer/summaries", Summary: "Summaries of the share of the nodes of this replica collected in its last cycle, for its peers, with --peers-service", Response: peerDocument{}},
+	{Path: "/debug/coverage", Summary: "Summary sections present or absent on the last scrape of each node", Parameters: []apiParameter{
+		{Name: "node", In: "query", Description: "Only the coverage of the node"},
+	}, Response: coverageDocument{}},
+	{Path: "/debug/collections", Summary: "Last collections of all nodes, the most recent first", Response: collectionsDocument{}},
+	{Path: "/catalog", Summary: "Metrics mapped from the summaries, with their type, labels and stability level", Response: catalogDocument{}},
+	{Path: "/-/config", Summary: "Effective configuration of the flags, config file and SummaryScrape resources, with the secrets redacted, and the active filters", Response: effectiveConfigDocument{}},
+	{Path: "/-/progress", Summary: "Collections of all nodes in flight, with the number of nodes completed and the estimated time left", Response: progressDocument{}},
+}
+
+// openAPIDocument returns the OpenAPI 3 document of the endpoints. The JSON
+// schemas are generated from the response types, so they follow the code.
+func openAPIDocument(endpoints []apiEndpoint) map[string]interface{} {
+	g := &schemaGenerator{schemas: map[string]interface{}{}}
+
+	paths := map[string]interface{}{}
+	for _, e := range endpoints {
+		var params []interface{}
+		for _, p := range e.Parameters {
+			params = append(params, map[string]interface{}{
+				"name":        p.Name,
+				"in":          p.In,
+				"description": p.Description,
+				"required":    p.Required,
+				"schema":      map[string]interface{}{"type": "string"},
+			})
+		}
+		params = append(params, map[string]interface{}{
+			"name":        "X-Prometheus-Scrape-Timeout-Seconds",
+			"in":          "header",
+			"description": "Collection timeout in seconds",
+			"schema":      map[string]interface{}{"type": "number"},
+		})
+
+		content := map[string]interface{}{e.ContentType: map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}}
+		if e.Response != nil {
+			content = map[string]interface{}{"application/json": map[string]interface{}{"schema": g.schema(reflect.TypeOf(e.Response))}}
+		}
+
+		errorContent := map[string]interface{}{"application/json": map[string]interface{}{"schema": g.schema(reflect.TypeOf(apiError{}))}}
+		responses := map[string]interface{}{
+			"200": map[string]interface{}{"description": "OK", "content": content},
+			"500": map[string]interface{}{"description": "No node could be collected", "content": errorContent},
+		}
+		for _, p := range e.Parameters {
+			if p == nodeParameter {
+				responses["404"] = map[string]interface{}{"description": "Invalid or unknown node name", "content": errorContent}
+			}
+		}
+
+		paths[e.Path] = map[string]interface{}{
+			"get": map[string]interface{}{
+				"summary":    e.Summary,
+				"parameters": params,
+				"responses":  responses,
+			},
+		}
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "Kube Summary Exporter",
+			"version": "v1",
+		},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": g.schemas},
+	}
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	metaTimeType = reflect.TypeOf(meta_v1.Time{})
+)
+
+// schemaGenerator maps Go types to JSON schemas following their encoding/json
+// representation. Named structs are stored as components and referenced, which
+// also stops the recursion on recursive types.
+type schemaGenerator struct {
+	schemas map[string]interface{}
+}
+
+func (g *schemaGenerator) schema(t reflect.Type) map[string]interface{} {
+	switch t {
+	case timeType, metaTimeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.schema(t.Elem())
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		name := t.Name()
+		if _, ok := g.schemas[name]; !ok {
+			g.schemas[name] = nil
+			g.schemas[name] = g.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+	return map[string]interface{}{}
+}
+
+func (g *schemaGenerator) object(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var required []string
+	g.fields(t, properties, &required)
+
+	s := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// fields adds the properties of the exported fields of the struct, including
+// the fields of embedded structs without a JSON name
+func (g *schemaGenerator) fields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.fields(ft, properties, required)
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		properties[name] = g.schema(f.Type)
+		if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Pointer {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// handleOpenAPI serves the OpenAPI document of the HTTP API
+func handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(openAPIDocument(apiEndpoints))
+}
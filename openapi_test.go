@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRouter_openAPI(t *testing.T) {
+	_, kubeClient := newTestServer(t)
+	r := newRouter(kubeClient, nil, nil, allNodesSelector, singleNodeSelector)
+
+	code, body := get(t, r, "/api/openapi.json", nil)
+	if code != http.StatusOK {
+		t.Fatalf("GET /api/openapi.json returned %d: %s", code, body)
+	}
+
+	var doc struct {
+		Paths      map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]interface{} `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range apiEndpoints {
+		if _, ok := doc.Paths[e.Path]; !ok {
+			t.Errorf("OpenAPI document lacks %s", e.Path)
+		}
+	}
+
+	// Every endpoint of the router is documented, the optional ones included
+	defer func(p *peerSet) { peers = p }(peers)
+	peers = testPeerSet(t, "10.0.0.1", "secret")
+	full := newRouter(kubeClient, newSummaryCache(1), newSummaryScrapes(true), allNodesSelector, singleNodeSelector)
+	err := full.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		switch path {
+		case "/", "/metrics", "/api/openapi.json", "/api/v1":
+			return nil
+		}
+		if _, ok := doc.Paths[path]; !ok {
+			t.Errorf("OpenAPI document lacks %s", path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for schema, property := range map[string]string{
+		"nodeDocument": "summary",
+		"Summary":      "pods",
+		"PodStats":     "ephemeral-storage",
+		"FsStats":      "usedBytes",
+	} {
+		if _, ok := doc.Components.Schemas[schema].Properties[property]; !ok {
+			t.Errorf("OpenAPI schema %s lacks property %s", schema, property)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// otlpSink pushes samples as OTLP gauges to an OTLP/HTTP metrics endpoint
+// using the JSON encoding, e.g. http://otel-collector:4318/v1/metrics
+type otlpSink struct {
+	url     string
+	headers http.Header
+}
+
+func newOTLPSink(url string, headers map[string]string) *otlpSink {
+	h := sinkHeaders(headers)
+	h.Set("Content-Type", "application/json")
+	return &otlpSink{
+		url:     url,
+		headers: h,
+	}
+}
+
+func (s *otlpSink) Name() string {
+	return "otlp"
+}
+
+func (s *otlpSink) Write(ctx context.Context, samples []sample, ts time.Time) error {
+	body, err := json.Marshal(otlpRequest(samples, ts))
+	if err != nil {
+		return err
+	}
+	return postSamples(ctx, s.url, s.headers, body)
+}
+
+// The subset of the OTLP ExportMetricsServiceRequest JSON encoding used by
+// the sink
+type (
+	otlpExportRequest struct {
+		ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+	}
+	otlpResourceMetrics struct {
+		Resource     otlpResource       `json:"resource"`
+		ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpScopeMetrics struct {
+		Scope   otlpScope    `json:"scope"`
+		Metrics []otlpMetric `json:"metrics"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpMetric struct {
+		Name  string    `json:"name"`
+		Gauge otlpGauge `json:"gauge"`
+	}
+	otlpGauge struct {
+		DataPoints []otlpDataPoint `json:"dataPoints"`
+	}
+	otlpDataPoint struct {
+		Attributes   []otlpAttribute `json:"attributes,omitempty"`
+		TimeUnixNano string          `json:"timeUnixNano"`
+		AsDouble     float64         `json:"asDouble"`
+	}
+	otlpAttribute struct {
+		Key   string       `json:"key"`
+		Value otlpAnyValue `json:"value"`
+	}
+	otlpAnyValue struct {
+		StringValue string `json:"stringValue"`
+	}
+)
+
+// otlpRequest groups the samples into one gauge per metric name, with the
+// labels as data point attributes. Samples with a NaN or infinite value,
+// which JSON can't represent, are skipped.
+func otlpRequest(samples []sample, ts time.Time) otlpExportRequest {
+	timestamp := strconv.FormatInt(ts.UnixNano(), 10)
+
+	var metrics []otlpMetric
+	index := map[string]int{}
+	for _, s := range samples {
+		if math.IsNaN(s.Value) || math.IsInf(s.Value, 0) {
+			continue
+		}
+		i, ok := index[s.Name]
+		if !ok {
+			i = len(metrics)
+			index[s.Name] = i
+			metrics = append(metrics, otlpMetric{Name: s.Name})
+		}
+
+		point := otlpDataPoint{TimeUnixNano: timestamp, AsDouble: s.Value}
+		for _, l := range s.Labels {
+			point.Attributes = append(point.Attributes, otlpAttribute{Key: l.Name, Value: otlpAnyValue{StringValue: l.Value}})
+		}
+		metrics[i].Gauge.DataPoints = append(metrics[i].Gauge.DataPoints, point)
+	}
+
+	return otlpExportRequest{
+		ResourceMetrics: []otlpResourceMetrics{{
+			Resource: otlpResource{Attributes: []otlpAttribute{
+				{Key: "service.name", Value: otlpAnyValue{StringValue: "kube-summary-exporter"}},
+			}},
+			ScopeMetrics: []otlpScopeMetrics{{
+				Scope:   otlpScope{Name: "github.com/utilitywarehouse/kube-summary-exporter"},
+				Metrics: metrics,
+			}},
+		}},
+	}
+}
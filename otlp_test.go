@@ -0,0 +1,25 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func Test_otlpRequest(t *testing.T) {
+	samples := []sample{
+		{Name: "kube_summary_node_scrape_success", Labels: []labelPair{{"node", "node-a"}}, Value: 1},
+		{Name: "kube_summary_node_scrape_success", Labels: []labelPair{{"node", "node-b"}}, Value: 0},
+		{Name: "kube_summary_node_runtime_imagefs_used_bytes", Labels: []labelPair{{"node", "node-a"}}, Value: math.NaN()},
+	}
+
+	req := otlpRequest(samples, time.Unix(1669817681, 0))
+	metrics := req.ResourceMetrics[0].ScopeMetrics[0].Metrics
+	if len(metrics) != 1 || metrics[0].Name != "kube_summary_node_scrape_success" {
+		t.Fatalf("otlpRequest() metrics = %+v", metrics)
+	}
+	points := metrics[0].Gauge.DataPoints
+	if len(points) != 2 || points[1].Attributes[0].Value.StringValue != "node-b" || points[1].TimeUnixNano != "1669817681000000000" {
+		t.Errorf("otlpRequest() data points = %+v", points)
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	peerReplicas = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "peer_replicas",
+		Help:      "Number of replicas the nodes were split between in the last background collection cycle with --peers-service, this one included",
+	})
+	peerFetchErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "peer_fetch_errors_total",
+		Help:      "Number of failed fetches of the summaries collected by a peer replica, whose nodes this replica collected instead",
+	},
+		[]string{
+			"peer",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(peerReplicas, peerFetchErrors)
+}
+
+// peers shares the background collection with the other replicas of
+// --peers-service, nil without
+var peers *peerSet
+
+// peerSet shares the background collection between the replicas of the
+// exporter, found behind its headless Service: the nodes are split between
+// them by hash, each replica collecting its share and fetching the summaries
+// of the other shares from the replicas that collected them. A replica that
+// can't be fetched has its share collected by the others, so that every
+// replica keeps serving every node.
+type peerSet struct {
+	service string
+	self    string
+	port    string
+	prefix  string
+	tokens  *tokenFile
+	client  *http.Client
+	lookup  func(ctx context.Context, host string) ([]string, error)
+	// failedPeers are the peers with a peerFetchErrors series, deleted once
+	// they leave the Service. It is only used by the collection loop.
+	failedPeers map[string]bool
+
+	mu        sync.RWMutex
+	published peerDocument
+}
+
+// peerDocument is the response of /peer/summaries: the summaries of the share
+// of a replica, collected in its last background collection cycle
+type peerDocument struct {
+	Timestamp time.Time `json:"timestamp"`
+	// Peers are the replicas the nodes were split between by the replica,
+	// the documents of a replica that split them differently being rejected
+	Peers []string        `json:"peers"`
+	Nodes []persistedNode `json:"nodes"`
+	// Errors are the errors of the nodes of the share that couldn't be
+	// collected, by node
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// newPeerSet returns the peers of the service, this replica being reached at
+// self, $POD_IP if empty, on the port of the listen address
+func newPeerSet(service, self, listenAddress, prefix string, tokens *tokenFile) (*peerSet, error) {
+	if self == "" {
+		self = os.Getenv("POD_IP")
+	}
+	ip := net.ParseIP(self)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid peer address %q, set --peer-address or $POD_IP to the IP of this replica", self)
+	}
+	_, port, err := net.SplitHostPort(listenAddress)
+	if err != nil {
+		return nil, fmt.Errorf("invalid listen address %q: %v", listenAddress, err)
+	}
+	return &peerSet{
+		service: service,
+		self:    ip.String(),
+		port:    port,
+		prefix:  strings.TrimSuffix(prefix, "/"),
+		tokens:  tokens,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		lookup:  net.DefaultResolver.LookupHost,
+
+		failedPeers: map[string]bool{},
+	}, nil
+}
+
+// peerShare is the share of the nodes of this replica in a cycle: the nodes
+// whose shardOf among the peers is the index of this replica
+type peerShare struct {
+	self  int
+	peers []string
+}
+
+// members resolves the replicas behind the service, sorted so that every
+// replica computes the same shares. The share is false if this replica isn't
+// among them, e.g. while it isn't ready yet, in which case it collects every
+// node.
+func (p *peerSet) members(ctx context.Context) (peerShare, bool) {
+	addrs, err := p.lookup(ctx, p.service)
+	if err != nil {
+		fmt.Printf("[Warning] Cannot resolve the peers of %s, collecting every node: %v\n", p.service, err)
+		return peerShare{}, false
+	}
+	var share peerShare
+	for _, addr := range addrs {
+		if ip := net.ParseIP(addr); ip != nil {
+			share.peers = append(share.peers, ip.String())
+		}
+	}
+	sort.Strings(share.peers)
+	share.self = sort.SearchStrings(share.peers, p.self)
+	if share.self == len(share.peers) || share.peers[share.self] != p.self {
+		fmt.Printf("[Warning] This replica, %s, isn't among the peers of %s yet, collecting every node\n", p.self, p.service)
+		return peerShare{}, false
+	}
+	return share, true
+}
+
+type peerShardsKey struct{}
+
+// peerShards are the shards of the peers collected by this replica
+type peerShards struct {
+	shards map[int]bool
+	of     int
+}
+
+// withPeerShards returns a context under which collectNodeStats only collects
+// the nodes of the shards, of the nodes split into of shards
+func withPeerShards(ctx context.Context, shards map[int]bool, of int) context.Context {
+	return context.WithValue(ctx, peerShardsKey{}, peerShards{shards: shards, of: of})
+}
+
+// peerOwned returns whether the node is collected by this replica under the
+// context. Every node is collected without peer shards.
+func peerOwned(ctx context.Context, nodeName string) bool {
+	s, ok := ctx.Value(peerShardsKey{}).(peerShards)
+	return !ok || s.shards[shardOf(nodeName, s.of)]
+}
+
+// exchange publishes the results this replica collected for its share and
+// returns the results of the other shares, fetched from the peers that
+// collected them or, for the peers that couldn't be fetched, collected by this
+// replica. The summaries of a peer older than maxAge are ignored, as its
+// collection loop is stalled. The fetch errors of the peers that left the
+// Service are deleted.
+func (p *peerSet) exchange(ctx context.Context, kubeClient *kubernetes.Clientset, nodesSelector nodeSelectorFunc, share peerShare, results []PerNodeResult, maxAge time.Duration) []PerNodeResult {
+	p.publish(share, results, time.Now())
+	peerReplicas.Set(float64(len(share.peers)))
+
+	for addr := range p.failedPeers {
+		if !slices.Contains(share.peers, addr) {
+			peerFetchErrors.DeleteLabelValues(addr)
+			delete(p.failedPeers, addr)
+		}
+	}
+
+	var (
+		shared []PerNodeResult
+		failed = map[int]bool{}
+	)
+	for i, addr := range share.peers {
+		if i == share.self {
+			continue
+		}
+		fetched, err := p.fetch(ctx, addr, share, maxAge)
+		if err != nil {
+			peerFetchErrors.WithLabelValues(addr).Inc()
+			p.failedPeers[addr] = true
+			fmt.Printf("[Error] Fetching the summaries of peer %s failed, collecting its nodes: %v\n", addr, err)
+			failed[i] = true
+			continue
+		}
+		shared = append(shared, fetched...)
+	}
+	if len(failed) == 0 {
+		return shared
+	}
+
+	collected, err := nodesSelector(withPeerShards(ctx, failed, len(share.peers)), kubeClient)
+	if err != nil {
+		fmt.Printf("[Error] Collecting the nodes of the failed peers failed: %v\n", err)
+	}
+	return append(shared, collected...)
+}
+
+// publish replaces the summaries /peer/summaries serves with the results of
+// the share
+func (p *peerSet) publish(share peerShare, results []PerNodeResult, ts time.Time) {
+	doc := peerDocument{Timestamp: ts.UTC(), Peers: share.peers, Errors: map[string]string{}}
+	var collected []PerNodeResult
+	for _, result := range results {
+		if result.Err != nil {
+			doc.Errors[result.NodeName] = result.Err.Error()
+			continue
+		}
+		collected = append(collected, result)
+	}
+	doc.Nodes = persistedNodes(collected)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.published = doc
+}
+
+// fetch returns the results published by the peer for the share. The
+// results of a peer that split the nodes between other peers, e.g. while the
+// Service is updated during a rollout, are rejected, as they don't cover the
+// nodes of its share in this split.
+func (p *peerSet) fetch(ctx context.Context, addr string, share peerShare, maxAge time.Duration) ([]PerNodeResult, error) {
+	token, err := p.tokens.load()
+	if err != nil {
+		return nil, fmt.Errorf("cannot load the peer token: %v", err)
+	}
+	url := "http://" + net.JoinHostPort(addr, p.port) + p.prefix + "/peer/summaries"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+string(token))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+
+	var doc peerDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid response of %s: %v", url, err)
+	}
+	if age := time.Since(doc.Timestamp); age > maxAge {
+		return nil, fmt.Errorf("the summaries of %s were collected %s ago", url, age.Round(time.Second))
+	}
+	if !slices.Equal(doc.Peers, share.peers) {
+		return nil, fmt.Errorf("%s split the nodes between the peers %v, not %v", url, doc.Peers, share.peers)
+	}
+
+	results := persistedResults(doc.Nodes)
+	for node, msg := range doc.Errors {
+		results = append(results, PerNodeResult{NodeName: node, Err: fmt.Errorf("collected by peer %s: %s", addr, msg)})
+	}
+	return results, nil
+}
+
+// handle serves the summaries published by this replica to its peers, which
+// present the peer token
+func (p *peerSet) handle(w http.ResponseWriter, r *http.Request) {
+	if !checkToken(p.tokens, w, r) {
+		return
+	}
+
+	p.mu.RLock()
+	doc := p.published
+	p.mu.RUnlock()
+
+	if doc.Timestamp.IsZero() {
+		writeError(w, r, http.StatusServiceUnavailable, apiError{Error: "no background collection cycle completed yet", Reason: reasonInternal})
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, doc)
+}
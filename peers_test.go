@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+)
+
+func testPeerSet(t *testing.T, self string, token string) *peerSet {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte(token), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p, err := newPeerSet("exporter.mon.svc", self, ":9779", "/", newTokenFile(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func Test_peerSet_members(t *testing.T) {
+	p := testPeerSet(t, "10.0.0.2", "secret")
+	p.lookup = func(context.Context, string) ([]string, error) {
+		return []string{"10.0.0.3", "10.0.0.1", "10.0.0.2"}, nil
+	}
+	share, ok := p.members(context.Background())
+	if !ok || share.self != 1 || strings.Join(share.peers, ",") != "10.0.0.1,10.0.0.2,10.0.0.3" {
+		t.Errorf("members() = %+v, %v, want 10.0.0.2 second of 3 peers", share, ok)
+	}
+
+	p.lookup = func(context.Context, string) ([]string, error) {
+		return []string{"10.0.0.1", "10.0.0.3"}, nil
+	}
+	if share, ok := p.members(context.Background()); ok {
+		t.Errorf("members() without this replica = %+v, want no share", share)
+	}
+
+	p.lookup = func(context.Context, string) ([]string, error) {
+		return nil, errors.New("no such host")
+	}
+	if share, ok := p.members(context.Background()); ok {
+		t.Errorf("members() of an unresolvable service = %+v, want no share", share)
+	}
+}
+
+func Test_newPeerSet(t *testing.T) {
+	t.Setenv("POD_IP", "10.0.0.7")
+	p, err := newPeerSet("exporter", "", "127.0.0.1:9779", "/metrics/", newTokenFile("token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.self != "10.0.0.7" || p.port != "9779" || p.prefix != "/metrics" {
+		t.Errorf("newPeerSet() = self %q, port %q, prefix %q", p.self, p.port, p.prefix)
+	}
+
+	t.Setenv("POD_IP", "")
+	if _, err := newPeerSet("exporter", "", ":9779", "/", newTokenFile("token")); err == nil {
+		t.Error("newPeerSet() without a peer address succeeded")
+	}
+}
+
+func Test_peerOwned(t *testing.T) {
+	if !peerOwned(context.Background(), "node-a") {
+		t.Error("peerOwned() without peer shards = false, want every node")
+	}
+	nodes := []string{"node-a", "node-b", "node-c", "node-d", "node-e"}
+	for _, node := range nodes {
+		owners := 0
+		for i := 0; i < 3; i++ {
+			if peerOwned(withPeerShards(context.Background(), map[int]bool{i: true}, 3), node) {
+				owners++
+			}
+		}
+		if owners != 1 {
+			t.Errorf("%s is owned by %d of 3 peers, want 1", node, owners)
+		}
+	}
+}
+
+// servePeer serves the peer set on an httptest server, returning its address
+func servePeer(t *testing.T, p *peerSet) string {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(p.handle))
+	t.Cleanup(srv.Close)
+	host, port, err := net.SplitHostPort(strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.port = port
+	return host
+}
+
+func Test_peerSet_fetch(t *testing.T) {
+	var nodeSummary stats.Summary
+	if err := json.Unmarshal(fakekubelet.Fixture("node"), &nodeSummary); err != nil {
+		t.Fatal(err)
+	}
+
+	peer := testPeerSet(t, "127.0.0.1", "secret")
+	addr := servePeer(t, peer)
+	client := testPeerSet(t, "127.0.0.2", "secret")
+	client.port = peer.port
+	share := peerShare{self: 1, peers: []string{"127.0.0.1", "127.0.0.2"}}
+
+	if _, err := client.fetch(context.Background(), addr, share, time.Minute); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("fetch() before a cycle = %v, want 503", err)
+	}
+
+	peer.publish(peerShare{self: 0, peers: share.peers}, []PerNodeResult{
+		{NodeName: "node-a", Summary: &nodeSummary},
+		{NodeName: "node-b", Err: errors.New("connection refused")},
+	}, time.Now())
+	results, err := client.fetch(context.Background(), addr, share, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].NodeName != "node-a" || results[0].Summary == nil || len(results[0].Summary.Pods) != len(nodeSummary.Pods) {
+		t.Fatalf("fetch() = %+v, want the summary of node-a and the error of node-b", results)
+	}
+	if results[1].NodeName != "node-b" || results[1].Err == nil || !strings.Contains(results[1].Err.Error(), "connection refused") {
+		t.Errorf("fetch() error of node-b = %v, want connection refused", results[1].Err)
+	}
+
+	// A peer that sees another replica splits the nodes differently
+	peer.publish(peerShare{self: 0, peers: []string{"127.0.0.1", "127.0.0.2", "127.0.0.3"}}, nil, time.Now())
+	if _, err := client.fetch(context.Background(), addr, share, time.Minute); err == nil || !strings.Contains(err.Error(), "split the nodes") {
+		t.Errorf("fetch() of another split = %v, want an error", err)
+	}
+
+	peer.publish(peerShare{self: 0, peers: share.peers}, nil, time.Now().Add(-time.Hour))
+	if _, err := client.fetch(context.Background(), addr, share, time.Minute); err == nil || !strings.Contains(err.Error(), "ago") {
+		t.Errorf("fetch() of stale summaries = %v, want an error", err)
+	}
+
+	stranger := testPeerSet(t, "127.0.0.3", "wrong")
+	stranger.port = peer.port
+	if _, err := stranger.fetch(context.Background(), addr, share, time.Minute); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("fetch() with a wrong token = %v, want 401", err)
+	}
+}
+
+func Test_peerSet_exchange(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	for _, node := range []string{"node-a", "node-b", "node-c", "node-d"} {
+		srv.AddNode(fakekubelet.Node{Name: node, Summary: fakekubelet.Fixture("node")})
+	}
+
+	// The second peer is unreachable, so this replica collects its share
+	p := testPeerSet(t, "127.0.0.1", "secret")
+	servePeer(t, p)
+	share := peerShare{self: 0, peers: []string{"127.0.0.1", "127.0.0.2"}}
+	p.client.Timeout = time.Second
+
+	owned, err := allNodesSelector(withPeerShards(context.Background(), map[int]bool{0: true}, 2), kubeClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared := p.exchange(context.Background(), kubeClient, allNodesSelector, share, owned, time.Minute)
+	if len(owned)+len(shared) != 4 {
+		t.Fatalf("collected %d and exchanged %d nodes, want 4 nodes", len(owned), len(shared))
+	}
+	for _, result := range shared {
+		if shardOf(result.NodeName, 2) != 1 || result.Err != nil {
+			t.Errorf("exchanged %s (shard %d): %v, want the nodes of the failed peer", result.NodeName, shardOf(result.NodeName, 2), result.Err)
+		}
+	}
+	if n := testutil.CollectAndCount(peerFetchErrors, "kube_summary_peer_fetch_errors_total"); n != 1 {
+		t.Errorf("got %d peer fetch error series, want the one of the failed peer", n)
+	}
+
+	// The fetch errors of a peer are deleted once it leaves the Service
+	p.exchange(context.Background(), kubeClient, allNodesSelector, peerShare{self: 0, peers: []string{"127.0.0.1"}}, owned, time.Minute)
+	if n := testutil.CollectAndCount(peerFetchErrors, "kube_summary_peer_fetch_errors_total"); n != 0 {
+		t.Errorf("got %d peer fetch error series once the peer left, want none", n)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+var cacheRestored = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: metricsNamespace,
+	Name:      "cache_restored",
+	Help:      "Set to 1 while the cache serves the stale summaries restored from --cache-file, until the first collection cycle completes",
+})
+
+func init() {
+	prometheus.MustRegister(cacheRestored)
+}
+
+// persistedCache is the document of the --cache-file
+type persistedCache struct {
+	Timestamp time.Time       `json:"timestamp"`
+	Nodes     []persistedNode `json:"nodes"`
+}
+
+// persistedNode is the last known good result of a node
+type persistedNode struct {
+	Node           string                 `json:"node"`
+	Provider       string                 `json:"provider,omitempty"`
+	KubeletVersion string                 `json:"kubeletVersion,omitempty"`
+	Metadata       summary.NodeMetadata   `json:"metadata"`
+	Conditions     []corev1.NodeCondition `json:"conditions,omitempty"`
+	Labels         map[string]string      `json:"labels,omitempty"`
+	Unschedulable  bool                   `json:"unschedulable,omitempty"`
+	Taints         []corev1.Taint         `json:"taints,omitempty"`
+	Allocatable    corev1.ResourceList    `json:"allocatable,omitempty"`
+	Capacity       corev1.ResourceList    `json:"capacity,omitempty"`
+	Capabilities   map[string]bool        `json:"capabilities,omitempty"`
+	Source         string                 `json:"source,omitempty"`
+	ResponseBytes  int                    `json:"responseBytes,omitempty"`
+	CollectedAt    time.Time              `json:"collectedAt"`
+	Summary        *stats.Summary         `json:"summary"`
+}
+
+// cacheFileSink persists the contents of the cache after every cycle, so that
+// a restarted exporter serves them while its first collection runs. The
+// results of the cycle are ignored: the cache holds the last known good
+// summary of the nodes that failed in the cycle too.
+type cacheFileSink struct {
+	path  string
+	cache *summaryCache
+}
+
+func newCacheFileSink(path string, cache *summaryCache) *cacheFileSink {
+	return &cacheFileSink{path: path, cache: cache}
+}
+
+func (s *cacheFileSink) Name() string {
+	return "cache-file"
+}
+
+// WriteSnapshot replaces the file atomically, so that a crash while writing
+// leaves the previous version in place
+func (s *cacheFileSink) WriteSnapshot(_ context.Context, _ []PerNodeResult, ts time.Time) error {
+	doc := persistedCache{Timestamp: ts.UTC(), Nodes: persistedNodes(s.cache.results())}
+
+	f, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := json.NewEncoder(f).Encode(doc); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.path)
+}
+
+// restoreCacheFile restores the cache from the file written by cacheFileSink,
+// unless it is older than maxAge. A missing file isn't an error, as on the
+// first start.
+func restoreCacheFile(path string, cache *summaryCache, maxAge time.Duration, now time.Time) error {
+	d, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var doc persistedCache
+	if err := json.Unmarshal(d, &doc); err != nil {
+		return fmt.Errorf("invalid cache file %s: %w", path, err)
+	}
+	if age := now.Sub(doc.Timestamp); maxAge > 0 && age > maxAge {
+		fmt.Printf("Ignoring cache file %s written %s ago\n", path, age.Round(time.Second))
+		return nil
+	}
+
+	results := persistedResults(doc.Nodes)
+	cache.restore(results, doc.Timestamp)
+	fmt.Printf("Restored %d nodes from cache file %s written at %s\n", len(results), path, doc.Timestamp.Format(time.RFC3339))
+	return nil
+}
+
+// persistedNodes returns the persisted nodes of the results
+func persistedNodes(results []PerNodeResult) []persistedNode {
+	nodes := make([]persistedNode, 0, len(results))
+	for _, result := range results {
+		nodes = append(nodes, persistedNode{
+			Node:           result.NodeName,
+			Provider:       result.Provider,
+			KubeletVersion: result.KubeletVersion,
+			Metadata:       result.Metadata,
+			Conditions:     result.Conditions,
+			Labels:         result.NodeLabels,
+			Unschedulable:  result.Unschedulable,
+			Taints:         result.Taints,
+			Allocatable:    result.Allocatable,
+			Capacity:       result.Capacity,
+			Capabilities:   result.Capabilities,
+			Source:         result.Source,
+			ResponseBytes:  result.ResponseBytes,
+			CollectedAt:    result.CollectedAt,
+			Summary:        result.Summary,
+		})
+	}
+	return nodes
+}
+
+// persistedResults returns the results of the persisted nodes that have a
+// summary
+func persistedResults(nodes []persistedNode) []PerNodeResult {
+	results := make([]PerNodeResult, 0, len(nodes))
+	for _, node := range nodes {
+		if node.Summary == nil {
+			continue
+		}
+		results = append(results, PerNodeResult{
+			NodeName:       node.Node,
+			Summary:        node.Summary,
+			ResponseBytes:  node.ResponseBytes,
+			CollectedAt:    node.CollectedAt,
+			Provider:       node.Provider,
+			KubeletVersion: node.KubeletVersion,
+			Metadata:       node.Metadata,
+			Conditions:     node.Conditions,
+			NodeLabels:     node.Labels,
+			Unschedulable:  node.Unschedulable,
+			Taints:         node.Taints,
+			Allocatable:    node.Allocatable,
+			Capacity:       node.Capacity,
+			Capabilities:   node.Capabilities,
+			Source:         node.Source,
+		})
+	}
+	return results
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+func Test_cacheFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	used := uint64(42)
+	results := []PerNodeResult{{
+		NodeName:       "node-a",
+		KubeletVersion: "v1.30.0",
+		NodeLabels:     map[string]string{"pool": "a"},
+		Summary: &stats.Summary{
+			Node: stats.NodeStats{NodeName: "node-a"},
+			Pods: []stats.PodStats{{PodRef: stats.PodReference{Name: "p", Namespace: "ns"}, EphemeralStorage: &stats.FsStats{UsedBytes: &used}}},
+		},
+	}}
+
+	cache := newSummaryCache(1)
+	cache.update(results)
+	ts := time.Now().Truncate(time.Second)
+	if err := newCacheFileSink(path, cache).WriteSnapshot(context.Background(), nil, ts); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := newSummaryCache(1)
+	if err := restoreCacheFile(path, restored, time.Hour, ts.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if !restored.ready() || testutil.ToFloat64(cacheRestored) != 1 {
+		t.Errorf("the restored cache isn't ready and flagged as restored")
+	}
+	if diff := cmp.Diff(cache.results(), restored.results(), cmp.Comparer(func(a, b error) bool { return a == b })); diff != "" {
+		t.Errorf("restored cache mismatch (-want +got):\n%s", diff)
+	}
+
+	// The restored entries expire after the first cycle if they don't appear
+	// in it
+	restored.update(nil)
+	if got := restored.results(); len(got) != 0 || testutil.ToFloat64(cacheRestored) != 0 {
+		t.Errorf("restored entries after the first cycle = %v", got)
+	}
+
+	tooOld := newSummaryCache(1)
+	if err := restoreCacheFile(path, tooOld, time.Hour, ts.Add(2*time.Hour)); err != nil || tooOld.ready() {
+		t.Errorf("restoreCacheFile() restored a file older than the maximum age: %v", err)
+	}
+	if err := restoreCacheFile(filepath.Join(t.TempDir(), "missing.json"), newSummaryCache(1), time.Hour, ts); err != nil {
+		t.Errorf("restoreCacheFile() of a missing file failed: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := restoreCacheFile(path, newSummaryCache(1), time.Hour, ts); err == nil {
+		t.Errorf("restoreCacheFile() of an invalid file didn't fail")
+	}
+}
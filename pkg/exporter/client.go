@@ -0,0 +1,238 @@
+// Package exporter fetches the kubelet /stats/summary API through the API
+// server proxy and exports it with the metrics of kube-summary-exporter, as a
+// Client, a prometheus.Collector and an http.Handler, to embed the exporter in
+// another program, e.g. a node agent. Together with package summary it is the
+// public API of the module, versioned semantically: the main package of the
+// exporter isn't part of it.
+package exporter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/pager"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+// ErrSummaryTooLarge is returned for the summaries larger than
+// ClientOptions.MaxSummaryBytes
+var ErrSummaryTooLarge = errors.New("summary exceeds the maximum size")
+
+// DefaultConcurrency is the number of nodes fetched at a time when
+// ClientOptions.Concurrency isn't set
+const DefaultConcurrency = 10
+
+// ClientOptions controls how the summaries are fetched
+type ClientOptions struct {
+	// LabelSelector selects the nodes fetched by Nodes, all of them if empty
+	LabelSelector string
+	// PageSize is the number of nodes listed per request by Nodes, the
+	// default of the client-go pager, 500, if zero
+	PageSize int64
+	// Concurrency is the number of nodes fetched at a time by Nodes,
+	// DefaultConcurrency if zero
+	Concurrency int
+	// KubeletPort is the port of the kubelets in the proxy path, for the
+	// kubelets listening on a port other than the one reported in the node
+	// status. Zero lets the API server pick it.
+	KubeletPort int
+	// MaxSummaryBytes is the maximum size of a summary, decompressed, larger
+	// ones failing with ErrSummaryTooLarge. Zero means no limit.
+	MaxSummaryBytes int64
+	// Gzip asks for gzip compressed responses
+	Gzip bool
+}
+
+// Client fetches the summaries of the nodes through the API server proxy. It is
+// a plain client: the exporter binary collects the summaries on its own, with
+// the retries, direct kubelet access and self metrics of its flags, which a
+// Client has none of.
+type Client struct {
+	kubeClient kubernetes.Interface
+	opts       ClientOptions
+}
+
+// NewClient returns a client fetching the summaries with the kube client
+func NewClient(kubeClient kubernetes.Interface, opts ClientOptions) *Client {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultConcurrency
+	}
+	return &Client{kubeClient: kubeClient, opts: opts}
+}
+
+// Summary fetches the summary of a node, along with the size of the raw
+// response. It is decoded leniently, see DecodeSummary.
+func (c *Client) Summary(ctx context.Context, nodeName string) (*stats.Summary, int, error) {
+	s, _, size, err := c.summary(ctx, nodeName)
+	return s, size, err
+}
+
+// summary fetches the summary of a node, along with its capabilities and the
+// size of the raw response
+func (c *Client) summary(ctx context.Context, nodeName string) (*stats.Summary, map[string]bool, int, error) {
+	stream, err := OpenSummary(ctx, c.kubeClient, nodeName, c.opts.KubeletPort, c.opts.Gzip)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("error querying /stats/summary for %s: %w", nodeName, err)
+	}
+	defer stream.Close()
+
+	resp, err := ReadSummary(stream, c.opts.MaxSummaryBytes)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("error reading /stats/summary response for %s: %w", nodeName, err)
+	}
+	s, capabilities, err := DecodeSummary(resp, nil)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("error unmarshaling /stats/summary response for %s: %w", nodeName, err)
+	}
+	return s, capabilities, len(resp), nil
+}
+
+// Node fetches the summary of the node. A node that fails is returned with
+// its error set, along with what is read from the node object.
+func (c *Client) Node(ctx context.Context, node corev1.Node) summary.NodeResult {
+	result := summary.NewNodeResult(node)
+	s, capabilities, size, err := c.summary(ctx, node.Name)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Summary, result.Capabilities, result.ResponseBytes, result.CollectedAt = s, capabilities, size, time.Now()
+	return result
+}
+
+// Nodes lists the nodes selected by ClientOptions.LabelSelector, in pages of
+// ClientOptions.PageSize, and fetches their summaries,
+// ClientOptions.Concurrency at a time. Only listing the nodes fails as a
+// whole, the nodes that fail being returned with their error set.
+func (c *Client) Nodes(ctx context.Context) ([]summary.NodeResult, error) {
+	nodes, err := c.listNodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing nodes: %w", err)
+	}
+
+	results := make([]summary.NodeResult, len(nodes))
+	var (
+		mu   sync.Mutex
+		next int
+		wg   sync.WaitGroup
+	)
+	for range min(c.opts.Concurrency, len(nodes)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				mu.Lock()
+				i := next
+				next++
+				mu.Unlock()
+				if i >= len(nodes) {
+					return
+				}
+				results[i] = c.Node(ctx, nodes[i])
+			}
+		}()
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// listNodes lists the selected nodes in pages
+func (c *Client) listNodes(ctx context.Context) ([]corev1.Node, error) {
+	return ListNodes(ctx, c.kubeClient, c.opts.LabelSelector, c.opts.PageSize)
+}
+
+// ListNodes lists the nodes matching the label selector, all of them if
+// empty, in pages of pageSize, the default of the client-go pager, 500, if
+// zero, so that the API server isn't asked for a single giant response on
+// large clusters. The pager falls back to a full list if the continue token
+// expires between pages.
+func ListNodes(ctx context.Context, kubeClient kubernetes.Interface, labelSelector string, pageSize int64) ([]corev1.Node, error) {
+	p := pager.New(func(ctx context.Context, opts meta_v1.ListOptions) (runtime.Object, error) {
+		return kubeClient.CoreV1().Nodes().List(ctx, opts)
+	})
+	if pageSize > 0 {
+		p.PageSize = pageSize
+	}
+
+	var nodes []corev1.Node
+	err := p.EachListItem(ctx, meta_v1.ListOptions{LabelSelector: labelSelector}, func(obj runtime.Object) error {
+		node, ok := obj.(*corev1.Node)
+		if !ok {
+			return fmt.Errorf("unexpected object %T in node list", obj)
+		}
+		nodes = append(nodes, *node)
+		return nil
+	})
+	return nodes, err
+}
+
+// ProxyNodeName returns the name of the node in the API server proxy path,
+// with the kubelet port if above zero, for the clusters whose kubelets don't
+// listen on the port reported in the node status
+func ProxyNodeName(nodeName string, kubeletPort int) string {
+	if kubeletPort <= 0 {
+		return nodeName
+	}
+	return nodeName + ":" + strconv.Itoa(kubeletPort)
+}
+
+// OpenSummary opens the /stats/summary response of a node through the API
+// server proxy, asking for a gzip compressed response if gzip is set, which
+// ReadSummary decompresses
+func OpenSummary(ctx context.Context, kubeClient kubernetes.Interface, nodeName string, kubeletPort int, gzip bool) (io.ReadCloser, error) {
+	req := kubeClient.CoreV1().RESTClient().Get().Resource("nodes").Name(ProxyNodeName(nodeName, kubeletPort)).SubResource("proxy").Suffix("stats/summary")
+	if gzip {
+		req.SetHeader("Accept-Encoding", "gzip")
+	}
+	return req.Stream(ctx)
+}
+
+// ReadSummary reads a /stats/summary response until EOF, decompressing it if
+// it's gzip encoded, and fails with ErrSummaryTooLarge as soon as more than
+// maxBytes are read, before or after decompression. A maxBytes of 0 means no
+// limit. The rest client doesn't expose the response headers, so the gzip
+// magic number is used instead of Content-Encoding, which is safe as JSON
+// can't start with it.
+func ReadSummary(r io.Reader, maxBytes int64) ([]byte, error) {
+	body, err := readLimited(r, maxBytes)
+	if err != nil || len(body) < 2 || body[0] != 0x1f || body[1] != 0x8b {
+		return body, err
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return readLimited(zr, maxBytes)
+}
+
+// readLimited reads r until EOF, failing with ErrSummaryTooLarge as soon as
+// more than maxBytes are read. A maxBytes of 0 means no limit.
+func readLimited(r io.Reader, maxBytes int64) ([]byte, error) {
+	if maxBytes <= 0 {
+		return io.ReadAll(r)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > maxBytes {
+		return nil, ErrSummaryTooLarge
+	}
+	return body, nil
+}
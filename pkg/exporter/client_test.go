@@ -0,0 +1,117 @@
+package exporter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+func newTestServer(t *testing.T) (*fakekubelet.Server, kubernetes.Interface) {
+	t.Helper()
+
+	srv := fakekubelet.NewServer()
+	t.Cleanup(srv.Close)
+	kubeClient, err := kubernetes.NewForConfig(srv.Config())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv, kubeClient
+}
+
+func TestClient_Nodes(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", KubeletVersion: "v1.30.2", Summary: fakekubelet.Fixture("node")})
+	srv.AddNode(fakekubelet.Node{Name: "node-b", StatusCode: http.StatusInternalServerError})
+	srv.AddNode(fakekubelet.Node{Name: "node-c", Labels: map[string]string{"pool": "batch"}, Summary: fakekubelet.Fixture("node")})
+
+	results, err := NewClient(kubeClient, ClientOptions{Concurrency: 2, PageSize: 2, Gzip: true}).Nodes(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	if r := results[0]; r.NodeName != "node-a" || r.Err != nil || r.Summary == nil || r.ResponseBytes == 0 || r.KubeletVersion != "v1.30.2" {
+		t.Errorf("got %+v, want the summary of node-a", r)
+	}
+	if r := results[1]; r.NodeName != "node-b" || r.Err == nil || r.Summary != nil {
+		t.Errorf("got %+v, want the error of node-b", r)
+	}
+
+	results, err = NewClient(kubeClient, ClientOptions{LabelSelector: "pool=batch"}).Nodes(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].NodeName != "node-c" {
+		t.Errorf("got %+v, want only node-c selected", results)
+	}
+}
+
+func TestClient_Summary_tooLarge(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Summary: fakekubelet.Fixture("node")})
+
+	if _, _, err := NewClient(kubeClient, ClientOptions{MaxSummaryBytes: 100}).Summary(context.Background(), "node-a"); !errors.Is(err, ErrSummaryTooLarge) {
+		t.Errorf("Summary() of a summary over the limit returned %v, want %v", err, ErrSummaryTooLarge)
+	}
+}
+
+func TestReadSummary(t *testing.T) {
+	plain := []byte(`{"pods":[]}`)
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(plain); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, body := range map[string][]byte{"plain": plain, "gzip": buf.Bytes()} {
+		got, err := ReadSummary(bytes.NewReader(body), 0)
+		if err != nil {
+			t.Fatalf("ReadSummary(%s) unexpected error: %v", name, err)
+		}
+		if diff := cmp.Diff(string(plain), string(got)); diff != "" {
+			t.Errorf("ReadSummary(%s) mismatch (-want +got):\n%s", name, diff)
+		}
+	}
+
+	if _, err := ReadSummary(bytes.NewReader(buf.Bytes()), int64(len(plain)-1)); !errors.Is(err, ErrSummaryTooLarge) {
+		t.Errorf("ReadSummary() decompressed over the limit returned %v, want %v", err, ErrSummaryTooLarge)
+	}
+	if _, err := ReadSummary(bytes.NewReader(plain), int64(len(plain))); err != nil {
+		t.Errorf("ReadSummary() at the limit returned %v", err)
+	}
+	if _, err := ReadSummary(bytes.NewReader(plain), int64(len(plain)-1)); !errors.Is(err, ErrSummaryTooLarge) {
+		t.Errorf("ReadSummary() over the limit returned %v, want %v", err, ErrSummaryTooLarge)
+	}
+}
+
+func TestClient_Summary_lenient(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Summary: []byte(`{"node":{"nodeName":"node-a","cpu":{"usageNanoCores":"100"},"swap":{"swapUsageBytes":1}},"pods":[{"podRef":{"name":"pod-a","namespace":"default"}}]}`)})
+
+	r := NewClient(kubeClient, ClientOptions{}).Node(context.Background(), corev1.Node{ObjectMeta: meta_v1.ObjectMeta{Name: "node-a"}})
+	if r.Err != nil {
+		t.Fatalf("Node() of a summary with a field of an unexpected type returned %v, want it skipped", r.Err)
+	}
+	if r.Summary.Node.NodeName != "node-a" || len(r.Summary.Pods) != 1 {
+		t.Errorf("got %+v, want the rest of the summary decoded", r.Summary)
+	}
+	if !r.Capabilities[summary.CapabilitySwap] {
+		t.Errorf("got capabilities %v, want swap", r.Capabilities)
+	}
+}
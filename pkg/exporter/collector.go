@@ -0,0 +1,81 @@
+package exporter
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+// CollectorOptions controls the metrics of a Collector
+type CollectorOptions struct {
+	// Options are the options the summaries are mapped with
+	Options summary.Options
+	// Timeout bounds the fetch of all the nodes on every collection, no
+	// timeout if zero
+	Timeout time.Duration
+}
+
+// Collector is a prometheus.Collector fetching the summaries of the nodes on
+// every collection, to register the metrics of the exporter with the
+// registry of another program. It is unchecked: the metrics depend on the
+// summaries of the nodes, which aren't known in advance.
+type Collector struct {
+	client *Client
+	opts   CollectorOptions
+}
+
+// NewCollector returns a collector fetching the summaries with the client
+func NewCollector(client *Client, opts CollectorOptions) *Collector {
+	return &Collector{client: client, opts: opts}
+}
+
+// Describe sends no descriptor, the collector being unchecked
+func (c *Collector) Describe(chan<- *prometheus.Desc) {}
+
+// Collect fetches the summaries of all the nodes and sends their metrics. A
+// failure to list the nodes is sent as an invalid metric, failing the
+// gathering.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	ctx := context.Background()
+	if c.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.opts.Timeout)
+		defer cancel()
+	}
+
+	results, err := c.client.Nodes(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(prometheus.NewDesc(summary.Namespace+"_error", "Error collecting the summaries", nil, nil), err)
+		return
+	}
+	collectResults(results, c.opts.Options, ch)
+}
+
+// collectResults sends the metrics of the results
+func collectResults(results []summary.NodeResult, opts summary.Options, ch chan<- prometheus.Metric) {
+	var collectors collectorList
+	summary.Collect(results, &collectors, opts)
+	for _, collector := range collectors {
+		collector.Collect(ch)
+	}
+}
+
+// collectorList is a prometheus.Registerer keeping the collectors summary.Collect
+// registers, so that their metrics are sent by another collector
+type collectorList []prometheus.Collector
+
+func (l *collectorList) Register(c prometheus.Collector) error {
+	*l = append(*l, c)
+	return nil
+}
+
+func (l *collectorList) MustRegister(cs ...prometheus.Collector) {
+	*l = append(*l, cs...)
+}
+
+func (l *collectorList) Unregister(prometheus.Collector) bool {
+	return false
+}
@@ -0,0 +1,41 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+func TestCollector(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Summary: fakekubelet.Fixture("node")})
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(NewCollector(NewClient(kubeClient, ClientOptions{}), CollectorOptions{
+		Options: summary.Options{ExtraLabels: prometheus.Labels{"cluster": "edge-1"}},
+		Timeout: 10 * time.Second,
+	}))
+
+	want := `# HELP kube_summary_node_scrape_success Whether the /stats/summary of the node was collected successfully
+# TYPE kube_summary_node_scrape_success gauge
+kube_summary_node_scrape_success{cluster="edge-1",kubelet_version="",node="node-a"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(want), "kube_summary_node_scrape_success"); err != nil {
+		t.Error(err)
+	}
+	if n, err := testutil.GatherAndCount(registry, "kube_summary_pod_ephemeral_storage_used_bytes"); err != nil || n != 2 {
+		t.Errorf("got %d pod series (%v), want 2", n, err)
+	}
+
+	// Failing to list the nodes fails the gathering
+	srv.Close()
+	if _, err := registry.Gather(); err == nil {
+		t.Error("Gather() succeeded without an API server")
+	}
+}
@@ -0,0 +1,76 @@
+package exporter
+
+import (
+	"encoding/json"
+	"errors"
+
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+// rawSummary decodes the pods of the summary while keeping the node stats raw,
+// so that fields unknown to the stats package, like PSI, can be detected
+// without decoding the whole response twice
+type rawSummary struct {
+	Node json.RawMessage  `json:"node"`
+	Pods []stats.PodStats `json:"pods"`
+}
+
+// nodeCapabilities holds the optional fields of the node stats
+type nodeCapabilities struct {
+	Swap    json.RawMessage `json:"swap"`
+	CPU     psiStats        `json:"cpu"`
+	Memory  psiStats        `json:"memory"`
+	IO      psiStats        `json:"io"`
+	Runtime struct {
+		ContainerFs json.RawMessage `json:"containerFs"`
+	} `json:"runtime"`
+}
+
+type psiStats struct {
+	PSI json.RawMessage `json:"psi"`
+}
+
+// DecodeSummary decodes a /stats/summary response leniently: fields missing
+// from older kubelets are left empty, unknown fields of newer ones are
+// ignored, and a field of an unexpected type is skipped, and passed to
+// onTypeError if not nil, instead of failing the whole summary. It also
+// returns which summary.Capabilities the response has.
+func DecodeSummary(data []byte, onTypeError func(*json.UnmarshalTypeError)) (*stats.Summary, map[string]bool, error) {
+	lenient := func(err error) error {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			if onTypeError != nil {
+				onTypeError(typeErr)
+			}
+			return nil
+		}
+		return err
+	}
+
+	var raw rawSummary
+	if err := lenient(json.Unmarshal(data, &raw)); err != nil {
+		return nil, nil, err
+	}
+
+	s := &stats.Summary{Pods: raw.Pods}
+	var c nodeCapabilities
+	if len(raw.Node) > 0 {
+		if err := lenient(json.Unmarshal(raw.Node, &s.Node)); err != nil {
+			return nil, nil, err
+		}
+		if err := lenient(json.Unmarshal(raw.Node, &c)); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	present := func(m json.RawMessage) bool {
+		return len(m) > 0 && string(m) != "null"
+	}
+	return s, map[string]bool{
+		summary.CapabilitySwap:        present(c.Swap),
+		summary.CapabilityPSI:         present(c.CPU.PSI) || present(c.Memory.PSI) || present(c.IO.PSI),
+		summary.CapabilityContainerFs: present(c.Runtime.ContainerFs),
+	}, nil
+}
@@ -0,0 +1,49 @@
+package exporter_test
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/exporter"
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+// The metrics of the exporter can be registered with the registry of an
+// agent
+func ExampleNewCollector() {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	kubeClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	client := exporter.NewClient(kubeClient, exporter.ClientOptions{Gzip: true, MaxSummaryBytes: 50 << 20})
+	prometheus.MustRegister(exporter.NewCollector(client, exporter.CollectorOptions{
+		Options: summary.Options{MaxPodsPerNode: 100},
+		Timeout: 30 * time.Second,
+	}))
+}
+
+// Or the collection endpoints of the exporter served by its HTTP server
+func ExampleNewServer() {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	kubeClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/kube-summary/", http.StripPrefix("/kube-summary", exporter.NewServer(exporter.NewClient(kubeClient, exporter.ClientOptions{}), summary.Options{})))
+	log.Fatal(http.ListenAndServe(":9779", mux))
+}
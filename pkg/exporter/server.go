@@ -0,0 +1,86 @@
+package exporter
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+// Server is a minimal http.Handler of the metrics of the summaries: /nodes
+// exports the metrics of all the nodes and /node/{node} the ones of a single
+// node, in the format negotiated with the scraper. A collection is bounded by
+// the X-Prometheus-Scrape-Timeout-Seconds header of the scrape. It isn't the
+// router of the exporter binary, and has none of its other endpoints, cache,
+// coalescing or backpressure.
+type Server struct {
+	client *Client
+	opts   summary.Options
+	mux    *http.ServeMux
+}
+
+// NewServer returns a server fetching the summaries with the client and
+// mapping them with the options
+func NewServer(client *Client, opts summary.Options) *Server {
+	s := &Server{client: client, opts: opts, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /nodes", s.handleNodes)
+	s.mux.HandleFunc("GET /node/{node}", s.handleNode)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleNodes(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := scrapeContext(r)
+	defer cancel()
+
+	results, err := s.client.Nodes(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.write(w, r, results)
+}
+
+func (s *Server) handleNode(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := scrapeContext(r)
+	defer cancel()
+
+	node, err := s.client.kubeClient.CoreV1().Nodes().Get(ctx, r.PathValue("node"), meta_v1.GetOptions{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	result := s.client.Node(ctx, *node)
+	if result.Err != nil {
+		http.Error(w, result.Err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.write(w, r, []summary.NodeResult{result})
+}
+
+// write writes the metrics of the results
+func (s *Server) write(w http.ResponseWriter, r *http.Request, results []summary.NodeResult) {
+	registry := prometheus.NewRegistry()
+	summary.Collect(results, registry, s.opts)
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+}
+
+// scrapeContext returns the context of the request, with the timeout of its
+// X-Prometheus-Scrape-Timeout-Seconds header if set
+func scrapeContext(r *http.Request) (context.Context, context.CancelFunc) {
+	if v := r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds"); v != "" {
+		if seconds, err := strconv.ParseFloat(v, 64); err == nil {
+			return context.WithTimeout(r.Context(), time.Duration(seconds*float64(time.Second)))
+		}
+	}
+	return context.WithCancel(r.Context())
+}
@@ -0,0 +1,47 @@
+package exporter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+func TestServer(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Summary: fakekubelet.Fixture("node")})
+	srv.AddNode(fakekubelet.Node{Name: "node-b", StatusCode: http.StatusServiceUnavailable})
+	s := NewServer(NewClient(kubeClient, ClientOptions{}), summary.Options{})
+
+	for _, tc := range []struct {
+		target   string
+		code     int
+		contains []string
+	}{
+		{"/nodes", http.StatusOK, []string{
+			`kube_summary_node_scrape_success{kubelet_version="",node="node-a"} 1`,
+			`kube_summary_node_scrape_success{kubelet_version="",node="node-b"} 0`,
+		}},
+		{"/node/node-a", http.StatusOK, []string{`kube_summary_node_scrape_success{kubelet_version="",node="node-a"} 1`}},
+		{"/node/node-b", http.StatusInternalServerError, []string{"error querying /stats/summary for node-b"}},
+		{"/node/node-c", http.StatusInternalServerError, []string{`nodes "node-c" not found`}},
+		{"/metrics", http.StatusNotFound, nil},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+		req.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", "10")
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+
+		if rec.Code != tc.code {
+			t.Errorf("GET %s returned %d, want %d: %s", tc.target, rec.Code, tc.code, rec.Body)
+		}
+		for _, want := range tc.contains {
+			if !strings.Contains(rec.Body.String(), want) {
+				t.Errorf("GET %s returned %q, want it to contain %q", tc.target, rec.Body, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,485 @@
+package summary
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Stability levels of the metrics
+const (
+	// StabilityStable metrics are only renamed or removed after a release in
+	// which they are deprecated
+	StabilityStable = "stable"
+	// StabilityBeta metrics aren't expected to change, but may without a
+	// deprecation
+	StabilityBeta = "beta"
+	// StabilityAlpha metrics may change or be removed at any time
+	StabilityAlpha = "alpha"
+	// StabilityDeprecated metrics are renamed to their ReplacedBy and only
+	// exported with Options.DeprecatedMetrics, until they are removed
+	StabilityDeprecated = "deprecated"
+)
+
+// Types of the metrics
+const (
+	MetricTypeGauge     = "gauge"
+	MetricTypeHistogram = "histogram"
+)
+
+// MetricInfo describes a metric mapped from the summaries
+type MetricInfo struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Help string `json:"help"`
+	// NodeLevel metrics have the node level labels, node, kubelet_version
+	// and, with Options.NodeMetadataLabels, os, arch and instance_type,
+	// before their Labels
+	NodeLevel bool     `json:"nodeLevel"`
+	Labels    []string `json:"labels"`
+	Stability string   `json:"stability"`
+	// ReplacedBy is the metric a deprecated metric was renamed to, which
+	// has the same labels and values
+	ReplacedBy string `json:"replacedBy,omitempty"`
+}
+
+// labelNames returns the labels of the metric with the options
+func (m MetricInfo) labelNames(opts Options) []string {
+	if m.NodeLevel {
+		return nodeLabelNames(opts, m.Labels...)
+	}
+	return m.Labels
+}
+
+// metrics is the catalog of the metrics Collect exports, from which their
+// collectors are created. A stable metric must not be renamed in place: it
+// is added under its new name, while the old one is kept as
+// StabilityDeprecated with ReplacedBy set.
+var metrics = append([]MetricInfo{
+	{
+		Name:      "kube_summary_container_logs_inodes_free",
+		Type:      MetricTypeGauge,
+		Help:      "Number of available Inodes for logs",
+		Labels:    []string{"node", "pod", "namespace", "name"},
+		Stability: StabilityStable,
+	},
+	{
+		Name:      "kube_summary_container_logs_inodes",
+		Type:      MetricTypeGauge,
+		Help:      "Number of Inodes for logs",
+		Labels:    []string{"node", "pod", "namespace", "name"},
+		Stability: StabilityStable,
+	},
+	{
+		Name:      "kube_summary_container_logs_inodes_used",
+		Type:      MetricTypeGauge,
+		Help:      "Number of used Inodes for logs",
+		Labels:    []string{"node", "pod", "namespace", "name"},
+		Stability: StabilityStable,
+	},
+	{
+		Name:      "kube_summary_container_logs_available_bytes",
+		Type:      MetricTypeGauge,
+		Help:      "Number of bytes that aren't consumed by the container logs",
+		Labels:    []string{"node", "pod", "namespace", "name"},
+		Stability: StabilityStable,
+	},
+	{
+		Name:      "kube_summary_container_logs_capacity_bytes",
+		Type:      MetricTypeGauge,
+		Help:      "Number of bytes that can be consumed by the container logs",
+		Labels:    []string{"node", "pod", "namespace", "name"},
+		Stability: StabilityStable,
+	},
+	{
+		Name:      "kube_summary_container_logs_used_bytes",
+		Type:      MetricTypeGauge,
+		Help:      "Number of bytes that are consumed by the container logs",
+		Labels:    []string{"node", "pod", "namespace", "name"},
+		Stability: StabilityStable,
+	},
+	{
+		Name:      "kube_summary_container_logs_used_ratio",
+		Type:      MetricTypeGauge,
+		Help:      "Ratio of the bytes consumed by the container logs to the containerLogMaxSize of the kubelet, above 1 once rotated logs are kept",
+		Labels:    []string{"node", "pod", "namespace", "name"},
+		Stability: StabilityAlpha,
+	},
+	{
+		Name:      "kube_summary_container_rootfs_inodes_free",
+		Type:      MetricTypeGauge,
+		Help:      "Number of available Inodes",
+		Labels:    []string{"node", "pod", "namespace", "name"},
+		Stability: StabilityStable,
+	},
+	{
+		Name:      "kube_summary_container_rootfs_inodes",
+		Type:      MetricTypeGauge,
+		Help:      "Number of Inodes",
+		Labels:    []string{"node", "pod", "namespace", "name"},
+		Stability: StabilityStable,
+	},
+	{
+		Name:      "kube_summary_container_rootfs_inodes_used",
+		Type:      MetricTypeGauge,
+		Help:      "Number of used Inodes",
+		Labels:    []string{"node", "pod", "namespace", "name"},
+		Stability: StabilityStable,
+	},
+	{
+		Name:      "kube_summary_container_rootfs_available_bytes",
+		Type:      MetricTypeGauge,
+		Help:      "Number of bytes that aren't consumed by the container",
+		Labels:    []string{"node", "pod", "namespace", "name"},
+		Stability: StabilityStable,
+	},
+	{
+		Name:      "kube_summary_container_rootfs_capacity_bytes",
+		Type:      MetricTypeGauge,
+		Help:      "Number of bytes that can be consumed by the container",
+		Labels:    []string{"node", "pod", "namespace", "name"},
+		Stability: StabilityStable,
+	},
+	{
+		Name:      "kube_summary_container_rootfs_used_bytes",
+		Type:      MetricTypeGauge,
+		Help:      "Number of bytes that are consumed by the container",
+		Labels:    []string{"node", "pod", "namespace", "name"},
+		Stability: StabilityStable,
+	},
+	{
+		Name:      "kube_summary_pod_ephemeral_storage_available_bytes",
+		Type:      MetricTypeGauge,
+		Help:      "Number of bytes of Ephemeral storage that aren't consumed by the pod",
+		Labels:    []string{"node", "pod", "namespace"},
+		Stability: StabilityStable,
+	},
+	{
+		Name:      "kube_summary_pod_ephemeral_storage_capacity_bytes",
+		Type:      MetricTypeGauge,
+		Help:      "Number of bytes of Ephemeral storage that can be consumed by the pod",
+		Labels:    []string{"node", "pod", "namespace"},
+		Stability: StabilityStable,
+	},
+	{
+		Name:      "kube_summary_pod_info",
+		Type:      MetricTypeGauge,
+		Help:      "Information about the pod, set to 1, with its UID to tell apart recreated pods with the same name",
+		Labels:    []string{"node", "pod", "namespace", "uid"},
+		Stability: StabilityAlpha,
+	},
+	{
+		Name:      "kube_summary_pod_mirror",
+		Type:      MetricTypeGauge,
+		Help:      "Set to 1 for the mirror pods of the static pods, with --mirror-pods=label",
+		Labels:    []string{"node", "pod", "namespace"},
+		Stability: StabilityAlpha,
+	},
+	{
+		Name:      "kube_summary_pod_ephemeral_storage_used_bytes",
+		Type:      MetricTypeGauge,
+		Help:      "Number of bytes of Ephemeral storage that are consumed by the pod",
+		Labels:    []string{"node", "pod", "namespace"},
+		Stability: StabilityStable,
+	},
+	{
+		Name:      "kube_summary_pod_ephemeral_storage_inodes_free",
+		Type:      MetricTypeGauge,
+		Help:      "Number of available Inodes for pod Ephemeral storage",
+		Labels:    []string{"node", "pod", "namespace"},
+		Stability: StabilityStable,
+	},
+	{
+		Name:      "kube_summary_pod_ephemeral_storage_inodes",
+		Type:      MetricTypeGauge,
+		Help:      "Number of Inodes for pod Ephemeral storage",
+		Labels:    []string{"node", "pod", "namespace"},
+		Stability: StabilityStable,
+	},
+	{
+		Name:      "kube_summary_pod_ephemeral_storage_inodes_used",
+		Type:      MetricTypeGauge,
+		Help:      "Number of used Inodes for pod Ephemeral storage",
+		Labels:    []string{"node", "pod", "namespace"},
+		Stability: StabilityStable,
+	},
+	{
+		Name:      "kube_summary_pod_volumes",
+		Type:      MetricTypeGauge,
+		Help:      "Number of volumes of the pod reported by the kubelet, projected, secret and configmap volumes included",
+		Labels:    []string{"node", "pod", "namespace"},
+		Stability: StabilityAlpha,
+	},
+	{
+		Name:      "kube_summary_pod_largest_volume_used_bytes",
+		Type:      MetricTypeGauge,
+		Help:      "Number of bytes that are consumed by the largest volume of the pod",
+		Labels:    []string{"node", "pod", "namespace"},
+		Stability: StabilityAlpha,
+	},
+	{
+		Name:      "kube_summary_node_runtime_imagefs_available_bytes",
+		Type:      MetricTypeGauge,
+		Help:      "Number of bytes of node Runtime ImageFS that aren't consumed",
+		NodeLevel: true,
+		Stability: StabilityStable,
+	},
+	{
+		Name:      "kube_summary_node_runtime_imagefs_capacity_bytes",
+		Type:      MetricTypeGauge,
+		Help:      "Number of bytes of node Runtime ImageFS that can be consumed",
+		NodeLevel: true,
+		Stability: StabilityStable,
+	},
+	{
+		Name:      "kube_summary_node_runtime_imagefs_used_bytes",
+		Type:      MetricTypeGauge,
+		Help:      "Number of bytes of node Runtime ImageFS that are consumed",
+		NodeLevel: true,
+		Stability: StabilityStable,
+	},
+	{
+		Name:      "kube_summary_node_runtime_imagefs_inodes_free",
+		Type:      MetricTypeGauge,
+		Help:      "Number of available Inodes for node Runtime ImageFS",
+		NodeLevel: true,
+		Stability: StabilityStable,
+	},
+	{
+		Name:      "kube_summary_node_runtime_imagefs_inodes",
+		Type:      MetricTypeGauge,
+		Help:      "Number of Inodes for node Runtime ImageFS",
+		NodeLevel: true,
+		Stability: StabilityStable,
+	},
+	{
+		Name:      "kube_summary_node_runtime_imagefs_inodes_used",
+		Type:      MetricTypeGauge,
+		Help:      "Number of used Inodes for node Runtime ImageFS",
+		NodeLevel: true,
+		Stability: StabilityStable,
+	},
+	{
+		Name:      "kube_summary_node_response_bytes",
+		Type:      MetricTypeGauge,
+		Help:      "Size in bytes of the /stats/summary response of the node",
+		NodeLevel: true,
+		Stability: StabilityAlpha,
+	},
+	{
+		Name:      "kube_summary_node_scrape_success",
+		Type:      MetricTypeGauge,
+		Help:      "Whether the /stats/summary of the node was collected successfully",
+		NodeLevel: true,
+		Stability: StabilityBeta,
+	},
+	{
+		Name:      "kube_summary_node_partial_summary",
+		Type:      MetricTypeGauge,
+		Help:      "Set to 1 for nodes whose provider only partially supports the summary API, the unsupported sections aren't exported",
+		NodeLevel: true,
+		Labels:    []string{"provider"},
+		Stability: StabilityAlpha,
+	},
+	{
+		Name:      "kube_summary_node_pod_ephemeral_storage_used_bytes",
+		Type:      MetricTypeHistogram,
+		Help:      "Distribution of the Ephemeral storage consumed by the pods of the node",
+		NodeLevel: true,
+		Stability: StabilityAlpha,
+	},
+	{
+		Name:      "kube_summary_node_summary_capability",
+		Type:      MetricTypeGauge,
+		Help:      "Whether the summary of the node has the optional stats of the capability, among " + strings.Join(Capabilities, ", "),
+		NodeLevel: true,
+		Labels:    []string{"capability"},
+		Stability: StabilityAlpha,
+	},
+	{
+		Name:      "kube_summary_node_condition",
+		Type:      MetricTypeGauge,
+		Help:      "Whether the condition of the node status is true, for the Ready, DiskPressure, MemoryPressure and PIDPressure conditions",
+		NodeLevel: true,
+		Labels:    []string{"condition"},
+		Stability: StabilityAlpha,
+	},
+	{
+		Name:      "kube_summary_node_container_log_files",
+		Type:      MetricTypeGauge,
+		Help:      "Approximate number of log files of all the containers of the node, from the Inodes used by their logs",
+		NodeLevel: true,
+		Stability: StabilityAlpha,
+	},
+	{
+		Name:      "kube_summary_node_omitted_pods",
+		Type:      MetricTypeGauge,
+		Help:      "Number of pods whose series were omitted because of the per node pod limit",
+		NodeLevel: true,
+		Stability: StabilityAlpha,
+	},
+	{
+		Name:      "kube_summary_node_omitted_pods_ephemeral_storage_used_bytes",
+		Type:      MetricTypeGauge,
+		Help:      "Number of bytes of Ephemeral storage that are consumed by the omitted pods",
+		NodeLevel: true,
+		Stability: StabilityAlpha,
+	},
+}, slices.Concat(resourceMetrics(), schedulingMetrics(), resourceUsageMetrics())...)
+
+// Catalog returns the metrics Collect may export with the options, the
+// deprecated and custom metrics included, with the labels and help text they are
+// exported with
+func Catalog(opts Options) []MetricInfo {
+	catalog := make([]MetricInfo, 0, len(metrics))
+	for _, m := range metrics {
+		m.Help = opts.help(m)
+		m.Labels = m.labelNames(opts)
+		catalog = append(catalog, m)
+	}
+	for _, m := range customMetricsInfo(opts.CustomMetrics) {
+		m.Labels = m.labelNames(opts)
+		catalog = append(catalog, m)
+	}
+	return catalog
+}
+
+// ValidateHelpOverrides returns an error if a help text is overridden for a
+// metric that isn't in the catalog
+func ValidateHelpOverrides(overrides map[string]string) error {
+	for name := range overrides {
+		if _, ok := lookupMetric(name); !ok {
+			return fmt.Errorf("unknown metric %q", name)
+		}
+	}
+	return nil
+}
+
+// lookupMetric returns the catalog entry of a metric
+func lookupMetric(name string) (MetricInfo, bool) {
+	i := slices.IndexFunc(metrics, func(m MetricInfo) bool { return m.Name == name })
+	if i < 0 {
+		return MetricInfo{}, false
+	}
+	return metrics[i], true
+}
+
+// help returns the help text of the metric, overridden by HelpOverrides
+func (opts Options) help(m MetricInfo) string {
+	if help, ok := opts.HelpOverrides[m.Name]; ok {
+		return help
+	}
+	if m.Stability == StabilityDeprecated {
+		return "Deprecated, use " + m.ReplacedBy + ": " + m.Help
+	}
+	return m.Help
+}
+
+// collectorBuilder creates the collectors of the metrics from the catalog, and
+// registers them along with the deprecated metrics they replace
+type collectorBuilder struct {
+	registry   prometheus.Registerer
+	opts       Options
+	collectors map[string]prometheus.Collector
+}
+
+func newCollectorBuilder(registry prometheus.Registerer, opts Options) *collectorBuilder {
+	return &collectorBuilder{registry: registry, opts: opts, collectors: map[string]prometheus.Collector{}}
+}
+
+// metric returns the catalog entry of a metric, which must be in the catalog
+func (b *collectorBuilder) metric(name, typ string) MetricInfo {
+	m, ok := lookupMetric(name)
+	if !ok || m.Type != typ {
+		panic(fmt.Sprintf("%s %s isn't in the metric catalog", typ, name))
+	}
+	return m
+}
+
+func (b *collectorBuilder) gaugeVec(name string) *prometheus.GaugeVec {
+	m := b.metric(name, MetricTypeGauge)
+	vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: m.Name, Help: b.opts.help(m)}, m.labelNames(b.opts))
+	b.collectors[name] = vec
+	return vec
+}
+
+func (b *collectorBuilder) histogramVec(name string, buckets []float64) *prometheus.HistogramVec {
+	m := b.metric(name, MetricTypeHistogram)
+	vec := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:                        m.Name,
+		Help:                        b.opts.help(m),
+		Buckets:                     buckets,
+		NativeHistogramBucketFactor: 1.1,
+	}, m.labelNames(b.opts))
+	b.collectors[name] = vec
+	return vec
+}
+
+// register registers the collectors that were created, in the order of the
+// catalog, and the deprecated metrics replaced by them with
+// Options.DeprecatedMetrics
+func (b *collectorBuilder) register() {
+	for _, m := range metrics {
+		if m.Stability == StabilityDeprecated {
+			if c, ok := b.collectors[m.ReplacedBy]; ok && b.opts.DeprecatedMetrics {
+				desc := prometheus.NewDesc(m.Name, b.opts.help(m), m.labelNames(b.opts), nil)
+				b.registry.MustRegister(renamedCollector{collector: c, desc: desc})
+			}
+			continue
+		}
+		if c, ok := b.collectors[m.Name]; ok {
+			b.registry.MustRegister(c)
+		}
+	}
+}
+
+// renamedCollector exports the metrics of a collector under another name
+type renamedCollector struct {
+	collector prometheus.Collector
+	desc      *prometheus.Desc
+}
+
+func (c renamedCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c renamedCollector) Collect(ch chan<- prometheus.Metric) {
+	metrics := make(chan prometheus.Metric)
+	go func() {
+		c.collector.Collect(metrics)
+		close(metrics)
+	}()
+	for metric := range metrics {
+		var pb dto.Metric
+		if err := metric.Write(&pb); err != nil {
+			ch <- prometheus.NewInvalidMetric(c.desc, err)
+			continue
+		}
+		ch <- renamedMetric{desc: c.desc, pb: &pb}
+	}
+}
+
+// renamedMetric is a metric written by another collector, with the
+// descriptor of its new name
+type renamedMetric struct {
+	desc *prometheus.Desc
+	pb   *dto.Metric
+}
+
+func (m renamedMetric) Desc() *prometheus.Desc {
+	return m.desc
+}
+
+func (m renamedMetric) Write(out *dto.Metric) error {
+	out.Label = m.pb.Label
+	out.Gauge = m.pb.Gauge
+	out.Counter = m.pb.Counter
+	out.Histogram = m.pb.Histogram
+	out.Summary = m.pb.Summary
+	out.Untyped = m.pb.Untyped
+	out.TimestampMs = m.pb.TimestampMs
+	return nil
+}
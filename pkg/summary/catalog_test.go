@@ -0,0 +1,105 @@
+package summary
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestCatalog(t *testing.T) {
+	seen := map[string]bool{}
+	for _, m := range Catalog(Options{}) {
+		if seen[m.Name] {
+			t.Errorf("%s is in the catalog twice", m.Name)
+		}
+		seen[m.Name] = true
+		if !strings.HasPrefix(m.Name, Namespace+"_") || m.Help == "" || m.Stability == "" {
+			t.Errorf("incomplete catalog entry %+v", m)
+		}
+		if m.Stability == StabilityDeprecated {
+			if _, ok := lookupMetric(m.ReplacedBy); !ok {
+				t.Errorf("%s is replaced by %q, which isn't in the catalog", m.Name, m.ReplacedBy)
+			}
+		}
+	}
+
+	// Every exported metric is in the catalog
+	results := []NodeResult{{
+		NodeName:     "node-a",
+		Summary:      fixtureSummary(t),
+		Capabilities: map[string]bool{},
+		Conditions:   []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		Capacity:     corev1.ResourceList{corev1.ResourcePods: resource.MustParse("110")},
+	}}
+	families, err := Gather(results, Options{NodeResources: true, NodeScheduling: true, NodeTaints: []string{"example.com/drain"}, PodInfo: true, ContainerLogMaxSize: 1 << 20, MaxPodsPerNode: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mf := range families {
+		if !seen[mf.GetName()] {
+			t.Errorf("%s isn't in the catalog", mf.GetName())
+		}
+	}
+
+	catalog := Catalog(Options{NodeMetadataLabels: true, HelpOverrides: map[string]string{"kube_summary_node_scrape_success": "See the runbook"}})
+	for _, m := range catalog {
+		if m.Name != "kube_summary_node_scrape_success" {
+			continue
+		}
+		if got := strings.Join(m.Labels, ","); got != "node,kubelet_version,os,arch,instance_type" {
+			t.Errorf("labels = %s", got)
+		}
+		if m.Help != "See the runbook" {
+			t.Errorf("help = %q, want the override", m.Help)
+		}
+	}
+}
+
+func TestCollect_deprecatedMetrics(t *testing.T) {
+	defer func(m []MetricInfo) { metrics = m }(metrics)
+	metrics = append(metrics[:len(metrics):len(metrics)], MetricInfo{
+		Name:       "kube_summary_node_scrape_ok",
+		Type:       MetricTypeGauge,
+		Help:       "Whether the /stats/summary of the node was collected successfully",
+		NodeLevel:  true,
+		Stability:  StabilityDeprecated,
+		ReplacedBy: "kube_summary_node_scrape_success",
+	})
+	results := []NodeResult{{NodeName: "node-a", KubeletVersion: "v1.30.2"}}
+
+	families, err := Gather(results, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mf := range families {
+		if mf.GetName() == "kube_summary_node_scrape_ok" {
+			t.Error("deprecated metric exported without DeprecatedMetrics")
+		}
+	}
+
+	registry := prometheus.NewPedanticRegistry()
+	Collect(results, registry, Options{DeprecatedMetrics: true})
+	want := `# HELP kube_summary_node_scrape_ok Deprecated, use kube_summary_node_scrape_success: Whether the /stats/summary of the node was collected successfully
+# TYPE kube_summary_node_scrape_ok gauge
+kube_summary_node_scrape_ok{kubelet_version="v1.30.2",node="node-a"} 1
+# HELP kube_summary_node_scrape_success Whether the /stats/summary of the node was collected successfully
+# TYPE kube_summary_node_scrape_success gauge
+kube_summary_node_scrape_success{kubelet_version="v1.30.2",node="node-a"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(want), "kube_summary_node_scrape_ok", "kube_summary_node_scrape_success"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestValidateHelpOverrides(t *testing.T) {
+	if err := ValidateHelpOverrides(map[string]string{"kube_summary_pod_info": "Pods"}); err != nil {
+		t.Errorf("ValidateHelpOverrides() = %v", err)
+	}
+	if err := ValidateHelpOverrides(map[string]string{"kube_summary_pod_infos": "Pods"}); err == nil {
+		t.Error("ValidateHelpOverrides() accepted an unknown metric")
+	}
+}
@@ -0,0 +1,351 @@
+package summary
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// Collect registers the metrics mapped from the summaries of the nodes with
+// the registry. The registry must be a new one for each call: Collect panics
+// if its collectors are registered already, e.g. when it is called twice with
+// the same registry. Gather uses a registry of its own.
+func Collect(results []NodeResult, registry prometheus.Registerer, opts Options) {
+	if len(opts.ExtraLabels) > 0 {
+		registry = prometheus.WrapRegistererWith(opts.ExtraLabels, registry)
+	}
+	ephemeralStorageBuckets := opts.EphemeralStorageBuckets
+	if ephemeralStorageBuckets == nil {
+		ephemeralStorageBuckets = DefaultEphemeralStorageBuckets
+	}
+	b := newCollectorBuilder(registry, opts)
+
+	var (
+		containerLogsInodesFree                  = b.gaugeVec("kube_summary_container_logs_inodes_free")
+		containerLogsInodes                      = b.gaugeVec("kube_summary_container_logs_inodes")
+		containerLogsInodesUsed                  = b.gaugeVec("kube_summary_container_logs_inodes_used")
+		containerLogsAvailableBytes              = b.gaugeVec("kube_summary_container_logs_available_bytes")
+		containerLogsCapacityBytes               = b.gaugeVec("kube_summary_container_logs_capacity_bytes")
+		containerLogsUsedBytes                   = b.gaugeVec("kube_summary_container_logs_used_bytes")
+		containerLogsUsedRatio                   = b.gaugeVec("kube_summary_container_logs_used_ratio")
+		containerRootFsInodesFree                = b.gaugeVec("kube_summary_container_rootfs_inodes_free")
+		containerRootFsInodes                    = b.gaugeVec("kube_summary_container_rootfs_inodes")
+		containerRootFsInodesUsed                = b.gaugeVec("kube_summary_container_rootfs_inodes_used")
+		containerRootFsAvailableBytes            = b.gaugeVec("kube_summary_container_rootfs_available_bytes")
+		containerRootFsCapacityBytes             = b.gaugeVec("kube_summary_container_rootfs_capacity_bytes")
+		containerRootFsUsedBytes                 = b.gaugeVec("kube_summary_container_rootfs_used_bytes")
+		podEphemeralStorageAvailableBytes        = b.gaugeVec("kube_summary_pod_ephemeral_storage_available_bytes")
+		podEphemeralStorageCapacityBytes         = b.gaugeVec("kube_summary_pod_ephemeral_storage_capacity_bytes")
+		podInfo                                  = b.gaugeVec("kube_summary_pod_info")
+		podMirror                                = b.gaugeVec("kube_summary_pod_mirror")
+		podEphemeralStorageUsedBytes             = b.gaugeVec("kube_summary_pod_ephemeral_storage_used_bytes")
+		podEphemeralStorageInodesFree            = b.gaugeVec("kube_summary_pod_ephemeral_storage_inodes_free")
+		podEphemeralStorageInodes                = b.gaugeVec("kube_summary_pod_ephemeral_storage_inodes")
+		podEphemeralStorageInodesUsed            = b.gaugeVec("kube_summary_pod_ephemeral_storage_inodes_used")
+		podVolumes                               = b.gaugeVec("kube_summary_pod_volumes")
+		podLargestVolumeUsedBytes                = b.gaugeVec("kube_summary_pod_largest_volume_used_bytes")
+		nodeRuntimeImageFSAvailableBytes         = b.gaugeVec("kube_summary_node_runtime_imagefs_available_bytes")
+		nodeRuntimeImageFSCapacityBytes          = b.gaugeVec("kube_summary_node_runtime_imagefs_capacity_bytes")
+		nodeRuntimeImageFSUsedBytes              = b.gaugeVec("kube_summary_node_runtime_imagefs_used_bytes")
+		nodeRuntimeImageFSInodesFree             = b.gaugeVec("kube_summary_node_runtime_imagefs_inodes_free")
+		nodeRuntimeImageFSInodes                 = b.gaugeVec("kube_summary_node_runtime_imagefs_inodes")
+		nodeRuntimeImageFSInodesUsed             = b.gaugeVec("kube_summary_node_runtime_imagefs_inodes_used")
+		nodeResponseBytes                        = b.gaugeVec("kube_summary_node_response_bytes")
+		nodeScrapeSuccess                        = b.gaugeVec("kube_summary_node_scrape_success")
+		nodePartialSummary                       = b.gaugeVec("kube_summary_node_partial_summary")
+		nodePodEphemeralStorageUsedBytes         = b.histogramVec("kube_summary_node_pod_ephemeral_storage_used_bytes", ephemeralStorageBuckets)
+		nodeSummaryCapability                    = b.gaugeVec("kube_summary_node_summary_capability")
+		nodeCondition                            = b.gaugeVec("kube_summary_node_condition")
+		nodeContainerLogFiles                    = b.gaugeVec("kube_summary_node_container_log_files")
+		nodeOmittedPods                          = b.gaugeVec("kube_summary_node_omitted_pods")
+		nodeOmittedPodsEphemeralStorageUsedBytes = b.gaugeVec("kube_summary_node_omitted_pods_ephemeral_storage_used_bytes")
+	)
+	if opts.NodeResources {
+		collectNodeResources(results, b)
+	}
+	if opts.NodeScheduling {
+		collectNodeScheduling(results, b)
+	}
+	var usage *resourceUsage
+	if opts.ResourceUsage {
+		usage = newResourceUsage(b)
+	}
+	b.register()
+	custom := newCustomMetrics(registry, opts)
+
+	// keep returns whether a value of the section is reported and, unless
+	// zero values are omitted for the section, non zero
+	keep := func(section string, value *uint64) bool {
+		return value != nil && (*value != 0 || !opts.OmitZeroValues[section])
+	}
+
+	for _, entry := range results {
+		nodeName := entry.NodeName
+		nodeValues := nodeLabelValues(entry, opts)
+		summary := entry.Summary
+
+		if entry.Err != nil {
+			nodeScrapeSuccess.WithLabelValues(nodeValues...).Set(0)
+		} else {
+			nodeScrapeSuccess.WithLabelValues(nodeValues...).Set(1)
+		}
+		for _, condition := range entry.Conditions {
+			if !exportedConditions[condition.Type] {
+				continue
+			}
+			var value float64
+			if condition.Status == corev1.ConditionTrue {
+				value = 1
+			}
+			nodeCondition.WithLabelValues(nodeLabelValues(entry, opts, string(condition.Type))...).Set(value)
+		}
+		if summary == nil {
+			continue
+		}
+		// The opted out pods are dropped before the node level series, which
+		// don't account for them either, as in the exporter binary
+		if opts.IsOptedOutPod != nil && slices.ContainsFunc(summary.Pods, func(pod stats.PodStats) bool {
+			return opts.IsOptedOutPod(pod.PodRef.Namespace, pod.PodRef.Name)
+		}) {
+			dropped := *summary
+			dropped.Pods = slices.DeleteFunc(slices.Clone(summary.Pods), func(pod stats.PodStats) bool {
+				return opts.IsOptedOutPod(pod.PodRef.Namespace, pod.PodRef.Name)
+			})
+			summary = &dropped
+		}
+
+		if usage != nil {
+			usage.node(entry, summary.Node)
+		}
+
+		unsupported := UnsupportedSections(entry.Provider)
+		if len(unsupported) > 0 {
+			nodePartialSummary.WithLabelValues(nodeLabelValues(entry, opts, entry.Provider)...).Set(1)
+		}
+
+		// skip holds the sections that are unsupported or not selected
+		skip := make(map[string]bool, len(Sections))
+		for _, section := range Sections {
+			skip[section] = unsupported[section] || (opts.Sections != nil && !opts.Sections[section])
+		}
+
+		if entry.Capabilities != nil {
+			for _, capability := range Capabilities {
+				var value float64
+				if entry.Capabilities[capability] {
+					value = 1
+				}
+				nodeSummaryCapability.WithLabelValues(nodeLabelValues(entry, opts, capability)...).Set(value)
+			}
+		}
+
+		if entry.ResponseBytes > 0 {
+			nodeResponseBytes.WithLabelValues(nodeValues...).Set(float64(entry.ResponseBytes))
+		}
+
+		if !skip[SectionPodEphemeralStorage] {
+			for _, pod := range summary.Pods {
+				if pod.EphemeralStorage != nil && pod.EphemeralStorage.UsedBytes != nil {
+					nodePodEphemeralStorageUsedBytes.WithLabelValues(nodeValues...).Observe(float64(*pod.EphemeralStorage.UsedBytes))
+				}
+			}
+		}
+
+		// The log files of the node are totalled over all its pods, the
+		// omitted ones included. A log file takes a single inode, the
+		// kubelet reporting the inodes of the log files of each container.
+		if !skip[SectionContainerLogs] {
+			var logFiles uint64
+			var reported bool
+			for _, pod := range summary.Pods {
+				for _, container := range pod.Containers {
+					if container.Logs != nil && container.Logs.InodesUsed != nil {
+						logFiles += *container.Logs.InodesUsed
+						reported = true
+					}
+				}
+			}
+			if reported && keep(SectionContainerLogs, &logFiles) {
+				nodeContainerLogFiles.WithLabelValues(nodeValues...).Set(float64(logFiles))
+			}
+		}
+
+		pods := summary.Pods
+		// The dropped mirror pods don't take the slots of the pods exported
+		// by MaxPodsPerNode, nor count as omitted
+		if opts.MirrorPods == MirrorPodsDrop && opts.IsMirrorPod != nil {
+			pods = slices.DeleteFunc(slices.Clone(pods), func(pod stats.PodStats) bool {
+				return opts.IsMirrorPod(pod.PodRef.Namespace, pod.PodRef.Name)
+			})
+		}
+		if opts.MaxPodsPerNode > 0 && len(pods) > opts.MaxPodsPerNode {
+			var omitted []stats.PodStats
+			pods, omitted = topPodsByEphemeralStorage(pods, opts.MaxPodsPerNode)
+
+			var omittedUsedBytes uint64
+			for _, pod := range omitted {
+				omittedUsedBytes += EphemeralStorageUsedBytes(pod)
+			}
+			nodeOmittedPods.WithLabelValues(nodeValues...).Set(float64(len(omitted)))
+			nodeOmittedPodsEphemeralStorageUsedBytes.WithLabelValues(nodeValues...).Set(float64(omittedUsedBytes))
+		}
+		if len(custom) > 0 {
+			exported := *summary
+			exported.Pods = pods
+			collectCustomMetrics(custom, entry, &exported, opts)
+		}
+
+		for _, pod := range pods {
+			if opts.MirrorPods == MirrorPodsLabel && opts.IsMirrorPod != nil && opts.IsMirrorPod(pod.PodRef.Namespace, pod.PodRef.Name) {
+				podMirror.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace).Set(1)
+			}
+			if opts.PodInfo {
+				podInfo.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace, pod.PodRef.UID).Set(1)
+			}
+			if usage != nil {
+				usage.pod(entry, pod)
+			}
+			for _, container := range pod.Containers {
+				if logs := container.Logs; logs != nil && !skip[SectionContainerLogs] {
+					if inodesFree := logs.InodesFree; keep(SectionContainerLogs, inodesFree) {
+						containerLogsInodesFree.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace, container.Name).Set(float64(*inodesFree))
+					}
+					if inodes := logs.Inodes; keep(SectionContainerLogs, inodes) {
+						containerLogsInodes.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace, container.Name).Set(float64(*inodes))
+					}
+					if inodesUsed := logs.InodesUsed; keep(SectionContainerLogs, inodesUsed) {
+						containerLogsInodesUsed.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace, container.Name).Set(float64(*inodesUsed))
+					}
+					if availableBytes := logs.AvailableBytes; keep(SectionContainerLogs, availableBytes) {
+						containerLogsAvailableBytes.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace, container.Name).Set(float64(*availableBytes))
+					}
+					if capacityBytes := logs.CapacityBytes; keep(SectionContainerLogs, capacityBytes) {
+						containerLogsCapacityBytes.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace, container.Name).Set(float64(*capacityBytes))
+					}
+					if usedBytes := logs.UsedBytes; keep(SectionContainerLogs, usedBytes) {
+						containerLogsUsedBytes.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace, container.Name).Set(float64(*usedBytes))
+						if opts.ContainerLogMaxSize > 0 {
+							containerLogsUsedRatio.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace, container.Name).Set(float64(*usedBytes) / float64(opts.ContainerLogMaxSize))
+						}
+					}
+				}
+				if rootfs := container.Rootfs; rootfs != nil && !skip[SectionContainerRootfs] {
+					if inodesFree := rootfs.InodesFree; keep(SectionContainerRootfs, inodesFree) {
+						containerRootFsInodesFree.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace, container.Name).Set(float64(*inodesFree))
+					}
+					if inodes := rootfs.Inodes; keep(SectionContainerRootfs, inodes) {
+						containerRootFsInodes.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace, container.Name).Set(float64(*inodes))
+					}
+					if inodesUsed := rootfs.InodesUsed; keep(SectionContainerRootfs, inodesUsed) {
+						containerRootFsInodesUsed.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace, container.Name).Set(float64(*inodesUsed))
+					}
+					if availableBytes := rootfs.AvailableBytes; keep(SectionContainerRootfs, availableBytes) {
+						containerRootFsAvailableBytes.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace, container.Name).Set(float64(*availableBytes))
+					}
+					if capacityBytes := rootfs.CapacityBytes; keep(SectionContainerRootfs, capacityBytes) {
+						containerRootFsCapacityBytes.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace, container.Name).Set(float64(*capacityBytes))
+					}
+					if usedBytes := rootfs.UsedBytes; keep(SectionContainerRootfs, usedBytes) {
+						containerRootFsUsedBytes.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace, container.Name).Set(float64(*usedBytes))
+					}
+				}
+			}
+
+			if ephemeralStorage := pod.EphemeralStorage; ephemeralStorage != nil && !skip[SectionPodEphemeralStorage] {
+				if keep(SectionPodEphemeralStorage, ephemeralStorage.AvailableBytes) {
+					podEphemeralStorageAvailableBytes.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace).Set(float64(*ephemeralStorage.AvailableBytes))
+				}
+				if keep(SectionPodEphemeralStorage, ephemeralStorage.CapacityBytes) {
+					podEphemeralStorageCapacityBytes.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace).Set(float64(*ephemeralStorage.CapacityBytes))
+				}
+				if keep(SectionPodEphemeralStorage, ephemeralStorage.UsedBytes) {
+					podEphemeralStorageUsedBytes.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace).Set(float64(*ephemeralStorage.UsedBytes))
+				}
+				if keep(SectionPodEphemeralStorage, ephemeralStorage.InodesFree) {
+					podEphemeralStorageInodesFree.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace).Set(float64(*ephemeralStorage.InodesFree))
+				}
+				if keep(SectionPodEphemeralStorage, ephemeralStorage.Inodes) {
+					podEphemeralStorageInodes.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace).Set(float64(*ephemeralStorage.Inodes))
+				}
+				if keep(SectionPodEphemeralStorage, ephemeralStorage.InodesUsed) {
+					podEphemeralStorageInodesUsed.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace).Set(float64(*ephemeralStorage.InodesUsed))
+				}
+			}
+
+			// The volumes tell the pods with so many projected or secret
+			// volumes that they slow down the disk scans of the kubelet
+			if len(pod.VolumeStats) > 0 && !skip[SectionPodEphemeralStorage] {
+				podVolumes.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace).Set(float64(len(pod.VolumeStats)))
+				if largest := largestVolumeUsedBytes(pod); keep(SectionPodEphemeralStorage, largest) {
+					podLargestVolumeUsedBytes.WithLabelValues(nodeName, pod.PodRef.Name, pod.PodRef.Namespace).Set(float64(*largest))
+				}
+			}
+		}
+
+		if runtime := summary.Node.Runtime; runtime != nil && runtime.ImageFs != nil && !skip[SectionNodeRuntimeImageFS] {
+			if keep(SectionNodeRuntimeImageFS, runtime.ImageFs.AvailableBytes) {
+				nodeRuntimeImageFSAvailableBytes.WithLabelValues(nodeValues...).Set(float64(*runtime.ImageFs.AvailableBytes))
+			}
+			if keep(SectionNodeRuntimeImageFS, runtime.ImageFs.CapacityBytes) {
+				nodeRuntimeImageFSCapacityBytes.WithLabelValues(nodeValues...).Set(float64(*runtime.ImageFs.CapacityBytes))
+			}
+			if keep(SectionNodeRuntimeImageFS, runtime.ImageFs.UsedBytes) {
+				nodeRuntimeImageFSUsedBytes.WithLabelValues(nodeValues...).Set(float64(*runtime.ImageFs.UsedBytes))
+			}
+			if keep(SectionNodeRuntimeImageFS, runtime.ImageFs.InodesFree) {
+				nodeRuntimeImageFSInodesFree.WithLabelValues(nodeValues...).Set(float64(*runtime.ImageFs.InodesFree))
+			}
+			if keep(SectionNodeRuntimeImageFS, runtime.ImageFs.Inodes) {
+				nodeRuntimeImageFSInodes.WithLabelValues(nodeValues...).Set(float64(*runtime.ImageFs.Inodes))
+			}
+			if keep(SectionNodeRuntimeImageFS, runtime.ImageFs.InodesUsed) {
+				nodeRuntimeImageFSInodesUsed.WithLabelValues(nodeValues...).Set(float64(*runtime.ImageFs.InodesUsed))
+			}
+		}
+	}
+}
+
+// exportedConditions are the node conditions exported alongside the summary,
+// so that disk usage can be correlated with the kubelet's pressure signals
+var exportedConditions = map[corev1.NodeConditionType]bool{
+	corev1.NodeReady:          true,
+	corev1.NodeDiskPressure:   true,
+	corev1.NodeMemoryPressure: true,
+	corev1.NodePIDPressure:    true,
+}
+
+// largestVolumeUsedBytes returns the bytes used by the largest volume of the
+// pod, nil if none of its volumes reports them
+func largestVolumeUsedBytes(pod stats.PodStats) *uint64 {
+	var largest *uint64
+	for _, volume := range pod.VolumeStats {
+		if volume.UsedBytes != nil && (largest == nil || *volume.UsedBytes > *largest) {
+			largest = volume.UsedBytes
+		}
+	}
+	return largest
+}
+
+// topPodsByEphemeralStorage splits pods into the k largest ephemeral storage
+// consumers and the remainder. The input slice is not modified.
+func topPodsByEphemeralStorage(pods []stats.PodStats, k int) ([]stats.PodStats, []stats.PodStats) {
+	sorted := make([]stats.PodStats, len(pods))
+	copy(sorted, pods)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return EphemeralStorageUsedBytes(sorted[i]) > EphemeralStorageUsedBytes(sorted[j])
+	})
+
+	return sorted[:k], sorted[k:]
+}
+
+// EphemeralStorageUsedBytes returns the ephemeral storage used by a pod, or 0
+// if the kubelet didn't report it
+func EphemeralStorageUsedBytes(pod stats.PodStats) uint64 {
+	if pod.EphemeralStorage == nil || pod.EphemeralStorage.UsedBytes == nil {
+		return 0
+	}
+	return *pod.EphemeralStorage.UsedBytes
+}
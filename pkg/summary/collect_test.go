@@ -0,0 +1,113 @@
+package summary
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+func Test_topPodsByEphemeralStorage(t *testing.T) {
+	used := func(name string, b uint64) stats.PodStats {
+		return stats.PodStats{
+			PodRef:           stats.PodReference{Name: name},
+			EphemeralStorage: &stats.FsStats{UsedBytes: &b},
+		}
+	}
+	pods := []stats.PodStats{
+		used("small", 10),
+		{PodRef: stats.PodReference{Name: "unreported"}},
+		used("large", 1000),
+		used("medium", 100),
+	}
+
+	top, rest := topPodsByEphemeralStorage(pods, 2)
+
+	names := func(pods []stats.PodStats) []string {
+		var out []string
+		for _, p := range pods {
+			out = append(out, p.PodRef.Name)
+		}
+		return out
+	}
+	if diff := cmp.Diff([]string{"large", "medium"}, names(top)); diff != "" {
+		t.Errorf("topPodsByEphemeralStorage() top mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"small", "unreported"}, names(rest)); diff != "" {
+		t.Errorf("topPodsByEphemeralStorage() rest mismatch (-want +got):\n%s", diff)
+	}
+	if pods[0].PodRef.Name != "small" {
+		t.Errorf("topPodsByEphemeralStorage() modified its input")
+	}
+}
+
+func TestCollect_logFiles(t *testing.T) {
+	results := []NodeResult{{NodeName: "node-a", Summary: fixtureSummary(t)}}
+	registry := prometheus.NewPedanticRegistry()
+	Collect(results, registry, Options{MaxPodsPerNode: 1})
+
+	// The node total counts the log files of the omitted pod too
+	want := `# HELP kube_summary_node_container_log_files Approximate number of log files of all the containers of the node, from the Inodes used by their logs
+# TYPE kube_summary_node_container_log_files gauge
+kube_summary_node_container_log_files{kubelet_version="",node="node-a"} 3
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(want), "kube_summary_node_container_log_files"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollect_optedOutPods(t *testing.T) {
+	one := uint64(1)
+	pod := func(name string) stats.PodStats {
+		return stats.PodStats{
+			PodRef:     stats.PodReference{Namespace: "apps", Name: name},
+			Containers: []stats.ContainerStats{{Name: "app", Logs: &stats.FsStats{InodesUsed: &one}}},
+		}
+	}
+	results := []NodeResult{{NodeName: "node-a", Summary: &stats.Summary{Pods: []stats.PodStats{pod("web"), pod("batch")}}}}
+	registry := prometheus.NewPedanticRegistry()
+	Collect(results, registry, Options{IsOptedOutPod: func(namespace, name string) bool { return name == "batch" }})
+
+	// The node total leaves the log files of the opted out pod out
+	want := `# HELP kube_summary_node_container_log_files Approximate number of log files of all the containers of the node, from the Inodes used by their logs
+# TYPE kube_summary_node_container_log_files gauge
+kube_summary_node_container_log_files{kubelet_version="",node="node-a"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(want), "kube_summary_node_container_log_files"); err != nil {
+		t.Error(err)
+	}
+	if len(results[0].Summary.Pods) != 2 {
+		t.Error("Collect() modified the summary of its results")
+	}
+}
+
+func TestCollect_volumes(t *testing.T) {
+	small, large := uint64(4096), uint64(1<<30)
+	results := []NodeResult{{NodeName: "node-a", Summary: &stats.Summary{Pods: []stats.PodStats{
+		{
+			PodRef: stats.PodReference{Name: "many-secrets", Namespace: "ns"},
+			VolumeStats: []stats.VolumeStats{
+				{Name: "secret-a", FsStats: stats.FsStats{UsedBytes: &small}},
+				{Name: "cache", FsStats: stats.FsStats{UsedBytes: &large}},
+				{Name: "pending"},
+			},
+		},
+		{PodRef: stats.PodReference{Name: "no-volumes", Namespace: "ns"}},
+	}}}}
+	registry := prometheus.NewPedanticRegistry()
+	Collect(results, registry, Options{})
+
+	want := `# HELP kube_summary_pod_largest_volume_used_bytes Number of bytes that are consumed by the largest volume of the pod
+# TYPE kube_summary_pod_largest_volume_used_bytes gauge
+kube_summary_pod_largest_volume_used_bytes{namespace="ns",node="node-a",pod="many-secrets"} 1.073741824e+09
+# HELP kube_summary_pod_volumes Number of volumes of the pod reported by the kubelet, projected, secret and configmap volumes included
+# TYPE kube_summary_pod_volumes gauge
+kube_summary_pod_volumes{namespace="ns",node="node-a",pod="many-secrets"} 3
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(want), "kube_summary_pod_volumes", "kube_summary_pod_largest_volume_used_bytes"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,236 @@
+package summary
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"k8s.io/client-go/util/jsonpath"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// CustomMetric is a gauge read from the summaries with JSONPath expressions,
+// in the syntax of kubectl, so that the fields the kubelets add can be
+// exported without waiting for them to be mapped by the catalog
+type CustomMetric struct {
+	Name string `json:"name"`
+	// Help is the help text of the metric, a description of Value if empty
+	Help string `json:"help,omitempty"`
+	// Path selects the objects of the summary with a series each, e.g.
+	// {.pods[*]}, the summary itself if empty
+	Path string `json:"path,omitempty"`
+	// Value is the value of the series, relative to the object, e.g.
+	// {.swap.swapUsageBytes}. Numbers, booleans and RFC 3339 timestamps,
+	// exported as Unix seconds, are supported, the object not having a
+	// series without.
+	Value string `json:"value"`
+	// Labels are the labels of the series, after the node level labels, by
+	// name, their values being the text of JSONPath expressions relative to
+	// the object, e.g. {.podRef.namespace}
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// labelNames returns the names of the Labels, sorted
+func (m CustomMetric) labelNames() []string {
+	names := make([]string, 0, len(m.Labels))
+	for name := range m.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// help returns the help text of the metric
+func (m CustomMetric) help() string {
+	if m.Help != "" {
+		return m.Help
+	}
+	return "Custom metric read from the summary at " + m.Value
+}
+
+// ValidateCustomMetrics returns an error if a custom metric has an invalid
+// name, label or JSONPath expression, or clashes with a metric of the catalog
+// or another custom metric
+func ValidateCustomMetrics(custom []CustomMetric) error {
+	reserved := nodeLabelNames(Options{NodeMetadataLabels: true})
+	seen := map[string]bool{}
+	for i, m := range custom {
+		switch {
+		case !model.IsValidMetricName(model.LabelValue(m.Name)):
+			return fmt.Errorf("custom metric %d has an invalid name %q", i, m.Name)
+		case seen[m.Name]:
+			return fmt.Errorf("custom metric %s is defined twice", m.Name)
+		case m.Value == "":
+			return fmt.Errorf("custom metric %s has no value", m.Name)
+		}
+		if _, ok := lookupMetric(m.Name); ok {
+			return fmt.Errorf("custom metric %s clashes with a metric of the catalog", m.Name)
+		}
+		seen[m.Name] = true
+
+		for name, expr := range m.Labels {
+			if !model.LabelName(name).IsValid() || slices.Contains(reserved, name) {
+				return fmt.Errorf("custom metric %s has an invalid label %q, the node level labels are reserved", m.Name, name)
+			}
+			if _, err := parseJSONPath(m.Name, expr); err != nil {
+				return fmt.Errorf("custom metric %s has an invalid label %s: %w", m.Name, name, err)
+			}
+		}
+		for _, expr := range []string{m.Path, m.Value} {
+			if expr == "" {
+				continue
+			}
+			if _, err := parseJSONPath(m.Name, expr); err != nil {
+				return fmt.Errorf("custom metric %s: %w", m.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// customMetricsInfo returns the catalog entries of the custom metrics
+func customMetricsInfo(custom []CustomMetric) []MetricInfo {
+	infos := make([]MetricInfo, 0, len(custom))
+	for _, m := range custom {
+		infos = append(infos, MetricInfo{Name: m.Name, Type: MetricTypeGauge, Help: m.help(), NodeLevel: true, Labels: m.labelNames(), Stability: StabilityAlpha})
+	}
+	return infos
+}
+
+func parseJSONPath(name, expr string) (*jsonpath.JSONPath, error) {
+	jp := jsonpath.New(name).AllowMissingKeys(true)
+	if err := jp.Parse(expr); err != nil {
+		return nil, fmt.Errorf("invalid JSONPath %q: %w", expr, err)
+	}
+	return jp, nil
+}
+
+// customMetric is a custom metric with its compiled expressions
+type customMetric struct {
+	vec    *prometheus.GaugeVec
+	path   *jsonpath.JSONPath
+	value  *jsonpath.JSONPath
+	labels []*jsonpath.JSONPath
+}
+
+// newCustomMetrics registers the collectors of the Options.CustomMetrics,
+// none if they don't pass ValidateCustomMetrics. The metrics with a label of
+// the Options.ExtraLabels are skipped.
+func newCustomMetrics(registry prometheus.Registerer, opts Options) []customMetric {
+	if ValidateCustomMetrics(opts.CustomMetrics) != nil {
+		return nil
+	}
+	var custom []customMetric
+	for _, m := range opts.CustomMetrics {
+		if slices.ContainsFunc(m.labelNames(), func(name string) bool { _, ok := opts.ExtraLabels[name]; return ok }) {
+			continue
+		}
+		c := customMetric{vec: prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: m.Name, Help: m.help()}, nodeLabelNames(opts, m.labelNames()...))}
+		if m.Path != "" {
+			c.path, _ = parseJSONPath(m.Name, m.Path)
+		}
+		c.value, _ = parseJSONPath(m.Name, m.Value)
+		for _, name := range m.labelNames() {
+			jp, _ := parseJSONPath(m.Name, m.Labels[name])
+			c.labels = append(c.labels, jp)
+		}
+		registry.MustRegister(c.vec)
+		custom = append(custom, c)
+	}
+	return custom
+}
+
+// collectCustomMetrics collects the custom metrics of the summary of a node,
+// whose pods are the ones Collect exports, so that the opted out pods and the
+// pods beyond Options.MaxPodsPerNode aren't exported by the custom metrics
+// either. The objects whose expressions fail are skipped.
+func collectCustomMetrics(custom []customMetric, entry NodeResult, summary *stats.Summary, opts Options) {
+	// The expressions are evaluated over the JSON document of the summary,
+	// whose field names they use
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return
+	}
+	for _, c := range custom {
+		c.collect(entry, doc, opts)
+	}
+}
+
+// collect sets the series of the objects of the summary document of the node
+func (c customMetric) collect(entry NodeResult, doc interface{}, opts Options) {
+	objects := []interface{}{doc}
+	if c.path != nil {
+		found, err := c.path.FindResults(doc)
+		if err != nil {
+			return
+		}
+		objects = objects[:0]
+		for _, values := range found {
+			for _, v := range values {
+				objects = append(objects, v.Interface())
+			}
+		}
+	}
+
+	for _, object := range objects {
+		found, err := c.value.FindResults(object)
+		if err != nil || len(found) == 0 || len(found[0]) == 0 {
+			continue
+		}
+		value, ok := customValue(found[0][0])
+		if !ok {
+			continue
+		}
+		labels := make([]string, 0, len(c.labels))
+		for _, jp := range c.labels {
+			var buf bytes.Buffer
+			if err := jp.Execute(&buf, object); err != nil {
+				buf.Reset()
+			}
+			labels = append(labels, buf.String())
+		}
+		c.vec.WithLabelValues(nodeLabelValues(entry, opts, labels...)...).Set(value)
+	}
+}
+
+// customValue returns the value of a custom metric selected in a summary
+// document
+func customValue(v reflect.Value) (float64, bool) {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return 0, false
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Bool:
+		if v.Bool() {
+			return 1, true
+		}
+		return 0, true
+	case reflect.String:
+		if t, err := time.Parse(time.RFC3339, v.String()); err == nil {
+			return float64(t.UnixNano()) / 1e9, true
+		}
+		f, err := strconv.ParseFloat(v.String(), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
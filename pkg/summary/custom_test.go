@@ -0,0 +1,117 @@
+package summary
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+func TestCollect_customMetrics(t *testing.T) {
+	swap, rlimit := uint64(4096), int64(1000)
+	results := []NodeResult{{
+		NodeName: "node-a",
+		Summary: &stats.Summary{
+			Node: stats.NodeStats{
+				StartTime: metav1.NewTime(time.Unix(1700000000, 0)),
+				Rlimit:    &stats.RlimitStats{NumOfRunningProcesses: &rlimit},
+			},
+			Pods: []stats.PodStats{
+				{PodRef: stats.PodReference{Namespace: "apps", Name: "app-0"}, Swap: &stats.SwapStats{SwapUsageBytes: &swap}},
+				{PodRef: stats.PodReference{Namespace: "apps", Name: "app-1"}},
+			},
+		},
+	}}
+	custom := []CustomMetric{
+		{Name: "node_processes", Help: "Number of processes of the node", Value: "{.node.rlimit.curproc}"},
+		{Name: "node_start_time_seconds", Value: "{.node.startTime}"},
+		{
+			Name:   "pod_swap_usage_bytes",
+			Help:   "Swap used by the pod",
+			Path:   "{.pods[*]}",
+			Value:  "{.swap.swapUsageBytes}",
+			Labels: map[string]string{"pod": "{.podRef.name}", "namespace": "{.podRef.namespace}"},
+		},
+	}
+	registry := prometheus.NewPedanticRegistry()
+	Collect(results, registry, Options{CustomMetrics: custom})
+
+	want := `# HELP node_processes Number of processes of the node
+# TYPE node_processes gauge
+node_processes{kubelet_version="",node="node-a"} 1000
+# HELP node_start_time_seconds Custom metric read from the summary at {.node.startTime}
+# TYPE node_start_time_seconds gauge
+node_start_time_seconds{kubelet_version="",node="node-a"} 1.7e+09
+# HELP pod_swap_usage_bytes Swap used by the pod
+# TYPE pod_swap_usage_bytes gauge
+pod_swap_usage_bytes{kubelet_version="",namespace="apps",node="node-a",pod="app-0"} 4096
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(want), "node_processes", "node_start_time_seconds", "pod_swap_usage_bytes"); err != nil {
+		t.Error(err)
+	}
+
+	catalog := Catalog(Options{CustomMetrics: custom})
+	last := catalog[len(catalog)-1]
+	if last.Name != "pod_swap_usage_bytes" || strings.Join(last.Labels, ",") != "node,kubelet_version,namespace,pod" {
+		t.Errorf("last catalog entry = %+v, want pod_swap_usage_bytes", last)
+	}
+}
+
+func TestValidateCustomMetrics(t *testing.T) {
+	if err := ValidateCustomMetrics([]CustomMetric{{Name: "pod_swap_usage_bytes", Path: "{.pods[*]}", Value: "{.swap.swapUsageBytes}", Labels: map[string]string{"pod": "{.podRef.name}"}}}); err != nil {
+		t.Errorf("ValidateCustomMetrics() = %v", err)
+	}
+	for _, tc := range []struct {
+		name   string
+		custom []CustomMetric
+	}{
+		{"invalid name", []CustomMetric{{Name: "pod-swap", Value: "{.node}"}}},
+		{"catalog clash", []CustomMetric{{Name: "kube_summary_pod_info", Value: "{.node}"}}},
+		{"duplicate", []CustomMetric{{Name: "swap", Value: "{.node}"}, {Name: "swap", Value: "{.node}"}}},
+		{"no value", []CustomMetric{{Name: "swap"}}},
+		{"invalid value", []CustomMetric{{Name: "swap", Value: "{.pods[}"}}},
+		{"reserved label", []CustomMetric{{Name: "swap", Value: "{.node}", Labels: map[string]string{"node": "{.nodeName}"}}}},
+		{"invalid label", []CustomMetric{{Name: "swap", Value: "{.node}", Labels: map[string]string{"pod-name": "{.podRef.name}"}}}},
+	} {
+		if err := ValidateCustomMetrics(tc.custom); err == nil {
+			t.Errorf("%s: ValidateCustomMetrics() accepted %+v", tc.name, tc.custom)
+		}
+	}
+}
+
+func TestCollect_customMetricsPodFilters(t *testing.T) {
+	swap, small, large := uint64(4096), uint64(10), uint64(100)
+	pod := func(name string, used *uint64) stats.PodStats {
+		return stats.PodStats{
+			PodRef:           stats.PodReference{Namespace: "apps", Name: name},
+			Swap:             &stats.SwapStats{SwapUsageBytes: &swap},
+			EphemeralStorage: &stats.FsStats{UsedBytes: used},
+		}
+	}
+	results := []NodeResult{{NodeName: "node-a", Summary: &stats.Summary{Pods: []stats.PodStats{pod("small", &small), pod("large", &large), pod("batch", &large)}}}}
+	registry := prometheus.NewPedanticRegistry()
+	Collect(results, registry, Options{
+		MaxPodsPerNode: 1,
+		IsOptedOutPod:  func(namespace, name string) bool { return name == "batch" },
+		CustomMetrics: []CustomMetric{{
+			Name:   "pod_swap_usage_bytes",
+			Help:   "Swap used by the pod",
+			Path:   "{.pods[*]}",
+			Value:  "{.swap.swapUsageBytes}",
+			Labels: map[string]string{"pod": "{.podRef.name}"},
+		}},
+	})
+
+	// The opted out pod and the pods beyond the limit have no series either
+	want := `# HELP pod_swap_usage_bytes Swap used by the pod
+# TYPE pod_swap_usage_bytes gauge
+pod_swap_usage_bytes{kubelet_version="",node="node-a",pod="large"} 4096
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(want), "pod_swap_usage_bytes"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,73 @@
+package summary
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// nodeMetadataLabelNames are added to the node level series by
+// --node-metadata-labels
+var nodeMetadataLabelNames = []string{"os", "arch", "instance_type"}
+
+// NodeMetadata describes the platform of a node
+type NodeMetadata struct {
+	OS           string `json:"os,omitempty"`
+	Arch         string `json:"arch,omitempty"`
+	InstanceType string `json:"instanceType,omitempty"`
+}
+
+// NewNodeMetadata reads the platform of a node from its status, falling back
+// to the well-known labels set by the kubelet and the cloud provider
+func NewNodeMetadata(node corev1.Node) NodeMetadata {
+	m := NodeMetadata{
+		OS:           node.Status.NodeInfo.OperatingSystem,
+		Arch:         node.Status.NodeInfo.Architecture,
+		InstanceType: node.Labels[corev1.LabelInstanceTypeStable],
+	}
+	if m.OS == "" {
+		m.OS = node.Labels[corev1.LabelOSStable]
+	}
+	if m.Arch == "" {
+		m.Arch = node.Labels[corev1.LabelArchStable]
+	}
+	if m.InstanceType == "" {
+		m.InstanceType = node.Labels[corev1.LabelInstanceType]
+	}
+	return m
+}
+
+// NewNodeResult returns the result of a node, without its summary, with what
+// is read from the node object
+func NewNodeResult(node corev1.Node) NodeResult {
+	return NodeResult{
+		NodeName:       node.Name,
+		Provider:       DetectProvider(node),
+		KubeletVersion: node.Status.NodeInfo.KubeletVersion,
+		Metadata:       NewNodeMetadata(node),
+		Conditions:     node.Status.Conditions,
+		NodeLabels:     node.Labels,
+		Unschedulable:  node.Spec.Unschedulable,
+		Taints:         node.Spec.Taints,
+		Allocatable:    node.Status.Allocatable,
+		Capacity:       node.Status.Capacity,
+	}
+}
+
+// nodeLabelNames returns the labels of the node level series, followed by
+// extra
+func nodeLabelNames(opts Options, extra ...string) []string {
+	names := []string{"node", "kubelet_version"}
+	if opts.NodeMetadataLabels {
+		names = append(names, nodeMetadataLabelNames...)
+	}
+	return append(names, extra...)
+}
+
+// nodeLabelValues returns the values of the nodeLabelNames of a node,
+// followed by extra
+func nodeLabelValues(entry NodeResult, opts Options, extra ...string) []string {
+	values := []string{entry.NodeName, entry.KubeletVersion}
+	if opts.NodeMetadataLabels {
+		values = append(values, entry.Metadata.OS, entry.Metadata.Arch, entry.Metadata.InstanceType)
+	}
+	return append(values, extra...)
+}
@@ -0,0 +1,22 @@
+package summary
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestNewNodeMetadata(t *testing.T) {
+	node := corev1.Node{}
+	node.Labels = map[string]string{
+		corev1.LabelOSStable:     "windows",
+		corev1.LabelArchStable:   "arm64",
+		corev1.LabelInstanceType: "m5.large",
+	}
+	node.Status.NodeInfo.OperatingSystem = "linux"
+
+	want := NodeMetadata{OS: "linux", Arch: "arm64", InstanceType: "m5.large"}
+	if got := NewNodeMetadata(node); got != want {
+		t.Errorf("NewNodeMetadata() = %+v, want %+v", got, want)
+	}
+}
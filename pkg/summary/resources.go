@@ -0,0 +1,54 @@
+package summary
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// exportedResources maps the node resources exported by
+// Options.NodeResources to the suffix of their metric names
+var exportedResources = []struct {
+	name   corev1.ResourceName
+	suffix string
+	help   string
+}{
+	{corev1.ResourceCPU, "cpu_cores", "Number of CPU cores"},
+	{corev1.ResourceMemory, "memory_bytes", "Number of bytes of memory"},
+	{corev1.ResourceEphemeralStorage, "ephemeral_storage_bytes", "Number of bytes of ephemeral storage"},
+	{corev1.ResourcePods, "pods", "Number of pods"},
+}
+
+// collectNodeResources collects the allocatable and capacity resources of the
+// node status, so that usage ratios don't need a join with another exporter.
+// They are reported even if the summary of the node couldn't be collected.
+func collectNodeResources(results []NodeResult, b *collectorBuilder) {
+	allocatable := make([]*prometheus.GaugeVec, len(exportedResources))
+	capacity := make([]*prometheus.GaugeVec, len(exportedResources))
+	for i, resource := range exportedResources {
+		allocatable[i] = b.gaugeVec(Namespace + "_node_allocatable_" + resource.suffix)
+		capacity[i] = b.gaugeVec(Namespace + "_node_capacity_" + resource.suffix)
+	}
+
+	for _, entry := range results {
+		for i, resource := range exportedResources {
+			if q, ok := entry.Allocatable[resource.name]; ok {
+				allocatable[i].WithLabelValues(nodeLabelValues(entry, b.opts)...).Set(q.AsApproximateFloat64())
+			}
+			if q, ok := entry.Capacity[resource.name]; ok {
+				capacity[i].WithLabelValues(nodeLabelValues(entry, b.opts)...).Set(q.AsApproximateFloat64())
+			}
+		}
+	}
+}
+
+// resourceMetrics returns the catalog entries of the node resources
+func resourceMetrics() []MetricInfo {
+	var metrics []MetricInfo
+	for _, resource := range exportedResources {
+		metrics = append(metrics,
+			MetricInfo{Name: Namespace + "_node_allocatable_" + resource.suffix, Type: MetricTypeGauge, Help: resource.help + " of the node allocatable to pods", NodeLevel: true, Stability: StabilityAlpha},
+			MetricInfo{Name: Namespace + "_node_capacity_" + resource.suffix, Type: MetricTypeGauge, Help: resource.help + " of the node", NodeLevel: true, Stability: StabilityAlpha},
+		)
+	}
+	return metrics
+}
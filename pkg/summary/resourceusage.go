@@ -0,0 +1,81 @@
+package summary
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// resourceUsage collects the CPU and memory usage of Options.ResourceUsage.
+// The series are labelled with the source of the summary, so that the nodes
+// whose usage was read from /metrics/resource rather than /stats/summary can
+// be told apart while being graphed alike.
+type resourceUsage struct {
+	opts                                                Options
+	nodeCPUUsageSeconds, nodeMemoryWorkingSet           *prometheus.GaugeVec
+	podCPUUsageSeconds, podMemoryWorkingSet             *prometheus.GaugeVec
+	containerCPUUsageSeconds, containerMemoryWorkingSet *prometheus.GaugeVec
+}
+
+func newResourceUsage(b *collectorBuilder) *resourceUsage {
+	return &resourceUsage{
+		opts:                      b.opts,
+		nodeCPUUsageSeconds:       b.gaugeVec(Namespace + "_node_cpu_usage_seconds"),
+		nodeMemoryWorkingSet:      b.gaugeVec(Namespace + "_node_memory_working_set_bytes"),
+		podCPUUsageSeconds:        b.gaugeVec(Namespace + "_pod_cpu_usage_seconds"),
+		podMemoryWorkingSet:       b.gaugeVec(Namespace + "_pod_memory_working_set_bytes"),
+		containerCPUUsageSeconds:  b.gaugeVec(Namespace + "_container_cpu_usage_seconds"),
+		containerMemoryWorkingSet: b.gaugeVec(Namespace + "_container_memory_working_set_bytes"),
+	}
+}
+
+// node collects the usage of the node
+func (u *resourceUsage) node(entry NodeResult, node stats.NodeStats) {
+	values := nodeLabelValues(entry, u.opts, source(entry))
+	if node.CPU != nil && node.CPU.UsageCoreNanoSeconds != nil {
+		u.nodeCPUUsageSeconds.WithLabelValues(values...).Set(float64(*node.CPU.UsageCoreNanoSeconds) / 1e9)
+	}
+	if node.Memory != nil && node.Memory.WorkingSetBytes != nil {
+		u.nodeMemoryWorkingSet.WithLabelValues(values...).Set(float64(*node.Memory.WorkingSetBytes))
+	}
+}
+
+// pod collects the usage of the pod and its containers
+func (u *resourceUsage) pod(entry NodeResult, pod stats.PodStats) {
+	src := source(entry)
+	if pod.CPU != nil && pod.CPU.UsageCoreNanoSeconds != nil {
+		u.podCPUUsageSeconds.WithLabelValues(entry.NodeName, pod.PodRef.Name, pod.PodRef.Namespace, src).Set(float64(*pod.CPU.UsageCoreNanoSeconds) / 1e9)
+	}
+	if pod.Memory != nil && pod.Memory.WorkingSetBytes != nil {
+		u.podMemoryWorkingSet.WithLabelValues(entry.NodeName, pod.PodRef.Name, pod.PodRef.Namespace, src).Set(float64(*pod.Memory.WorkingSetBytes))
+	}
+	for _, container := range pod.Containers {
+		if container.CPU != nil && container.CPU.UsageCoreNanoSeconds != nil {
+			u.containerCPUUsageSeconds.WithLabelValues(entry.NodeName, pod.PodRef.Name, pod.PodRef.Namespace, container.Name, src).Set(float64(*container.CPU.UsageCoreNanoSeconds) / 1e9)
+		}
+		if container.Memory != nil && container.Memory.WorkingSetBytes != nil {
+			u.containerMemoryWorkingSet.WithLabelValues(entry.NodeName, pod.PodRef.Name, pod.PodRef.Namespace, container.Name, src).Set(float64(*container.Memory.WorkingSetBytes))
+		}
+	}
+}
+
+// source returns the source of the summary of the result
+func source(entry NodeResult) string {
+	if entry.Source == "" {
+		return SourceSummary
+	}
+	return entry.Source
+}
+
+// resourceUsageMetrics returns the catalog entries of the CPU and memory usage
+func resourceUsageMetrics() []MetricInfo {
+	podLabels := []string{"node", "pod", "namespace", "source"}
+	containerLabels := []string{"node", "pod", "namespace", "name", "source"}
+	return []MetricInfo{
+		{Name: Namespace + "_node_cpu_usage_seconds", Type: MetricTypeGauge, Help: "Cumulative CPU time consumed by the node, in core seconds", NodeLevel: true, Labels: []string{"source"}, Stability: StabilityAlpha},
+		{Name: Namespace + "_node_memory_working_set_bytes", Type: MetricTypeGauge, Help: "Number of bytes of the working set memory of the node", NodeLevel: true, Labels: []string{"source"}, Stability: StabilityAlpha},
+		{Name: Namespace + "_pod_cpu_usage_seconds", Type: MetricTypeGauge, Help: "Cumulative CPU time consumed by the pod, in core seconds", Labels: podLabels, Stability: StabilityAlpha},
+		{Name: Namespace + "_pod_memory_working_set_bytes", Type: MetricTypeGauge, Help: "Number of bytes of the working set memory of the pod", Labels: podLabels, Stability: StabilityAlpha},
+		{Name: Namespace + "_container_cpu_usage_seconds", Type: MetricTypeGauge, Help: "Cumulative CPU time consumed by the container, in core seconds", Labels: containerLabels, Stability: StabilityAlpha},
+		{Name: Namespace + "_container_memory_working_set_bytes", Type: MetricTypeGauge, Help: "Number of bytes of the working set memory of the container", Labels: containerLabels, Stability: StabilityAlpha},
+	}
+}
@@ -0,0 +1,51 @@
+package summary
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+func TestCollect_resourceUsage(t *testing.T) {
+	cpu, memory := uint64(90e9), uint64(1<<30)
+	results := []NodeResult{
+		{
+			NodeName: "node-a",
+			Summary: &stats.Summary{Node: stats.NodeStats{
+				CPU:    &stats.CPUStats{UsageCoreNanoSeconds: &cpu},
+				Memory: &stats.MemoryStats{WorkingSetBytes: &memory},
+			}},
+		},
+		{
+			NodeName: "node-b",
+			Source:   SourceResourceMetrics,
+			Summary: &stats.Summary{Node: stats.NodeStats{
+				CPU: &stats.CPUStats{UsageCoreNanoSeconds: &cpu},
+			}},
+		},
+	}
+	registry := prometheus.NewPedanticRegistry()
+	Collect(results, registry, Options{ResourceUsage: true})
+
+	want := `# HELP kube_summary_node_cpu_usage_seconds Cumulative CPU time consumed by the node, in core seconds
+# TYPE kube_summary_node_cpu_usage_seconds gauge
+kube_summary_node_cpu_usage_seconds{kubelet_version="",node="node-a",source="summary"} 90
+kube_summary_node_cpu_usage_seconds{kubelet_version="",node="node-b",source="resource_metrics"} 90
+# HELP kube_summary_node_memory_working_set_bytes Number of bytes of the working set memory of the node
+# TYPE kube_summary_node_memory_working_set_bytes gauge
+kube_summary_node_memory_working_set_bytes{kubelet_version="",node="node-a",source="summary"} 1.073741824e+09
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(want), "kube_summary_node_cpu_usage_seconds", "kube_summary_node_memory_working_set_bytes"); err != nil {
+		t.Error(err)
+	}
+
+	// The usage isn't exported by default
+	registry = prometheus.NewPedanticRegistry()
+	Collect(results, registry, Options{})
+	if n, err := testutil.GatherAndCount(registry, "kube_summary_node_cpu_usage_seconds"); err != nil || n != 0 {
+		t.Errorf("got %d kube_summary_node_cpu_usage_seconds series without ResourceUsage: %v", n, err)
+	}
+}
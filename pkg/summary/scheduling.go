@@ -0,0 +1,37 @@
+package summary
+
+// collectNodeScheduling collects whether the nodes are cordoned and tainted
+// with the Options.NodeTaints keys, so that the disk pressure alerts can be
+// silenced for the nodes being drained. Like the conditions, they are
+// reported even if the summary of the node couldn't be collected.
+func collectNodeScheduling(results []NodeResult, b *collectorBuilder) {
+	unschedulable := b.gaugeVec(Namespace + "_node_unschedulable")
+	taint := b.gaugeVec(Namespace + "_node_taint")
+
+	for _, entry := range results {
+		var value float64
+		if entry.Unschedulable {
+			value = 1
+		}
+		unschedulable.WithLabelValues(nodeLabelValues(entry, b.opts)...).Set(value)
+
+		for _, key := range b.opts.NodeTaints {
+			var value float64
+			for _, t := range entry.Taints {
+				if t.Key == key {
+					value = 1
+					break
+				}
+			}
+			taint.WithLabelValues(nodeLabelValues(entry, b.opts, key)...).Set(value)
+		}
+	}
+}
+
+// schedulingMetrics returns the catalog entries of the node scheduling state
+func schedulingMetrics() []MetricInfo {
+	return []MetricInfo{
+		{Name: Namespace + "_node_unschedulable", Type: MetricTypeGauge, Help: "Whether the node is cordoned, its spec being unschedulable", NodeLevel: true, Stability: StabilityAlpha},
+		{Name: Namespace + "_node_taint", Type: MetricTypeGauge, Help: "Whether the node has a taint of the key, whatever its value and effect, for the selected taint keys", NodeLevel: true, Labels: []string{"key"}, Stability: StabilityAlpha},
+	}
+}
@@ -0,0 +1,55 @@
+package summary
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestCollect_nodeScheduling(t *testing.T) {
+	results := []NodeResult{
+		{
+			NodeName:      "node-a",
+			Summary:       fixtureSummary(t),
+			Unschedulable: true,
+			Taints: []corev1.Taint{
+				{Key: "ToBeDeletedByClusterAutoscaler", Value: "1714557600", Effect: corev1.TaintEffectNoSchedule},
+				{Key: corev1.TaintNodeUnschedulable, Effect: corev1.TaintEffectNoSchedule},
+			},
+		},
+		// Failed nodes are still reported
+		{NodeName: "node-b", Err: errors.New("connection refused")},
+	}
+	opts := Options{NodeScheduling: true, NodeTaints: []string{"ToBeDeletedByClusterAutoscaler", "example.com/maintenance"}}
+	registry := prometheus.NewPedanticRegistry()
+	Collect(results, registry, opts)
+
+	want := `# HELP kube_summary_node_taint Whether the node has a taint of the key, whatever its value and effect, for the selected taint keys
+# TYPE kube_summary_node_taint gauge
+kube_summary_node_taint{key="ToBeDeletedByClusterAutoscaler",kubelet_version="",node="node-a"} 1
+kube_summary_node_taint{key="ToBeDeletedByClusterAutoscaler",kubelet_version="",node="node-b"} 0
+kube_summary_node_taint{key="example.com/maintenance",kubelet_version="",node="node-a"} 0
+kube_summary_node_taint{key="example.com/maintenance",kubelet_version="",node="node-b"} 0
+# HELP kube_summary_node_unschedulable Whether the node is cordoned, its spec being unschedulable
+# TYPE kube_summary_node_unschedulable gauge
+kube_summary_node_unschedulable{kubelet_version="",node="node-a"} 1
+kube_summary_node_unschedulable{kubelet_version="",node="node-b"} 0
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(want), "kube_summary_node_taint", "kube_summary_node_unschedulable"); err != nil {
+		t.Error(err)
+	}
+
+	families, err := Gather(results, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mf := range families {
+		if name := mf.GetName(); name == "kube_summary_node_taint" || name == "kube_summary_node_unschedulable" {
+			t.Errorf("%s exported without NodeScheduling", name)
+		}
+	}
+}
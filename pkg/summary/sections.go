@@ -0,0 +1,105 @@
+package summary
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Sections of a summary that are mapped to metrics
+const (
+	SectionContainerLogs       = "container_logs"
+	SectionContainerRootfs     = "container_rootfs"
+	SectionPodEphemeralStorage = "pod_ephemeral_storage"
+	SectionNodeRuntimeImageFS  = "node_runtime_imagefs"
+)
+
+// Sections lists every section, which are also the metric groups of the
+// exporter's flags
+var Sections = []string{
+	SectionContainerLogs,
+	SectionContainerRootfs,
+	SectionPodEphemeralStorage,
+	SectionNodeRuntimeImageFS,
+}
+
+// Optional parts of the summary, see NodeResult.Capabilities
+const (
+	CapabilitySwap        = "swap"
+	CapabilityPSI         = "psi"
+	CapabilityContainerFs = "containerfs"
+)
+
+// Capabilities are the optional parts of the summary, added by different
+// kubelet versions, whose presence is exported for each node
+var Capabilities = []string{CapabilitySwap, CapabilityPSI, CapabilityContainerFs}
+
+// How the mirror pods of the static pods, e.g. kube-proxy or the control plane
+// components, are exported, see Options.MirrorPods
+const (
+	// MirrorPodsInclude exports them like any other pod
+	MirrorPodsInclude = "include"
+	// MirrorPodsDrop doesn't export their series
+	MirrorPodsDrop = "drop"
+	// MirrorPodsLabel exports them along with kube_summary_pod_mirror, to
+	// filter them out in queries
+	MirrorPodsLabel = "label"
+)
+
+var MirrorPodsModes = []string{MirrorPodsInclude, MirrorPodsDrop, MirrorPodsLabel}
+
+// provider describes a kubelet implementation whose summaries only partially
+// follow the kubelet's, identified by a node label
+type provider struct {
+	name       string
+	labelKey   string
+	labelValue string
+	// unsupported lists the summary sections the provider doesn't report, or
+	// reports with values that don't reflect real usage
+	unsupported map[string]bool
+}
+
+var providers = []provider{
+	{
+		// Virtual kubelet providers (ACI, ...) report pods only, container
+		// filesystems and the image filesystem belong to the provider
+		name:       "virtual-kubelet",
+		labelKey:   "type",
+		labelValue: "virtual-kubelet",
+		unsupported: map[string]bool{
+			SectionContainerLogs:      true,
+			SectionContainerRootfs:    true,
+			SectionNodeRuntimeImageFS: true,
+		},
+	},
+	{
+		// Fargate runs every pod on its own micro VM, the image filesystem
+		// isn't shared with other pods
+		name:       "fargate",
+		labelKey:   "eks.amazonaws.com/compute-type",
+		labelValue: "fargate",
+		unsupported: map[string]bool{
+			SectionNodeRuntimeImageFS: true,
+		},
+	},
+}
+
+// DetectProvider returns the name of the provider of the node, or an empty
+// string for regular kubelets
+func DetectProvider(node corev1.Node) string {
+	for _, p := range providers {
+		if node.Labels[p.labelKey] == p.labelValue {
+			return p.name
+		}
+	}
+	return ""
+}
+
+// UnsupportedSections returns the summary sections that shouldn't be mapped
+// to metrics for the provider
+func UnsupportedSections(name string) map[string]bool {
+	for _, p := range providers {
+		if p.name == name {
+			return p.unsupported
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+// Package summary maps the kubelet /stats/summary API to the metrics of
+// kube-summary-exporter. It needs neither an HTTP server nor a Kubernetes
+// client, so that other tools, e.g. kubectl plugins or node agents, can
+// export summaries with the same metric names and labels as the exporter.
+package summary
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+	corev1 "k8s.io/api/core/v1"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// Namespace prefixes the names of the metrics
+const Namespace = "kube_summary"
+
+// Sources of the summaries, see NodeResult.Source
+const (
+	// SourceSummary is the kubelet /stats/summary endpoint
+	SourceSummary = "summary"
+	// SourceResourceMetrics is the kubelet /metrics/resource endpoint, whose
+	// summaries only have the CPU and memory usage
+	SourceResourceMetrics = "resource_metrics"
+)
+
+// NodeResult is the summary of a node along with what is known of the node
+type NodeResult struct {
+	NodeName string
+	Summary  *stats.Summary
+	// Source is where the summary was collected from, SourceSummary if empty
+	Source string
+	// ResponseBytes is the size of the raw /stats/summary response
+	ResponseBytes int
+	// CollectedAt is the time the summary was collected, zero if it wasn't
+	CollectedAt time.Time
+	// Provider is the kubelet implementation of the node, see DetectProvider
+	Provider string
+	// KubeletVersion is reported by the node status, it is empty when the
+	// node object isn't fetched
+	KubeletVersion string
+	// Metadata is the platform of the node, it is empty when the node object
+	// isn't fetched
+	Metadata NodeMetadata
+	// Conditions are reported by the node status, they are empty when the
+	// node object isn't fetched
+	Conditions []corev1.NodeCondition
+	// NodeLabels are the labels of the node object, they are empty when the
+	// node object isn't fetched
+	NodeLabels map[string]string
+	// Unschedulable and Taints are set in the node spec, they are empty when
+	// the node object isn't fetched
+	Unschedulable bool
+	Taints        []corev1.Taint
+	// Allocatable and Capacity are reported by the node status, they are
+	// empty when the node object isn't fetched
+	Allocatable corev1.ResourceList
+	Capacity    corev1.ResourceList
+	// Capabilities tells which of the Capabilities the summary has, it
+	// is nil when the summary wasn't decoded
+	Capabilities map[string]bool
+	// Err is set when the summary of the node couldn't be collected. Summary
+	// may still hold previously cached data in that case.
+	Err error
+}
+
+// Options controls which series Collect emits
+type Options struct {
+	// MaxPodsPerNode limits per-pod and per-container series to the K largest
+	// ephemeral storage consumers on each node. Zero means no limit.
+	MaxPodsPerNode int
+	// OmitZeroValues lists the sections whose zero values aren't exported
+	OmitZeroValues map[string]bool
+	// Sections lists the sections mapped to metrics, nil selects them all
+	Sections map[string]bool
+	// EphemeralStorageBuckets are the buckets of the per node distribution of
+	// the pods' ephemeral storage usage, DefaultEphemeralStorageBuckets if nil
+	EphemeralStorageBuckets []float64
+	// ExtraLabels are added to every series
+	ExtraLabels prometheus.Labels
+	// NodeResources exports the allocatable and capacity resources of the
+	// nodes, see collectNodeResources
+	NodeResources bool
+	// NodeScheduling exports whether the nodes are cordoned and, for the
+	// NodeTaints keys, tainted, see collectNodeScheduling
+	NodeScheduling bool
+	NodeTaints     []string
+	// ResourceUsage exports the CPU and memory usage of the nodes, pods and
+	// containers, labelled with the Source of their summary, see
+	// resourceUsage
+	ResourceUsage bool
+	// PodInfo exports kube_summary_pod_info for every exported pod
+	PodInfo bool
+	// ContainerLogMaxSize is the containerLogMaxSize of the kubelet config,
+	// kube_summary_container_logs_used_ratio is exported if it's positive
+	ContainerLogMaxSize int64
+	// NodeMetadataLabels adds the nodeMetadataLabelNames to the node level
+	// series
+	NodeMetadataLabels bool
+	// MirrorPods is how the mirror pods told apart by IsMirrorPod are
+	// exported, one of MirrorPodsModes
+	MirrorPods  string
+	IsMirrorPod func(namespace, name string) bool
+	// IsOptedOutPod tells the pods opted out of the collection, whose series
+	// aren't exported, nor accounted for by the node level series
+	IsOptedOutPod func(namespace, name string) bool
+	// HelpOverrides replaces the help text of the metrics of the Catalog, by
+	// name
+	HelpOverrides map[string]string
+	// CustomMetrics are read from the summaries with JSONPath expressions,
+	// see ValidateCustomMetrics
+	CustomMetrics []CustomMetric
+	// DeprecatedMetrics also exports the deprecated metrics of the Catalog,
+	// along with the metrics replacing them
+	DeprecatedMetrics bool
+}
+
+// DefaultEphemeralStorageBuckets go from 1MiB to 256GiB
+var DefaultEphemeralStorageBuckets = prometheus.ExponentialBuckets(1<<20, 4, 10)
+
+// Gather returns the metric families Collect registers for the results,
+// sorted by name
+func Gather(results []NodeResult, opts Options) ([]*dto.MetricFamily, error) {
+	registry := prometheus.NewRegistry()
+	Collect(results, registry, opts)
+	return registry.Gather()
+}
+
+// MetricFamilies maps the summary of a node to metric families. The series
+// only carry the labels found in the summary, use Gather with a NodeResult
+// to set the kubelet version, metadata, conditions and resources read from the
+// node object.
+func MetricFamilies(summary *stats.Summary, nodeName string, opts Options) ([]*dto.MetricFamily, error) {
+	return Gather([]NodeResult{{NodeName: nodeName, Summary: summary}}, opts)
+}
+
+// Samples maps the summary of a node to samples, like MetricFamilies, the
+// histograms and summaries expanded into their buckets, quantiles, sums and
+// counts. The samples without a timestamp get ts.
+func Samples(summary *stats.Summary, nodeName string, opts Options, ts time.Time) (model.Vector, error) {
+	families, err := MetricFamilies(summary, nodeName, opts)
+	if err != nil {
+		return nil, err
+	}
+	return expfmt.ExtractSamples(&expfmt.DecodeOptions{Timestamp: model.TimeFromUnixNano(ts.UnixNano())}, families...)
+}
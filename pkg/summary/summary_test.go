@@ -0,0 +1,77 @@
+package summary
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+)
+
+func fixtureSummary(t *testing.T) *stats.Summary {
+	t.Helper()
+	var s stats.Summary
+	if err := json.Unmarshal(fakekubelet.Fixture("node"), &s); err != nil {
+		t.Fatal(err)
+	}
+	return &s
+}
+
+func TestMetricFamilies(t *testing.T) {
+	families, err := MetricFamilies(fixtureSummary(t), "node-a", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := map[string]int{}
+	for _, mf := range families {
+		found[mf.GetName()] = len(mf.GetMetric())
+	}
+	for name, n := range map[string]int{
+		"kube_summary_node_scrape_success":              1,
+		"kube_summary_pod_ephemeral_storage_used_bytes": 2,
+		"kube_summary_node_runtime_imagefs_used_bytes":  1,
+	} {
+		if found[name] != n {
+			t.Errorf("MetricFamilies() returned %d %s series, want %d", found[name], name, n)
+		}
+	}
+
+	// The options apply like they do in the exporter
+	families, err = MetricFamilies(fixtureSummary(t), "node-a", Options{Sections: map[string]bool{SectionContainerLogs: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mf := range families {
+		if mf.GetName() == "kube_summary_pod_ephemeral_storage_used_bytes" {
+			t.Errorf("MetricFamilies() returned %s without its section", mf.GetName())
+		}
+	}
+}
+
+func TestSamples(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	samples, err := Samples(fixtureSummary(t), "node-a", Options{}, ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var found bool
+	for _, s := range samples {
+		if s.Timestamp != model.TimeFromUnixNano(ts.UnixNano()) {
+			t.Errorf("sample %s has timestamp %v, want %v", s.Metric, s.Timestamp, ts)
+		}
+		if s.Metric[model.MetricNameLabel] == "kube_summary_pod_ephemeral_storage_used_bytes" && s.Metric["pod"] == "dev-server-0" {
+			found = true
+			if s.Metric["node"] != "node-a" || s.Metric["namespace"] != "mon" || s.Value != 133947392 {
+				t.Errorf("unexpected sample %s %v", s.Metric, s.Value)
+			}
+		}
+	}
+	if !found {
+		t.Error("Samples() didn't return the ephemeral storage of dev-server-0")
+	}
+}
@@ -0,0 +1,34 @@
+package summary
+
+import (
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// PodUsage is the storage used by a pod, the rootfs and logs of its
+// containers summed up
+type PodUsage struct {
+	Namespace                 string
+	Pod                       string
+	EphemeralStorageUsedBytes uint64
+	RootfsUsedBytes           uint64
+	LogsUsedBytes             uint64
+}
+
+// NewPodUsage returns the storage used by a pod, the stats the kubelet didn't
+// report counting as 0
+func NewPodUsage(pod stats.PodStats) PodUsage {
+	u := PodUsage{
+		Namespace:                 pod.PodRef.Namespace,
+		Pod:                       pod.PodRef.Name,
+		EphemeralStorageUsedBytes: EphemeralStorageUsedBytes(pod),
+	}
+	for _, container := range pod.Containers {
+		if container.Rootfs != nil && container.Rootfs.UsedBytes != nil {
+			u.RootfsUsedBytes += *container.Rootfs.UsedBytes
+		}
+		if container.Logs != nil && container.Logs.UsedBytes != nil {
+			u.LogsUsedBytes += *container.Logs.UsedBytes
+		}
+	}
+	return u
+}
@@ -0,0 +1,27 @@
+package summary
+
+import (
+	"testing"
+
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+func TestNewPodUsage(t *testing.T) {
+	used := func(b uint64) *stats.FsStats { return &stats.FsStats{UsedBytes: &b} }
+	pod := stats.PodStats{
+		PodRef:           stats.PodReference{Name: "a", Namespace: "ns"},
+		EphemeralStorage: used(100),
+		Containers: []stats.ContainerStats{
+			{Name: "app", Rootfs: used(30), Logs: used(20)},
+			{Name: "sidecar", Rootfs: used(5)},
+		},
+	}
+
+	want := PodUsage{Namespace: "ns", Pod: "a", EphemeralStorageUsedBytes: 100, RootfsUsedBytes: 35, LogsUsedBytes: 20}
+	if got := NewPodUsage(pod); got != want {
+		t.Errorf("NewPodUsage() = %+v, want %+v", got, want)
+	}
+	if got := NewPodUsage(stats.PodStats{}); got != (PodUsage{}) {
+		t.Errorf("NewPodUsage() of an empty pod = %+v", got)
+	}
+}
@@ -0,0 +1,158 @@
+// Package summarypb holds the protobuf messages and gRPC service of the
+// summaries, generated from summary.proto, and their conversion from the
+// kubelet types. The Go and other clients of the gRPC server of the exporter
+// are generated from the same summary.proto.
+package summarypb
+
+//go:generate protoc --proto_path=.. --go_out=.. --go_opt=paths=source_relative --go-grpc_out=.. --go-grpc_opt=paths=source_relative summarypb/summary.proto
+
+import (
+	"google.golang.org/protobuf/types/known/timestamppb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// FromSummary returns the message of the summary of a node, nil if s is nil
+func FromSummary(s *stats.Summary) *Summary {
+	if s == nil {
+		return nil
+	}
+	m := &Summary{
+		Node: &NodeStats{
+			NodeName:         s.Node.NodeName,
+			SystemContainers: containers(s.Node.SystemContainers),
+			StartTime:        timestamp(s.Node.StartTime),
+			Cpu:              cpu(s.Node.CPU),
+			Memory:           memory(s.Node.Memory),
+			Network:          network(s.Node.Network),
+			Fs:               fs(s.Node.Fs),
+			Swap:             swap(s.Node.Swap),
+		},
+		Pods: make([]*PodStats, 0, len(s.Pods)),
+	}
+	if r := s.Node.Runtime; r != nil {
+		m.Node.Runtime = &RuntimeStats{ImageFs: fs(r.ImageFs), ContainerFs: fs(r.ContainerFs)}
+	}
+	if r := s.Node.Rlimit; r != nil {
+		m.Node.Rlimit = &RlimitStats{Time: timestamp(r.Time), MaxPid: r.MaxPID, NumOfRunningProcesses: r.NumOfRunningProcesses}
+	}
+
+	for _, pod := range s.Pods {
+		p := &PodStats{
+			PodRef:           &PodReference{Name: pod.PodRef.Name, Namespace: pod.PodRef.Namespace, Uid: pod.PodRef.UID},
+			StartTime:        timestamp(pod.StartTime),
+			Containers:       containers(pod.Containers),
+			Cpu:              cpu(pod.CPU),
+			Memory:           memory(pod.Memory),
+			Network:          network(pod.Network),
+			EphemeralStorage: fs(pod.EphemeralStorage),
+			Swap:             swap(pod.Swap),
+		}
+		if pod.ProcessStats != nil {
+			p.ProcessStats = &ProcessStats{ProcessCount: pod.ProcessStats.ProcessCount}
+		}
+		for _, v := range pod.VolumeStats {
+			volume := &VolumeStats{Fs: fs(&v.FsStats), Name: v.Name}
+			if v.PVCRef != nil {
+				volume.PvcRef = &PVCReference{Name: v.PVCRef.Name, Namespace: v.PVCRef.Namespace}
+			}
+			if v.VolumeHealthStats != nil {
+				volume.VolumeHealthStats = &VolumeHealthStats{Abnormal: v.VolumeHealthStats.Abnormal}
+			}
+			p.VolumeStats = append(p.VolumeStats, volume)
+		}
+		m.Pods = append(m.Pods, p)
+	}
+	return m
+}
+
+func containers(cs []stats.ContainerStats) []*ContainerStats {
+	if len(cs) == 0 {
+		return nil
+	}
+	ms := make([]*ContainerStats, 0, len(cs))
+	for _, c := range cs {
+		m := &ContainerStats{
+			Name:      c.Name,
+			StartTime: timestamp(c.StartTime),
+			Cpu:       cpu(c.CPU),
+			Memory:    memory(c.Memory),
+			Rootfs:    fs(c.Rootfs),
+			Logs:      fs(c.Logs),
+			Swap:      swap(c.Swap),
+		}
+		for _, a := range c.Accelerators {
+			m.Accelerators = append(m.Accelerators, &AcceleratorStats{Make: a.Make, Model: a.Model, Id: a.ID, MemoryTotal: a.MemoryTotal, MemoryUsed: a.MemoryUsed, DutyCycle: a.DutyCycle})
+		}
+		ms = append(ms, m)
+	}
+	return ms
+}
+
+func cpu(c *stats.CPUStats) *CPUStats {
+	if c == nil {
+		return nil
+	}
+	return &CPUStats{Time: timestamp(c.Time), UsageNanoCores: c.UsageNanoCores, UsageCoreNanoSeconds: c.UsageCoreNanoSeconds}
+}
+
+func memory(m *stats.MemoryStats) *MemoryStats {
+	if m == nil {
+		return nil
+	}
+	return &MemoryStats{
+		Time:            timestamp(m.Time),
+		AvailableBytes:  m.AvailableBytes,
+		UsageBytes:      m.UsageBytes,
+		WorkingSetBytes: m.WorkingSetBytes,
+		RssBytes:        m.RSSBytes,
+		PageFaults:      m.PageFaults,
+		MajorPageFaults: m.MajorPageFaults,
+	}
+}
+
+func network(n *stats.NetworkStats) *NetworkStats {
+	if n == nil {
+		return nil
+	}
+	m := &NetworkStats{Time: timestamp(n.Time), DefaultInterface: networkInterface(n.InterfaceStats)}
+	for _, i := range n.Interfaces {
+		m.Interfaces = append(m.Interfaces, networkInterface(i))
+	}
+	return m
+}
+
+func networkInterface(i stats.InterfaceStats) *InterfaceStats {
+	return &InterfaceStats{Name: i.Name, RxBytes: i.RxBytes, RxErrors: i.RxErrors, TxBytes: i.TxBytes, TxErrors: i.TxErrors}
+}
+
+func fs(f *stats.FsStats) *FsStats {
+	if f == nil {
+		return nil
+	}
+	return &FsStats{
+		Time:           timestamp(f.Time),
+		AvailableBytes: f.AvailableBytes,
+		CapacityBytes:  f.CapacityBytes,
+		UsedBytes:      f.UsedBytes,
+		InodesFree:     f.InodesFree,
+		Inodes:         f.Inodes,
+		InodesUsed:     f.InodesUsed,
+	}
+}
+
+func swap(s *stats.SwapStats) *SwapStats {
+	if s == nil {
+		return nil
+	}
+	return &SwapStats{Time: timestamp(s.Time), SwapAvailableBytes: s.SwapAvailableBytes, SwapUsageBytes: s.SwapUsageBytes}
+}
+
+// timestamp returns the message of the time, nil for the zero time the
+// kubelet reports when it doesn't know it
+func timestamp(t metav1.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t.Time)
+}
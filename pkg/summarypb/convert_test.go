@@ -0,0 +1,112 @@
+package summarypb
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+)
+
+func TestFromSummary(t *testing.T) {
+	u := func(v uint64) *uint64 { return &v }
+	i := func(v int64) *int64 { return &v }
+	at := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	now := metav1.NewTime(at)
+
+	s := &stats.Summary{
+		Node: stats.NodeStats{
+			NodeName:  "node-a",
+			StartTime: now,
+			Memory:    &stats.MemoryStats{Time: now, WorkingSetBytes: u(100)},
+			Network: &stats.NetworkStats{
+				Time:           now,
+				InterfaceStats: stats.InterfaceStats{Name: "eth0", RxBytes: u(1)},
+				Interfaces:     []stats.InterfaceStats{{Name: "eth0", RxBytes: u(1)}, {Name: "eth1"}},
+			},
+			Runtime: &stats.RuntimeStats{ImageFs: &stats.FsStats{UsedBytes: u(10)}},
+			Rlimit:  &stats.RlimitStats{Time: now, MaxPID: i(4096)},
+			SystemContainers: []stats.ContainerStats{
+				{Name: "kubelet", CPU: &stats.CPUStats{UsageNanoCores: u(5)}},
+			},
+		},
+		Pods: []stats.PodStats{{
+			PodRef:           stats.PodReference{Name: "pod-a", Namespace: "default", UID: "uid-a"},
+			EphemeralStorage: &stats.FsStats{Time: now, UsedBytes: u(20), Inodes: u(0)},
+			VolumeStats: []stats.VolumeStats{{
+				Name:    "data",
+				FsStats: stats.FsStats{UsedBytes: u(30)},
+				PVCRef:  &stats.PVCReference{Name: "data-pod-a", Namespace: "default"},
+			}},
+			ProcessStats: &stats.ProcessStats{ProcessCount: u(3)},
+		}},
+	}
+
+	want := &Summary{
+		Node: &NodeStats{
+			NodeName:  "node-a",
+			StartTime: timestamppb.New(at),
+			Memory:    &MemoryStats{Time: timestamppb.New(at), WorkingSetBytes: u(100)},
+			Network: &NetworkStats{
+				Time:             timestamppb.New(at),
+				DefaultInterface: &InterfaceStats{Name: "eth0", RxBytes: u(1)},
+				Interfaces:       []*InterfaceStats{{Name: "eth0", RxBytes: u(1)}, {Name: "eth1"}},
+			},
+			Runtime: &RuntimeStats{ImageFs: &FsStats{UsedBytes: u(10)}},
+			Rlimit:  &RlimitStats{Time: timestamppb.New(at), MaxPid: i(4096)},
+			SystemContainers: []*ContainerStats{
+				{Name: "kubelet", Cpu: &CPUStats{UsageNanoCores: u(5)}},
+			},
+		},
+		Pods: []*PodStats{{
+			PodRef: &PodReference{Name: "pod-a", Namespace: "default", Uid: "uid-a"},
+			// The zero inodes are kept, unlike the unreported ones
+			EphemeralStorage: &FsStats{Time: timestamppb.New(at), UsedBytes: u(20), Inodes: u(0)},
+			VolumeStats: []*VolumeStats{{
+				Name:   "data",
+				Fs:     &FsStats{UsedBytes: u(30)},
+				PvcRef: &PVCReference{Name: "data-pod-a", Namespace: "default"},
+			}},
+			ProcessStats: &ProcessStats{ProcessCount: u(3)},
+		}},
+	}
+
+	if diff := cmp.Diff(want, FromSummary(s), protocmp.Transform()); diff != "" {
+		t.Errorf("FromSummary() mismatch (-want +got):\n%s", diff)
+	}
+	if FromSummary(nil) != nil {
+		t.Error("FromSummary(nil) isn't nil")
+	}
+}
+
+func TestFromSummary_fixture(t *testing.T) {
+	var s stats.Summary
+	if err := json.Unmarshal(fakekubelet.Fixture("node"), &s); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := proto.Marshal(FromSummary(&s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Summary
+	if err := proto.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.GetNode().GetNodeName() != s.Node.NodeName || len(got.GetPods()) != len(s.Pods) {
+		t.Errorf("got node %q with %d pods, want %q with %d", got.GetNode().GetNodeName(), len(got.GetPods()), s.Node.NodeName, len(s.Pods))
+	}
+	for i, pod := range got.GetPods() {
+		if want := s.Pods[i].EphemeralStorage.UsedBytes; want != nil && pod.GetEphemeralStorage().GetUsedBytes() != *want {
+			t.Errorf("pod %s: got %d ephemeral storage used bytes, want %d", pod.GetPodRef().GetName(), pod.GetEphemeralStorage().GetUsedBytes(), *want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+var podGraceKept = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: metricsNamespace,
+	Name:      "pod_grace_kept_total",
+	Help:      "Number of times a pod missing from the summary of its node was exported with its last stats, see --pod-grace-period",
+})
+
+// podGrace holds the last stats of the pods of every node for
+// --pod-grace-period
+var podGrace = newPodGraceStore()
+
+func init() {
+	prometheus.MustRegister(podGraceKept)
+}
+
+// gracePod is the last stats of a pod and when they were collected
+type gracePod struct {
+	stats  stats.PodStats
+	seenAt time.Time
+}
+
+// podGraceStore keeps the pods missing from a summary for a grace period
+// after they were last seen. The kubelet sometimes leaves a pod out of a
+// single summary while its containers restart: exporting its last stats
+// instead of dropping its series avoids the gaps that break rate() and
+// increase() over them.
+type podGraceStore struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	nodes     map[string]map[string]gracePod
+	lastSweep time.Time
+}
+
+func newPodGraceStore() *podGraceStore {
+	return &podGraceStore{now: time.Now, nodes: map[string]map[string]gracePod{}}
+}
+
+// apply records the pods of a successfully collected summary of the node and
+// returns it with the pods it misses that were seen within the grace period,
+// with their last stats. The summary isn't modified.
+func (g *podGraceStore) apply(nodeName string, s *stats.Summary, grace time.Duration) *stats.Summary {
+	now := g.now()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	pods := make(map[string]gracePod, len(s.Pods))
+	for _, pod := range s.Pods {
+		pods[podKey(pod.PodRef)] = gracePod{stats: pod, seenAt: now}
+	}
+	var kept []string
+	for key, pod := range g.nodes[nodeName] {
+		if _, ok := pods[key]; ok || now.Sub(pod.seenAt) >= grace {
+			continue
+		}
+		pods[key] = pod
+		kept = append(kept, key)
+	}
+	g.nodes[nodeName] = pods
+	g.sweep(now, grace)
+	if len(kept) == 0 {
+		return s
+	}
+
+	sort.Strings(kept)
+	out := *s
+	out.Pods = make([]stats.PodStats, len(s.Pods), len(s.Pods)+len(kept))
+	copy(out.Pods, s.Pods)
+	for _, key := range kept {
+		out.Pods = append(out.Pods, pods[key].stats)
+	}
+	podGraceKept.Add(float64(len(kept)))
+	return &out
+}
+
+// sweep drops the nodes whose pods were all seen longer than the grace
+// period ago, e.g. deleted nodes, at most once per grace period. It must be
+// called with the lock held.
+func (g *podGraceStore) sweep(now time.Time, grace time.Duration) {
+	if now.Sub(g.lastSweep) < grace {
+		return
+	}
+	g.lastSweep = now
+	for nodeName, pods := range g.nodes {
+		expired := true
+		for _, pod := range pods {
+			if now.Sub(pod.seenAt) < grace {
+				expired = false
+				break
+			}
+		}
+		if expired {
+			delete(g.nodes, nodeName)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+func Test_podGraceStore(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	g := newPodGraceStore()
+	g.now = func() time.Time { return now }
+
+	pod := func(name string, usedBytes uint64) stats.PodStats {
+		return stats.PodStats{
+			PodRef:           stats.PodReference{Namespace: "team-a", Name: name},
+			EphemeralStorage: &stats.FsStats{UsedBytes: &usedBytes},
+		}
+	}
+	names := func(s *stats.Summary) (names []string) {
+		for _, pod := range s.Pods {
+			names = append(names, pod.PodRef.Name)
+		}
+		return names
+	}
+	kept := testutil.ToFloat64(podGraceKept)
+
+	g.apply("node-a", &stats.Summary{Pods: []stats.PodStats{pod("a", 1), pod("b", 2)}}, time.Minute)
+
+	// b is missing from the next summary and kept with its last stats
+	now = now.Add(30 * time.Second)
+	partial := &stats.Summary{Pods: []stats.PodStats{pod("a", 3)}}
+	s := g.apply("node-a", partial, time.Minute)
+	if got := names(s); len(got) != 2 || got[1] != "b" || *s.Pods[1].EphemeralStorage.UsedBytes != 2 {
+		t.Errorf("apply() pods = %v, want a and the last stats of b", got)
+	}
+	if len(partial.Pods) != 1 {
+		t.Error("apply() modified the summary")
+	}
+	if got := testutil.ToFloat64(podGraceKept) - kept; got != 1 {
+		t.Errorf("%v pods kept, want 1", got)
+	}
+
+	// Once the grace period is over since b was last seen, it's dropped
+	now = now.Add(31 * time.Second)
+	if got := names(g.apply("node-a", &stats.Summary{Pods: []stats.PodStats{pod("a", 3)}}, time.Minute)); len(got) != 1 {
+		t.Errorf("apply() pods = %v after the grace period, want a", got)
+	}
+
+	// Nodes that aren't collected anymore are swept
+	now = now.Add(2 * time.Minute)
+	g.apply("node-b", &stats.Summary{}, time.Minute)
+	if _, ok := g.nodes["node-a"]; ok {
+		t.Error("node-a wasn't swept")
+	}
+}
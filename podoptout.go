@@ -0,0 +1,35 @@
+package main
+
+import (
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// optedOutPods are the pods opted out of the collection with
+// --pod-scrape-annotation, seen by the pod informer
+var optedOutPods = newPodSet(scrapeOptedOut(""))
+
+// scrapeOptedOut returns whether a pod is opted out of the collection by the
+// annotation of the key set to "false", e.g. kube-summary.io/scrape: "false"
+// for the batch workloads that don't want to be monitored
+func scrapeOptedOut(key string) func(*corev1.Pod) bool {
+	return func(pod *corev1.Pod) bool {
+		return key != "" && pod.Annotations[key] == "false"
+	}
+}
+
+// dropOptedOutPods returns the summary without the pods opted out, so that
+// none of the outputs, the metrics, the JSON API, the CSV export and gRPC,
+// exports them. The summary is left alone if no pod is dropped.
+func dropOptedOutPods(s *stats.Summary, optedOut func(namespace, name string) bool) *stats.Summary {
+	if s == nil || !slices.ContainsFunc(s.Pods, func(pod stats.PodStats) bool { return optedOut(pod.PodRef.Namespace, pod.PodRef.Name) }) {
+		return s
+	}
+	dropped := *s
+	dropped.Pods = slices.DeleteFunc(slices.Clone(s.Pods), func(pod stats.PodStats) bool {
+		return optedOut(pod.PodRef.Namespace, pod.PodRef.Name)
+	})
+	return &dropped
+}
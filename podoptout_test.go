@@ -0,0 +1,101 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+)
+
+func Test_scrapeOptedOut(t *testing.T) {
+	pod := func(annotations map[string]string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: meta_v1.ObjectMeta{Name: "batch", Namespace: "jobs", Annotations: annotations}}
+	}
+	optedOut := scrapeOptedOut("kube-summary.io/scrape")
+	for _, tc := range []struct {
+		annotations map[string]string
+		want        bool
+	}{
+		{map[string]string{"kube-summary.io/scrape": "false"}, true},
+		{map[string]string{"kube-summary.io/scrape": "true"}, false},
+		{map[string]string{"other.io/scrape": "false"}, false},
+		{nil, false},
+	} {
+		if got := optedOut(pod(tc.annotations)); got != tc.want {
+			t.Errorf("scrapeOptedOut() of a pod annotated %v = %t, want %t", tc.annotations, got, tc.want)
+		}
+	}
+	if scrapeOptedOut("")(pod(map[string]string{"": "false"})) {
+		t.Error("scrapeOptedOut() without an annotation opted a pod out")
+	}
+}
+
+func Test_optedOutPods(t *testing.T) {
+	small, large := uint64(1), uint64(100)
+	pod := func(name string, used *uint64) stats.PodStats {
+		return stats.PodStats{
+			PodRef:           stats.PodReference{Name: name, Namespace: "jobs"},
+			EphemeralStorage: &stats.FsStats{UsedBytes: used},
+		}
+	}
+	results := []PerNodeResult{{NodeName: "node-a", Summary: &stats.Summary{Pods: []stats.PodStats{pod("batch", &large), pod("web", &small)}}}}
+	pods := newPodSet(scrapeOptedOut("kube-summary.io/scrape"))
+	pods.eventHandler().OnAdd(&corev1.Pod{ObjectMeta: meta_v1.ObjectMeta{
+		Name:        "batch",
+		Namespace:   "jobs",
+		Annotations: map[string]string{"kube-summary.io/scrape": "false"},
+	}}, false)
+
+	// The opted out pod doesn't take the place of the largest pod either
+	samples, err := resultSamples(results, collectorOptions{MaxPodsPerNode: 1, IsOptedOutPod: pods.contains})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range samples {
+		for _, l := range s.Labels {
+			if l.Name == "pod" {
+				got = append(got, s.Name+"/"+l.Value)
+			}
+		}
+	}
+	if diff := cmp.Diff([]string{"kube_summary_pod_ephemeral_storage_used_bytes/web"}, got); diff != "" {
+		t.Errorf("exported pod series mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func Test_dropOptedOutPods(t *testing.T) {
+	defer func(pods *podSet) { optedOutPods = pods }(optedOutPods)
+	optedOutPods = newPodSet(scrapeOptedOut("kube-summary.io/scrape"))
+	optedOutPods.eventHandler().OnAdd(&corev1.Pod{ObjectMeta: meta_v1.ObjectMeta{
+		Name:        "dev-server-0",
+		Namespace:   "mon",
+		Annotations: map[string]string{"kube-summary.io/scrape": "false"},
+	}}, false)
+
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Summary: fakekubelet.Fixture("node")})
+	r := newRouter(kubeClient, nil, nil, allNodesSelector, singleNodeSelector)
+
+	// Every output drops the opted out pod
+	for _, target := range []string{"/nodes", "/api/v1/nodes", "/export/csv?groupBy=namespace"} {
+		code, body := get(t, r, target, nil)
+		if code != http.StatusOK {
+			t.Fatalf("GET %s returned %d: %s", target, code, body)
+		}
+		assertNotContains(t, body, "dev-server-0")
+		if target == "/export/csv?groupBy=namespace" {
+			assertNotContains(t, body, "mon,")
+		}
+	}
+
+	s := &stats.Summary{Pods: []stats.PodStats{{PodRef: stats.PodReference{Name: "web", Namespace: "apps"}}}}
+	if got := dropOptedOutPods(s, optedOutPods.contains); got != s {
+		t.Error("dropOptedOutPods() copied a summary without opted out pods")
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+// defaultProbeModule exports every section
+const defaultProbeModule = "default"
+
+// probeSections returns the sections selected by the probe modules, each
+// either defaultProbeModule or a comma separated list of sections. The
+// sections of repeated modules, e.g. from the params of a Prometheus scrape
+// config, are merged.
+func probeSections(modules []string) (map[string]bool, error) {
+	if len(modules) == 0 || slices.ContainsFunc(modules, func(module string) bool { return module == "" || module == defaultProbeModule }) {
+		return nil, nil
+	}
+
+	selected := sectionsFlag{}
+	if err := selected.Set(strings.Join(modules, ",")); err != nil {
+		return nil, err
+	}
+	return selected, nil
+}
+
+// handleProbe serves the metrics of the node named by the target parameter,
+// following the multi-target exporter pattern, so that a node service
+// discovery can be relabeled into per node targets of a single exporter
+func handleProbe(w http.ResponseWriter, r *http.Request, kubeClient *kubernetes.Clientset, nodeSelector func(string) nodeSelectorFunc) {
+	target := strings.TrimSpace(r.URL.Query().Get("target"))
+	if target == "" {
+		writeError(w, r, http.StatusBadRequest, apiError{Error: "target parameter is missing", Reason: reasonBadRequest})
+		return
+	}
+
+	modules := r.URL.Query()["module"]
+	selected, err := probeSections(modules)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, apiError{Error: fmt.Sprintf("unknown module %q: %v", strings.Join(modules, ","), err), Node: target, Reason: reasonBadRequest})
+		return
+	}
+
+	opts := flagCollectorOptions()
+	opts.Sections = selected
+	handleCollection(w, r, kubeClient, nodeSelector(target), opts, writePrometheus)
+}
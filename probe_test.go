@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+)
+
+func TestRouter_probe(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Summary: fakekubelet.Fixture("node")})
+	srv.AddNode(fakekubelet.Node{Name: "node-b", Summary: fakekubelet.Fixture("node")})
+	r := newRouter(kubeClient, nil, nil, allNodesSelector, singleNodeSelector)
+
+	code, body := get(t, r, "/probe?target=node-a", nil)
+	if code != http.StatusOK {
+		t.Fatalf("GET /probe returned %d: %s", code, body)
+	}
+	assertContains(t, body, `kube_summary_container_logs_used_bytes{`, `kube_summary_node_runtime_imagefs_used_bytes{`)
+	assertNotContains(t, body, `node="node-b"`)
+
+	code, body = get(t, r, "/probe?target=node-a&module=pod_ephemeral_storage,node_runtime_imagefs", nil)
+	if code != http.StatusOK {
+		t.Fatalf("GET /probe with a module returned %d: %s", code, body)
+	}
+	assertContains(t, body, `kube_summary_pod_ephemeral_storage_used_bytes{`, `kube_summary_node_runtime_imagefs_used_bytes{`)
+	assertNotContains(t, body, `kube_summary_container_logs_used_bytes{`, `kube_summary_container_rootfs_used_bytes{`)
+
+	// The repeated modules of the params of a scrape config are merged
+	code, body = get(t, r, "/probe?target=node-a&module=pod_ephemeral_storage&module=node_runtime_imagefs", nil)
+	if code != http.StatusOK {
+		t.Fatalf("GET /probe with repeated modules returned %d: %s", code, body)
+	}
+	assertContains(t, body, `kube_summary_pod_ephemeral_storage_used_bytes{`, `kube_summary_node_runtime_imagefs_used_bytes{`)
+	assertNotContains(t, body, `kube_summary_container_logs_used_bytes{`)
+
+	for _, target := range []string{"/probe", "/probe?target=node-a&module=unknown", "/probe?target=node-a&module=pod_ephemeral_storage&module=unknown"} {
+		if code, body := get(t, r, target, nil); code != http.StatusBadRequest {
+			t.Errorf("GET %s returned %d, want %d: %s", target, code, http.StatusBadRequest, body)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// progress holds the collections of all nodes in flight for /-/progress
+var progress = newCollectionProgress()
+
+type progressKey struct{}
+
+// collectionProgress tracks the collections of all nodes in flight, so that
+// a request collecting a large cluster, or a slow background cycle, can be
+// followed while it runs
+type collectionProgress struct {
+	mu       sync.Mutex
+	next     int
+	inFlight map[int]*progressEntry
+}
+
+func newCollectionProgress() *collectionProgress {
+	return &collectionProgress{inFlight: map[int]*progressEntry{}}
+}
+
+// progressEntry is a collection of all nodes in flight, its nodes being
+// counted by collectNodeStats
+type progressEntry struct {
+	start      time.Time
+	background bool
+
+	mu sync.Mutex
+	// collectStart is when the nodes were listed and their collection
+	// started, zero until then
+	collectStart time.Time
+	total        int
+	completed    int
+	failed       int
+}
+
+// track returns a context under which collectNodeStats counts the nodes it
+// collects, and a func removing the collection once it is done
+func (p *collectionProgress) track(ctx context.Context) (context.Context, func()) {
+	e := &progressEntry{start: time.Now(), background: ctx.Value(backgroundCollectionKey{}) != nil}
+
+	p.mu.Lock()
+	id := p.next
+	p.next++
+	p.inFlight[id] = e
+	p.mu.Unlock()
+
+	return context.WithValue(ctx, progressKey{}, e), func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		delete(p.inFlight, id)
+	}
+}
+
+// progressTotal sets the number of nodes the collection of the context is
+// about to collect
+func progressTotal(ctx context.Context, total int) {
+	if e, ok := ctx.Value(progressKey{}).(*progressEntry); ok {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		e.collectStart = time.Now()
+		e.total += total
+	}
+}
+
+// progressNode counts a node collected by the collection of the context
+func progressNode(ctx context.Context, result PerNodeResult) {
+	if e, ok := ctx.Value(progressKey{}).(*progressEntry); ok {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		e.completed++
+		if result.Err != nil {
+			e.failed++
+		}
+	}
+}
+
+// progressRecord is a collection of all nodes in flight
+type progressRecord struct {
+	Start time.Time `json:"start"`
+	// Background tells whether the collection is a cycle of the background
+	// loop rather than a request
+	Background bool `json:"background"`
+	// Listing is true while the nodes are listed, their number being
+	// unknown yet
+	Listing   bool `json:"listing"`
+	Total     int  `json:"total"`
+	Completed int  `json:"completed"`
+	Failed    int  `json:"failed"`
+	// ElapsedSeconds is the time since the collection started, the listing
+	// of the nodes included
+	ElapsedSeconds float64 `json:"elapsedSeconds"`
+	// ETASeconds is the time left to collect the remaining nodes at the rate
+	// the completed ones were collected, absent until a node is completed
+	ETASeconds *float64 `json:"etaSeconds,omitempty"`
+}
+
+// progressDocument is the response of /-/progress
+type progressDocument struct {
+	// Collections are the collections in flight, the oldest first
+	Collections []progressRecord `json:"collections"`
+}
+
+// list returns the collections in flight at now, the oldest first
+func (p *collectionProgress) list(now time.Time) []progressRecord {
+	p.mu.Lock()
+	entries := make([]*progressEntry, 0, len(p.inFlight))
+	for _, e := range p.inFlight {
+		entries = append(entries, e)
+	}
+	p.mu.Unlock()
+
+	records := make([]progressRecord, 0, len(entries))
+	for _, e := range entries {
+		records = append(records, e.record(now))
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Start.Before(records[j].Start) })
+	return records
+}
+
+func (e *progressEntry) record(now time.Time) progressRecord {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	r := progressRecord{
+		Start:          e.start,
+		Background:     e.background,
+		Listing:        e.collectStart.IsZero(),
+		Total:          e.total,
+		Completed:      e.completed,
+		Failed:         e.failed,
+		ElapsedSeconds: now.Sub(e.start).Seconds(),
+	}
+	if e.completed > 0 && !r.Listing {
+		eta := now.Sub(e.collectStart).Seconds() / float64(e.completed) * float64(max(e.total-e.completed, 0))
+		r.ETASeconds = &eta
+	}
+	return r
+}
+
+func handleProgress(w http.ResponseWriter, r *http.Request) {
+	writeAPIJSON(w, http.StatusOK, progressDocument{Collections: progress.list(time.Now())})
+}
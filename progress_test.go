@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+)
+
+func Test_collectionProgress(t *testing.T) {
+	p := newCollectionProgress()
+	ctx, done := p.track(context.WithValue(context.Background(), backgroundCollectionKey{}, true))
+
+	records := p.list(time.Now())
+	if len(records) != 1 || !records[0].Listing || !records[0].Background || records[0].ETASeconds != nil {
+		t.Fatalf("list() while listing = %+v, want a background collection listing its nodes", records)
+	}
+
+	progressTotal(ctx, 4)
+	progressNode(ctx, PerNodeResult{NodeName: "node-a"})
+	progressNode(ctx, PerNodeResult{NodeName: "node-b", Err: errors.New("connection refused")})
+	e := ctx.Value(progressKey{}).(*progressEntry)
+	now := e.collectStart.Add(10 * time.Second)
+	records = p.list(now)
+	if len(records) != 1 {
+		t.Fatalf("got %d collections in flight, want 1", len(records))
+	}
+	r := records[0]
+	if r.Listing || r.Total != 4 || r.Completed != 2 || r.Failed != 1 || r.ETASeconds == nil || *r.ETASeconds != 10 {
+		t.Errorf("list() = %+v, want 2 of 4 nodes completed, 10s left", r)
+	}
+
+	done()
+	if records := p.list(time.Now()); len(records) != 0 {
+		t.Errorf("list() once done = %+v, want none", records)
+	}
+
+	// The contexts without progress aren't tracked
+	progressTotal(context.Background(), 1)
+	progressNode(context.Background(), PerNodeResult{NodeName: "node-a"})
+}
+
+func Test_handleProgress(t *testing.T) {
+	defer func(p *collectionProgress) { progress = p }(progress)
+	progress = newCollectionProgress()
+
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Summary: fakekubelet.Fixture("node")})
+	srv.AddNode(fakekubelet.Node{Name: "node-b", Summary: fakekubelet.Fixture("node"), Delay: time.Second})
+	r := newRouter(kubeClient, nil, nil, allNodesSelector, singleNodeSelector)
+
+	scraped := make(chan struct{})
+	go func() {
+		defer close(scraped)
+		get(t, r, "/nodes", nil)
+	}()
+
+	var doc progressDocument
+	deadline := time.Now().Add(5 * time.Second)
+	for len(doc.Collections) == 0 || doc.Collections[0].Completed == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("no progress of GET /nodes: %+v", doc)
+		}
+		time.Sleep(10 * time.Millisecond)
+		code, body := get(t, r, "/-/progress", nil)
+		if code != http.StatusOK {
+			t.Fatalf("GET /-/progress returned %d: %s", code, body)
+		}
+		if err := json.Unmarshal([]byte(body), &doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c := doc.Collections[0]
+	if c.Background || c.Total != 2 || c.Completed != 1 || c.ETASeconds == nil {
+		t.Errorf("got %+v, want 1 of 2 nodes completed with an ETA", c)
+	}
+
+	<-scraped
+	if records := progress.list(time.Now()); len(records) != 0 {
+		t.Errorf("got %+v in flight after GET /nodes, want none", records)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/kubernetes"
+)
+
+var sinkErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metricsNamespace,
+	Name:      "sink_errors_total",
+	Help:      "Number of failed pushes of collected samples to an output sink",
+},
+	[]string{
+		"sink",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(sinkErrors)
+}
+
+// sink is an output that collected samples are pushed to
+type sink interface {
+	// Name identifies the sink in logs and metrics
+	Name() string
+	// Write pushes the samples collected at ts
+	Write(ctx context.Context, samples []sample, ts time.Time) error
+}
+
+// sampleCollector returns a function collecting the samples of the selected
+// nodes, as served by the HTTP handlers
+func sampleCollector(kubeClient *kubernetes.Clientset, nodeSelector nodeSelectorFunc) func(context.Context) ([]sample, error) {
+	return func(ctx context.Context) ([]sample, error) {
+		results, err := nodeSelector(ctx, kubeClient)
+		if err == nil {
+			err = allFailed(results)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return resultSamples(results, flagCollectorOptions())
+	}
+}
+
+// runPushLoop collects samples every interval and writes them to the sink.
+// Each collection is bounded by the interval.
+func runPushLoop(ctx context.Context, s sink, interval time.Duration, collect func(context.Context) ([]sample, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		pushCtx, cancel := context.WithTimeout(ctx, interval)
+		samples, err := collect(pushCtx)
+		if err == nil {
+			err = s.Write(pushCtx, samples, time.Now())
+		}
+		cancel()
+		if err != nil {
+			sinkErrors.WithLabelValues(s.Name()).Inc()
+			fmt.Printf("[Error] Pushing to %s failed: %v\n", s.Name(), err)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"hash/fnv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var startupRampRatio = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: metricsNamespace,
+	Name:      "startup_ramp_ratio",
+	Help:      "Share of the nodes the last background collection cycle collected while ramping up with --startup-ramp-cycles, 1 once every node is collected",
+})
+
+func init() {
+	prometheus.MustRegister(startupRampRatio)
+}
+
+type startupRampKey struct{}
+
+// startupRamp is a cycle of the startup ramp-up: the nodes of the first
+// batches of the batches the nodes are split into are collected
+type startupRamp struct {
+	batches, of int
+}
+
+// withStartupRamp returns a context under which collectNodeStats only collects
+// the nodes among the first batches of the nodes split into of batches, so
+// that the first collections of a fresh exporter don't query every node of a
+// large cluster through the API server at once
+func withStartupRamp(ctx context.Context, batches, of int) context.Context {
+	return context.WithValue(ctx, startupRampKey{}, startupRamp{batches: batches, of: of})
+}
+
+// rampedUp returns whether the node is collected by the startup ramp-up of the
+// context. Every node is collected without one.
+func rampedUp(ctx context.Context, nodeName string) bool {
+	ramp, ok := ctx.Value(startupRampKey{}).(startupRamp)
+	return !ok || rampBatch(nodeName, ramp.of) < ramp.batches
+}
+
+// rampBatch is the batch of the node among of batches, derived from a hash of
+// its name so that a node collected in a cycle is collected in the next ones
+func rampBatch(nodeName string, of int) int {
+	if of <= 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(nodeName))
+	return int(h.Sum32() % uint32(of))
+}
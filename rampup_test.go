@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+)
+
+func Test_rampedUp(t *testing.T) {
+	var names []string
+	for i := 0; i < 1000; i++ {
+		names = append(names, fmt.Sprintf("node-%d", i))
+	}
+
+	previous := map[string]bool{}
+	for batches := 1; batches <= 4; batches++ {
+		ctx := withStartupRamp(context.Background(), batches, 4)
+		collected := map[string]bool{}
+		for _, name := range names {
+			if rampedUp(ctx, name) {
+				collected[name] = true
+			}
+		}
+		// Every cycle collects the nodes of the previous one and a quarter more
+		for name := range previous {
+			if !collected[name] {
+				t.Errorf("cycle %d doesn't collect %s, collected by the previous cycle", batches, name)
+			}
+		}
+		if want := batches * len(names) / 4; len(collected) < want-100 || len(collected) > want+100 {
+			t.Errorf("cycle %d collected %d nodes, want about %d", batches, len(collected), want)
+		}
+		previous = collected
+	}
+	if len(previous) != len(names) {
+		t.Errorf("the last cycle collected %d nodes, want all %d", len(previous), len(names))
+	}
+
+	if !rampedUp(context.Background(), "node-0") {
+		t.Error("rampedUp() without a ramp-up skips node-0")
+	}
+}
+
+func Test_collectNodeStats_startupRamp(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	var want int
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("node-%d", i)
+		srv.AddNode(fakekubelet.Node{Name: name, Summary: fakekubelet.Fixture("node")})
+		if rampBatch(name, 2) == 0 {
+			want++
+		}
+	}
+
+	results, err := allNodesSelector(withStartupRamp(context.Background(), 1, 2), kubeClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != want {
+		t.Errorf("the first of 2 ramp-up cycles collected %d nodes, want %d", len(results), want)
+	}
+	for _, result := range results {
+		if rampBatch(result.NodeName, 2) != 0 {
+			t.Errorf("the first ramp-up cycle collected %s of the second batch", result.NodeName)
+		}
+	}
+}
+
+func Test_summaryCache_readyAfterRamp(t *testing.T) {
+	cache := newSummaryCache(3)
+	cache.rampCycles = 3
+
+	for cycle := 1; cycle <= 3; cycle++ {
+		cache.update(nil)
+		if got, want := cache.ready(), cycle == 3; got != want {
+			t.Errorf("ready() after cycle %d of the ramp-up = %t, want %t", cycle, got, want)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// Modes of the background collection, see --collection-mode
+const (
+	collectionModeCycle     = "cycle"
+	collectionModeWorkqueue = "workqueue"
+)
+
+var collectionModes = []string{collectionModeCycle, collectionModeWorkqueue}
+
+// reconcilerRetryBaseDelay is the delay before the first retry of a node
+// whose collection failed, doubled on every failure up to the interval
+const reconcilerRetryBaseDelay = time.Second
+
+var (
+	reconcilerRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "collection_retries_total",
+		Help:      "Number of failed node collections retried with backoff by the workqueue collector",
+	},
+		[]string{
+			"node",
+		},
+	)
+	reconcilerQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "collection_queue_depth",
+		Help:      "Number of nodes due for collection waiting for a worker of the workqueue collector",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(reconcilerRetries, reconcilerQueueDepth)
+}
+
+// nodeReconciler collects every node on its own schedule: the nodes watched
+// by an informer are queued as soon as they are added, collected by
+// --concurrency workers and queued again an interval, or the one of their
+// --node-interval-annotation, later, or after a backoff starting at
+// reconcilerRetryBaseDelay if the collection failed and the retry budget
+// allows it. Deleted nodes are dropped from the cache right away, while
+// the pods still expire after the cache expiry cycles.
+type nodeReconciler struct {
+	kubeClient *kubernetes.Clientset
+	cache      *summaryCache
+	interval   time.Duration
+	queue      workqueue.TypedRateLimitingInterface[string]
+
+	mu    sync.Mutex
+	nodes map[string]corev1.Node
+	// pending are the nodes synced by the informer that weren't collected
+	// yet, the cache completing its first cycle once they all were
+	pending   map[string]bool
+	firstPass chan struct{}
+}
+
+func newNodeReconciler(kubeClient *kubernetes.Clientset, c *summaryCache, interval time.Duration) *nodeReconciler {
+	return &nodeReconciler{
+		kubeClient: kubeClient,
+		cache:      c,
+		interval:   interval,
+		queue: workqueue.NewTypedRateLimitingQueueWithConfig(
+			workqueue.NewTypedItemExponentialFailureRateLimiter[string](reconcilerRetryBaseDelay, interval),
+			workqueue.TypedRateLimitingQueueConfig[string]{Name: "nodes"},
+		),
+		nodes:     map[string]corev1.Node{},
+		firstPass: make(chan struct{}),
+	}
+}
+
+// set stores the node and queues it if it's new
+func (r *nodeReconciler) set(node corev1.Node) {
+	if len(excludeNodes([]corev1.Node{node}, flagExcludeNodes)) == 0 || !nodeShard.owns(node) {
+		return
+	}
+	r.mu.Lock()
+	_, known := r.nodes[node.Name]
+	r.nodes[node.Name] = node
+	r.mu.Unlock()
+	if !known {
+		r.queue.Add(node.Name)
+	}
+}
+
+// remove forgets the node and drops it from the cache, and its retries and
+// other per node series from the metrics
+func (r *nodeReconciler) remove(name string) {
+	r.mu.Lock()
+	delete(r.nodes, name)
+	r.donePending(name)
+	r.mu.Unlock()
+	r.queue.Forget(name)
+	r.cache.removeNode(name)
+	reconcilerRetries.DeleteLabelValues(name)
+	sourceNodes.removed(name)
+}
+
+// donePending records that a node was collected, or removed, since the start.
+// It must be called with the lock held.
+func (r *nodeReconciler) donePending(name string) {
+	if r.pending == nil {
+		return
+	}
+	delete(r.pending, name)
+	if len(r.pending) == 0 {
+		r.pending = nil
+		close(r.firstPass)
+	}
+}
+
+func (r *nodeReconciler) eventHandler() cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if node, ok := obj.(*corev1.Node); ok {
+				r.set(*node)
+			}
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			if node, ok := obj.(*corev1.Node); ok {
+				r.set(*node)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if node, ok := obj.(*corev1.Node); ok {
+				r.remove(node.Name)
+			}
+		},
+	}
+}
+
+// processNext collects the next node of the queue and schedules its next
+// collection. It returns false once the queue is shut down.
+func (r *nodeReconciler) processNext(ctx context.Context) bool {
+	name, shutdown := r.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer r.queue.Done(name)
+	reconcilerQueueDepth.Set(float64(r.queue.Len()))
+
+	r.mu.Lock()
+	node, ok := r.nodes[name]
+	r.mu.Unlock()
+	if !ok {
+		// Deleted while queued
+		r.queue.Forget(name)
+		return true
+	}
+
+	collectCtx, cancel := context.WithTimeout(ctx, r.interval)
+	collectCtx, span := tracer.Start(collectCtx, "reconcileNode")
+	result := collectNode(collectCtx, r.kubeClient, node, 1, 1)
+	span.End()
+	cancel()
+	r.cache.updateNode(result)
+
+	r.mu.Lock()
+	r.donePending(name)
+	_, ok = r.nodes[name]
+	r.mu.Unlock()
+	if !ok {
+		// Deleted while collected
+		r.cache.removeNode(name)
+		return true
+	}
+
+	if result.Err != nil && summaryRetryBudget.retry() {
+		reconcilerRetries.WithLabelValues(name).Inc()
+		r.queue.AddRateLimited(name)
+		return true
+	}
+	every := nodeInterval(node, *flagNodeIntervalAnnotation, r.interval)
+	r.cache.setNodeInterval(name, every)
+	r.queue.Forget(name)
+	r.queue.AddAfter(name, every)
+	return true
+}
+
+// runNodeReconciler watches the nodes and collects them in the background
+// with a nodeReconciler until the context is done. It returns once the
+// informer is synced, failing if it isn't within informerSyncTimeout. Every
+// interval, and once every node was collected after
+// the start, the cache completes a cycle, expiring the pods that disappeared,
+// the snapshot sinks are written and the watchdog beats.
+func runNodeReconciler(ctx context.Context, kubeClient *kubernetes.Clientset, c *summaryCache, interval time.Duration, wd *watchdog, snapshots ...snapshotSink) error {
+	c.mu.Lock()
+	c.interval = interval
+	c.mu.Unlock()
+
+	r := newNodeReconciler(kubeClient, c, interval)
+	factory := informers.NewSharedInformerFactory(kubeClient, 0)
+	informer := factory.Core().V1().Nodes().Informer()
+	registration, err := informer.AddEventHandler(r.eventHandler())
+	if err != nil {
+		return err
+	}
+	factory.Start(ctx.Done())
+	syncCtx, cancel := context.WithTimeout(ctx, informerSyncTimeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(syncCtx.Done(), registration.HasSynced) {
+		return fmt.Errorf("nodes not synced within %s, can the exporter list and watch them?", informerSyncTimeout)
+	}
+
+	r.mu.Lock()
+	r.pending = make(map[string]bool, len(r.nodes))
+	for name := range r.nodes {
+		r.pending[name] = true
+	}
+	if len(r.pending) == 0 {
+		r.pending = nil
+		close(r.firstPass)
+	}
+	r.mu.Unlock()
+
+	for range max(*flagConcurrency, 1) {
+		go func() {
+			for r.processNext(ctx) {
+			}
+		}()
+	}
+	go func() {
+		<-ctx.Done()
+		r.queue.ShutDown()
+	}()
+
+	go wd.run(ctx, interval, 2*interval)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		firstPass := r.firstPass
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-firstPass:
+				firstPass = nil
+			case <-ticker.C:
+			}
+			c.update(nil)
+			lastCollectionTimestamp.SetToCurrentTime()
+			writeSnapshots(ctx, snapshots, c.results(), interval)
+			wd.beat()
+		}
+	}()
+	return nil
+}
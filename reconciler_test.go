@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+)
+
+func Test_nodeReconciler(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Summary: fakekubelet.Fixture("node")})
+
+	cache := newSummaryCache(1)
+	r := newNodeReconciler(kubeClient, cache, time.Hour)
+	t.Cleanup(r.queue.ShutDown)
+	r.pending = map[string]bool{"node-a": true, "node-b": true}
+
+	handler := r.eventHandler()
+	nodeA := &corev1.Node{ObjectMeta: meta_v1.ObjectMeta{Name: "node-a"}}
+	nodeB := &corev1.Node{ObjectMeta: meta_v1.ObjectMeta{Name: "node-b"}}
+	handler.OnAdd(nodeA, true)
+	handler.OnAdd(nodeB, true)
+	// Updates don't queue the known nodes again
+	handler.OnUpdate(nodeA, nodeA)
+	if n := r.queue.Len(); n != 2 {
+		t.Fatalf("got %d queued nodes, want 2", n)
+	}
+
+	r.processNext(context.Background())
+	r.processNext(context.Background())
+	if _, ok := cache.result("node-a"); !ok {
+		t.Error("node-a isn't cached after its collection")
+	}
+	if n := r.queue.NumRequeues("node-b"); n != 1 {
+		t.Errorf("node-b, whose collection failed, was requeued %d times, want 1", n)
+	}
+	if n := r.queue.NumRequeues("node-a"); n != 0 {
+		t.Errorf("node-a was requeued %d times with backoff, want 0", n)
+	}
+	if n := testutil.ToFloat64(reconcilerRetries.WithLabelValues("node-b")); n != 1 {
+		t.Errorf("counted %v retries of node-b, want 1", n)
+	}
+	select {
+	case <-r.firstPass:
+	default:
+		t.Error("the first pass isn't done once every node was collected")
+	}
+
+	handler.OnDelete(nodeA)
+	if _, ok := cache.result("node-a"); ok {
+		t.Error("node-a is still cached after its deletion")
+	}
+	if n := srv.SummaryRequests("node-a"); n != 1 {
+		t.Errorf("node-a received %d summary requests, want 1", n)
+	}
+
+	// The retries of a deleted node are forgotten
+	handler.OnDelete(nodeB)
+	if n := testutil.CollectAndCount(reconcilerRetries); n != 0 {
+		t.Errorf("got %d retry series once the nodes were deleted, want none", n)
+	}
+}
+
+func Test_runNodeReconciler_syncTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { informerSyncTimeout = timeout }(informerSyncTimeout)
+	informerSyncTimeout = 100 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := runNodeReconciler(ctx, newForbiddenKubeClient(t), newSummaryCache(1), time.Hour, nil); err == nil || !strings.Contains(err.Error(), "not synced") {
+		t.Errorf("runNodeReconciler() without access to the nodes = %v, want a sync error", err)
+	}
+}
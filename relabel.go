@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/model"
+)
+
+// metricRelabelRules are applied to every series served or pushed, they are
+// set from the metric_relabel_configs of the --config-file
+var metricRelabelRules []relabelRule
+
+// relabelConfig is a Prometheus metric_relabel_configs entry, the metric name
+// being the __name__ label
+type relabelConfig struct {
+	SourceLabels []string `json:"source_labels,omitempty"`
+	Separator    *string  `json:"separator,omitempty"`
+	Regex        *string  `json:"regex,omitempty"`
+	TargetLabel  string   `json:"target_label,omitempty"`
+	Replacement  *string  `json:"replacement,omitempty"`
+	Action       string   `json:"action,omitempty"`
+}
+
+// relabelRule is a relabelConfig with the Prometheus defaults applied and the
+// regular expression compiled
+type relabelRule struct {
+	sourceLabels []string
+	separator    string
+	regex        *regexp.Regexp
+	targetLabel  string
+	replacement  string
+	action       string
+}
+
+var relabelActions = []string{"replace", "keep", "drop", "labelmap", "labeldrop", "labelkeep"}
+
+func (c relabelConfig) compile() (relabelRule, error) {
+	rule := relabelRule{
+		sourceLabels: c.SourceLabels,
+		separator:    ";",
+		targetLabel:  c.TargetLabel,
+		replacement:  "$1",
+		action:       c.Action,
+	}
+	if c.Separator != nil {
+		rule.separator = *c.Separator
+	}
+	if c.Replacement != nil {
+		rule.replacement = *c.Replacement
+	}
+	if rule.action == "" {
+		rule.action = "replace"
+	}
+
+	regex := "(.*)"
+	if c.Regex != nil {
+		regex = *c.Regex
+	}
+	var err error
+	if rule.regex, err = regexp.Compile("^(?:" + regex + ")$"); err != nil {
+		return relabelRule{}, fmt.Errorf("invalid regex %q: %w", regex, err)
+	}
+
+	switch rule.action {
+	case "replace":
+		if rule.targetLabel == "" {
+			return relabelRule{}, fmt.Errorf("target_label is required by the replace action")
+		}
+	case "keep", "drop", "labelmap", "labeldrop", "labelkeep":
+	default:
+		return relabelRule{}, fmt.Errorf("unknown action %q, expected one of %s", rule.action, strings.Join(relabelActions, ", "))
+	}
+	return rule, nil
+}
+
+// apply relabels the label set in place and returns false if the series is
+// dropped
+func (r relabelRule) apply(lset map[string]string) bool {
+	values := make([]string, 0, len(r.sourceLabels))
+	for _, name := range r.sourceLabels {
+		values = append(values, lset[name])
+	}
+	value := strings.Join(values, r.separator)
+
+	switch r.action {
+	case "drop":
+		return !r.regex.MatchString(value)
+	case "keep":
+		return r.regex.MatchString(value)
+	case "replace":
+		match := r.regex.FindStringSubmatchIndex(value)
+		if match == nil {
+			return true
+		}
+		target := string(r.regex.ExpandString(nil, r.targetLabel, value, match))
+		if !model.LabelName(target).IsValid() {
+			return true
+		}
+		replacement := string(r.regex.ExpandString(nil, r.replacement, value, match))
+		if replacement == "" {
+			delete(lset, target)
+		} else {
+			lset[target] = replacement
+		}
+	case "labelmap":
+		mapped := map[string]string{}
+		for name, v := range lset {
+			if r.regex.MatchString(name) {
+				mapped[r.regex.ReplaceAllString(name, r.replacement)] = v
+			}
+		}
+		for name, v := range mapped {
+			lset[name] = v
+		}
+	case "labeldrop", "labelkeep":
+		for name := range lset {
+			if r.regex.MatchString(name) == (r.action == "labeldrop") {
+				delete(lset, name)
+			}
+		}
+	}
+	return true
+}
+
+// relabel applies the rules in order and returns false if the series is
+// dropped
+func relabel(lset map[string]string, rules []relabelRule) bool {
+	for _, rule := range rules {
+		if !rule.apply(lset) {
+			return false
+		}
+	}
+	return true
+}
+
+// relabelGatherer applies relabel rules to the gathered series, which are
+// sorted by name and labels again. Series left without a valid metric name are
+// dropped, as are the series that collide with a series already gathered.
+type relabelGatherer struct {
+	gatherer prometheus.Gatherer
+	rules    []relabelRule
+}
+
+// relabeled returns the gatherer with the metricRelabelRules applied
+func relabeled(g prometheus.Gatherer) prometheus.Gatherer {
+	if len(metricRelabelRules) == 0 {
+		return g
+	}
+	return relabelGatherer{gatherer: g, rules: metricRelabelRules}
+}
+
+func (g relabelGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.gatherer.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	relabeled := map[string]*dto.MetricFamily{}
+	seen := map[string]bool{}
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			lset := map[string]string{model.MetricNameLabel: mf.GetName()}
+			for _, lp := range m.GetLabel() {
+				lset[lp.GetName()] = lp.GetValue()
+			}
+			if !relabel(lset, g.rules) {
+				continue
+			}
+
+			name := lset[model.MetricNameLabel]
+			if !model.IsValidMetricName(model.LabelValue(name)) {
+				continue
+			}
+			delete(lset, model.MetricNameLabel)
+
+			out, ok := relabeled[name]
+			if !ok {
+				out = &dto.MetricFamily{Name: &name, Help: mf.Help, Type: mf.Type}
+				relabeled[name] = out
+			}
+			if out.GetType() != mf.GetType() {
+				continue
+			}
+
+			m.Label = labelPairs(lset)
+			key := seriesKey(name, m.Label)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			out.Metric = append(out.Metric, m)
+		}
+	}
+
+	result := make([]*dto.MetricFamily, 0, len(relabeled))
+	for _, mf := range relabeled {
+		if len(mf.Metric) == 0 {
+			continue
+		}
+		sort.Slice(mf.Metric, func(i, j int) bool {
+			return seriesKey("", mf.Metric[i].Label) < seriesKey("", mf.Metric[j].Label)
+		})
+		result = append(result, mf)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].GetName() < result[j].GetName() })
+	return result, nil
+}
+
+// seriesKey identifies a series by its name and sorted labels
+func seriesKey(name string, pairs []*dto.LabelPair) string {
+	var b strings.Builder
+	b.WriteString(name)
+	for _, lp := range pairs {
+		fmt.Fprintf(&b, ",%s=%q", lp.GetName(), lp.GetValue())
+	}
+	return b.String()
+}
+
+// labelPairs returns the non empty labels sorted by name
+func labelPairs(lset map[string]string) []*dto.LabelPair {
+	names := make([]string, 0, len(lset))
+	for name, value := range lset {
+		if value != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	pairs := make([]*dto.LabelPair, 0, len(names))
+	for _, name := range names {
+		name, value := name, lset[name]
+		pairs = append(pairs, &dto.LabelPair{Name: &name, Value: &value})
+	}
+	return pairs
+}
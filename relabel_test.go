@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func Test_relabelGatherer(t *testing.T) {
+	str := func(s string) *string { return &s }
+	var rules []relabelRule
+	for _, c := range []relabelConfig{
+		// Map namespaces to teams
+		{SourceLabels: []string{"namespace"}, Regex: str("team-(a|b)-.*"), TargetLabel: "team", Replacement: str("$1")},
+		{SourceLabels: []string{"__name__", "namespace"}, Regex: str("used;kube-system"), Action: "drop"},
+		{Regex: str("node"), Action: "labeldrop"},
+		{SourceLabels: []string{"__name__"}, Regex: str("renamed"), TargetLabel: "__name__", Replacement: str("used")},
+	} {
+		rule, err := c.compile()
+		if err != nil {
+			t.Fatal(err)
+		}
+		rules = append(rules, rule)
+	}
+
+	registry := prometheus.NewRegistry()
+	used := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "used", Help: "Used"}, []string{"node", "namespace"})
+	renamed := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "renamed", Help: "Renamed"}, []string{"node", "namespace"})
+	registry.MustRegister(used, renamed)
+	used.WithLabelValues("node-a", "team-a-web").Set(1)
+	used.WithLabelValues("node-a", "kube-system").Set(2)
+	used.WithLabelValues("node-a", "mon").Set(3)
+	// Collides with the series above once node is dropped
+	used.WithLabelValues("node-b", "mon").Set(4)
+	renamed.WithLabelValues("node-a", "team-b-api").Set(5)
+
+	families, err := relabelGatherer{gatherer: registry, rules: rules}.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []sample{
+		{Name: "used", Labels: []labelPair{{"namespace", "mon"}}, Value: 3},
+		{Name: "used", Labels: []labelPair{{"namespace", "team-a-web"}, {"team", "a"}}, Value: 1},
+		{Name: "used", Labels: []labelPair{{"namespace", "team-b-api"}, {"team", "b"}}, Value: 5},
+	}
+	if diff := cmp.Diff(want, flattenFamilies(families)); diff != "" {
+		t.Errorf("relabelGatherer.Gather() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func Test_relabelConfig_compile(t *testing.T) {
+	str := func(s string) *string { return &s }
+	for _, c := range []relabelConfig{
+		{Action: "replace"},
+		{Action: "hashmod", TargetLabel: "shard"},
+		{Regex: str("("), Action: "drop"},
+	} {
+		if _, err := c.compile(); err == nil {
+			t.Errorf("compile(%+v) accepted an invalid config", c)
+		}
+	}
+}
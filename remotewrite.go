@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/klauspost/compress/s2"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// remoteWriteSink pushes samples to a Prometheus remote write endpoint, e.g.
+// Mimir, Thanos Receive or a Prometheus with --web.enable-remote-write-receiver
+type remoteWriteSink struct {
+	url     string
+	headers http.Header
+}
+
+func newRemoteWriteSink(url string, headers map[string]string) *remoteWriteSink {
+	h := sinkHeaders(headers)
+	h.Set("Content-Type", "application/x-protobuf")
+	h.Set("Content-Encoding", "snappy")
+	h.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	return &remoteWriteSink{
+		url:     url,
+		headers: h,
+	}
+}
+
+func (s *remoteWriteSink) Name() string {
+	return "remote_write"
+}
+
+func (s *remoteWriteSink) Write(ctx context.Context, samples []sample, ts time.Time) error {
+	return postSamples(ctx, s.url, s.headers, s2.EncodeSnappy(nil, encodeWriteRequest(samples, ts)))
+}
+
+// encodeWriteRequest encodes the samples as a remote write 1.0 WriteRequest
+// protobuf message, one time series per sample with the metric name as the
+// __name__ label:
+//
+//	message WriteRequest { repeated TimeSeries timeseries = 1; }
+//	message TimeSeries { repeated Label labels = 1; repeated Sample samples = 2; }
+//	message Label { string name = 1; string value = 2; }
+//	message Sample { double value = 1; int64 timestamp = 2; }
+func encodeWriteRequest(samples []sample, ts time.Time) []byte {
+	var req, series, pair, point []byte
+	for _, s := range samples {
+		labels := append([]labelPair{{Name: "__name__", Value: s.Name}}, s.Labels...)
+		sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+
+		series = series[:0]
+		for _, l := range labels {
+			if l.Value == "" {
+				continue
+			}
+			pair = pair[:0]
+			pair = protowire.AppendTag(pair, 1, protowire.BytesType)
+			pair = protowire.AppendString(pair, l.Name)
+			pair = protowire.AppendTag(pair, 2, protowire.BytesType)
+			pair = protowire.AppendString(pair, l.Value)
+			series = protowire.AppendTag(series, 1, protowire.BytesType)
+			series = protowire.AppendBytes(series, pair)
+		}
+
+		point = point[:0]
+		point = protowire.AppendTag(point, 1, protowire.Fixed64Type)
+		point = protowire.AppendFixed64(point, math.Float64bits(s.Value))
+		point = protowire.AppendTag(point, 2, protowire.VarintType)
+		point = protowire.AppendVarint(point, uint64(ts.UnixMilli()))
+		series = protowire.AppendTag(series, 2, protowire.BytesType)
+		series = protowire.AppendBytes(series, point)
+
+		req = protowire.AppendTag(req, 1, protowire.BytesType)
+		req = protowire.AppendBytes(req, series)
+	}
+	return req
+}
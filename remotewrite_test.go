@@ -0,0 +1,76 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// decodeFields returns the fields of a protobuf message by number, the
+// varint and fixed64 values as uint64 and the length delimited ones as bytes
+func decodeFields(t *testing.T, b []byte) map[protowire.Number][]interface{} {
+	t.Helper()
+	fields := map[protowire.Number][]interface{}{}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			t.Fatalf("invalid tag: %v", protowire.ParseError(n))
+		}
+		b = b[n:]
+		var v interface{}
+		switch typ {
+		case protowire.BytesType:
+			v, n = protowire.ConsumeBytes(b)
+		case protowire.VarintType:
+			v, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			v, n = protowire.ConsumeFixed64(b)
+		default:
+			t.Fatalf("unexpected wire type %d", typ)
+		}
+		if n < 0 {
+			t.Fatalf("invalid field %d: %v", num, protowire.ParseError(n))
+		}
+		b = b[n:]
+		fields[num] = append(fields[num], v)
+	}
+	return fields
+}
+
+func Test_encodeWriteRequest(t *testing.T) {
+	samples := []sample{
+		{
+			Name:   "kube_summary_pod_ephemeral_storage_used_bytes",
+			Labels: []labelPair{{"namespace", "mon"}, {"node", "node-a"}, {"provider", ""}},
+			Value:  8192,
+		},
+	}
+	ts := time.Unix(1669817681, 0)
+
+	req := decodeFields(t, encodeWriteRequest(samples, ts))
+	if len(req[1]) != 1 {
+		t.Fatalf("encoded %d time series, want 1", len(req[1]))
+	}
+	series := decodeFields(t, req[1][0].([]byte))
+
+	var labels []labelPair
+	for _, l := range series[1] {
+		pair := decodeFields(t, l.([]byte))
+		labels = append(labels, labelPair{string(pair[1][0].([]byte)), string(pair[2][0].([]byte))})
+	}
+	want := []labelPair{{"__name__", "kube_summary_pod_ephemeral_storage_used_bytes"}, {"namespace", "mon"}, {"node", "node-a"}}
+	if diff := cmp.Diff(want, labels); diff != "" {
+		t.Errorf("encodeWriteRequest() labels mismatch (-want +got):\n%s", diff)
+	}
+
+	point := decodeFields(t, series[2][0].([]byte))
+	if v := math.Float64frombits(point[1][0].(uint64)); v != 8192 {
+		t.Errorf("encodeWriteRequest() value = %g, want 8192", v)
+	}
+	if ms := int64(point[2][0].(uint64)); ms != ts.UnixMilli() {
+		t.Errorf("encodeWriteRequest() timestamp = %d, want %d", ms, ts.UnixMilli())
+	}
+}
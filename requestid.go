@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+)
+
+// requestIDHeader carries the ID correlating a request with the logs and the
+// error body of its response
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the IDs propagated from the callers
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// withRequestID propagates the X-Request-ID of the request, or generates one,
+// to the context of the request and the response headers
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// validRequestID accepts the non empty IDs of printable ASCII characters, so
+// that a caller can't inject anything into the logs
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// requestID returns the ID of the request of the context, if any
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// logError logs an error, prefixed by the ID of the request of the context if
+// any
+func logError(ctx context.Context, format string, args ...interface{}) {
+	if id := requestID(ctx); id != "" {
+		format = "[" + id + "] " + format
+	}
+	fmt.Printf("[Error] "+format+"\n", args...)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+)
+
+func Test_withRequestID(t *testing.T) {
+	var got string
+	h := withRequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = requestID(r.Context())
+	}))
+
+	for header, propagated := range map[string]bool{
+		"scraper-1/abc": true,
+		"":              false,
+		"two words":     false,
+		"line\nbreak":   false,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/nodes", nil)
+		if header != "" {
+			req.Header.Set(requestIDHeader, header)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if got == "" || rec.Header().Get(requestIDHeader) != got {
+			t.Errorf("request ID %q in the context, %q in the response", got, rec.Header().Get(requestIDHeader))
+		}
+		if (got == header) != propagated {
+			t.Errorf("X-Request-ID %q: got %q, propagated %t", header, got, propagated)
+		}
+	}
+}
+
+func TestRouter_errorBody(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", StatusCode: http.StatusServiceUnavailable})
+	h := withRequestID(newRouter(kubeClient, nil, nil, allNodesSelector, singleNodeSelector))
+
+	for target, want := range map[string]apiError{
+		"/node/missing": {Error: `node "missing" not found`, Node: "missing", Reason: reasonNotFound, RequestID: "req-1"},
+		"/probe":        {Error: "target parameter is missing", Reason: reasonBadRequest, RequestID: "req-1"},
+	} {
+		code, body := get(t, h, target, http.Header{http.CanonicalHeaderKey(requestIDHeader): {"req-1"}})
+		var got apiError
+		if err := json.Unmarshal([]byte(body), &got); err != nil {
+			t.Fatalf("GET %s returned %d and an invalid JSON error %q: %v", target, code, body, err)
+		}
+		if got != want {
+			t.Errorf("GET %s returned %+v, want %+v", target, got, want)
+		}
+	}
+
+	code, body := get(t, h, "/node/node-a", http.Header{http.CanonicalHeaderKey(requestIDHeader): {"req-2"}})
+	var got apiError
+	if err := json.Unmarshal([]byte(body), &got); err != nil {
+		t.Fatal(err)
+	}
+	if code != http.StatusInternalServerError || got.Node != "node-a" || got.Reason != reasonCollectionFailed || got.RequestID != "req-2" {
+		t.Errorf("GET of a failing node returned %d %+v", code, got)
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/exporter"
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+var resourceMetricsFallbacks = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metricsNamespace,
+	Name:      "node_resource_metrics_fallbacks_total",
+	Help:      "Number of collections of the node that fell back to the kubelet /metrics/resource endpoint with --resource-metrics-fallback",
+},
+	[]string{
+		"node",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(resourceMetricsFallbacks)
+}
+
+// proxyResourceMetrics opens the /metrics/resource response of a node through
+// the API server proxy
+func proxyResourceMetrics(ctx context.Context, kubeClient *kubernetes.Clientset, nodeName string) (io.ReadCloser, error) {
+	return kubeClient.CoreV1().RESTClient().Get().Resource("nodes").Name(exporter.ProxyNodeName(nodeName, *flagKubeletPort)).SubResource("proxy").Suffix("metrics/resource").Stream(ctx)
+}
+
+// resourceMetricsFallback returns whether the collection of a node falls back
+// to /metrics/resource after /stats/summary failed with err: the kubelet
+// answered, but didn't serve a summary, as some virtual and managed nodes do
+func resourceMetricsFallback(err error) bool {
+	switch class, _ := classifySummaryError(err); class {
+	case "not_found", "server_error", "unmarshal":
+		return true
+	}
+	return false
+}
+
+// fallBackToResourceMetrics replaces the failed summary of the result with
+// the summary read from the /metrics/resource response of the node, keeping
+// the error of /stats/summary if it can't be read either
+func fallBackToResourceMetrics(ctx context.Context, kubeClient *kubernetes.Clientset, result *PerNodeResult) {
+	s, size, err := getNodeResourceMetrics(ctx, kubeClient, result.NodeName)
+	if err != nil {
+		logError(ctx, "%v", err)
+		return
+	}
+	resourceMetricsFallbacks.WithLabelValues(result.NodeName).Inc()
+	result.Summary, result.ResponseBytes, result.Err = s, size, nil
+	result.Capabilities = nil
+	result.Source = summary.SourceResourceMetrics
+}
+
+// getNodeResourceMetrics retrieves the /metrics/resource response of a node
+// as a summary, along with the size of the raw response
+func getNodeResourceMetrics(ctx context.Context, kubeClient *kubernetes.Clientset, nodeName string) (*stats.Summary, int, error) {
+	stream, err := proxyResourceMetrics(ctx, kubeClient, nodeName)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error querying /metrics/resource for %s: %w", nodeName, err)
+	}
+	defer stream.Close()
+
+	resp, err := exporter.ReadSummary(stream, flagMaxSummaryBytes.Int64())
+	if err != nil {
+		return nil, 0, fmt.Errorf("error reading /metrics/resource response for %s: %w", nodeName, err)
+	}
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(resp))
+	if err != nil {
+		return nil, 0, fmt.Errorf("error parsing /metrics/resource response for %s: %w", nodeName, err)
+	}
+	return resourceMetricsSummary(nodeName, families), len(resp), nil
+}
+
+// resourceMetricsSummary maps the metric families of a /metrics/resource
+// response to a summary, which only has the CPU and memory usage of the node,
+// its pods and their containers. The kubelet reports the cumulative CPU time
+// in seconds where the summary has nanoseconds.
+func resourceMetricsSummary(nodeName string, families map[string]*dto.MetricFamily) *stats.Summary {
+	s := &stats.Summary{Node: stats.NodeStats{NodeName: nodeName}}
+	pods := map[stats.PodReference]*stats.PodStats{}
+	pod := func(m *dto.Metric) *stats.PodStats {
+		ref := stats.PodReference{Name: sampleLabel(m, "pod"), Namespace: sampleLabel(m, "namespace")}
+		if pods[ref] == nil {
+			pods[ref] = &stats.PodStats{PodRef: ref}
+		}
+		return pods[ref]
+	}
+	container := func(m *dto.Metric) *stats.ContainerStats {
+		p, name := pod(m), sampleLabel(m, "container")
+		for i := range p.Containers {
+			if p.Containers[i].Name == name {
+				return &p.Containers[i]
+			}
+		}
+		p.Containers = append(p.Containers, stats.ContainerStats{Name: name})
+		return &p.Containers[len(p.Containers)-1]
+	}
+
+	for _, m := range families["node_cpu_usage_seconds_total"].GetMetric() {
+		s.Node.CPU = cpuStats(m)
+	}
+	for _, m := range families["node_memory_working_set_bytes"].GetMetric() {
+		s.Node.Memory = memoryStats(m)
+	}
+	for _, m := range families["pod_cpu_usage_seconds_total"].GetMetric() {
+		pod(m).CPU = cpuStats(m)
+	}
+	for _, m := range families["pod_memory_working_set_bytes"].GetMetric() {
+		pod(m).Memory = memoryStats(m)
+	}
+	for _, m := range families["container_cpu_usage_seconds_total"].GetMetric() {
+		container(m).CPU = cpuStats(m)
+	}
+	for _, m := range families["container_memory_working_set_bytes"].GetMetric() {
+		container(m).Memory = memoryStats(m)
+	}
+
+	for _, p := range pods {
+		s.Pods = append(s.Pods, *p)
+	}
+	sort.Slice(s.Pods, func(i, j int) bool {
+		a, b := s.Pods[i].PodRef, s.Pods[j].PodRef
+		return a.Namespace < b.Namespace || a.Namespace == b.Namespace && a.Name < b.Name
+	})
+	return s
+}
+
+func cpuStats(m *dto.Metric) *stats.CPUStats {
+	nanoSeconds := uint64(sampleValue(m) * 1e9)
+	return &stats.CPUStats{Time: sampleTime(m), UsageCoreNanoSeconds: &nanoSeconds}
+}
+
+func memoryStats(m *dto.Metric) *stats.MemoryStats {
+	workingSet := uint64(sampleValue(m))
+	return &stats.MemoryStats{Time: sampleTime(m), WorkingSetBytes: &workingSet}
+}
+
+// sampleValue returns the value of a counter, gauge or untyped sample
+func sampleValue(m *dto.Metric) float64 {
+	switch {
+	case m.Counter != nil:
+		return m.Counter.GetValue()
+	case m.Gauge != nil:
+		return m.Gauge.GetValue()
+	}
+	return m.GetUntyped().GetValue()
+}
+
+// sampleTime returns the timestamp of a sample, the zero time if it has none
+func sampleTime(m *dto.Metric) meta_v1.Time {
+	if m.TimestampMs == nil {
+		return meta_v1.Time{}
+	}
+	return meta_v1.NewTime(time.UnixMilli(m.GetTimestampMs()))
+}
+
+// sampleLabel returns the value of a label of a sample
+func sampleLabel(m *dto.Metric, name string) string {
+	for _, l := range m.GetLabel() {
+		if l.GetName() == name {
+			return l.GetValue()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/common/expfmt"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+const testResourceMetrics = `# HELP container_cpu_usage_seconds_total [STABLE] Cumulative cpu time consumed by the container in core-seconds
+# TYPE container_cpu_usage_seconds_total counter
+container_cpu_usage_seconds_total{container="app",namespace="apps",pod="app-0"} 12.5 1700000000000
+container_cpu_usage_seconds_total{container="sidecar",namespace="apps",pod="app-0"} 0.5 1700000000000
+# HELP container_memory_working_set_bytes [STABLE] Current working set of the container in bytes
+# TYPE container_memory_working_set_bytes gauge
+container_memory_working_set_bytes{container="app",namespace="apps",pod="app-0"} 1.048576e+08 1700000000000
+# HELP node_cpu_usage_seconds_total [STABLE] Cumulative cpu time consumed by the node in core-seconds
+# TYPE node_cpu_usage_seconds_total counter
+node_cpu_usage_seconds_total 3600 1700000000000
+# HELP node_memory_working_set_bytes [STABLE] Current working set of the node in bytes
+# TYPE node_memory_working_set_bytes gauge
+node_memory_working_set_bytes 2.147483648e+09 1700000000000
+# HELP pod_cpu_usage_seconds_total [STABLE] Cumulative cpu time consumed by the pod in core-seconds
+# TYPE pod_cpu_usage_seconds_total counter
+pod_cpu_usage_seconds_total{namespace="apps",pod="app-0"} 13 1700000000000
+# HELP pod_memory_working_set_bytes [STABLE] Current working set of the pod in bytes
+# TYPE pod_memory_working_set_bytes gauge
+pod_memory_working_set_bytes{namespace="apps",pod="app-0"} 1.1e+08 1700000000000
+# HELP scrape_error [ALPHA] 1 if there was an error while getting container metrics, 0 otherwise
+# TYPE scrape_error gauge
+scrape_error 0
+`
+
+func Test_resourceMetricsSummary(t *testing.T) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(strings.NewReader(testResourceMetrics))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := resourceMetricsSummary("node-a", families)
+
+	if got := *s.Node.CPU.UsageCoreNanoSeconds; got != 3600e9 {
+		t.Errorf("node usageCoreNanoSeconds = %d, want %d", got, uint64(3600e9))
+	}
+	if got := *s.Node.Memory.WorkingSetBytes; got != 2147483648 {
+		t.Errorf("node workingSetBytes = %d, want 2147483648", got)
+	}
+	if got := s.Node.CPU.Time.UnixMilli(); got != 1700000000000 {
+		t.Errorf("node CPU time = %d, want 1700000000000", got)
+	}
+	if len(s.Pods) != 1 {
+		t.Fatalf("got %d pods, want 1", len(s.Pods))
+	}
+	pod := s.Pods[0]
+	if pod.PodRef.Namespace != "apps" || pod.PodRef.Name != "app-0" || *pod.CPU.UsageCoreNanoSeconds != 13e9 || *pod.Memory.WorkingSetBytes != 110000000 {
+		t.Errorf("unexpected pod %+v", pod)
+	}
+	if len(pod.Containers) != 2 {
+		t.Fatalf("got %d containers, want 2", len(pod.Containers))
+	}
+	if c := pod.Containers[0]; c.Name != "app" || *c.CPU.UsageCoreNanoSeconds != 12.5e9 || *c.Memory.WorkingSetBytes != 104857600 {
+		t.Errorf("unexpected container %+v", c)
+	}
+	if c := pod.Containers[1]; c.Name != "sidecar" || c.Memory != nil {
+		t.Errorf("unexpected container %+v", c)
+	}
+}
+
+func Test_resourceMetricsFallback(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Summary: fakekubelet.Fixture("node")})
+	srv.AddNode(fakekubelet.Node{Name: "node-b", StatusCode: http.StatusNotFound, ErrorBody: "404 page not found", ResourceMetrics: testResourceMetrics})
+	srv.AddNode(fakekubelet.Node{Name: "node-c", StatusCode: http.StatusNotFound, ErrorBody: "404 page not found"})
+	r := newRouter(kubeClient, nil, nil, allNodesSelector, singleNodeSelector)
+
+	code, body := get(t, r, "/nodes", nil)
+	if code != http.StatusOK {
+		t.Fatalf("GET /nodes returned %d: %s", code, body)
+	}
+	assertContains(t, body, `kube_summary_node_scrape_success{kubelet_version="",node="node-b"} 0`)
+	assertNotContains(t, body, `kube_summary_node_cpu_usage_seconds`)
+
+	*flagResourceMetricsFallback = true
+	defer func() { *flagResourceMetricsFallback = false }()
+	code, body = get(t, r, "/nodes", nil)
+	if code != http.StatusOK {
+		t.Fatalf("GET /nodes returned %d: %s", code, body)
+	}
+	assertContains(t, body,
+		`kube_summary_node_scrape_success{kubelet_version="",node="node-b"} 1`,
+		`kube_summary_node_scrape_success{kubelet_version="",node="node-c"} 0`,
+		`kube_summary_node_cpu_usage_seconds{kubelet_version="",node="node-b",source="resource_metrics"} 3600`,
+		`kube_summary_node_memory_working_set_bytes{kubelet_version="",node="node-b",source="resource_metrics"} 2.147483648e+09`,
+		`kube_summary_pod_cpu_usage_seconds{namespace="apps",node="node-b",pod="app-0",source="resource_metrics"} 13`,
+		`kube_summary_container_memory_working_set_bytes{name="app",namespace="apps",node="node-b",pod="app-0",source="resource_metrics"} 1.048576e+08`,
+		`kube_summary_pod_memory_working_set_bytes{namespace="mon",node="node-a",pod="dev-server-0",source="summary"} 7.8651392e+08`,
+	)
+	assertNotContains(t, body, `kube_summary_pod_ephemeral_storage_used_bytes{namespace="apps"`)
+}
+
+func Test_summaryCache_source(t *testing.T) {
+	cache := newSummaryCache(3)
+	cache.update([]PerNodeResult{{NodeName: "node-a", Summary: resourceMetricsSummary("node-a", nil), Source: summary.SourceResourceMetrics}})
+	results := cache.results()
+	if len(results) != 1 || results[0].Source != summary.SourceResourceMetrics {
+		t.Errorf("cached results = %+v, want node-a from %s", results, summary.SourceResourceMetrics)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// retryBudgetWindow is the period over which the retries are compared to
+	// the requests
+	retryBudgetWindow = time.Minute
+	// retryBudgetBuckets is the number of buckets the window is split into,
+	// the oldest one being dropped as time passes
+	retryBudgetBuckets = 10
+	// retryBudgetMinRetries are allowed in every window whatever the number of
+	// requests, so that small clusters can still retry
+	retryBudgetMinRetries = 10
+	// summaryRetryDelay is the delay before the first retry of a summary
+	// request, doubled on every retry
+	summaryRetryDelay = 200 * time.Millisecond
+)
+
+var summaryRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metricsNamespace,
+	Name:      "retries_total",
+	Help:      "Number of failed /stats/summary requests retried, or shed when they exceeded the retry budget, see --retry-budget-ratio",
+},
+	[]string{
+		"outcome",
+	},
+)
+
+// summaryRetryBudget caps the retries of the failed /stats/summary requests,
+// the immediate ones of --summary-retries and the backoffs of the workqueue
+// collector
+var summaryRetryBudget = newRetryBudget(0.2, retryBudgetMinRetries)
+
+func init() {
+	prometheus.MustRegister(summaryRetries, prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "retry_budget_used_ratio",
+		Help:      "Ratio of the retry budget spent over the last minute, retries being shed once it reaches 1",
+	}, func() float64 { return summaryRetryBudget.usedRatio() }))
+}
+
+// retryBudget allows retries as long as they stay below a ratio of the
+// requests of the last retryBudgetWindow, on top of a minimum. When many
+// kubelets fail at once, e.g. during an API server outage, the retries are
+// shed rather than multiplying the load on what is already failing.
+type retryBudget struct {
+	// ratio is the maximum number of retries per request, 0 disables the
+	// budget
+	ratio      float64
+	minRetries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	buckets [retryBudgetBuckets]retryBudgetBucket
+}
+
+// retryBudgetBucket counts the requests and retries of a slice of the window
+type retryBudgetBucket struct {
+	start    time.Time
+	requests int
+	retries  int
+}
+
+func newRetryBudget(ratio float64, minRetries int) *retryBudget {
+	return &retryBudget{ratio: ratio, minRetries: minRetries, now: time.Now}
+}
+
+// bucket returns the bucket of the current time, reset if it held an older
+// slice of the window. It must be called with the lock held.
+func (b *retryBudget) bucket() *retryBudgetBucket {
+	size := retryBudgetWindow / retryBudgetBuckets
+	start := b.now().Truncate(size)
+	bucket := &b.buckets[int(start.UnixNano()/int64(size))%retryBudgetBuckets]
+	if !bucket.start.Equal(start) {
+		*bucket = retryBudgetBucket{start: start}
+	}
+	return bucket
+}
+
+// remaining returns the number of retries left in the window. It must be
+// called with the lock held.
+func (b *retryBudget) remaining() (left, total float64) {
+	var requests, retries int
+	now := b.now()
+	for _, bucket := range b.buckets {
+		if now.Sub(bucket.start) < retryBudgetWindow {
+			requests += bucket.requests
+			retries += bucket.retries
+		}
+	}
+	total = float64(b.minRetries) + b.ratio*float64(requests)
+	return total - float64(retries), total
+}
+
+// request records a first attempt
+func (b *retryBudget) request() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.bucket().requests++
+}
+
+// retry tells whether a failed request can be retried, spending the budget
+// if it can
+func (b *retryBudget) retry() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ratio > 0 {
+		if left, _ := b.remaining(); left < 1 {
+			summaryRetries.WithLabelValues("shed").Inc()
+			return false
+		}
+	}
+	b.bucket().retries++
+	summaryRetries.WithLabelValues("retried").Inc()
+	return true
+}
+
+// usedRatio returns the share of the budget spent in the window
+func (b *retryBudget) usedRatio() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ratio <= 0 {
+		return 0
+	}
+	left, total := b.remaining()
+	return (total - left) / total
+}
+
+// retryableSummaryError tells whether a getNodeSummary error may be transient,
+// the kubelet or the API server being unreachable or overloaded, rather than
+// failing again on retry
+func retryableSummaryError(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	switch class, _ := classifySummaryError(err); class {
+	case "timeout", "connection_refused", "server_error":
+		return true
+	}
+	return false
+}
+
+// sleepRetry waits for the delay of the retry, numbered from 0, returning
+// false if the context is done first
+func sleepRetry(ctx context.Context, retry int) bool {
+	timer := time.NewTimer(summaryRetryDelay << retry)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	corev1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+)
+
+func Test_retryBudget(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	b := newRetryBudget(0.2, 2)
+	b.now = func() time.Time { return now }
+
+	for range 10 {
+		b.request()
+	}
+	// 2 retries of the minimum and 2 for the 10 requests
+	for i := range 4 {
+		if !b.retry() {
+			t.Fatalf("retry %d was shed", i)
+		}
+	}
+	if b.retry() {
+		t.Error("retry() allowed a retry beyond the budget")
+	}
+	if got := b.usedRatio(); got != 1 {
+		t.Errorf("usedRatio() = %v, want 1", got)
+	}
+
+	// The requests and retries are forgotten once out of the window
+	now = now.Add(retryBudgetWindow / 2)
+	b.request()
+	if b.retry() {
+		t.Error("retry() allowed a retry within the window")
+	}
+	now = now.Add(retryBudgetWindow/2 + time.Second)
+	if got := b.usedRatio(); got != 0 {
+		t.Errorf("usedRatio() = %v after the window, want 0", got)
+	}
+	if !b.retry() {
+		t.Error("retry() shed a retry after the window")
+	}
+
+	// A zero ratio disables the budget
+	unlimited := newRetryBudget(0, 0)
+	for i := range 100 {
+		if !unlimited.retry() {
+			t.Fatalf("retry %d was shed without a budget", i)
+		}
+	}
+}
+
+func Test_retryableSummaryError(t *testing.T) {
+	for err, want := range map[error]bool{
+		fmt.Errorf("error querying /stats/summary: %w", context.DeadlineExceeded): true,
+		fmt.Errorf("error querying /stats/summary: %w", context.Canceled):         false,
+		fmt.Errorf("error reading: %w", errSummaryTooLarge):                       false,
+		errors.New("unknown"): false,
+	} {
+		if got := retryableSummaryError(err); got != want {
+			t.Errorf("retryableSummaryError(%v) = %v, want %v", err, got, want)
+		}
+	}
+}
+
+func Test_collectNode_retries(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", StatusCode: http.StatusServiceUnavailable})
+	srv.AddNode(fakekubelet.Node{Name: "node-b", StatusCode: http.StatusForbidden})
+
+	*flagSummaryRetries = 2
+	defer func() { *flagSummaryRetries = 0 }()
+	defer func(b *retryBudget) { summaryRetryBudget = b }(summaryRetryBudget)
+	summaryRetryBudget = newRetryBudget(0.2, 3)
+
+	node := func(name string) corev1.Node { return corev1.Node{ObjectMeta: meta_v1.ObjectMeta{Name: name}} }
+	shed := testutil.ToFloat64(summaryRetries.WithLabelValues("shed"))
+	fetches := func() uint64 {
+		var m dto.Metric
+		if err := fetchDuration.WithLabelValues("").(prometheus.Histogram).Write(&m); err != nil {
+			t.Fatal(err)
+		}
+		return m.GetHistogram().GetSampleCount()
+	}
+	observed := fetches()
+
+	// Server errors are retried, each request observing its own duration
+	if result := collectNode(context.Background(), kubeClient, node("node-a"), 1, 1); result.Err == nil {
+		t.Fatal("collectNode() of a failing node succeeded")
+	}
+	if n := srv.SummaryRequests("node-a"); n != 3 {
+		t.Errorf("node-a got %d summary requests, want 3", n)
+	}
+	if got := fetches() - observed; got != 3 {
+		t.Errorf("observed %d fetch durations, want one per request", got)
+	}
+
+	// Forbidden requests aren't
+	collectNode(context.Background(), kubeClient, node("node-b"), 1, 1)
+	if n := srv.SummaryRequests("node-b"); n != 1 {
+		t.Errorf("node-b got %d summary requests, want 1", n)
+	}
+
+	// The budget has a single retry left
+	collectNode(context.Background(), kubeClient, node("node-a"), 1, 1)
+	if n := srv.SummaryRequests("node-a"); n != 5 {
+		t.Errorf("node-a got %d summary requests, want 5", n)
+	}
+	if got := testutil.ToFloat64(summaryRetries.WithLabelValues("shed")) - shed; got != 1 {
+		t.Errorf("%v retries were shed, want 1", got)
+	}
+}
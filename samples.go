@@ -0,0 +1,95 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+// sample is a single value of a metric, as it appears in the Prometheus text
+// exposition format. Histograms and summaries are flattened into their
+// _bucket, _sum and _count samples.
+type sample struct {
+	Name   string
+	Labels []labelPair
+	Value  float64
+}
+
+type labelPair struct {
+	Name  string
+	Value string
+}
+
+// resultSamples returns the samples of the metrics of the results, failing on
+// the problems of --self-check
+func resultSamples(results []PerNodeResult, opts collectorOptions) ([]sample, error) {
+	registry := prometheus.NewRegistry()
+	summary.Collect(results, registry, opts)
+	families, err := selfChecked(relabeled(registry)).Gather()
+	if err != nil {
+		return nil, err
+	}
+	return flattenFamilies(families), nil
+}
+
+// flattenFamilies converts gathered metric families into samples, with labels
+// sorted by name
+func flattenFamilies(families []*dto.MetricFamily) []sample {
+	var samples []sample
+	for _, mf := range families {
+		name := mf.GetName()
+		for _, m := range mf.GetMetric() {
+			labels := make([]labelPair, 0, len(m.GetLabel()))
+			for _, lp := range m.GetLabel() {
+				labels = append(labels, labelPair{Name: lp.GetName(), Value: lp.GetValue()})
+			}
+			sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+
+			switch mf.GetType() {
+			case dto.MetricType_GAUGE:
+				samples = append(samples, sample{name, labels, m.GetGauge().GetValue()})
+			case dto.MetricType_COUNTER:
+				samples = append(samples, sample{name, labels, m.GetCounter().GetValue()})
+			case dto.MetricType_UNTYPED:
+				samples = append(samples, sample{name, labels, m.GetUntyped().GetValue()})
+			case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+				h := m.GetHistogram()
+				for _, b := range h.GetBucket() {
+					samples = append(samples, sample{name + "_bucket", withLabel(labels, "le", formatFloat(b.GetUpperBound())), float64(b.GetCumulativeCount())})
+				}
+				samples = append(samples, sample{name + "_bucket", withLabel(labels, "le", "+Inf"), float64(h.GetSampleCount())})
+				samples = append(samples, sample{name + "_sum", labels, h.GetSampleSum()})
+				samples = append(samples, sample{name + "_count", labels, float64(h.GetSampleCount())})
+			case dto.MetricType_SUMMARY:
+				s := m.GetSummary()
+				for _, q := range s.GetQuantile() {
+					samples = append(samples, sample{name, withLabel(labels, "quantile", formatFloat(q.GetQuantile())), q.GetValue()})
+				}
+				samples = append(samples, sample{name + "_sum", labels, s.GetSampleSum()})
+				samples = append(samples, sample{name + "_count", labels, float64(s.GetSampleCount())})
+			}
+		}
+	}
+	return samples
+}
+
+// withLabel returns a copy of the sorted labels with an extra label inserted
+func withLabel(labels []labelPair, name, value string) []labelPair {
+	out := make([]labelPair, 0, len(labels)+1)
+	out = append(out, labels...)
+	out = append(out, labelPair{Name: name, Value: value})
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, +1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
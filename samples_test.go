@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func Test_flattenFamilies(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "g", Help: "g"}, []string{"node", "a"})
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "h", Help: "h", Buckets: []float64{1, 10}})
+	registry.MustRegister(gauge, histogram)
+
+	gauge.WithLabelValues("node-a", "x").Set(3)
+	histogram.Observe(0.5)
+	histogram.Observe(5)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []sample{
+		{"g", []labelPair{{"a", "x"}, {"node", "node-a"}}, 3},
+		{"h_bucket", []labelPair{{"le", "1"}}, 1},
+		{"h_bucket", []labelPair{{"le", "10"}}, 2},
+		{"h_bucket", []labelPair{{"le", "+Inf"}}, 2},
+		{"h_sum", []labelPair{}, 5.5},
+		{"h_count", []labelPair{}, 2},
+	}
+	if diff := cmp.Diff(want, flattenFamilies(families)); diff != "" {
+		t.Errorf("flattenFamilies() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// sdNotify sends a state, e.g. READY=1, to the notification socket of
+// systemd. It returns false without an error when the exporter isn't run by a
+// Type=notify unit.
+func sdNotify(state string) (bool, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+
+	addr := &net.UnixAddr{Name: socket, Net: "unixgram"}
+	if socket[0] == '@' {
+		// Abstract socket
+		addr.Name = "\x00" + socket[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, addr)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// sdWatchdogInterval returns the interval between two watchdog pings, half
+// the WatchdogSec of the unit, or 0 if the watchdog isn't enabled for this
+// process
+func sdWatchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond / 2
+}
+
+// runSystemdNotify tells systemd that the exporter is ready once ready
+// returns true, and then pings the watchdog while healthy returns true, so
+// that systemd restarts a wedged exporter. A nil ready or healthy is always
+// true.
+func runSystemdNotify(ctx context.Context, ready, healthy func() bool) {
+	if os.Getenv("NOTIFY_SOCKET") == "" {
+		return
+	}
+
+	poll := time.NewTicker(time.Second)
+	for ready != nil && !ready() {
+		select {
+		case <-ctx.Done():
+			poll.Stop()
+			return
+		case <-poll.C:
+		}
+	}
+	poll.Stop()
+	if _, err := sdNotify("READY=1"); err != nil {
+		fmt.Printf("[Error] Cannot notify systemd: %v\n", err)
+	}
+
+	interval := sdWatchdogInterval()
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if healthy != nil && !healthy() {
+			fmt.Printf("[Error] Skipping the systemd watchdog ping, the exporter is unhealthy\n")
+			continue
+		}
+		if _, err := sdNotify("WATCHDOG=1"); err != nil {
+			fmt.Printf("[Error] Cannot ping the systemd watchdog: %v\n", err)
+		}
+	}
+}
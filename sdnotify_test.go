@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func listenNotifySocket(t *testing.T) *net.UnixConn {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	t.Setenv("NOTIFY_SOCKET", path)
+	return conn
+}
+
+func readNotification(t *testing.T, conn *net.UnixConn) string {
+	t.Helper()
+
+	buf := make([]byte, 256)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(buf[:n])
+}
+
+func Test_sdNotify(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	if sent, err := sdNotify("READY=1"); sent || err != nil {
+		t.Errorf("sdNotify() without a socket = %v, %v", sent, err)
+	}
+
+	conn := listenNotifySocket(t)
+	if sent, err := sdNotify("READY=1"); !sent || err != nil {
+		t.Fatalf("sdNotify() = %v, %v", sent, err)
+	}
+	if got := readNotification(t, conn); got != "READY=1" {
+		t.Errorf("received %q, want READY=1", got)
+	}
+}
+
+func Test_sdWatchdogInterval(t *testing.T) {
+	for _, tc := range []struct {
+		usec, pid string
+		want      time.Duration
+	}{
+		{"", "", 0},
+		{"bogus", "", 0},
+		{"10000000", "", 5 * time.Second},
+		{"10000000", strconv.Itoa(os.Getpid()), 5 * time.Second},
+		{"10000000", "1", 0},
+	} {
+		t.Setenv("WATCHDOG_USEC", tc.usec)
+		t.Setenv("WATCHDOG_PID", tc.pid)
+		if got := sdWatchdogInterval(); got != tc.want {
+			t.Errorf("sdWatchdogInterval() with WATCHDOG_USEC=%q WATCHDOG_PID=%q = %v, want %v", tc.usec, tc.pid, got, tc.want)
+		}
+	}
+}
+
+func Test_runSystemdNotify(t *testing.T) {
+	conn := listenNotifySocket(t)
+	t.Setenv("WATCHDOG_USEC", "20000")
+	t.Setenv("WATCHDOG_PID", "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runSystemdNotify(ctx, func() bool { return true }, func() bool { return true })
+
+	if got := readNotification(t, conn); got != "READY=1" {
+		t.Errorf("first notification is %q, want READY=1", got)
+	}
+	if got := readNotification(t, conn); got != "WATCHDOG=1" {
+		t.Errorf("second notification is %q, want WATCHDOG=1", got)
+	}
+}
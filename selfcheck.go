@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil/promlint"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+)
+
+var selfCheckFailures = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: metricsNamespace,
+	Name:      "self_check_failures_total",
+	Help:      "Number of collections whose output failed --self-check",
+})
+
+func init() {
+	prometheus.MustRegister(selfCheckFailures)
+}
+
+// selfChecked returns the gatherer of the registry, whose output is checked by
+// selfCheck with --self-check. A collection failing the check fails instead of
+// being written, so that Prometheus doesn't silently drop the invalid series.
+func selfChecked(g prometheus.Gatherer) prometheus.Gatherer {
+	if !*flagSelfCheck {
+		return g
+	}
+	return selfCheckGatherer{gatherer: g}
+}
+
+type selfCheckGatherer struct {
+	gatherer prometheus.Gatherer
+}
+
+func (g selfCheckGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.gatherer.Gather()
+	problems := selfCheck(families)
+	if err != nil {
+		problems = append([]string{err.Error()}, problems...)
+	}
+	if len(problems) == 0 {
+		return families, nil
+	}
+
+	selfCheckFailures.Inc()
+	for _, problem := range problems {
+		fmt.Printf("[Error] Self-check: %s\n", problem)
+	}
+	return nil, fmt.Errorf("self-check failed with %d problems: %s", len(problems), strings.Join(problems, "; "))
+}
+
+// selfCheck returns the problems of the metric families: the names and label
+// values Prometheus rejects, the duplicate series, the families that don't
+// survive a round trip through the text exposition format, and the problems
+// found by the lint rules of promtool check metrics
+func selfCheck(families []*dto.MetricFamily) []string {
+	var problems []string
+	for _, mf := range families {
+		name := mf.GetName()
+		if !model.IsValidMetricName(model.LabelValue(name)) {
+			problems = append(problems, fmt.Sprintf("invalid metric name %q", name))
+		}
+		seen := map[string]bool{}
+		for _, m := range mf.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if !model.LabelName(l.GetName()).IsValid() {
+					problems = append(problems, fmt.Sprintf("%s: invalid label name %q", name, l.GetName()))
+				}
+				if !model.LabelValue(l.GetValue()).IsValid() {
+					problems = append(problems, fmt.Sprintf("%s: invalid value %q of label %s", name, l.GetValue(), l.GetName()))
+				}
+			}
+			// The registries sort the labels of the series they gather
+			if key := seriesKey(name, m.GetLabel()); seen[key] {
+				problems = append(problems, fmt.Sprintf("duplicate series %s", key))
+			} else {
+				seen[key] = true
+			}
+		}
+
+		var buf bytes.Buffer
+		if _, err := expfmt.MetricFamilyToText(&buf, mf); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: cannot be encoded: %v", name, err))
+			continue
+		}
+		var parser expfmt.TextParser
+		if _, err := parser.TextToMetricFamilies(&buf); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: invalid exposition: %v", name, err))
+		}
+	}
+
+	lintProblems, err := promlint.NewWithMetricFamilies(families).Lint()
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("lint: %v", err))
+	}
+	for _, p := range lintProblems {
+		problems = append(problems, fmt.Sprintf("%s: %s", p.Metric, p.Text))
+	}
+	return problems
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+func Test_selfCheck(t *testing.T) {
+	var nodeSummary stats.Summary
+	if err := json.Unmarshal(fakekubelet.Fixture("node"), &nodeSummary); err != nil {
+		t.Fatal(err)
+	}
+	families, err := summary.Gather([]PerNodeResult{{NodeName: "node-a", Summary: &nodeSummary}}, collectorOptions{PodInfo: true, ResourceUsage: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problems := selfCheck(families); len(problems) > 0 {
+		t.Errorf("selfCheck() of the summary metrics = %q, want no problems", problems)
+	}
+
+	gauge := func(name string, values ...string) *dto.MetricFamily {
+		mf := &dto.MetricFamily{Name: proto.String(name), Help: proto.String("Test gauge"), Type: dto.MetricType_GAUGE.Enum()}
+		for _, value := range values {
+			mf.Metric = append(mf.Metric, &dto.Metric{
+				Label: []*dto.LabelPair{{Name: proto.String("pod"), Value: proto.String(value)}},
+				Gauge: &dto.Gauge{Value: proto.Float64(1)},
+			})
+		}
+		return mf
+	}
+	for _, tc := range []struct {
+		name   string
+		family *dto.MetricFamily
+		want   string
+	}{
+		{"invalid label value", gauge("kube_summary_test", "app-\xff"), `invalid value "app-\xff" of label pod`},
+		{"duplicate series", gauge("kube_summary_test", "app-0", "app-0"), `duplicate series kube_summary_test,pod="app-0"`},
+		{"invalid metric name", gauge("kube-summary-test", "app-0"), `invalid metric name "kube-summary-test"`},
+		{"lint", &dto.MetricFamily{Name: proto.String("kube_summary_test"), Type: dto.MetricType_GAUGE.Enum(), Metric: []*dto.Metric{{Gauge: &dto.Gauge{Value: proto.Float64(1)}}}}, "kube_summary_test: no help text"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			problems := selfCheck([]*dto.MetricFamily{tc.family})
+			if !strings.Contains(strings.Join(problems, "\n"), tc.want) {
+				t.Errorf("selfCheck() = %q, want a problem containing %q", problems, tc.want)
+			}
+		})
+	}
+}
+
+func Test_selfChecked(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Summary: fakekubelet.Fixture("node")})
+	r := newRouter(kubeClient, nil, nil, allNodesSelector, singleNodeSelector)
+
+	*flagSelfCheck = true
+	defer func() { *flagSelfCheck = false }()
+	if code, body := get(t, r, "/nodes", nil); code != http.StatusOK {
+		t.Fatalf("GET /nodes with --self-check returned %d: %s", code, body)
+	}
+
+	// The streamed collections, /metrics and the push sinks are checked too
+	defer func(stream, include bool) { *flagStreamNodes, *flagMetricsIncludeSummaries = stream, include }(*flagStreamNodes, *flagMetricsIncludeSummaries)
+	*flagStreamNodes, *flagMetricsIncludeSummaries = true, true
+	for _, target := range []string{"/nodes", "/metrics"} {
+		code, body := get(t, r, target, nil)
+		if code != http.StatusOK {
+			t.Fatalf("GET %s with --self-check returned %d: %s", target, code, body)
+		}
+		assertContains(t, body, `kube_summary_container_logs_used_bytes`)
+	}
+	results, err := allNodesSelector(context.Background(), kubeClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if samples, err := resultSamples(results, flagCollectorOptions()); err != nil || len(samples) == 0 {
+		t.Errorf("resultSamples() with --self-check = %d samples, %v", len(samples), err)
+	}
+
+	// A relabel rule renaming a gauge to a _count name fails the lint rules
+	defer func(rules []relabelRule) { metricRelabelRules = rules }(metricRelabelRules)
+	rule, err := relabelConfig{SourceLabels: []string{"__name__"}, Regex: proto.String("kube_summary_container_logs_used_bytes"), TargetLabel: "__name__", Replacement: proto.String("kube_summary_container_logs_count")}.compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	metricRelabelRules = []relabelRule{rule}
+	if _, body := get(t, r, "/nodes", nil); strings.Contains(body, "kube_summary_container_logs") {
+		t.Errorf("GET /nodes streamed the node failing --self-check: %s", body)
+	}
+	if code, _ := get(t, r, "/metrics", nil); code != http.StatusInternalServerError {
+		t.Errorf("GET /metrics failing --self-check returned %d, want %d", code, http.StatusInternalServerError)
+	}
+	if _, err := resultSamples(results, flagCollectorOptions()); err == nil {
+		t.Error("resultSamples() failing --self-check returned no error")
+	}
+
+	invalid := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		return []*dto.MetricFamily{{
+			Name:   proto.String("kube_summary_test"),
+			Help:   proto.String("Test gauge"),
+			Type:   dto.MetricType_GAUGE.Enum(),
+			Metric: []*dto.Metric{{Gauge: &dto.Gauge{Value: proto.Float64(1)}}, {Gauge: &dto.Gauge{Value: proto.Float64(2)}}},
+		}}, nil
+	})
+	failures := testutil.ToFloat64(selfCheckFailures)
+	if _, err := selfChecked(invalid).Gather(); err == nil || !strings.Contains(err.Error(), "duplicate series kube_summary_test") {
+		t.Errorf("Gather() of duplicate series with --self-check = %v, want a self-check error", err)
+	}
+	if got := testutil.ToFloat64(selfCheckFailures) - failures; got != 1 {
+		t.Errorf("counted %v self-check failures, want 1", got)
+	}
+}
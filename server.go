@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"k8s.io/client-go/kubernetes"
+)
+
+// newRouter returns the HTTP handlers of the exporter. nodesSelector and
+// nodeSelector select all the nodes and a single node. If cache is not nil the
+// summaries are served from it instead, and they are only used by the
+// background collection. The SummaryScrape and ClusterSummaryScrape resources
+// are served if scrapes is not nil.
+func newRouter(kubeClient *kubernetes.Clientset, cache *summaryCache, scrapes *summaryScrapes, nodesSelector nodeSelectorFunc, nodeSelector func(string) nodeSelectorFunc) *mux.Router {
+	namespaceSelector := namespaceNodesSelector
+	nodesSelector, nodeSelector = servedSelectors(cache, nodesSelector, nodeSelector)
+	if cache != nil {
+		namespaceSelector = func(string) nodeSelectorFunc {
+			return nodesSelector
+		}
+	}
+
+	// The cached summaries are cheap to serve, only the live collections are
+	// coalesced
+	var group *collectionGroup
+	if cache == nil && *flagCoalesceRequests {
+		group = newCollectionGroup(*flagCoalesceTimeout)
+	}
+
+	exists := servedNodeExists(kubeClient, cache)
+
+	r := mux.NewRouter()
+	if cache != nil {
+		r.Use(withMaxAgeParam)
+	}
+	r.HandleFunc("/nodes", func(w http.ResponseWriter, r *http.Request) {
+		selector := withExcludeParam(r, nodesSelector)
+		if *flagStreamNodes {
+			handleStreamedCollection(w, r, kubeClient, selector)
+			return
+		}
+		handleMetricsCollection(w, r, kubeClient, coalesced(group, nodesCollectionKey(r), selector))
+	})
+	r.HandleFunc("/node/{node}", withNodeValidation(exists, func(w http.ResponseWriter, r *http.Request) {
+		nodeName := mux.Vars(r)["node"]
+		handleMetricsCollection(w, r, kubeClient, coalesced(group, "node/"+nodeName, nodeSelector(nodeName)))
+	}))
+	r.HandleFunc("/influx", func(w http.ResponseWriter, r *http.Request) {
+		selector := coalesced(group, nodesCollectionKey(r), withExcludeParam(r, nodesSelector))
+		handleCollection(w, r, kubeClient, selector, flagCollectorOptions(), writeInflux)
+	})
+	r.HandleFunc("/influx/node/{node}", withNodeValidation(exists, func(w http.ResponseWriter, r *http.Request) {
+		nodeName := mux.Vars(r)["node"]
+		handleCollection(w, r, kubeClient, coalesced(group, "node/"+nodeName, nodeSelector(nodeName)), flagCollectorOptions(), writeInflux)
+	}))
+	r.HandleFunc("/namespace/{namespace}/pods", func(w http.ResponseWriter, r *http.Request) {
+		namespace := mux.Vars(r)["namespace"]
+		handleNamespaceMetricsCollection(w, r, kubeClient, namespace, namespaceSelector(namespace))
+	})
+	cors := flagCORSConfig()
+	r.Handle("/api/openapi.json", withCORS(cors, http.HandlerFunc(handleOpenAPI))).Methods(http.MethodGet, http.MethodOptions)
+	api := r.PathPrefix("/api/v1").Subrouter()
+	api.Use(func(next http.Handler) http.Handler {
+		return withCORS(cors, next)
+	})
+	api.HandleFunc("/nodes", func(w http.ResponseWriter, r *http.Request) {
+		handleAPICollection(w, r, kubeClient, withExcludeParam(r, nodesSelector))
+	}).Methods(http.MethodGet, http.MethodOptions)
+	api.HandleFunc("/nodes/{node}", withNodeValidation(exists, func(w http.ResponseWriter, r *http.Request) {
+		nodeName := mux.Vars(r)["node"]
+		handleAPICollection(w, r, kubeClient, nodeSelector(nodeName))
+	})).Methods(http.MethodGet, http.MethodOptions)
+	if scrapes != nil {
+		r.HandleFunc("/scrape/{namespace}/{name}", func(w http.ResponseWriter, r *http.Request) {
+			vars := mux.Vars(r)
+			handleSummaryScrape(w, r, kubeClient, scrapes, vars["namespace"], vars["name"], nodesSelector)
+		})
+		r.HandleFunc("/scrape/{name}", func(w http.ResponseWriter, r *http.Request) {
+			handleSummaryScrape(w, r, kubeClient, scrapes, "", mux.Vars(r)["name"], nodesSelector)
+		})
+	}
+	if cache != nil {
+		r.HandleFunc("/diff", func(w http.ResponseWriter, r *http.Request) {
+			handleDiff(w, r, cache)
+		})
+	}
+	r.HandleFunc("/export/csv", func(w http.ResponseWriter, r *http.Request) {
+		handleCSVExport(w, r, kubeClient, nodesSelector)
+	})
+	r.HandleFunc("/probe", func(w http.ResponseWriter, r *http.Request) {
+		handleProbe(w, r, kubeClient, nodeSelector)
+	})
+	if peers != nil {
+		r.Handle("/peer/summaries", http.HandlerFunc(peers.handle)).Methods(http.MethodGet)
+	}
+	r.HandleFunc("/debug/coverage", handleCoverage)
+	r.HandleFunc("/debug/collections", handleCollections)
+	r.HandleFunc("/catalog", handleCatalog)
+	r.HandleFunc("/-/config", handleEffectiveConfig(scrapes))
+	r.HandleFunc("/-/progress", handleProgress)
+	r.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		handleSelfMetrics(w, r, kubeClient, nodesSelector)
+	})
+	links := landingPage(linkPrefix(*flagWebExternalURL, *flagWebRoutePrefix))
+	r.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(links)
+	})
+
+	return r
+}
+
+// servedSelectors returns the selectors of all nodes and of a single node the
+// collection endpoints serve: the cached summaries with background
+// collection, the live ones falling back to the last good snapshot otherwise
+func servedSelectors(cache *summaryCache, nodesSelector nodeSelectorFunc, nodeSelector func(string) nodeSelectorFunc) (nodeSelectorFunc, func(string) nodeSelectorFunc) {
+	if cache == nil {
+		return lastGoodSnapshot.selector(nodesSelector), nodeSelector
+	}
+	return cachedAllNodesSelector(cache, nodesSelector), func(nodeName string) nodeSelectorFunc {
+		return cachedSingleNodeSelector(cache, nodeName, nodeSelector(nodeName))
+	}
+}
+
+// servedNodeExists checks the node names of the requests against the node
+// list, or the cached nodes which are the only ones served
+func servedNodeExists(kubeClient *kubernetes.Clientset, cache *summaryCache) nodeExists {
+	nodeNames := newNodeNameCache(nodeNamesSource)
+	return func(ctx context.Context, name string) (bool, error) {
+		if cache != nil {
+			_, ok := cache.result(name)
+			return ok, nil
+		}
+		return nodeNames.has(ctx, kubeClient, name)
+	}
+}
+
+// withExcludeParam excludes the nodes listed in the exclude query parameter,
+// comma separated or repeated, from the selector
+func withExcludeParam(r *http.Request, nodesSelector nodeSelectorFunc) nodeSelectorFunc {
+	var names []string
+	for _, value := range r.URL.Query()["exclude"] {
+		names = append(names, splitList(value)...)
+	}
+	if len(names) == 0 {
+		return nodesSelector
+	}
+	return excludedNodesFilter(names, nodesSelector)
+}
+
+// landingPage returns the HTML of the landing page, whose links start with the
+// prefix
+func landingPage(prefix string) []byte {
+	prefix = html.EscapeString(prefix)
+	return []byte(`<html>
+    <head><title>Kube Summary Exporter</title></head>
+    <body>
+        <h1>Kube Summary Exporter</h1>
+        <p><a href="` + prefix + `/nodes">Retrieve metrics for all nodes</a></p>
+        <p><a href="` + prefix + `/node/example-node">Retrieve metrics for 'example-node'</a></p>
+        <p><a href="` + prefix + `/probe?target=example-node">Probe 'example-node'</a></p>
+        <p><a href="` + prefix + `/influx">Retrieve metrics for all nodes in InfluxDB line protocol</a></p>
+        <p><a href="` + prefix + `/export/csv?groupBy=namespace">Export the storage usage by namespace as CSV</a></p>
+        <p><a href="` + prefix + `/debug/coverage">Summary sections present on the last scrape of each node</a></p>
+        <p><a href="` + prefix + `/debug/collections">Last collections of all nodes</a></p>
+        <p><a href="` + prefix + `/catalog">Catalog of the metrics, with their labels and stability</a></p>
+        <p><a href="` + prefix + `/-/config">Effective configuration and filters</a></p>
+        <p><a href="` + prefix + `/-/progress">Progress of the collections in flight</a></p>
+        <p><a href="` + prefix + `/api/openapi.json">OpenAPI specification</a></p>
+        <p><a href="` + prefix + `/metrics">Metrics</a></p>
+    </body>
+</html>`)
+}
+
+// routePrefix returns the path prefix the handlers are served under, without
+// a trailing slash. It defaults to the path of the external URL, as the
+// prefix is usually kept by the reverse proxy.
+func routePrefix(externalURL, prefix string) (string, error) {
+	if prefix == "" && externalURL != "" {
+		u, err := url.Parse(externalURL)
+		if err != nil {
+			return "", fmt.Errorf("invalid external URL %q: %v", externalURL, err)
+		}
+		prefix = u.Path
+	}
+	return "/" + strings.Trim(prefix, "/"), nil
+}
+
+// linkPrefix returns the prefix of the links to the handlers, the path of the
+// external URL if set, as seen by the clients, or the route prefix
+func linkPrefix(externalURL, prefix string) string {
+	if externalURL != "" {
+		if u, err := url.Parse(externalURL); err == nil {
+			return strings.TrimSuffix(u.Path, "/")
+		}
+	}
+	return strings.TrimSuffix("/"+strings.Trim(prefix, "/"), "/")
+}
+
+// withRoutePrefix serves the handler under the prefix, redirecting the prefix
+// itself to the landing page
+func withRoutePrefix(prefix string, h http.Handler) http.Handler {
+	if prefix == "/" {
+		return h
+	}
+
+	stripped := http.StripPrefix(prefix, h)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == prefix:
+			http.Redirect(w, r, prefix+"/", http.StatusFound)
+		case strings.HasPrefix(r.URL.Path, prefix+"/"):
+			stripped.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+)
+
+func newTestServer(t *testing.T) (*fakekubelet.Server, *kubernetes.Clientset) {
+	t.Helper()
+
+	srv := fakekubelet.NewServer()
+	t.Cleanup(srv.Close)
+
+	kubeClient, err := kubernetes.NewForConfig(srv.Config())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv, kubeClient
+}
+
+func get(t *testing.T, h http.Handler, target string, header http.Header) (int, string) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code, rec.Body.String()
+}
+
+func assertContains(t *testing.T, body string, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		if !strings.Contains(body, w) {
+			t.Errorf("response doesn't contain %q:\n%s", w, body)
+		}
+	}
+}
+
+func assertNotContains(t *testing.T, body string, unwanted ...string) {
+	t.Helper()
+	for _, u := range unwanted {
+		if strings.Contains(body, u) {
+			t.Errorf("response unexpectedly contains %q:\n%s", u, body)
+		}
+	}
+}
+
+func TestRouter_nodes(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{
+		Name:           "node-a",
+		KubeletVersion: "v1.30.2",
+		Conditions: []corev1.NodeCondition{
+			{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+			{Type: corev1.NodeDiskPressure, Status: corev1.ConditionFalse},
+			{Type: "NetworkUnavailable", Status: corev1.ConditionFalse},
+		},
+		Summary: fakekubelet.Fixture("node"),
+	})
+	srv.AddNode(fakekubelet.Node{Name: "node-b", StatusCode: http.StatusServiceUnavailable})
+	srv.AddNode(fakekubelet.Node{
+		Name:    "virtual-node",
+		Labels:  map[string]string{"type": "virtual-kubelet"},
+		Summary: fakekubelet.Fixture("virtual-kubelet"),
+	})
+
+	code, body := get(t, newRouter(kubeClient, nil, nil, allNodesSelector, singleNodeSelector), "/nodes", nil)
+	if code != http.StatusOK {
+		t.Fatalf("GET /nodes returned %d: %s", code, body)
+	}
+	assertContains(t, body,
+		`kube_summary_node_scrape_success{kubelet_version="v1.30.2",node="node-a"} 1`,
+		`kube_summary_node_scrape_success{kubelet_version="",node="node-b"} 0`,
+		`kube_summary_node_condition{condition="Ready",kubelet_version="v1.30.2",node="node-a"} 1`,
+		`kube_summary_node_condition{condition="DiskPressure",kubelet_version="v1.30.2",node="node-a"} 0`,
+		`kube_summary_node_scrape_success{kubelet_version="",node="virtual-node"} 1`,
+		`kube_summary_node_partial_summary{kubelet_version="",node="virtual-node",provider="virtual-kubelet"} 1`,
+		`kube_summary_node_runtime_imagefs_used_bytes{kubelet_version="v1.30.2",node="node-a"} 4.812355584e+09`,
+		`kube_summary_container_rootfs_used_bytes{name="coredns",namespace="kube-system",node="node-a",pod="coredns-5d78c9869d-x2x8z"} 40960`,
+		`kube_summary_pod_ephemeral_storage_used_bytes{namespace="batch",node="virtual-node",pod="batch-job-7xk2p"} 1.048576e+06`,
+	)
+	assertNotContains(t, body, `kube_summary_container_rootfs_used_bytes{name="job"`, `condition="NetworkUnavailable"`)
+}
+
+func TestRouter_nodesExclude(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	for _, name := range []string{"node-a", "node-b", "node-c"} {
+		srv.AddNode(fakekubelet.Node{Name: name, Summary: fakekubelet.Fixture("node")})
+	}
+	r := newRouter(kubeClient, nil, nil, allNodesSelector, singleNodeSelector)
+
+	code, body := get(t, r, "/nodes?exclude=node-a,+node-c", nil)
+	if code != http.StatusOK {
+		t.Fatalf("GET /nodes?exclude returned %d: %s", code, body)
+	}
+	assertContains(t, body, `node="node-b"`)
+	assertNotContains(t, body, `node="node-a"`, `node="node-c"`)
+	for _, name := range []string{"node-a", "node-c"} {
+		if n := srv.SummaryRequests(name); n != 0 {
+			t.Errorf("excluded %s received %d summary requests", name, n)
+		}
+	}
+}
+
+func TestRouter_node(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", KubeletVersion: "v1.30.2", Summary: fakekubelet.Fixture("node")})
+	r := newRouter(kubeClient, nil, nil, allNodesSelector, singleNodeSelector)
+
+	code, body := get(t, r, "/node/node-a", nil)
+	if code != http.StatusOK {
+		t.Fatalf("GET /node/node-a returned %d: %s", code, body)
+	}
+	assertContains(t, body, `kube_summary_node_scrape_success{kubelet_version="v1.30.2",node="node-a"} 1`)
+
+	for _, target := range []string{"/node/missing", "/node/Not_A_Node", "/api/v1/nodes/missing", "/influx/node/missing"} {
+		code, body := get(t, r, target, nil)
+		if code != http.StatusNotFound {
+			t.Errorf("GET %s returned %d, want %d: %s", target, code, http.StatusNotFound, body)
+		}
+		assertContains(t, body, `{"error":`)
+	}
+	if n := srv.SummaryRequests("missing"); n != 0 {
+		t.Errorf("missing node received %d summary requests", n)
+	}
+}
+
+func TestRouter_namespace(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", KubeletVersion: "v1.30.2", Summary: fakekubelet.Fixture("node")})
+	srv.AddToken("tenant-token", "kube-system")
+	r := newRouter(kubeClient, nil, nil, allNodesSelector, singleNodeSelector)
+
+	if code, _ := get(t, r, "/namespace/kube-system/pods", nil); code != http.StatusUnauthorized {
+		t.Errorf("GET without a token returned %d, want %d", code, http.StatusUnauthorized)
+	}
+
+	auth := http.Header{"Authorization": {"Bearer tenant-token"}}
+	if code, _ := get(t, r, "/namespace/mon/pods", auth); code != http.StatusForbidden {
+		t.Errorf("GET of another namespace returned %d, want %d", code, http.StatusForbidden)
+	}
+
+	code, body := get(t, r, "/namespace/kube-system/pods", auth)
+	if code != http.StatusOK {
+		t.Fatalf("GET /namespace/kube-system/pods returned %d: %s", code, body)
+	}
+	assertContains(t, body, `pod="coredns-5d78c9869d-x2x8z"`)
+	assertNotContains(t, body, `namespace="mon"`, `kube_summary_node_runtime_imagefs`)
+}
+
+func TestRouter_cached(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", KubeletVersion: "v1.30.2", Summary: fakekubelet.Fixture("node")})
+
+	cache := newSummaryCache(2)
+	results, err := allNodesSelector(context.Background(), kubeClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.update(results)
+
+	srv.RemoveNode("node-a")
+	r := newRouter(kubeClient, cache, nil, allNodesSelector, singleNodeSelector)
+
+	code, body := get(t, r, "/node/node-a", nil)
+	if code != http.StatusOK {
+		t.Fatalf("GET /node/node-a returned %d: %s", code, body)
+	}
+	assertContains(t, body, `kube_summary_node_scrape_success{kubelet_version="v1.30.2",node="node-a"} 1`)
+	if n := srv.SummaryRequests("node-a"); n != 1 {
+		t.Errorf("node-a received %d summary requests, want 1", n)
+	}
+}
+
+func Test_listNodes(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	for _, name := range []string{"node-a", "node-b", "node-c", "node-d", "node-e"} {
+		srv.AddNode(fakekubelet.Node{Name: name})
+	}
+
+	nodes, err := listNodes(context.Background(), kubeClient, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+	if diff := cmp.Diff([]string{"node-a", "node-b", "node-c", "node-d", "node-e"}, names); diff != "" {
+		t.Errorf("listNodes() mismatch (-want +got):\n%s", diff)
+	}
+	if n := srv.ListRequests(); n != 3 {
+		t.Errorf("listNodes() sent %d list requests, want 3", n)
+	}
+}
+
+func Test_collectNodeStats_budget(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Summary: fakekubelet.Fixture("node"), Delay: time.Minute})
+	srv.AddNode(fakekubelet.Node{Name: "node-b", Summary: fakekubelet.Fixture("node")})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	results, err := allNodesSelector(ctx, kubeClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("allNodesSelector() returned %d results, want 2", len(results))
+	}
+	if results[0].Err == nil {
+		t.Errorf("slow node-a didn't time out")
+	}
+	// node-a only gets half of the deadline, leaving the other half to node-b
+	if results[1].Err != nil {
+		t.Errorf("node-b was starved by node-a: %v", results[1].Err)
+	}
+}
+
+func Test_withRoutePrefix(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Summary: fakekubelet.Fixture("node")})
+
+	prefix, err := routePrefix("https://example.com/kube-summary/", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prefix != "/kube-summary" {
+		t.Fatalf("routePrefix() = %q, want %q", prefix, "/kube-summary")
+	}
+	h := withRoutePrefix(prefix, newRouter(kubeClient, nil, nil, allNodesSelector, singleNodeSelector))
+
+	if code, body := get(t, h, "/kube-summary/node/node-a", nil); code != http.StatusOK {
+		t.Errorf("GET /kube-summary/node/node-a returned %d: %s", code, body)
+	}
+	if code, _ := get(t, h, "/kube-summary", nil); code != http.StatusFound {
+		t.Errorf("GET /kube-summary returned %d, want %d", code, http.StatusFound)
+	}
+	if code, _ := get(t, h, "/nodes", nil); code != http.StatusNotFound {
+		t.Errorf("GET /nodes outside of the prefix returned %d, want %d", code, http.StatusNotFound)
+	}
+}
+
+func Test_linkPrefix(t *testing.T) {
+	for _, tc := range []struct {
+		externalURL, prefix, want string
+	}{
+		{"", "", ""},
+		{"", "/kube-summary/", "/kube-summary"},
+		{"https://example.com/kube-summary/", "", "/kube-summary"},
+		// The proxy strips its prefix before forwarding the requests
+		{"https://example.com/api/v1/namespaces/sys-mon/services/kube-summary-exporter:9779/proxy/", "/", "/api/v1/namespaces/sys-mon/services/kube-summary-exporter:9779/proxy"},
+	} {
+		if got := linkPrefix(tc.externalURL, tc.prefix); got != tc.want {
+			t.Errorf("linkPrefix(%q, %q) = %q, want %q", tc.externalURL, tc.prefix, got, tc.want)
+		}
+	}
+}
+
+func TestRouter_metricsIncludeSummaries(t *testing.T) {
+	*flagMetricsIncludeSummaries = true
+	defer func() { *flagMetricsIncludeSummaries = false }()
+
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Summary: fakekubelet.Fixture("node")})
+	srv.AddNode(fakekubelet.Node{Name: "node-b", Summary: fakekubelet.Fixture("node"), Delay: time.Minute})
+	r := newRouter(kubeClient, nil, nil, allNodesSelector, singleNodeSelector)
+
+	start := time.Now()
+	code, body := get(t, r, "/metrics", http.Header{"X-Prometheus-Scrape-Timeout-Seconds": {"0.5"}})
+	if code != http.StatusOK {
+		t.Fatalf("GET /metrics returned %d: %s", code, body)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("GET /metrics took %s, past the scrape timeout", elapsed)
+	}
+	assertContains(t, body,
+		"go_goroutines",
+		`kube_summary_node_scrape_success{kubelet_version="",node="node-a"} 1`,
+		`kube_summary_node_scrape_success{kubelet_version="",node="node-b"} 0`,
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	cache := newSummaryCache(2)
+	results, err := allNodesSelector(ctx, kubeClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.update(results)
+	srv.RemoveNode("node-a")
+
+	code, body = get(t, newRouter(kubeClient, cache, nil, allNodesSelector, singleNodeSelector), "/metrics", nil)
+	if code != http.StatusOK {
+		t.Fatalf("GET /metrics in background mode returned %d: %s", code, body)
+	}
+	assertContains(t, body, `kube_summary_node_scrape_success{kubelet_version="",node="node-a"} 1`)
+}
+
+// protobufAccept is the Accept header of the Prometheus protobuf scrapes
+const protobufAccept = "application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=delimited;q=0.7,text/plain;version=0.0.4;q=0.3"
+
+// decodeFamilies decodes a delimited protobuf response, by family name
+func decodeFamilies(t *testing.T, body string) map[string][]*dto.MetricFamily {
+	t.Helper()
+	families := map[string][]*dto.MetricFamily{}
+	dec := expfmt.NewDecoder(strings.NewReader(body), expfmt.NewFormat(expfmt.TypeProtoDelim))
+	for {
+		mf := &dto.MetricFamily{}
+		if err := dec.Decode(mf); err == io.EOF {
+			return families
+		} else if err != nil {
+			t.Fatalf("error decoding the protobuf response: %v", err)
+		}
+		families[mf.GetName()] = append(families[mf.GetName()], mf)
+	}
+}
+
+func TestRouter_protobuf(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Summary: fakekubelet.Fixture("node")})
+	srv.AddNode(fakekubelet.Node{Name: "node-b", Summary: fakekubelet.Fixture("node")})
+	r := newRouter(kubeClient, nil, nil, allNodesSelector, singleNodeSelector)
+	defer func() { *flagStreamNodes = false }()
+
+	for _, stream := range []bool{false, true} {
+		*flagStreamNodes = stream
+		req := httptest.NewRequest(http.MethodGet, "/nodes", nil)
+		req.Header.Set("Accept", protobufAccept)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/vnd.google.protobuf") {
+			t.Fatalf("stream %v: Content-Type = %q, want protobuf", stream, ct)
+		}
+
+		families := decodeFamilies(t, rec.Body.String())
+		var nodes int
+		for _, mf := range families["kube_summary_node_scrape_success"] {
+			nodes += len(mf.GetMetric())
+		}
+		if nodes != 2 {
+			t.Errorf("stream %v: kube_summary_node_scrape_success has %d series, want 2", stream, nodes)
+		}
+		histograms := families["kube_summary_node_pod_ephemeral_storage_used_bytes"]
+		if len(histograms) == 0 || histograms[0].GetMetric()[0].GetHistogram().Schema == nil {
+			t.Errorf("stream %v: kube_summary_node_pod_ephemeral_storage_used_bytes isn't a native histogram", stream)
+		}
+	}
+}
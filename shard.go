@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Ways the nodes are assigned to the shards, see --shard-by
+const (
+	shardByHash = "hash"
+	shardByZone = "zone"
+)
+
+var shardByModes = []string{shardByHash, shardByZone}
+
+// httpSDZoneLabel is the zone label of the --nodes-http-sd-url target groups,
+// whose label names can't hold the dots and slash of
+// topology.kubernetes.io/zone
+const httpSDZoneLabel = "zone"
+
+var (
+	shardNodes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "shard_nodes",
+		Help:      "Number of nodes assigned to the shard of this replica when the nodes were last listed, see --shards and --shard-by",
+	})
+	shardUnassignedNodes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "shard_unassigned_nodes",
+		Help:      "Number of nodes without a zone when the nodes were last listed, which no replica collects with --shard-by=zone",
+	})
+)
+
+// nodeShard is the shard of the nodes this replica collects
+var nodeShard nodeSharding
+
+func init() {
+	prometheus.MustRegister(shardNodes, shardUnassignedNodes)
+}
+
+// nodeSharding splits the nodes between the replicas of the exporter. With a
+// zone, a replica only collects the nodes of its zone, so that the summaries
+// don't cross zones, the nodes of the zone being split by hash between the
+// replicas of the zone.
+type nodeSharding struct {
+	// shards is the number of shards the nodes are split into by hash, 0 or
+	// 1 for a single shard
+	shards int
+	// shard is the shard of this replica, from 0
+	shard int
+	// zone is the zone of this replica with --shard-by=zone, empty otherwise
+	zone string
+}
+
+// newNodeSharding returns the sharding of the flags
+func newNodeSharding(shards, shard int, by, zone string) (nodeSharding, error) {
+	if shards < 1 {
+		return nodeSharding{}, errors.New("--shards must be at least 1")
+	}
+	if shard < 0 || shard >= shards {
+		return nodeSharding{}, fmt.Errorf("--shard must be between 0 and %d", shards-1)
+	}
+	switch {
+	case by == shardByZone && zone == "":
+		return nodeSharding{}, errors.New("--shard-by=zone requires --shard-zone")
+	case by != shardByZone && zone != "":
+		return nodeSharding{}, errors.New("--shard-zone requires --shard-by=zone")
+	}
+	return nodeSharding{shards: shards, shard: shard, zone: zone}, nil
+}
+
+// enabled tells whether the nodes are split between several replicas
+func (s nodeSharding) enabled() bool {
+	return s.shards > 1 || s.zone != ""
+}
+
+// owns tells whether the node is collected by this replica
+func (s nodeSharding) owns(node corev1.Node) bool {
+	if s.zone != "" && nodeZone(node) != s.zone {
+		return false
+	}
+	return s.shards <= 1 || shardOf(node.Name, s.shards) == s.shard
+}
+
+// filter returns the nodes collected by this replica
+func (s nodeSharding) filter(nodes []corev1.Node) []corev1.Node {
+	if !s.enabled() {
+		return nodes
+	}
+
+	owned := make([]corev1.Node, 0, len(nodes))
+	unassigned := 0
+	for _, node := range nodes {
+		if s.owns(node) {
+			owned = append(owned, node)
+		}
+		if s.zone != "" && nodeZone(node) == "" {
+			unassigned++
+		}
+	}
+	shardNodes.Set(float64(len(owned)))
+	shardUnassignedNodes.Set(float64(unassigned))
+	return owned
+}
+
+// nodeZone returns the topology.kubernetes.io/zone label of the node, or the
+// zone label of its HTTP service discovery target group
+func nodeZone(node corev1.Node) string {
+	if zone := node.Labels[corev1.LabelTopologyZone]; zone != "" {
+		return zone
+	}
+	return node.Labels[httpSDZoneLabel]
+}
+
+// shardOf returns the shard of a node, from a hash of its name so that it
+// stays in the same shard as the other nodes come and go
+func shardOf(nodeName string, shards int) int {
+	h := fnv.New32a()
+	h.Write([]byte(nodeName))
+	return int(h.Sum32() % uint32(shards))
+}
+
+// nodeSourceHasLabels tells whether the nodes of the source have labels, and
+// so zones. The nodes of the --kubelets only have the label of their zone,
+// if any is set.
+func nodeSourceHasLabels(source nodeSource) bool {
+	switch source := source.(type) {
+	case staticNodeSource, fileNodeSource:
+		return false
+	case kubeletNodeSource:
+		return slices.ContainsFunc(source, func(target kubeletTarget) bool { return target.zone != "" })
+	}
+	return true
+}
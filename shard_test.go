@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_nodeSharding(t *testing.T) {
+	var nodes []corev1.Node
+	for i := range 30 {
+		labels := map[string]string{corev1.LabelTopologyZone: fmt.Sprintf("eu-west-1%c", 'a'+i%3)}
+		nodes = append(nodes, corev1.Node{ObjectMeta: meta_v1.ObjectMeta{Name: fmt.Sprintf("node-%d", i), Labels: labels}})
+	}
+	nodes = append(nodes,
+		corev1.Node{ObjectMeta: meta_v1.ObjectMeta{Name: "sd-node", Labels: map[string]string{httpSDZoneLabel: "eu-west-1a"}}},
+		corev1.Node{ObjectMeta: meta_v1.ObjectMeta{Name: "unlabeled"}},
+	)
+
+	// Every node is in exactly one hash shard
+	seen := map[string]int{}
+	for shard := range 3 {
+		s, err := newNodeSharding(3, shard, shardByHash, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, node := range s.filter(nodes) {
+			seen[node.Name]++
+		}
+	}
+	for _, node := range nodes {
+		if seen[node.Name] != 1 {
+			t.Errorf("%s is in %d shards", node.Name, seen[node.Name])
+		}
+	}
+
+	// The zone shards only hold the nodes of their zone, split by hash
+	zoneNodes := 0
+	for shard := range 2 {
+		s, err := newNodeSharding(2, shard, shardByZone, "eu-west-1a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		owned := s.filter(nodes)
+		for _, node := range owned {
+			if nodeZone(node) != "eu-west-1a" {
+				t.Errorf("%s of zone %s is in a shard of eu-west-1a", node.Name, nodeZone(node))
+			}
+		}
+		zoneNodes += len(owned)
+	}
+	if zoneNodes != 11 {
+		t.Errorf("the eu-west-1a shards hold %d nodes, want 11", zoneNodes)
+	}
+	if got := testutil.ToFloat64(shardUnassignedNodes); got != 1 {
+		t.Errorf("%v unassigned nodes, want 1", got)
+	}
+
+	// Without sharding every node is collected
+	if got := (nodeSharding{}).filter(nodes); len(got) != len(nodes) {
+		t.Errorf("filter() returned %d nodes without sharding, want %d", len(got), len(nodes))
+	}
+}
+
+func Test_newNodeSharding(t *testing.T) {
+	for _, tc := range []struct {
+		shards, shard int
+		by, zone      string
+		valid         bool
+	}{
+		{1, 0, shardByHash, "", true},
+		{3, 2, shardByHash, "", true},
+		{3, 3, shardByHash, "", false},
+		{0, 0, shardByHash, "", false},
+		{1, 0, shardByZone, "eu-west-1a", true},
+		{1, 0, shardByZone, "", false},
+		{1, 0, shardByHash, "eu-west-1a", false},
+	} {
+		if _, err := newNodeSharding(tc.shards, tc.shard, tc.by, tc.zone); (err == nil) != tc.valid {
+			t.Errorf("newNodeSharding(%d, %d, %s, %q) = %v, want valid %v", tc.shards, tc.shard, tc.by, tc.zone, err, tc.valid)
+		}
+	}
+}
+
+func Test_nodeSourceHasLabels(t *testing.T) {
+	targets, err := parseKubelets("edge-a=10.0.0.1@eu-west-1a,edge-b=10.0.0.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !nodeSourceHasLabels(kubeletNodeSource(targets)) {
+		t.Error("nodeSourceHasLabels() of kubelets with zones = false")
+	}
+	if nodeSourceHasLabels(kubeletNodeSource(targets[1:])) || nodeSourceHasLabels(staticNodeSource{"node-a"}) {
+		t.Error("nodeSourceHasLabels() of nodes without zones = true")
+	}
+
+	// The zone shard of the kubelets only holds the kubelets of the zone
+	nodes, err := kubeletNodeSource(targets).nodes(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := newNodeSharding(1, 0, shardByZone, "eu-west-1a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owned := s.filter(nodes); len(owned) != 1 || owned[0].Name != "edge-a" {
+		t.Errorf("filter() = %v, want edge-a", owned)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// defaultSinkInterval is the push interval of the sinks of the config file
+// that don't set one
+const defaultSinkInterval = time.Minute
+
+// sinkConfig is an entry of the sinks of the --config-file
+type sinkConfig struct {
+	// Type selects the output format, one of the sinkTypes
+	Type string `json:"type"`
+	// Address is the host:port of the graphite and statsd sinks
+	Address string `json:"address,omitempty"`
+	// URL is the endpoint of the influx, remote_write and otlp sinks
+	URL string `json:"url,omitempty"`
+	// Path is the file the csv sink appends to
+	Path string `json:"path,omitempty"`
+	// Prefix is prepended to the metric names of the graphite and statsd
+	// sinks
+	Prefix string `json:"prefix,omitempty"`
+	// Headers are added to the requests of the HTTP sinks, e.g. for
+	// authentication
+	Headers map[string]string `json:"headers,omitempty"`
+	// Interval between pushes, defaultSinkInterval if unset
+	Interval *meta_v1.Duration `json:"interval,omitempty"`
+}
+
+// sinkTypes build the sinks of the config file by type. Adding an output
+// format is a matter of implementing sink and registering it here.
+var sinkTypes = map[string]func(sinkConfig) (sink, error){
+	"graphite": func(c sinkConfig) (sink, error) {
+		if c.Address == "" {
+			return nil, fmt.Errorf("address is required")
+		}
+		return newGraphiteSink(c.Address, c.Prefix), nil
+	},
+	"statsd": func(c sinkConfig) (sink, error) {
+		if c.Address == "" {
+			return nil, fmt.Errorf("address is required")
+		}
+		return newStatsdSink(c.Address, c.Prefix), nil
+	},
+	"influx": func(c sinkConfig) (sink, error) {
+		if c.URL == "" {
+			return nil, fmt.Errorf("url is required")
+		}
+		return newInfluxSink(c.URL, c.Headers), nil
+	},
+	"csv": func(c sinkConfig) (sink, error) {
+		if c.Path == "" {
+			return nil, fmt.Errorf("path is required")
+		}
+		return newCSVSink(c.Path), nil
+	},
+	"remote_write": func(c sinkConfig) (sink, error) {
+		if c.URL == "" {
+			return nil, fmt.Errorf("url is required")
+		}
+		return newRemoteWriteSink(c.URL, c.Headers), nil
+	},
+	"otlp": func(c sinkConfig) (sink, error) {
+		if c.URL == "" {
+			return nil, fmt.Errorf("url is required")
+		}
+		return newOTLPSink(c.URL, c.Headers), nil
+	},
+}
+
+// sinkTypeNames returns the names of the sinkTypes, sorted
+func sinkTypeNames() []string {
+	names := make([]string, 0, len(sinkTypes))
+	for name := range sinkTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// pushSink is a sink along with the interval it's pushed to at
+type pushSink struct {
+	sink     sink
+	interval time.Duration
+}
+
+// newPushSink builds the sink of a config entry
+func newPushSink(c sinkConfig) (pushSink, error) {
+	newSink, ok := sinkTypes[c.Type]
+	if !ok {
+		return pushSink{}, fmt.Errorf("unknown type %q, expected one of %s", c.Type, strings.Join(sinkTypeNames(), ", "))
+	}
+	s, err := newSink(c)
+	if err != nil {
+		return pushSink{}, fmt.Errorf("invalid %s sink: %w", c.Type, err)
+	}
+
+	interval := defaultSinkInterval
+	if c.Interval != nil {
+		if c.Interval.Duration <= 0 {
+			return pushSink{}, fmt.Errorf("invalid %s sink: interval must be positive", c.Type)
+		}
+		interval = c.Interval.Duration
+	}
+	return pushSink{sink: s, interval: interval}, nil
+}
+
+// flagSinks returns the sinks set by the --graphite-* and --statsd-* flags
+func flagSinks() []sinkConfig {
+	var sinks []sinkConfig
+	if *flagGraphiteAddress != "" {
+		sinks = append(sinks, sinkConfig{
+			Type:     "graphite",
+			Address:  *flagGraphiteAddress,
+			Prefix:   *flagGraphitePrefix,
+			Interval: &meta_v1.Duration{Duration: *flagGraphiteInterval},
+		})
+	}
+	if *flagStatsdAddress != "" {
+		sinks = append(sinks, sinkConfig{
+			Type:     "statsd",
+			Address:  *flagStatsdAddress,
+			Prefix:   *flagStatsdPrefix,
+			Interval: &meta_v1.Duration{Duration: *flagStatsdInterval},
+		})
+	}
+	return sinks
+}
+
+// postSamples sends the body encoded by a sink to an HTTP endpoint, with the
+// headers of the sink, and fails on a non 2xx response
+func postSamples(ctx context.Context, url string, headers http.Header, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header = headers.Clone()
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s returned %s: %s", url, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// sinkHeaders returns the headers of a sink config as an http.Header
+func sinkHeaders(headers map[string]string) http.Header {
+	h := http.Header{}
+	for name, value := range headers {
+		h.Set(name, value)
+	}
+	return h
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_newPushSink(t *testing.T) {
+	s, err := newPushSink(sinkConfig{Type: "remote_write", URL: "http://mimir/api/v1/push"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.sink.Name() != "remote_write" || s.interval != defaultSinkInterval {
+		t.Errorf("newPushSink() = %+v", s)
+	}
+
+	for _, c := range []sinkConfig{
+		{Type: "bogus"},
+		{Type: "graphite"},
+		{Type: "otlp"},
+		{Type: "csv"},
+		{Type: "influx", URL: "http://influxdb:8086/write", Interval: &meta_v1.Duration{}},
+	} {
+		if _, err := newPushSink(c); err == nil {
+			t.Errorf("newPushSink(%+v) didn't fail", c)
+		}
+	}
+}
+
+func Test_postSamples(t *testing.T) {
+	var gotHeader http.Header
+	var gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotHeader, gotBody = r.Header, string(body)
+		if r.URL.Query().Get("db") != "k8s" {
+			http.Error(w, "database not found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	samples := []sample{{Name: "kube_summary_node_scrape_success", Labels: []labelPair{{"node", "node-a"}}, Value: 1}}
+	s := newInfluxSink(srv.URL+"/write?db=k8s", map[string]string{"authorization": "Token secret"})
+	if err := s.Write(context.Background(), samples, time.Unix(1669817681, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if gotHeader.Get("Authorization") != "Token secret" || gotHeader.Get("Content-Type") != "text/plain; charset=utf-8" {
+		t.Errorf("influx sink sent headers %v", gotHeader)
+	}
+	if want := "kube_summary_node_scrape_success,node=node-a value=1 1669817681000000000\n"; gotBody != want {
+		t.Errorf("influx sink sent %q, want %q", gotBody, want)
+	}
+
+	s = newInfluxSink(srv.URL+"/write?db=missing", nil)
+	if err := s.Write(context.Background(), samples, time.Now()); err == nil {
+		t.Error("influx sink didn't fail on a 404")
+	}
+}
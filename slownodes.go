@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// slowNodeForgetAfter is the time after which the nodes that weren't
+// requested anymore, e.g. deleted nodes, are forgotten
+const slowNodeForgetAfter = time.Hour
+
+var nodeSlow = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: metricsNamespace,
+	Name:      "node_slow",
+	Help:      "Whether the p95 of the last /stats/summary requests of the node exceeds --slow-node-threshold",
+},
+	[]string{
+		"node",
+	},
+)
+
+// slowNodes tracks the fetch latencies of the nodes for --slow-node-threshold
+var slowNodes = newSlowNodeDetector(0, 20)
+
+func init() {
+	prometheus.MustRegister(nodeSlow)
+}
+
+// slowNodeDetector keeps the durations of the last /stats/summary requests of
+// every node and flags the nodes whose p95 exceeds the threshold, the kubelets
+// holding back the collections of the whole cluster
+type slowNodeDetector struct {
+	// threshold is the p95 above which a node is slow, 0 disables the
+	// detector
+	threshold time.Duration
+	// window is the number of requests the p95 is computed over, a node
+	// being only considered once it was requested that many times
+	window int
+	now    func() time.Time
+
+	mu        sync.Mutex
+	nodes     map[string]*nodeLatencies
+	lastSweep time.Time
+}
+
+// nodeLatencies are the durations of the last requests of a node, a ring of
+// the window size
+type nodeLatencies struct {
+	durations []time.Duration
+	next      int
+	slow      bool
+	lastSeen  time.Time
+}
+
+func newSlowNodeDetector(threshold time.Duration, window int) *slowNodeDetector {
+	return &slowNodeDetector{threshold: threshold, window: window, now: time.Now, nodes: map[string]*nodeLatencies{}}
+}
+
+// observe records the duration of a request of the node, failed ones
+// included, and updates whether it's slow
+func (d *slowNodeDetector) observe(nodeName string, duration time.Duration) {
+	if d.threshold <= 0 || d.window <= 0 {
+		return
+	}
+	now := d.now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	n, ok := d.nodes[nodeName]
+	if !ok {
+		n = &nodeLatencies{durations: make([]time.Duration, 0, d.window)}
+		d.nodes[nodeName] = n
+	}
+	if len(n.durations) < d.window {
+		n.durations = append(n.durations, duration)
+	} else {
+		n.durations[n.next] = duration
+	}
+	n.next = (n.next + 1) % d.window
+	n.lastSeen = now
+
+	p95 := percentile(n.durations, 0.95)
+	slow := len(n.durations) == d.window && p95 > d.threshold
+	if slow && !n.slow {
+		fmt.Printf("[Warning] Node %s is slow, the p95 of its last %d /stats/summary requests is %s, above --slow-node-threshold=%s, consider skipping it with --exclude-node=%s\n", nodeName, len(n.durations), p95.Round(time.Millisecond), d.threshold, nodeName)
+	}
+	n.slow = slow
+	var value float64
+	if slow {
+		value = 1
+	}
+	nodeSlow.WithLabelValues(nodeName).Set(value)
+	d.sweep(now)
+}
+
+// sweep forgets the nodes that weren't requested for slowNodeForgetAfter, at
+// most once a minute. It must be called with the lock held.
+func (d *slowNodeDetector) sweep(now time.Time) {
+	if now.Sub(d.lastSweep) < time.Minute {
+		return
+	}
+	d.lastSweep = now
+	for nodeName, n := range d.nodes {
+		if now.Sub(n.lastSeen) >= slowNodeForgetAfter {
+			delete(d.nodes, nodeName)
+			nodeSlow.DeleteLabelValues(nodeName)
+		}
+	}
+}
+
+// percentile returns the nearest-rank percentile of the durations
+func percentile(durations []time.Duration, p float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(rank, 0)]
+}
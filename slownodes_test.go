@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func Test_slowNodeDetector(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	d := newSlowNodeDetector(time.Second, 5)
+	d.now = func() time.Time { return now }
+	slow := func(nodeName string) float64 { return testutil.ToFloat64(nodeSlow.WithLabelValues(nodeName)) }
+
+	// A node is only considered once the window is full
+	for range 4 {
+		d.observe("node-a", 5*time.Second)
+	}
+	if slow("node-a") != 0 {
+		t.Error("node-a is slow before the window is full")
+	}
+	d.observe("node-a", 100*time.Millisecond)
+	if slow("node-a") != 1 {
+		t.Error("node-a with 4 slow requests out of 5 isn't slow")
+	}
+
+	// The slow requests leave the window
+	for range 5 {
+		d.observe("node-a", 100*time.Millisecond)
+	}
+	if slow("node-a") != 0 {
+		t.Error("node-a is still slow after 5 fast requests")
+	}
+
+	// Nodes that aren't requested anymore are forgotten
+	now = now.Add(slowNodeForgetAfter)
+	d.observe("node-b", time.Millisecond)
+	if _, ok := d.nodes["node-a"]; ok {
+		t.Error("node-a wasn't forgotten")
+	}
+
+	disabled := newSlowNodeDetector(0, 10)
+	disabled.observe("node-c", time.Hour)
+	if len(disabled.nodes) != 0 {
+		t.Error("the disabled detector tracked node-c")
+	}
+}
+
+func Test_percentile(t *testing.T) {
+	var durations []time.Duration
+	for i := 1; i <= 20; i++ {
+		durations = append(durations, time.Duration(i)*time.Second)
+	}
+	if got := percentile(durations, 0.95); got != 19*time.Second {
+		t.Errorf("percentile(0.95) = %s, want 19s", got)
+	}
+	if got := percentile(nil, 0.95); got != 0 {
+		t.Errorf("percentile() of no durations = %s, want 0", got)
+	}
+}
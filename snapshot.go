@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// snapshotSink receives the raw summaries of every background collection
+// cycle, as opposed to sink which receives the mapped samples
+type snapshotSink interface {
+	// Name identifies the sink in logs and metrics
+	Name() string
+	// WriteSnapshot stores the summaries of the nodes collected in a cycle
+	// that ended at ts
+	WriteSnapshot(ctx context.Context, results []PerNodeResult, ts time.Time) error
+}
+
+// nodeSnapshot is the JSON document describing the summary of a node in a
+// collection cycle
+type nodeSnapshot struct {
+	Node      string         `json:"node"`
+	Timestamp time.Time      `json:"timestamp"`
+	Summary   *stats.Summary `json:"summary"`
+}
+
+// marshalNodeSnapshots returns the JSON snapshots of the nodes that were
+// collected successfully, keyed by node name
+func marshalNodeSnapshots(results []PerNodeResult, ts time.Time) (map[string][]byte, error) {
+	snapshots := make(map[string][]byte, len(results))
+	for _, result := range results {
+		if result.Err != nil || result.Summary == nil {
+			continue
+		}
+
+		d, err := json.Marshal(nodeSnapshot{
+			Node:      result.NodeName,
+			Timestamp: ts.UTC(),
+			Summary:   result.Summary,
+		})
+		if err != nil {
+			return nil, err
+		}
+		snapshots[result.NodeName] = d
+	}
+	return snapshots, nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+func Test_marshalNodeSnapshots(t *testing.T) {
+	ts := time.Date(2022, 11, 30, 14, 14, 41, 0, time.UTC)
+	results := []PerNodeResult{
+		{NodeName: "node-a", Summary: &stats.Summary{Node: stats.NodeStats{NodeName: "node-a"}}},
+		{NodeName: "node-b", Err: errors.New("failed")},
+	}
+
+	snapshots, err := marshalNodeSnapshots(results, ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 1 {
+		t.Fatalf("marshalNodeSnapshots() returned %d snapshots, want 1", len(snapshots))
+	}
+
+	var got nodeSnapshot
+	if err := json.Unmarshal(snapshots["node-a"], &got); err != nil {
+		t.Fatal(err)
+	}
+	want := nodeSnapshot{Node: "node-a", Timestamp: ts, Summary: results[0].Summary}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("marshalNodeSnapshots() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+type collectionSpreadKey struct{}
+
+// withCollectionSpread returns a context under which collectNodeStats starts
+// the collection of each node at its spreadOffset within the window, so that
+// the load on the API server is even instead of aligned on the start of the
+// cycle
+func withCollectionSpread(ctx context.Context, window time.Duration) context.Context {
+	return context.WithValue(ctx, collectionSpreadKey{}, window)
+}
+
+func collectionSpread(ctx context.Context) time.Duration {
+	window, _ := ctx.Value(collectionSpreadKey{}).(time.Duration)
+	return window
+}
+
+// spreadOffset is the deterministic offset of a node within the window,
+// derived from a hash of its name, so that it is collected at the same point
+// of every cycle
+func spreadOffset(nodeName string, window time.Duration) time.Duration {
+	if window <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(nodeName))
+	return time.Duration(h.Sum64() % uint64(window))
+}
+
+// collectSpread collects each node at its offset from now, at most
+// concurrency at once. A node whose offset is past the deadline of the
+// context fails with the context error.
+func collectSpread(ctx context.Context, kubeClient *kubernetes.Clientset, nodes []corev1.Node, stale map[string]time.Duration, window time.Duration, concurrency int) []PerNodeResult {
+	start := time.Now()
+	results := make([]PerNodeResult, len(nodes))
+	slots := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			timer := time.NewTimer(time.Until(start.Add(spreadOffset(node.Name, window))))
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+				select {
+				case slots <- struct{}{}:
+					defer func() { <-slots }()
+				case <-ctx.Done():
+				}
+			case <-ctx.Done():
+			}
+
+			if age, ok := stale[node.Name]; ok {
+				results[i] = staleLeaseResult(node, age)
+			} else {
+				results[i] = collectNode(ctx, kubeClient, node, 1, 1)
+			}
+			streamResult(ctx, results[i])
+			progressNode(ctx, results[i])
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+)
+
+func Test_spreadOffset(t *testing.T) {
+	window := time.Minute
+	if spreadOffset("node-a", window) != spreadOffset("node-a", window) {
+		t.Errorf("spreadOffset() isn't deterministic")
+	}
+	if got := spreadOffset("node-a", 0); got != 0 {
+		t.Errorf("spreadOffset() with no window = %v, want 0", got)
+	}
+
+	// The offsets of many nodes cover every tenth of the window
+	buckets := make([]int, 10)
+	for i := 0; i < 1000; i++ {
+		offset := spreadOffset(fmt.Sprintf("node-%d", i), window)
+		if offset < 0 || offset >= window {
+			t.Fatalf("spreadOffset() = %v, outside of the window", offset)
+		}
+		buckets[offset*10/window]++
+	}
+	for i, n := range buckets {
+		if n < 50 {
+			t.Errorf("only %d of 1000 nodes are in the tenth %d of the window", n, i)
+		}
+	}
+}
+
+func Test_collectSpread(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	for _, name := range []string{"node-a", "node-b", "node-c"} {
+		srv.AddNode(fakekubelet.Node{Name: name, Summary: fakekubelet.Fixture("node")})
+	}
+
+	window := 300 * time.Millisecond
+	var (
+		mu        sync.Mutex
+		collected = map[string]time.Duration{}
+	)
+	start := time.Now()
+	ctx := withResultStream(withCollectionSpread(context.Background(), window), func(result PerNodeResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		collected[result.NodeName] = time.Since(start)
+	})
+	results, err := allNodesSelector(ctx, kubeClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 3 || len(collected) != 3 {
+		t.Fatalf("collected %d results and streamed %v, want all 3 nodes", len(results), collected)
+	}
+	for _, result := range results {
+		if result.Err != nil {
+			t.Errorf("%s failed: %v", result.NodeName, result.Err)
+		}
+		if offset := spreadOffset(result.NodeName, window); collected[result.NodeName] < offset {
+			t.Errorf("%s was collected after %v, before its offset %v", result.NodeName, collected[result.NodeName], offset)
+		}
+	}
+}
+
+func Test_summaryCache_updateNode(t *testing.T) {
+	result := func(nodeName string) PerNodeResult {
+		return PerNodeResult{NodeName: nodeName, Summary: &stats.Summary{Node: stats.NodeStats{NodeName: nodeName}}}
+	}
+
+	cache := newSummaryCache(1)
+	cache.update([]PerNodeResult{result("a"), result("b")})
+
+	// Nodes collected during the cycle are served right away and are kept by
+	// the update completing the cycle, unlike those not collected in it
+	cache.updateNode(result("a"))
+	if _, ok := cache.result("a"); !ok {
+		t.Fatalf("node a isn't served before the cycle completes")
+	}
+	cache.update(nil)
+	if _, ok := cache.result("a"); !ok {
+		t.Errorf("node a collected during the cycle expired")
+	}
+	if _, ok := cache.result("b"); ok {
+		t.Errorf("node b not collected during the cycle didn't expire")
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+// defaultKubeletPort is the port of the kubelets of --kubelets without one
+const defaultKubeletPort = "10250"
+
+// errNoAPIServer is returned by the API server calls in standalone kubelet
+// mode
+var errNoAPIServer = errors.New("no API server in standalone kubelet mode, --kubelets is set")
+
+// kubeletTarget is a kubelet of --kubelets, reached directly
+type kubeletTarget struct {
+	name string
+	url  string
+	// zone is the zone of the node, empty if unknown
+	zone string
+}
+
+// parseKubelets parses the --kubelets list. An entry is an address,
+// host[:port] for an HTTPS kubelet, or a URL, e.g. http://host:10255 for the
+// read-only port, optionally prefixed by name= to set the node name, which is
+// the host otherwise, and suffixed by @zone to set the zone of the node.
+func parseKubelets(value string) ([]kubeletTarget, error) {
+	var targets []kubeletTarget
+	seen := map[string]bool{}
+	for _, entry := range splitList(value) {
+		address, zone, zoned := cutLast(entry, "@")
+		if zoned && (zone == "" || len(validation.IsValidLabelValue(zone)) > 0) {
+			return nil, fmt.Errorf("invalid zone of kubelet %q", entry)
+		}
+		name, address, named := strings.Cut(address, "=")
+		if !named {
+			address = name
+		}
+		if !strings.Contains(address, "://") {
+			address = "https://" + address
+		}
+		u, err := url.Parse(address)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid kubelet address %q", entry)
+		}
+		if u.Port() == "" {
+			u.Host = net.JoinHostPort(u.Hostname(), defaultKubeletPort)
+		}
+		if !named {
+			name = u.Hostname()
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate kubelet %q", name)
+		}
+		seen[name] = true
+		targets = append(targets, kubeletTarget{name: name, url: strings.TrimSuffix(u.String(), "/") + "/stats/summary", zone: zone})
+	}
+	if len(targets) == 0 {
+		return nil, errors.New("no kubelet address")
+	}
+	return targets, nil
+}
+
+// cutLast slices s around the last instance of sep
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// standaloneKubelets fetches the summaries from the kubelets directly, with
+// the credentials of the --kubelet-* flags
+type standaloneKubelets struct {
+	client *http.Client
+	urls   map[string]string
+}
+
+func newStandaloneKubelets(targets []kubeletTarget) (*standaloneKubelets, error) {
+	transport, err := newKubeletTransport(&rest.Config{
+		BearerTokenFile: *flagKubeletTokenFile,
+		TLSClientConfig: rest.TLSClientConfig{
+			CAFile:   *flagKubeletCAFile,
+			CertFile: *flagKubeletClientCertFile,
+			KeyFile:  *flagKubeletClientKeyFile,
+			Insecure: *flagKubeletInsecureSkipTLSVerify,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubelet credentials: %w", err)
+	}
+	if len(flagUpstreamHeaders) > 0 {
+		transport = &headerRoundTripper{headers: http.Header(flagUpstreamHeaders), rt: transport}
+	}
+
+	k := &standaloneKubelets{client: &http.Client{Transport: transport}, urls: map[string]string{}}
+	for _, target := range targets {
+		k.urls[target.name] = target.url
+	}
+	return k, nil
+}
+
+// newKubeletTransport returns the transport of the --kubelets, authenticated
+// with the credentials of the config. Its connection pooling is set by the
+// --kubelet-* transport flags, which default to the client-go defaults but
+// can be raised for the collections of many nodes to reuse the connections
+// instead of dialing and handshaking again.
+func newKubeletTransport(config *rest.Config) (http.RoundTripper, error) {
+	tlsConfig, err := rest.TLSConfigFor(config)
+	if err != nil {
+		return nil, err
+	}
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   *flagKubeletDialTimeout,
+			KeepAlive: *flagKubeletKeepAlive,
+		}).DialContext,
+		TLSClientConfig:     tlsConfig,
+		TLSHandshakeTimeout: *flagKubeletTLSHandshakeTimeout,
+		MaxIdleConnsPerHost: *flagKubeletMaxIdleConnsPerHost,
+		IdleConnTimeout:     *flagKubeletIdleConnTimeout,
+		ForceAttemptHTTP2:   true,
+	}
+	return rest.HTTPWrappersForConfig(config, transport)
+}
+
+// open opens the /stats/summary response of a kubelet. Error responses are
+// returned as API errors, so that they are classified like the responses of
+// the API server proxy.
+func (k *standaloneKubelets) open(ctx context.Context, _ *kubernetes.Clientset, nodeName string) (io.ReadCloser, error) {
+	u, ok := k.urls[nodeName]
+	if !ok {
+		return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "nodes"}, nodeName)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if *flagRequestGzip {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, apierrors.NewGenericServerResponse(resp.StatusCode, http.MethodGet, schema.GroupResource{Resource: "nodes"}, nodeName, strings.TrimSpace(string(msg)), 0, true)
+	}
+	return resp.Body, nil
+}
+
+// noAPIServerTransport fails every request, for the API server client of the
+// standalone kubelet mode
+type noAPIServerTransport struct{}
+
+func (noAPIServerTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errNoAPIServer
+}
+
+// setupStandaloneKubelets switches to the standalone kubelet mode of
+// --kubelets: the summaries are fetched from the kubelets directly and the
+// returned config, used by the features that need an API server, fails every
+// request
+func setupStandaloneKubelets() (*rest.Config, error) {
+	switch {
+	case *flagSummaryScrapes:
+		return nil, errors.New("--summary-scrapes needs an API server")
+	case flagMirrorPods.value != summary.MirrorPodsInclude:
+		return nil, errors.New("--mirror-pods needs an API server")
+	case *flagNodeLeaseStaleThreshold > 0:
+		return nil, errors.New("--node-lease-stale-threshold needs an API server")
+	case *flagPodScrapeAnnotation != "":
+		return nil, errors.New("--pod-scrape-annotation needs an API server")
+	case *flagResourceMetricsFallback:
+		return nil, errors.New("--resource-metrics-fallback needs an API server")
+	}
+
+	if *flagKubeletMaxIdleConnsPerHost < 1 {
+		return nil, errors.New("--kubelet-max-idle-conns-per-host must be at least 1")
+	}
+
+	targets, err := parseKubelets(*flagKubelets)
+	if err != nil {
+		return nil, err
+	}
+	kubelets, err := newStandaloneKubelets(targets)
+	if err != nil {
+		return nil, err
+	}
+	openSummary = kubelets.open
+	return &rest.Config{Host: "https://api-server.invalid", Transport: noAPIServerTransport{}}, nil
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+)
+
+func Test_parseKubelets(t *testing.T) {
+	targets, err := parseKubelets("10.0.0.1, edge-b=edge-b.local:10443@eu-west-1a, http://10.0.0.3:10255/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []kubeletTarget{
+		{name: "10.0.0.1", url: "https://10.0.0.1:10250/stats/summary"},
+		{name: "edge-b", url: "https://edge-b.local:10443/stats/summary", zone: "eu-west-1a"},
+		{name: "10.0.0.3", url: "http://10.0.0.3:10255/stats/summary"},
+	}
+	if diff := cmp.Diff(want, targets, cmp.AllowUnexported(kubeletTarget{})); diff != "" {
+		t.Errorf("parseKubelets() mismatch (-want +got):\n%s", diff)
+	}
+
+	for _, value := range []string{"", "10.0.0.1,10.0.0.1", "http://", "10.0.0.1@", "10.0.0.1@eu/west"} {
+		if _, err := parseKubelets(value); err == nil {
+			t.Errorf("parseKubelets(%q) returned no error", value)
+		}
+	}
+}
+
+func Test_setupStandaloneKubelets(t *testing.T) {
+	kubelet := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer edge-token" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/stats/summary" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(fakekubelet.Fixture("node"))
+	}))
+	t.Cleanup(kubelet.Close)
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("edge-token"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(kubelets, token string, insecure bool) {
+		*flagKubelets, *flagKubeletTokenFile, *flagKubeletInsecureSkipTLSVerify = kubelets, token, insecure
+	}(*flagKubelets, *flagKubeletTokenFile, *flagKubeletInsecureSkipTLSVerify)
+	defer func(open func(context.Context, *kubernetes.Clientset, string) (io.ReadCloser, error)) {
+		openSummary = open
+	}(openSummary)
+	*flagKubelets = "edge-a=" + kubelet.URL + ",edge-b=" + strings.Replace(kubelet.URL, "https", "http", 1)
+	*flagKubeletTokenFile = tokenFile
+	*flagKubeletInsecureSkipTLSVerify = true
+
+	config, err := setupStandaloneKubelets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kubeClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, err := newNodeSource()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := sourceNodesSelector(source)(context.Background(), kubeClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if results[0].NodeName != "edge-a" || results[0].Err != nil || results[0].Summary == nil {
+		t.Errorf("got %+v, want the summary of edge-a", results[0])
+	}
+	if results[1].NodeName != "edge-b" || results[1].Err == nil {
+		t.Errorf("got %+v, want an error for edge-b, whose kubelet only speaks TLS", results[1])
+	}
+
+	if _, err := kubeClient.CoreV1().Nodes().List(context.Background(), meta_v1.ListOptions{}); !errors.Is(err, errNoAPIServer) {
+		t.Errorf("listing the nodes returned %v, want %v", err, errNoAPIServer)
+	}
+}
+
+func Test_standaloneKubelets_open(t *testing.T) {
+	kubelet := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Forbidden (user=system:anonymous, verb=get, resource=nodes, subresource=stats)", http.StatusForbidden)
+	}))
+	t.Cleanup(kubelet.Close)
+
+	targets, err := parseKubelets("edge-a=" + kubelet.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := newStandaloneKubelets(targets)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = k.open(context.Background(), nil, "edge-a")
+	if class, code := classifySummaryError(err); class != "forbidden" || code != "403" {
+		t.Errorf("classifySummaryError() = %s, %s, want forbidden, 403", class, code)
+	}
+	_, err = k.open(context.Background(), nil, "edge-b")
+	if class, _ := classifySummaryError(err); class != "not_found" {
+		t.Errorf("classifySummaryError() of an unknown kubelet = %s, want not_found", class)
+	}
+}
+
+func Test_standaloneKubelets_upstreamHeaders(t *testing.T) {
+	kubelet := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Tenant") != "edge" {
+			http.Error(w, "missing X-Tenant", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write(fakekubelet.Fixture("node"))
+	}))
+	t.Cleanup(kubelet.Close)
+
+	defer func(headers headerFlag) { flagUpstreamHeaders = headers }(flagUpstreamHeaders)
+	flagUpstreamHeaders = headerFlag{"X-Tenant": {"edge"}}
+
+	targets, err := parseKubelets("edge-a=" + kubelet.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := newStandaloneKubelets(targets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream, err := k.open(context.Background(), nil, "edge-a")
+	if err != nil {
+		t.Fatalf("open() without the --upstream-header reaching the kubelet: %v", err)
+	}
+	stream.Close()
+}
+
+func Test_newKubeletTransport(t *testing.T) {
+	defer func(idle int, idleTimeout, keepAlive, dial, handshake time.Duration) {
+		*flagKubeletMaxIdleConnsPerHost, *flagKubeletIdleConnTimeout, *flagKubeletKeepAlive, *flagKubeletDialTimeout, *flagKubeletTLSHandshakeTimeout = idle, idleTimeout, keepAlive, dial, handshake
+	}(*flagKubeletMaxIdleConnsPerHost, *flagKubeletIdleConnTimeout, *flagKubeletKeepAlive, *flagKubeletDialTimeout, *flagKubeletTLSHandshakeTimeout)
+	*flagKubeletMaxIdleConnsPerHost = 4
+	*flagKubeletIdleConnTimeout = 5 * time.Minute
+	*flagKubeletTLSHandshakeTimeout = 3 * time.Second
+
+	rt, err := newKubeletTransport(&rest.Config{TLSClientConfig: rest.TLSClientConfig{Insecure: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport, ok := rt.(*http.Transport)
+	if !ok {
+		t.Fatalf("got a %T, want an *http.Transport without credentials", rt)
+	}
+	if transport.MaxIdleConnsPerHost != 4 || transport.IdleConnTimeout != 5*time.Minute || transport.TLSHandshakeTimeout != 3*time.Second {
+		t.Errorf("got MaxIdleConnsPerHost=%d IdleConnTimeout=%s TLSHandshakeTimeout=%s, want the flags", transport.MaxIdleConnsPerHost, transport.IdleConnTimeout, transport.TLSHandshakeTimeout)
+	}
+	if transport.TLSClientConfig == nil || !transport.TLSClientConfig.InsecureSkipVerify {
+		t.Error("got a TLS config verifying the serving certificates, want them skipped")
+	}
+
+	// The bearer token is added by a wrapper of the transport
+	kubelet := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	t.Cleanup(kubelet.Close)
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("edge-token"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	rt, err = newKubeletTransport(&rest.Config{BearerTokenFile: tokenFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := (&http.Client{Transport: rt}).Get(kubelet.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); string(body) != "Bearer edge-token" {
+		t.Errorf("got Authorization %q, want the bearer token", body)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// statsdMaxPacketSize keeps datagrams under the usual Ethernet MTU, as
+// recommended for DogStatsD
+const statsdMaxPacketSize = 1432
+
+// statsdSink emits samples as DogStatsD gauges, with the labels as tags, over
+// UDP to a local Datadog agent
+type statsdSink struct {
+	address string
+	prefix  string
+}
+
+func newStatsdSink(address, prefix string) *statsdSink {
+	return &statsdSink{
+		address: address,
+		prefix:  prefix,
+	}
+}
+
+func (s *statsdSink) Name() string {
+	return "statsd"
+}
+
+func (s *statsdSink) Write(ctx context.Context, samples []sample, ts time.Time) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", s.address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for _, packet := range statsdPackets(s.prefix, samples, statsdMaxPacketSize) {
+		if _, err := conn.Write(packet); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// statsdPackets formats the samples as "name:value|g|#tag:value,..." lines
+// and packs them into newline separated datagrams of at most maxSize bytes
+func statsdPackets(prefix string, samples []sample, maxSize int) [][]byte {
+	var (
+		packets [][]byte
+		packet  bytes.Buffer
+		line    bytes.Buffer
+	)
+
+	for _, s := range samples {
+		if math.IsNaN(s.Value) || math.IsInf(s.Value, 0) {
+			continue
+		}
+
+		line.Reset()
+		if prefix != "" {
+			line.WriteString(prefix)
+			line.WriteByte('.')
+		}
+		line.WriteString(s.Name)
+		line.WriteByte(':')
+		line.WriteString(strconv.FormatFloat(s.Value, 'g', -1, 64))
+		line.WriteString("|g")
+		sep := "|#"
+		for _, l := range s.Labels {
+			if l.Value == "" {
+				continue
+			}
+			line.WriteString(sep)
+			line.WriteString(statsdTagEscaper.Replace(l.Name))
+			line.WriteByte(':')
+			line.WriteString(statsdTagEscaper.Replace(l.Value))
+			sep = ","
+		}
+
+		if packet.Len() > 0 && packet.Len()+1+line.Len() > maxSize {
+			packets = append(packets, bytes.Clone(packet.Bytes()))
+			packet.Reset()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.Write(line.Bytes())
+	}
+	if packet.Len() > 0 {
+		packets = append(packets, bytes.Clone(packet.Bytes()))
+	}
+
+	return packets
+}
+
+// statsdTagEscaper replaces the separators of the DogStatsD datagram format
+var statsdTagEscaper = strings.NewReplacer(",", "_", "|", "_", "#", "_", "\n", "_")
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_statsdPackets(t *testing.T) {
+	samples := []sample{
+		{
+			Name:   "kube_summary_pod_ephemeral_storage_used_bytes",
+			Labels: []labelPair{{"namespace", "mon"}, {"node", "node-a"}, {"pod", "a,b"}},
+			Value:  8192,
+		},
+		{
+			Name:   "kube_summary_node_scrape_success",
+			Labels: []labelPair{{"node", "node-a"}, {"provider", ""}},
+			Value:  1,
+		},
+		{
+			Name:  "kube_summary_node_response_bytes",
+			Value: 1.5e+06,
+		},
+	}
+
+	var got []string
+	for _, p := range statsdPackets("k8s", samples, 120) {
+		got = append(got, string(p))
+	}
+
+	want := []string{
+		"k8s.kube_summary_pod_ephemeral_storage_used_bytes:8192|g|#namespace:mon,node:node-a,pod:a_b",
+		"k8s.kube_summary_node_scrape_success:1|g|#node:node-a\nk8s.kube_summary_node_response_bytes:1.5e+06|g",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("statsdPackets() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+type resultStreamKey struct{}
+
+// withResultStream returns a context under which collectNodeStats passes the
+// result of every node to fn as soon as it is collected
+func withResultStream(ctx context.Context, fn func(PerNodeResult)) context.Context {
+	return context.WithValue(ctx, resultStreamKey{}, fn)
+}
+
+func streamResult(ctx context.Context, result PerNodeResult) {
+	if fn, ok := ctx.Value(resultStreamKey{}).(func(PerNodeResult)); ok {
+		fn(result)
+	}
+}
+
+// handleStreamedCollection writes the metrics of each node in the Prometheus
+// text or protobuf format, as negotiated, as soon as the node is collected,
+// instead of buffering the metrics of all nodes. The nodes returned by the
+// selector that weren't streamed, e.g. when they are served from the cache,
+// are written at the end.
+func handleStreamedCollection(w http.ResponseWriter, r *http.Request, kubeClient *kubernetes.Clientset, nodeSelector nodeSelectorFunc) {
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	ctx, span := tracer.Start(ctx, "handleMetricsCollection", trace.WithAttributes(attribute.String("http.target", r.URL.Path)))
+	defer span.End()
+
+	ctx, cancel := getTimeoutContext(r.WithContext(ctx))
+	defer cancel()
+
+	sw := newStreamWriter(w, expfmt.Negotiate(r.Header), flagCollectorOptions())
+	results, err := nodeSelector(withResultStream(ctx, sw.write), kubeClient)
+	if err == nil && !sw.started() {
+		err = allFailed(results)
+	}
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		if sw.started() {
+			// The status is already sent, the scraper sees a truncated body
+			logError(ctx, "Error collecting node stats after streaming started: %v", err)
+			return
+		}
+		writeCollectionError(w, r, err)
+		return
+	}
+
+	for _, result := range results {
+		sw.write(result)
+	}
+	sw.flushPending()
+}
+
+// streamWriter writes the metrics of one node at a time. In the text format
+// the HELP and TYPE lines of a family are only written before its first
+// series, the series of a family are spread across the nodes though, which the
+// Prometheus text parser accepts. In the protobuf format every node gets its
+// own message of each family, native histograms included. Failed nodes are
+// held back until a node succeeds, so that the response can still fail if no
+// node is collected.
+type streamWriter struct {
+	w      http.ResponseWriter
+	format expfmt.Format
+	opts   collectorOptions
+
+	mu      sync.Mutex
+	seen    map[string]bool
+	written map[string]bool
+	pending []PerNodeResult
+}
+
+func newStreamWriter(w http.ResponseWriter, format expfmt.Format, opts collectorOptions) *streamWriter {
+	return &streamWriter{
+		w:       w,
+		format:  format,
+		opts:    opts,
+		seen:    map[string]bool{},
+		written: map[string]bool{},
+	}
+}
+
+func (sw *streamWriter) started() bool {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return len(sw.written) > len(sw.pending)
+}
+
+// write writes the result unless the node was already written
+func (sw *streamWriter) write(result PerNodeResult) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	if sw.written[result.NodeName] {
+		return
+	}
+	sw.written[result.NodeName] = true
+
+	if result.Err != nil && result.Summary == nil && len(sw.written) == len(sw.pending)+1 {
+		sw.pending = append(sw.pending, result)
+		return
+	}
+	sw.encode(result)
+	for _, p := range sw.pending {
+		sw.encode(p)
+	}
+	sw.pending = nil
+}
+
+// flushPending writes the failed nodes that were held back
+func (sw *streamWriter) flushPending() {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	for _, p := range sw.pending {
+		sw.encode(p)
+	}
+	sw.pending = nil
+}
+
+// encode writes the metrics of the result, a node failing --self-check being
+// left out
+func (sw *streamWriter) encode(result PerNodeResult) {
+	registry := prometheus.NewRegistry()
+	summary.Collect([]PerNodeResult{result}, registry, sw.opts)
+	families, err := selfChecked(relabeled(registry)).Gather()
+	if err != nil {
+		fmt.Printf("[Error] Error gathering the metrics of %s: %v\n", result.NodeName, err)
+		return
+	}
+
+	if len(sw.seen) == 0 {
+		sw.w.Header().Set("Content-Type", string(sw.format))
+	}
+	if sw.format.FormatType() != expfmt.TypeTextPlain {
+		sw.encodeFamilies(result.NodeName, families)
+		return
+	}
+
+	var buf bytes.Buffer
+	for _, mf := range families {
+		if _, err := expfmt.MetricFamilyToText(&buf, mf); err != nil {
+			fmt.Printf("[Error] Error encoding %s of %s: %v\n", mf.GetName(), result.NodeName, err)
+			buf.Reset()
+			continue
+		}
+		if sw.seen[mf.GetName()] {
+			writeSeries(sw.w, &buf)
+		} else {
+			sw.seen[mf.GetName()] = true
+			_, _ = buf.WriteTo(sw.w)
+		}
+		buf.Reset()
+	}
+
+	if f, ok := sw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// encodeFamilies writes the families in a format other than text, whose
+// families can be repeated
+func (sw *streamWriter) encodeFamilies(nodeName string, families []*dto.MetricFamily) {
+	enc := expfmt.NewEncoder(sw.w, sw.format)
+	for _, mf := range families {
+		sw.seen[mf.GetName()] = true
+		if err := enc.Encode(mf); err != nil {
+			fmt.Printf("[Error] Error encoding %s of %s: %v\n", mf.GetName(), nodeName, err)
+		}
+	}
+	if f, ok := sw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// writeSeries writes the lines of an encoded family without the comments
+func writeSeries(w http.ResponseWriter, buf *bytes.Buffer) {
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		if line := scanner.Text(); !strings.HasPrefix(line, "#") {
+			_, _ = fmt.Fprintln(w, line)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+)
+
+func TestRouter_streamNodes(t *testing.T) {
+	*flagStreamNodes = true
+	defer func() { *flagStreamNodes = false }()
+
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", StatusCode: http.StatusServiceUnavailable})
+	srv.AddNode(fakekubelet.Node{Name: "node-b", Summary: fakekubelet.Fixture("node")})
+	srv.AddNode(fakekubelet.Node{Name: "node-c", Summary: fakekubelet.Fixture("node")})
+	r := newRouter(kubeClient, nil, nil, allNodesSelector, singleNodeSelector)
+
+	code, body := get(t, r, "/nodes", nil)
+	if code != http.StatusOK {
+		t.Fatalf("GET /nodes returned %d: %s", code, body)
+	}
+	assertContains(t, body,
+		`kube_summary_node_scrape_success{kubelet_version="",node="node-a"} 0`,
+		`kube_summary_node_scrape_success{kubelet_version="",node="node-b"} 1`,
+		`kube_summary_node_scrape_success{kubelet_version="",node="node-c"} 1`,
+	)
+	if n := strings.Count(body, "# TYPE kube_summary_node_scrape_success gauge"); n != 1 {
+		t.Errorf("response contains %d TYPE lines for kube_summary_node_scrape_success, want 1:\n%s", n, body)
+	}
+	// The failed node is held back until a node succeeds
+	if i, j := strings.Index(body, `node="node-a"`), strings.Index(body, `node="node-b"`); i < j {
+		t.Errorf("node-a was written before node-b:\n%s", body)
+	}
+
+	srv.RemoveNode("node-b")
+	srv.RemoveNode("node-c")
+	if code, body := get(t, r, "/nodes", nil); code != http.StatusInternalServerError {
+		t.Errorf("GET /nodes with all nodes failing returned %d, want %d: %s", code, http.StatusInternalServerError, body)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/exporter"
+)
+
+var summaryDecodeWarnings = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metricsNamespace,
+	Name:      "node_summary_decode_warnings_total",
+	Help:      "Number of /stats/summary responses with a field of an unexpected type, which was skipped while the rest of the summary was kept",
+},
+	[]string{
+		"node",
+		"field",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(summaryDecodeWarnings)
+}
+
+// decodeSummary decodes a /stats/summary response leniently with
+// exporter.DecodeSummary, counting and logging the fields of an unexpected
+// type it skips. It also returns which summary.Capabilities the response has.
+func decodeSummary(nodeName string, data []byte) (*stats.Summary, map[string]bool, error) {
+	return exporter.DecodeSummary(data, func(err *json.UnmarshalTypeError) {
+		summaryDecodeWarnings.WithLabelValues(nodeName, fieldPath(err.Field)).Inc()
+		fmt.Printf("[Error] Skipping field %s of the /stats/summary response for %s: %v\n", err.Field, nodeName, err)
+	})
+}
+
+// fieldPath drops the array indexes from the path of a field, e.g.
+// pods.3.containers.0.rootfs becomes pods.containers.rootfs, to keep the
+// cardinality of the warnings bounded
+func fieldPath(field string) string {
+	parts := strings.Split(field, ".")
+	kept := parts[:0]
+	for _, part := range parts {
+		if _, err := strconv.Atoi(part); err != nil {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, ".")
+}
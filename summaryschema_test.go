@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+func Test_decodeSummary(t *testing.T) {
+	nodeSummary, capabilities, err := decodeSummary("node-a", []byte(`{
+		"node": {
+			"nodeName": "node-a",
+			"memory": {"psi": {"full": {"total": 1}}},
+			"runtime": {"containerFs": {"usedBytes": 10}, "imageFs": {"usedBytes": 20}},
+			"swap": null,
+			"fromTheFuture": {"answer": 42}
+		},
+		"pods": [
+			{"podRef": {"name": "a", "namespace": "ns"}, "ephemeral-storage": {"usedBytes": "oops"}},
+			{"podRef": {"name": "b", "namespace": "ns"}}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(map[string]bool{summary.CapabilitySwap: false, summary.CapabilityPSI: true, summary.CapabilityContainerFs: true}, capabilities); diff != "" {
+		t.Errorf("decodeSummary() capabilities mismatch (-want +got):\n%s", diff)
+	}
+	if nodeSummary.Node.NodeName != "node-a" || *nodeSummary.Node.Runtime.ImageFs.UsedBytes != 20 {
+		t.Errorf("decodeSummary() node = %+v", nodeSummary.Node)
+	}
+	// The pod with a field of the wrong type is kept
+	if len(nodeSummary.Pods) != 2 || nodeSummary.Pods[0].PodRef.Name != "a" {
+		t.Errorf("decodeSummary() pods = %+v", nodeSummary.Pods)
+	}
+	if n := testutil.ToFloat64(summaryDecodeWarnings.WithLabelValues("node-a", "pods.ephemeral-storage.usedBytes")); n != 1 {
+		t.Errorf("counted %v decode warnings, want 1", n)
+	}
+
+	if _, _, err := decodeSummary("node-a", []byte(`{"node": `)); err == nil {
+		t.Errorf("decodeSummary() accepted a truncated response")
+	}
+}
@@ -0,0 +1,363 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/kubernetes"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+// summaryScrapeResource is the SummaryScrape custom resource, defined in
+// manifests/cluster/summaryscrape-crd.yaml
+var summaryScrapeResource = schema.GroupVersionResource{
+	Group:    "kube-summary-exporter.utilitywarehouse.io",
+	Version:  "v1alpha1",
+	Resource: "summaryscrapes",
+}
+
+// clusterSummaryScrapeResource is the ClusterSummaryScrape custom resource,
+// the cluster scoped SummaryScrape, defined in
+// manifests/cluster/clustersummaryscrape-crd.yaml
+var clusterSummaryScrapeResource = schema.GroupVersionResource{
+	Group:    "kube-summary-exporter.utilitywarehouse.io",
+	Version:  "v1alpha1",
+	Resource: "clustersummaryscrapes",
+}
+
+var summaryScrapeConfigs = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: metricsNamespace,
+	Name:      "summary_scrapes",
+	Help:      "Number of SummaryScrape and ClusterSummaryScrape resources watched, by whether their spec is valid",
+},
+	[]string{
+		"valid",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(summaryScrapeConfigs)
+}
+
+// reservedLabels are the labels set by the exporter, which extra labels can't
+// override
+var reservedLabels = map[string]bool{
+	"node":            true,
+	"pod":             true,
+	"namespace":       true,
+	"name":            true,
+	"kubelet_version": true,
+	"os":              true,
+	"arch":            true,
+	"instance_type":   true,
+	"provider":        true,
+	"condition":       true,
+	"le":              true,
+}
+
+// summaryScrapeSpec is the spec of a SummaryScrape or ClusterSummaryScrape
+// resource
+type summaryScrapeSpec struct {
+	// NodeSelector selects the nodes by label, all nodes if empty
+	NodeSelector *meta_v1.LabelSelector `json:"nodeSelector,omitempty"`
+	// Namespaces restricts the series to the pods of these namespaces,
+	// dropping the node level series, if not empty. A SummaryScrape is
+	// always restricted to its own namespace.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// MetricGroups lists the sections mapped to metrics, all if empty
+	MetricGroups []string `json:"metricGroups,omitempty"`
+	// ExtraLabels are added to every series
+	ExtraLabels map[string]string `json:"extraLabels,omitempty"`
+	// Thresholds are threshold rules notified to the webhook
+	Thresholds []string `json:"thresholds,omitempty"`
+}
+
+// summaryScrape is the parsed configuration of a SummaryScrape or
+// ClusterSummaryScrape resource
+type summaryScrape struct {
+	// namespace is the namespace of a SummaryScrape, empty for a
+	// ClusterSummaryScrape
+	namespace    string
+	name         string
+	nodeSelector labels.Selector
+	namespaces   map[string]bool
+	sections     map[string]bool
+	extraLabels  prometheus.Labels
+	rules        []thresholdRule
+}
+
+// parseSummaryScrape validates the spec of a SummaryScrape or
+// ClusterSummaryScrape resource. nodeLabels tells whether the nodes have
+// labels for the nodeSelector to match.
+func parseSummaryScrape(obj *unstructured.Unstructured, nodeLabels bool) (*summaryScrape, error) {
+	var spec summaryScrapeSpec
+	if raw, ok := obj.Object["spec"].(map[string]interface{}); ok {
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &spec); err != nil {
+			return nil, fmt.Errorf("invalid spec: %w", err)
+		}
+	}
+
+	s := &summaryScrape{
+		namespace:    obj.GetNamespace(),
+		name:         obj.GetName(),
+		nodeSelector: labels.Everything(),
+	}
+
+	if spec.NodeSelector != nil {
+		if !nodeLabels {
+			return nil, errors.New("nodeSelector needs the labels of the nodes, which --nodes and --nodes-file don't have, nor --kubelets without zones")
+		}
+		selector, err := meta_v1.LabelSelectorAsSelector(spec.NodeSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid nodeSelector: %w", err)
+		}
+		s.nodeSelector = selector
+	}
+
+	if len(spec.Namespaces) > 0 {
+		s.namespaces = make(map[string]bool, len(spec.Namespaces))
+		for _, namespace := range spec.Namespaces {
+			s.namespaces[namespace] = true
+		}
+	}
+	// Anyone allowed to create a SummaryScrape in a namespace would otherwise
+	// read the pods of every namespace, and the nodes, through the exporter
+	if s.namespace != "" {
+		for namespace := range s.namespaces {
+			if namespace != s.namespace {
+				return nil, fmt.Errorf("a SummaryScrape can't select the pods of namespace %s, only a ClusterSummaryScrape can", namespace)
+			}
+		}
+		s.namespaces = map[string]bool{s.namespace: true}
+	}
+
+	if len(spec.MetricGroups) > 0 {
+		selected := sectionsFlag{}
+		if err := selected.Set(strings.Join(spec.MetricGroups, ",")); err != nil {
+			return nil, fmt.Errorf("invalid metricGroups: %w", err)
+		}
+		s.sections = selected
+	}
+
+	if len(spec.ExtraLabels) > 0 {
+		s.extraLabels = make(prometheus.Labels, len(spec.ExtraLabels))
+		for name, value := range spec.ExtraLabels {
+			if !model.LabelName(name).IsValid() || reservedLabels[name] {
+				return nil, fmt.Errorf("invalid extra label %q", name)
+			}
+			s.extraLabels[name] = value
+		}
+	}
+
+	for _, threshold := range spec.Thresholds {
+		rule, err := parseThresholdRule(threshold)
+		if err != nil {
+			return nil, err
+		}
+		s.rules = append(s.rules, rule)
+	}
+
+	return s, nil
+}
+
+func (s *summaryScrape) key() string {
+	return summaryScrapeKey(s.namespace, s.name)
+}
+
+// summaryScrapeKey returns the namespace/name of a SummaryScrape, the name of
+// a ClusterSummaryScrape
+func summaryScrapeKey(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+// summaryScrapeKind returns the kind of the resources of the namespace
+func summaryScrapeKind(namespace string) string {
+	if namespace == "" {
+		return "ClusterSummaryScrape"
+	}
+	return "SummaryScrape"
+}
+
+// options returns the collector options of the flags, restricted to the
+// metric groups and with the extra labels of the scrape
+func (s *summaryScrape) options() collectorOptions {
+	opts := flagCollectorOptions()
+	opts.Sections = s.sections
+	opts.ExtraLabels = s.extraLabels
+	return opts
+}
+
+// filter returns the results of the nodes and namespaces selected by the
+// scrape
+func (s *summaryScrape) filter(results []PerNodeResult) []PerNodeResult {
+	selected := make([]PerNodeResult, 0, len(results))
+	for _, result := range results {
+		if s.nodeSelector.Matches(labels.Set(result.NodeLabels)) {
+			selected = append(selected, result)
+		}
+	}
+	if s.namespaces != nil {
+		selected = filterNamespaces(selected, s.namespaces)
+	}
+	return selected
+}
+
+// summaryScrapeFilter wraps a node selector so that only the nodes and
+// namespaces selected by the scrape are collected and returned
+func summaryScrapeFilter(s *summaryScrape, nodeSelector nodeSelectorFunc) nodeSelectorFunc {
+	return func(ctx context.Context, kubeClient *kubernetes.Clientset) ([]PerNodeResult, error) {
+		results, err := nodeSelector(context.WithValue(ctx, nodeLabelSelectorKey{}, s.nodeSelector), kubeClient)
+		if err != nil {
+			return nil, err
+		}
+		return s.filter(results), nil
+	}
+}
+
+// summaryScrapes holds the valid SummaryScrape and ClusterSummaryScrape
+// resources by summaryScrapeKey
+type summaryScrapes struct {
+	// nodeLabels tells whether the nodes of the node source have labels
+	nodeLabels bool
+
+	mu      sync.RWMutex
+	scrapes map[string]*summaryScrape
+	invalid map[string]bool
+}
+
+func newSummaryScrapes(nodeLabels bool) *summaryScrapes {
+	return &summaryScrapes{
+		nodeLabels: nodeLabels,
+		scrapes:    map[string]*summaryScrape{},
+		invalid:    map[string]bool{},
+	}
+}
+
+func (c *summaryScrapes) get(namespace, name string) (*summaryScrape, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	s, ok := c.scrapes[summaryScrapeKey(namespace, name)]
+	return s, ok
+}
+
+// list returns the scrapes sorted by summaryScrapeKey
+func (c *summaryScrapes) list() []*summaryScrape {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	list := make([]*summaryScrape, 0, len(c.scrapes))
+	for _, s := range c.scrapes {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].key() < list[j].key() })
+	return list
+}
+
+// apply adds or replaces the scrape of a resource. A resource whose spec is
+// invalid is removed until it is fixed, so that a bad change doesn't keep
+// serving a stale configuration.
+func (c *summaryScrapes) apply(obj *unstructured.Unstructured) {
+	key := summaryScrapeKey(obj.GetNamespace(), obj.GetName())
+	s, err := parseSummaryScrape(obj, c.nodeLabels)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		fmt.Printf("[Error] Ignoring %s %s: %v\n", summaryScrapeKind(obj.GetNamespace()), key, err)
+		delete(c.scrapes, key)
+		c.invalid[key] = true
+	} else {
+		c.scrapes[key] = s
+		delete(c.invalid, key)
+	}
+	c.updateMetrics()
+}
+
+func (c *summaryScrapes) remove(namespace, name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := summaryScrapeKey(namespace, name)
+	delete(c.scrapes, key)
+	delete(c.invalid, key)
+	c.updateMetrics()
+}
+
+func (c *summaryScrapes) updateMetrics() {
+	summaryScrapeConfigs.WithLabelValues("true").Set(float64(len(c.scrapes)))
+	summaryScrapeConfigs.WithLabelValues("false").Set(float64(len(c.invalid)))
+}
+
+// eventHandler updates the scrapes on the informer events
+func (c *summaryScrapes) eventHandler() toolscache.ResourceEventHandler {
+	return toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if u, ok := obj.(*unstructured.Unstructured); ok {
+				c.apply(u)
+			}
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			if u, ok := obj.(*unstructured.Unstructured); ok {
+				c.apply(u)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if u, ok := obj.(*unstructured.Unstructured); ok {
+				c.remove(u.GetNamespace(), u.GetName())
+			}
+		},
+	}
+}
+
+// runSummaryScrapeController watches the SummaryScrape resources of every
+// namespace and the ClusterSummaryScrape resources, and keeps the scrapes up
+// to date until the context is done. It fails if they aren't synced within
+// informerSyncTimeout, e.g. when a CRD isn't installed.
+func runSummaryScrapeController(ctx context.Context, client dynamic.Interface, scrapes *summaryScrapes) error {
+	factory := dynamicinformer.NewDynamicSharedInformerFactory(client, 0)
+	for _, resource := range []schema.GroupVersionResource{summaryScrapeResource, clusterSummaryScrapeResource} {
+		if _, err := factory.ForResource(resource).Informer().AddEventHandler(scrapes.eventHandler()); err != nil {
+			return err
+		}
+	}
+
+	factory.Start(ctx.Done())
+	syncCtx, cancel := context.WithTimeout(ctx, informerSyncTimeout)
+	defer cancel()
+	for resource, synced := range factory.WaitForCacheSync(syncCtx.Done()) {
+		if !synced {
+			return fmt.Errorf("%s not synced within %s, is their CRD installed and can the exporter list them?", resource.Resource, informerSyncTimeout)
+		}
+	}
+	return nil
+}
+
+// handleSummaryScrape serves the metrics selected by a SummaryScrape resource,
+// or a ClusterSummaryScrape one if namespace is empty
+func handleSummaryScrape(w http.ResponseWriter, r *http.Request, kubeClient *kubernetes.Clientset, scrapes *summaryScrapes, namespace, name string, nodesSelector nodeSelectorFunc) {
+	s, ok := scrapes.get(namespace, name)
+	if !ok {
+		writeError(w, r, http.StatusNotFound, apiError{Error: fmt.Sprintf("%s %s not found", summaryScrapeKind(namespace), summaryScrapeKey(namespace, name)), Reason: reasonNotFound})
+		return
+	}
+	handleCollection(w, r, kubeClient, summaryScrapeFilter(s, nodesSelector), s.options(), writePrometheus)
+}
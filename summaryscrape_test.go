@@ -0,0 +1,105 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/utilitywarehouse/kube-summary-exporter/internal/fakekubelet"
+	"github.com/utilitywarehouse/kube-summary-exporter/pkg/summary"
+)
+
+func newSummaryScrapeObject(namespace, name string, spec map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	obj.SetAPIVersion(summaryScrapeResource.GroupVersion().String())
+	obj.SetKind(summaryScrapeKind(namespace))
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+func Test_parseSummaryScrape(t *testing.T) {
+	s, err := parseSummaryScrape(newSummaryScrapeObject("team-a", "storage", map[string]interface{}{
+		"nodeSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"pool": "a"}},
+		"namespaces":   []interface{}{"team-a"},
+		"metricGroups": []interface{}{summary.SectionContainerLogs},
+		"extraLabels":  map[string]interface{}{"team": "a"},
+		"thresholds":   []interface{}{"kube_summary_container_logs_used_bytes>1e9"},
+	}), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.key() != "team-a/storage" || s.nodeSelector.String() != "pool=a" || !s.namespaces["team-a"] || !s.sections[summary.SectionContainerLogs] || s.extraLabels["team"] != "a" || len(s.rules) != 1 {
+		t.Errorf("parseSummaryScrape() = %+v", s)
+	}
+
+	for _, spec := range []map[string]interface{}{
+		{"metricGroups": []interface{}{"unknown"}},
+		{"extraLabels": map[string]interface{}{"node": "a"}},
+		{"extraLabels": map[string]interface{}{"not-a-label": "a"}},
+		{"thresholds": []interface{}{"used"}},
+		{"nodeSelector": map[string]interface{}{"matchExpressions": []interface{}{map[string]interface{}{"key": "pool", "operator": "Bogus"}}}},
+		{"namespaces": []interface{}{"team-a", "team-b"}},
+	} {
+		if _, err := parseSummaryScrape(newSummaryScrapeObject("team-a", "storage", spec), true); err == nil {
+			t.Errorf("parseSummaryScrape(%v) didn't fail", spec)
+		}
+	}
+
+	// A SummaryScrape is restricted to its own namespace, a
+	// ClusterSummaryScrape to none
+	if s, err := parseSummaryScrape(newSummaryScrapeObject("team-a", "storage", nil), true); err != nil || len(s.namespaces) != 1 || !s.namespaces["team-a"] {
+		t.Errorf("parseSummaryScrape() without namespaces = %+v, %v, want team-a", s, err)
+	}
+	s, err = parseSummaryScrape(newSummaryScrapeObject("", "storage", map[string]interface{}{"namespaces": []interface{}{"team-a", "team-b"}}), true)
+	if err != nil || s.key() != "storage" || len(s.namespaces) != 2 {
+		t.Errorf("parseSummaryScrape() of a ClusterSummaryScrape = %+v, %v", s, err)
+	}
+	if s, err := parseSummaryScrape(newSummaryScrapeObject("", "storage", nil), true); err != nil || s.namespaces != nil {
+		t.Errorf("parseSummaryScrape() of a ClusterSummaryScrape without namespaces = %+v, %v, want every namespace", s, err)
+	}
+
+	nodeSelector := map[string]interface{}{"nodeSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"pool": "a"}}}
+	if _, err := parseSummaryScrape(newSummaryScrapeObject("team-a", "storage", nodeSelector), false); err == nil {
+		t.Error("parseSummaryScrape() of a nodeSelector without node labels didn't fail")
+	}
+}
+
+func TestRouter_summaryScrape(t *testing.T) {
+	srv, kubeClient := newTestServer(t)
+	srv.AddNode(fakekubelet.Node{Name: "node-a", Labels: map[string]string{"pool": "a"}, Summary: fakekubelet.Fixture("node")})
+	srv.AddNode(fakekubelet.Node{Name: "node-b", Labels: map[string]string{"pool": "b"}, Summary: fakekubelet.Fixture("node")})
+
+	scrapes := newSummaryScrapes(true)
+	scrapes.eventHandler().OnAdd(newSummaryScrapeObject("", "storage", map[string]interface{}{
+		"nodeSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"pool": "a"}},
+		"namespaces":   []interface{}{"kube-system"},
+		"extraLabels":  map[string]interface{}{"team": "a"},
+	}), false)
+	scrapes.eventHandler().OnAdd(newSummaryScrapeObject("mon", "storage", nil), false)
+	r := newRouter(kubeClient, nil, scrapes, allNodesSelector, singleNodeSelector)
+
+	code, body := get(t, r, "/scrape/storage", nil)
+	if code != http.StatusOK {
+		t.Fatalf("GET /scrape/storage returned %d: %s", code, body)
+	}
+	assertContains(t, body, `kube_summary_container_rootfs_used_bytes{name="coredns",namespace="kube-system",node="node-a",pod="coredns-5d78c9869d-x2x8z",team="a"} 40960`)
+	assertNotContains(t, body, `node="node-b"`, `namespace="mon"`, `kube_summary_node_runtime_imagefs`)
+	if n := srv.SummaryRequests("node-b"); n != 0 {
+		t.Errorf("unselected node-b received %d summary requests", n)
+	}
+
+	// The SummaryScrape only sees the pods of its own namespace
+	code, body = get(t, r, "/scrape/mon/storage", nil)
+	if code != http.StatusOK {
+		t.Fatalf("GET /scrape/mon/storage returned %d: %s", code, body)
+	}
+	assertContains(t, body, `namespace="mon"`)
+	assertNotContains(t, body, `namespace="kube-system"`, `kube_summary_node_runtime_imagefs`)
+
+	scrapes.eventHandler().OnDelete(newSummaryScrapeObject("", "storage", nil))
+	if code, _ := get(t, r, "/scrape/storage", nil); code != http.StatusNotFound {
+		t.Errorf("GET of a deleted SummaryScrape returned %d, want %d", code, http.StatusNotFound)
+	}
+}
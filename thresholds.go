@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// thresholdRule fires for every sample of the metric whose value compares to
+// the threshold with the operator, e.g.
+// kube_summary_pod_ephemeral_storage_used_bytes>10e9
+type thresholdRule struct {
+	metric    string
+	op        string
+	threshold float64
+}
+
+// thresholdOperators is ordered so that two character operators are matched
+// before their one character prefix
+var thresholdOperators = []string{">=", "<=", ">", "<"}
+
+func parseThresholdRule(rule string) (thresholdRule, error) {
+	for _, op := range thresholdOperators {
+		metric, value, ok := strings.Cut(rule, op)
+		if !ok {
+			continue
+		}
+
+		metric = strings.TrimSpace(metric)
+		threshold, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if metric == "" || err != nil {
+			break
+		}
+		return thresholdRule{metric: metric, op: op, threshold: threshold}, nil
+	}
+	return thresholdRule{}, fmt.Errorf("invalid threshold rule %q, expected <metric><op><value> with op one of %s", rule, strings.Join(thresholdOperators, " "))
+}
+
+func (r thresholdRule) String() string {
+	return r.metric + r.op + strconv.FormatFloat(r.threshold, 'g', -1, 64)
+}
+
+func (r thresholdRule) fires(s sample) bool {
+	if s.Name != r.metric {
+		return false
+	}
+	switch r.op {
+	case ">":
+		return s.Value > r.threshold
+	case ">=":
+		return s.Value >= r.threshold
+	case "<":
+		return s.Value < r.threshold
+	case "<=":
+		return s.Value <= r.threshold
+	}
+	return false
+}
+
+// thresholdRulesFlag is a repeatable flag of threshold rules
+type thresholdRulesFlag []thresholdRule
+
+func (f *thresholdRulesFlag) String() string {
+	var rules []string
+	for _, r := range *f {
+		rules = append(rules, r.String())
+	}
+	return strings.Join(rules, ",")
+}
+
+func (f *thresholdRulesFlag) Set(value string) error {
+	rule, err := parseThresholdRule(value)
+	if err != nil {
+		return err
+	}
+	*f = append(*f, rule)
+	return nil
+}
+
+// thresholdAlert is a sample breaching a rule
+type thresholdAlert struct {
+	// Scrape is the namespace/name of the SummaryScrape defining the rule,
+	// empty for the --threshold rules
+	Scrape    string            `json:"scrape,omitempty"`
+	Rule      string            `json:"rule"`
+	Metric    string            `json:"metric"`
+	Labels    map[string]string `json:"labels"`
+	Value     float64           `json:"value"`
+	Threshold float64           `json:"threshold"`
+}
+
+func (a thresholdAlert) key() string {
+	names := make([]string, 0, len(a.Labels))
+	for name := range a.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(a.Scrape)
+	b.WriteString(":")
+	b.WriteString(a.Rule)
+	for _, name := range names {
+		fmt.Fprintf(&b, ",%s=%q", name, a.Labels[name])
+	}
+	return b.String()
+}
+
+// evaluateThresholds returns the alerts of every rule firing on the samples
+func evaluateThresholds(rules []thresholdRule, samples []sample) []thresholdAlert {
+	var alerts []thresholdAlert
+	for _, rule := range rules {
+		for _, s := range samples {
+			if !rule.fires(s) {
+				continue
+			}
+			labels := make(map[string]string, len(s.Labels))
+			for _, l := range s.Labels {
+				labels[l.Name] = l.Value
+			}
+			alerts = append(alerts, thresholdAlert{
+				Rule:      rule.String(),
+				Metric:    s.Name,
+				Labels:    labels,
+				Value:     s.Value,
+				Threshold: rule.threshold,
+			})
+		}
+	}
+	return alerts
+}
+
+// webhookPayload is compatible with Slack incoming webhooks through the text
+// field, while generic receivers can use the structured alerts
+type webhookPayload struct {
+	Text   string           `json:"text"`
+	Alerts []thresholdAlert `json:"alerts"`
+}
+
+// webhookNotifier evaluates the threshold rules on every background
+// collection cycle and POSTs the new alerts to a webhook. The rules of the
+// SummaryScrape resources, if watched, only apply to the series they select.
+// An alert that keeps firing is only sent again once the cooldown has passed.
+type webhookNotifier struct {
+	url      string
+	rules    []thresholdRule
+	scrapes  *summaryScrapes
+	cooldown time.Duration
+	client   *http.Client
+
+	mu   sync.Mutex
+	sent map[string]time.Time
+}
+
+func newWebhookNotifier(url string, rules []thresholdRule, scrapes *summaryScrapes, cooldown time.Duration) *webhookNotifier {
+	return &webhookNotifier{
+		url:      url,
+		rules:    rules,
+		scrapes:  scrapes,
+		cooldown: cooldown,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		sent:     map[string]time.Time{},
+	}
+}
+
+func (n *webhookNotifier) Name() string {
+	return "webhook"
+}
+
+func (n *webhookNotifier) WriteSnapshot(ctx context.Context, results []PerNodeResult, ts time.Time) error {
+	samples, err := resultSamples(results, flagCollectorOptions())
+	if err != nil {
+		return err
+	}
+	alerts := evaluateThresholds(n.rules, samples)
+
+	if n.scrapes != nil {
+		for _, s := range n.scrapes.list() {
+			if len(s.rules) == 0 {
+				continue
+			}
+			samples, err := resultSamples(s.filter(results), s.options())
+			if err != nil {
+				return err
+			}
+			for _, a := range evaluateThresholds(s.rules, samples) {
+				a.Scrape = s.key()
+				alerts = append(alerts, a)
+			}
+		}
+	}
+
+	alerts = n.dedup(alerts, ts)
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	if err := n.post(ctx, alerts); err != nil {
+		return err
+	}
+	n.markSent(alerts, ts)
+	return nil
+}
+
+// dedup returns the alerts that weren't sent within the cooldown and forgets
+// the alerts that stopped firing, so that they are sent again as soon as they
+// fire again. The alerts are only recorded as sent by markSent, once the
+// webhook accepted them, so that they are sent again on the next cycle if it
+// failed.
+func (n *webhookNotifier) dedup(alerts []thresholdAlert, now time.Time) []thresholdAlert {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	firing := make(map[string]bool, len(alerts))
+	var send []thresholdAlert
+	for _, a := range alerts {
+		key := a.key()
+		firing[key] = true
+		if last, ok := n.sent[key]; ok && now.Sub(last) < n.cooldown {
+			continue
+		}
+		send = append(send, a)
+	}
+	for key := range n.sent {
+		if !firing[key] {
+			delete(n.sent, key)
+		}
+	}
+	return send
+}
+
+// markSent records the alerts the webhook accepted at now
+func (n *webhookNotifier) markSent(alerts []thresholdAlert, now time.Time) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, a := range alerts {
+		n.sent[a.key()] = now
+	}
+}
+
+func (n *webhookNotifier) post(ctx context.Context, alerts []thresholdAlert) error {
+	lines := make([]string, 0, len(alerts))
+	for _, a := range alerts {
+		rule := a.Rule
+		if a.Scrape != "" {
+			rule = a.Scrape + " " + rule
+		}
+		lines = append(lines, fmt.Sprintf("%s: node=%s namespace=%s pod=%s value=%g", rule, a.Labels["node"], a.Labels["namespace"], a.Labels["pod"], a.Value))
+	}
+	body, err := json.Marshal(webhookPayload{
+		Text:   fmt.Sprintf("kube-summary-exporter: %d threshold alert(s)\n%s", len(alerts), strings.Join(lines, "\n")),
+		Alerts: alerts,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
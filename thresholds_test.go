@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_parseThresholdRule(t *testing.T) {
+	for rule, want := range map[string]thresholdRule{
+		"kube_summary_pod_ephemeral_storage_used_bytes>10e9": {"kube_summary_pod_ephemeral_storage_used_bytes", ">", 10e9},
+		"kube_summary_node_scrape_success <= 0":              {"kube_summary_node_scrape_success", "<=", 0},
+	} {
+		got, err := parseThresholdRule(rule)
+		if err != nil {
+			t.Errorf("parseThresholdRule(%q) unexpected error: %v", rule, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("parseThresholdRule(%q) = %+v, want %+v", rule, got, want)
+		}
+	}
+
+	for _, rule := range []string{"", "metric", ">1", "metric>abc"} {
+		if _, err := parseThresholdRule(rule); err == nil {
+			t.Errorf("parseThresholdRule(%q) accepted an invalid rule", rule)
+		}
+	}
+}
+
+func Test_webhookNotifier_dedup(t *testing.T) {
+	rule, err := parseThresholdRule("used>10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := newWebhookNotifier("http://example.com", []thresholdRule{rule}, nil, time.Hour)
+
+	pod := func(name string, value float64) sample {
+		return sample{Name: "used", Labels: []labelPair{{"pod", name}}, Value: value}
+	}
+	sent := func(samples []sample, now time.Time) []string {
+		var pods []string
+		alerts := n.dedup(evaluateThresholds(n.rules, samples), now)
+		for _, a := range alerts {
+			pods = append(pods, a.Labels["pod"])
+		}
+		n.markSent(alerts, now)
+		return pods
+	}
+
+	now := time.Now()
+	if diff := cmp.Diff([]string{"a"}, sent([]sample{pod("a", 20), pod("b", 5)}, now)); diff != "" {
+		t.Errorf("first cycle mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"b"}, sent([]sample{pod("a", 20), pod("b", 30)}, now.Add(time.Minute))); diff != "" {
+		t.Errorf("cycle within the cooldown mismatch (-want +got):\n%s", diff)
+	}
+	// b resolves, so it is sent again as soon as it fires again
+	sent([]sample{pod("a", 20)}, now.Add(2*time.Minute))
+	if diff := cmp.Diff([]string{"b"}, sent([]sample{pod("a", 20), pod("b", 30)}, now.Add(3*time.Minute))); diff != "" {
+		t.Errorf("cycle after resolving mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"a", "b"}, sent([]sample{pod("a", 20), pod("b", 30)}, now.Add(2*time.Hour))); diff != "" {
+		t.Errorf("cycle after the cooldown mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func Test_webhookNotifier_failedPost(t *testing.T) {
+	var posts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first POST fails
+		if posts.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	rule, err := parseThresholdRule("kube_summary_node_scrape_success<1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := newWebhookNotifier(srv.URL, []thresholdRule{rule}, nil, time.Hour)
+	results := []PerNodeResult{{NodeName: "node-a", Err: errors.New("connection refused")}}
+
+	now := time.Now()
+	if err := n.WriteSnapshot(context.Background(), results, now); err == nil {
+		t.Fatal("WriteSnapshot() to a failing webhook succeeded")
+	}
+	// The alert the webhook didn't accept is sent again on the next cycle
+	if err := n.WriteSnapshot(context.Background(), results, now.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.WriteSnapshot(context.Background(), results, now.Add(2*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if got := posts.Load(); got != 2 {
+		t.Errorf("got %d POSTs, want the failed one and its retry", got)
+	}
+}
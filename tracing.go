@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// tracer creates the spans of the collection pipeline. It is a no-op until
+// setupTracing installs a tracer provider.
+var tracer = otel.Tracer("github.com/utilitywarehouse/kube-summary-exporter")
+
+// setupTracing exports spans over OTLP/HTTP to the given endpoint (host:port).
+// The standard OTEL_EXPORTER_OTLP_* environment variables can be used to
+// configure the exporter further. The returned function flushes and stops the
+// tracer provider.
+func setupTracing(ctx context.Context, endpoint string, insecure bool) (func(context.Context) error, error) {
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpoint)}
+	if insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceName("kube-summary-exporter"),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
+
+// tracingShutdownTimeout bounds the flush of the batched spans on exit
+const tracingShutdownTimeout = 5 * time.Second
+
+// flushTracing flushes the batched spans and stops the tracer provider with
+// shutdown. It is called explicitly, the deferred calls of main not running
+// when the exporter exits with os.Exit.
+func flushTracing(shutdown func(context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+	defer cancel()
+	if err := shutdown(ctx); err != nil {
+		fmt.Printf("[Error] Cannot flush the spans: %v\n", err)
+	}
+}
+
+// flushTracingOnSignal flushes the spans with shutdown once the process is
+// asked to stop with SIGTERM or SIGINT, then exits
+func flushTracingOnSignal(shutdown func(context.Context) error) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	go func() {
+		<-signals
+		flushTracing(shutdown)
+		os.Exit(0)
+	}()
+}
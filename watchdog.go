@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	lastCollectionTimestamp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "last_collection_timestamp_seconds",
+		Help:      "Unix timestamp of the last successful background collection cycle",
+	})
+	collectionLoopStalls = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "collection_loop_stalls_total",
+		Help:      "Number of times the background collection loop didn't complete a cycle within the stall threshold",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(lastCollectionTimestamp, collectionLoopStalls)
+}
+
+// watchdog detects a background collection loop that stopped completing
+// cycles, for instance because it deadlocked or every cycle overruns
+type watchdog struct {
+	lastBeat atomic.Int64
+}
+
+func newWatchdog() *watchdog {
+	w := &watchdog{}
+	w.beat()
+	return w
+}
+
+// beat records that the loop completed a cycle
+func (w *watchdog) beat() {
+	w.lastBeat.Store(time.Now().UnixNano())
+}
+
+// healthy returns whether the loop completed a cycle within threshold
+func (w *watchdog) healthy(threshold time.Duration) bool {
+	return time.Since(time.Unix(0, w.lastBeat.Load())) <= threshold
+}
+
+// run checks the loop every interval and counts a stall, once per missed
+// beat, when no cycle completed within threshold
+func (w *watchdog) run(ctx context.Context, interval, threshold time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var reported int64
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if w.check(threshold, &reported) {
+			fmt.Printf("[Error] Background collection loop hasn't completed a cycle in %s\n", threshold)
+		}
+	}
+}
+
+// check reports whether the loop stalled and wasn't already reported for the
+// same last beat
+func (w *watchdog) check(threshold time.Duration, reported *int64) bool {
+	last := w.lastBeat.Load()
+	if w.healthy(threshold) || *reported == last {
+		return false
+	}
+	*reported = last
+	collectionLoopStalls.Inc()
+	return true
+}
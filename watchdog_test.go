@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func Test_watchdog(t *testing.T) {
+	before := testutil.ToFloat64(collectionLoopStalls)
+
+	wd := newWatchdog()
+	var reported int64
+	if wd.check(time.Minute, &reported) {
+		t.Errorf("watchdog.check() reported a stall right after a beat")
+	}
+
+	wd.lastBeat.Store(time.Now().Add(-2 * time.Minute).UnixNano())
+	if !wd.check(time.Minute, &reported) {
+		t.Errorf("watchdog.check() didn't report a stalled loop")
+	}
+	if wd.check(time.Minute, &reported) {
+		t.Errorf("watchdog.check() reported the same stall twice")
+	}
+
+	if got := testutil.ToFloat64(collectionLoopStalls) - before; got != 1 {
+		t.Errorf("collectionLoopStalls increased by %v, want 1", got)
+	}
+}